@@ -2,6 +2,7 @@ package bidengine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"github.com/tendermint/tendermint/libs/log"
 
 	"github.com/akash-network/node/pubsub"
+	types "github.com/akash-network/node/types/v1beta2"
 	metricsutils "github.com/akash-network/node/util/metrics"
 	"github.com/akash-network/node/util/runner"
 	atypes "github.com/akash-network/node/x/audit/types/v1beta2"
@@ -22,6 +24,7 @@ import (
 
 	"github.com/akash-network/provider/cluster"
 	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+	clusterutil "github.com/akash-network/provider/cluster/util"
 	"github.com/akash-network/provider/event"
 	"github.com/akash-network/provider/session"
 )
@@ -346,6 +349,11 @@ loop:
 
 			if result.Error() != nil {
 				reservationCounter.WithLabelValues(metricsutils.OpenLabel, metricsutils.FailLabel)
+				if errors.Is(result.Error(), ctypes.ErrOwnerQuotaExceeded) {
+					shouldBidCounter.WithLabelValues("decline").Inc()
+					o.log.Info("declined to bid: owner quota exceeded", "reason", result.Error())
+					break loop
+				}
 				o.log.Error("reserving resources", "err", result.Error())
 				break loop
 			}
@@ -498,6 +506,12 @@ func (o *order) shouldBid(group *dtypes.Group) (bool, error) {
 		return false, nil
 	}
 
+	// does provider run the cpu architectures requested?
+	if !matchCPUArchRequirements(group.GroupSpec, attr) {
+		o.log.Debug("unable to fulfill: incompatible cpu architecture", "wanted", clusterutil.GetCPUArchesOfResourceGroup(group.GroupSpec), "have", attr)
+		return false, nil
+	}
+
 	for _, resources := range group.GroupSpec.GetResources() {
 		if len(resources.Resources.Storage) > o.cfg.MaxGroupVolumes {
 			o.log.Info(fmt.Sprintf("unable to fulfill: group volumes count exceeds (%d > %d)", len(resources.Resources.Storage), o.cfg.MaxGroupVolumes))
@@ -546,3 +560,31 @@ func (o *order) shouldBid(group *dtypes.Group) (bool, error) {
 	}
 	return true, nil
 }
+
+const capabilitiesGroupCPU = "cpu"
+
+// matchCPUArchRequirements checks every cpu architecture requested by the group spec
+// is advertised by the provider with capabilities/cpu/<n>/arch attributes.
+// Providers which do not advertise cpu capabilities accept any architecture
+// and leave placement to the cluster inventory.
+func matchCPUArchRequirements(gspec dtypes.GroupSpec, pattr types.Attributes) bool {
+	pgroup := pattr.GetCapabilitiesGroup(capabilitiesGroupCPU)
+	if len(pgroup) == 0 {
+		return true
+	}
+
+	for _, arch := range clusterutil.GetCPUArchesOfResourceGroup(gspec) {
+		wanted := types.Attributes{
+			{
+				Key:   clusterutil.CPUAttributeArch,
+				Value: arch,
+			},
+		}
+
+		if !wanted.IN(pgroup) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -703,3 +703,38 @@ func Test_ShouldntBidIfOrderAttrsDontMatch(t *testing.T) {
 
 // TODO - add test failing the call to Broadcast on TxClient and
 // and then confirm that the reservation is cancelled
+
+func Test_MatchCPUArchRequirements(t *testing.T) {
+	gspec := dtypes.GroupSpec{
+		Resources: []dtypes.Resource{
+			{
+				Resources: atypes.ResourceUnits{
+					CPU: &atypes.CPU{
+						Units: atypes.NewResourceValue(100),
+						Attributes: atypes.Attributes{
+							{Key: "arch", Value: "arm64"},
+						},
+					},
+				},
+				Count: 1,
+			},
+		},
+	}
+
+	// provider does not advertise cpu capabilities
+	require.True(t, matchCPUArchRequirements(gspec, nil))
+
+	amd64Only := atypes.Attributes{
+		{Key: "capabilities/cpu/1/arch", Value: "amd64"},
+	}
+	require.False(t, matchCPUArchRequirements(gspec, amd64Only))
+
+	mixed := atypes.Attributes{
+		{Key: "capabilities/cpu/1/arch", Value: "amd64"},
+		{Key: "capabilities/cpu/2/arch", Value: "arm64"},
+	}
+	require.True(t, matchCPUArchRequirements(gspec, mixed))
+
+	// group without arch requirement matches any provider
+	require.True(t, matchCPUArchRequirements(dtypes.GroupSpec{}, amd64Only))
+}
@@ -0,0 +1,146 @@
+package bandwidth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	atypes "github.com/akash-network/node/types/v1beta2"
+)
+
+const (
+	// AnnotationIngress and AnnotationEgress are enforced by the bandwidth CNI plugin,
+	// which must be chained in the cluster network configuration
+	AnnotationIngress = "kubernetes.io/ingress-bandwidth"
+	AnnotationEgress  = "kubernetes.io/egress-bandwidth"
+)
+
+var (
+	ErrBandwidthPolicy = errors.New("bandwidth policy")
+	ErrInvalidLimit    = fmt.Errorf("%w: invalid limit", ErrBandwidthPolicy)
+)
+
+// Limits are the bandwidth of a pod in bits per second, as understood by the bandwidth CNI plugin
+// such as "10M". An empty limit leaves the direction unlimited.
+type Limits struct {
+	Ingress string `yaml:"ingress"`
+	Egress  string `yaml:"egress"`
+}
+
+// Tier applies its limits to services with at least the given resources per replica.
+// CPU is in millicores and memory in bytes.
+type Tier struct {
+	MinCPU    uint64 `yaml:"min_cpu"`
+	MinMemory uint64 `yaml:"min_memory"`
+	Limits    `yaml:",inline"`
+}
+
+// Policy limits the bandwidth of every lease pod. The largest tier a service qualifies for
+// wins, services qualifying for no tier get the default limits.
+type Policy struct {
+	Limits `yaml:",inline"`
+	Tiers  []Tier `yaml:"tiers"`
+}
+
+// ReadFile loads and validates a policy from the YAML file at the given path
+func ReadFile(fpath string) (*Policy, error) {
+	buf, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &Policy{}
+	if err := yaml.Unmarshal(buf, policy); err != nil {
+		return nil, err
+	}
+
+	if err := policy.ValidateBasic(); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// ValidateBasic checks all limits of the policy are valid quantities
+func (p *Policy) ValidateBasic() error {
+	if p == nil {
+		return nil
+	}
+
+	if err := p.Limits.validate(); err != nil {
+		return err
+	}
+
+	for idx, tier := range p.Tiers {
+		if err := tier.Limits.validate(); err != nil {
+			return fmt.Errorf("tier %d: %w", idx, err)
+		}
+	}
+
+	return nil
+}
+
+// LimitsFor returns the limits applying to a replica with the given resources
+func (p *Policy) LimitsFor(units atypes.ResourceUnits) Limits {
+	if p == nil {
+		return Limits{}
+	}
+
+	var cpu, memory uint64
+	if units.CPU != nil {
+		cpu = units.CPU.Units.Value()
+	}
+	if units.Memory != nil {
+		memory = units.Memory.Quantity.Value()
+	}
+
+	var best *Tier
+	for idx := range p.Tiers {
+		tier := &p.Tiers[idx]
+		if cpu < tier.MinCPU || memory < tier.MinMemory {
+			continue
+		}
+
+		if best == nil || tier.MinCPU > best.MinCPU || (tier.MinCPU == best.MinCPU && tier.MinMemory > best.MinMemory) {
+			best = tier
+		}
+	}
+
+	if best == nil {
+		return p.Limits
+	}
+
+	return best.Limits
+}
+
+// Annotations returns the pod annotations enforcing the limits
+func (l Limits) Annotations() map[string]string {
+	annotations := make(map[string]string)
+
+	if l.Ingress != "" {
+		annotations[AnnotationIngress] = l.Ingress
+	}
+	if l.Egress != "" {
+		annotations[AnnotationEgress] = l.Egress
+	}
+
+	return annotations
+}
+
+func (l Limits) validate() error {
+	for _, limit := range []string{l.Ingress, l.Egress} {
+		if limit == "" {
+			continue
+		}
+
+		qty, err := resource.ParseQuantity(limit)
+		if err != nil || qty.Sign() <= 0 {
+			return fmt.Errorf("%w: %q", ErrInvalidLimit, limit)
+		}
+	}
+
+	return nil
+}
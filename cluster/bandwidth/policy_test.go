@@ -0,0 +1,55 @@
+package bandwidth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	atypes "github.com/akash-network/node/types/v1beta2"
+)
+
+func TestReadFile(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "bandwidth.yaml")
+	require.NoError(t, os.WriteFile(fpath, []byte(`
+ingress: 10M
+egress: 10M
+tiers:
+  - min_cpu: 1000
+    ingress: 100M
+    egress: 50M
+  - min_cpu: 4000
+    min_memory: 8589934592
+    ingress: 1G
+    egress: 500M
+`), 0600))
+
+	policy, err := ReadFile(fpath)
+	require.NoError(t, err)
+
+	units := func(cpu, memory uint64) atypes.ResourceUnits {
+		return atypes.ResourceUnits{
+			CPU:    &atypes.CPU{Units: atypes.NewResourceValue(cpu)},
+			Memory: &atypes.Memory{Quantity: atypes.NewResourceValue(memory)},
+		}
+	}
+
+	require.Equal(t, Limits{Ingress: "10M", Egress: "10M"}, policy.LimitsFor(units(100, 512*1024*1024)))
+	require.Equal(t, Limits{Ingress: "100M", Egress: "50M"}, policy.LimitsFor(units(1000, 512*1024*1024)))
+	require.Equal(t, Limits{Ingress: "100M", Egress: "50M"}, policy.LimitsFor(units(4000, 512*1024*1024)))
+	require.Equal(t, Limits{Ingress: "1G", Egress: "500M"}, policy.LimitsFor(units(4000, 8589934592)))
+}
+
+func TestValidateBasic(t *testing.T) {
+	require.ErrorIs(t, (&Policy{Limits: Limits{Ingress: "fast"}}).ValidateBasic(), ErrInvalidLimit)
+	require.ErrorIs(t, (&Policy{Limits: Limits{Egress: "-1M"}}).ValidateBasic(), ErrInvalidLimit)
+	require.ErrorIs(t, (&Policy{Tiers: []Tier{{Limits: Limits{Egress: "x"}}}}).ValidateBasic(), ErrInvalidLimit)
+	require.NoError(t, (&Policy{Limits: Limits{Egress: "1G"}}).ValidateBasic())
+}
+
+func TestNilPolicy(t *testing.T) {
+	var policy *Policy
+	require.NoError(t, policy.ValidateBasic())
+	require.Empty(t, policy.LimitsFor(atypes.ResourceUnits{}).Annotations())
+}
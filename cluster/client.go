@@ -54,18 +54,25 @@ var _ Client = (*nullClient)(nil)
 type ReadClient interface {
 	LeaseStatus(context.Context, mtypes.LeaseID) (map[string]*ctypes.ServiceStatus, error)
 	ForwardedPortStatus(context.Context, mtypes.LeaseID) (map[string][]ctypes.ForwardedPortStatus, error)
+	// NodePorts lists the node ports held by the services of every lease, ordered by node port
+	NodePorts(context.Context) ([]ctypes.NodePortAllocation, error)
 	LeaseEvents(context.Context, mtypes.LeaseID, string, bool) (ctypes.EventsWatcher, error)
 	LeaseLogs(context.Context, mtypes.LeaseID, string, bool, *int64) ([]*ctypes.ServiceLog, error)
 	ServiceStatus(context.Context, mtypes.LeaseID, string) (*ctypes.ServiceStatus, error)
+	// LeaseVolumeSnapshots lists the snapshots of persistent volumes of the lease, newest first
+	LeaseVolumeSnapshots(context.Context, mtypes.LeaseID) ([]ctypes.VolumeSnapshot, error)
 
 	AllHostnames(context.Context) ([]ctypes.ActiveHostname, error)
 	GetManifestGroup(context.Context, mtypes.LeaseID) (bool, crd.ManifestGroup, error)
+	GetManifestStatus(context.Context, mtypes.LeaseID) (bool, crd.ManifestStatus, error)
 
 	ObserveHostnameState(ctx context.Context) (<-chan ctypes.HostnameResourceEvent, error)
 	GetHostnameDeploymentConnections(ctx context.Context) ([]ctypes.LeaseIDHostnameConnection, error)
 
 	ObserveIPState(ctx context.Context) (<-chan ctypes.IPResourceEvent, error)
 	GetDeclaredIPs(ctx context.Context, leaseID mtypes.LeaseID) ([]akashtypes.ProviderLeasedIPSpec, error)
+	// DeclaredIPStates returns the state the IP operator recorded for the leased IPs of the lease
+	DeclaredIPStates(ctx context.Context, leaseID mtypes.LeaseID) ([]ctypes.DeclaredIPState, error)
 }
 
 // Client interface lease and deployment methods
@@ -73,7 +80,21 @@ type Client interface {
 	ReadClient
 	Deploy(ctx context.Context, lID mtypes.LeaseID, mgroup *manifest.Group) error
 	TeardownLease(context.Context, mtypes.LeaseID) error
+	// UpdateManifestStatus records the state of the lease on its Manifest CRD
+	UpdateManifestStatus(context.Context, mtypes.LeaseID, crd.ManifestStatus) error
+	// LastGoodGroup returns the group last found healthy for the lease, nil when none was recorded
+	LastGoodGroup(context.Context, mtypes.LeaseID) (*manifest.Group, error)
+	// SaveLastGoodGroup records the group last found healthy on the Manifest CRD of the lease. Its rollout
+	// completed, the resource quota of the lease namespace is tightened to the group.
+	SaveLastGoodGroup(context.Context, mtypes.LeaseID, *manifest.Group) error
+	// RestartLeasePods deletes pods of the lease which are not ready, letting their controllers recreate them
+	RestartLeasePods(context.Context, mtypes.LeaseID) error
+	// RestoreVolumeSnapshot starts replacing the persistent volume a snapshot of the lease was taken from with its
+	// content. The restore runs in the background and reports its progress in the snapshot status
+	RestoreVolumeSnapshot(context.Context, mtypes.LeaseID, string) error
 	Deployments(context.Context) ([]ctypes.Deployment, error)
+	// LeaseNamespaces returns the IDs of the leases which have a namespace in the cluster
+	LeaseNamespaces(context.Context) ([]mtypes.LeaseID, error)
 	Inventory(context.Context) (ctypes.Inventory, error)
 	Exec(ctx context.Context,
 		lID mtypes.LeaseID,
@@ -90,6 +111,10 @@ type Client interface {
 	ConnectHostnameToDeployment(ctx context.Context, directive ctypes.ConnectHostnameToDeploymentDirective) error
 	// Remove a given hostname from a deployment
 	RemoveHostnameFromDeployment(ctx context.Context, hostname string, leaseID mtypes.LeaseID, allowMissing bool) error
+	// UpdateHostnameTLSStatus checks the certificate served for a hostname and records it in the hostname status
+	UpdateHostnameTLSStatus(ctx context.Context, leaseID mtypes.LeaseID, hostname string) (crd.ProviderHostTLSStatus, error)
+	// UpdateHostnameState records whether the ingress of a hostname is applied in the hostname status
+	UpdateHostnameState(ctx context.Context, hostname string, state string, message string) error
 
 	// Declare that a given deployment should be connected to a given hostname
 	DeclareHostname(ctx context.Context, lID mtypes.LeaseID, host string, serviceName string, externalPort uint32) error
@@ -102,6 +127,8 @@ type Client interface {
 	KubeVersion() (*version.Info, error)
 
 	DeclareIP(ctx context.Context, lID mtypes.LeaseID, serviceName string, port uint32, externalPort uint32, proto manifest.ServiceProtocol, sharingKey string, overwrite bool) error
+	// UpdateIPState records whether the LoadBalancer service of a leased IP is applied in the leased IP status
+	UpdateIPState(ctx context.Context, sharingKey string, proto manifest.ServiceProtocol, externalPort uint32, state string, message string) error
 	PurgeDeclaredIP(ctx context.Context, lID mtypes.LeaseID, serviceName string, externalPort uint32, proto manifest.ServiceProtocol) error
 	PurgeDeclaredIPs(ctx context.Context, lID mtypes.LeaseID) error
 }
@@ -315,6 +342,11 @@ func (inv *inventory) Metrics() ctypes.InventoryMetrics {
 		ret.Nodes = append(ret.Nodes, invNode)
 	}
 
+	for class, storage := range inv.storage {
+		storageTotal[class] = storage.allocatable.Int64()
+		storageAvailable[class] = storage.available().Int64()
+	}
+
 	ret.TotalAllocatable = ctypes.InventoryMetricTotal{
 		CPU:              cpuTotal,
 		Memory:           memoryTotal,
@@ -334,7 +366,8 @@ func (inv *inventory) Metrics() ctypes.InventoryMetrics {
 
 func (inv *inventory) dup() *inventory {
 	res := &inventory{
-		nodes: make([]*node, 0, len(inv.nodes)),
+		storage: inv.storage.dup(),
+		nodes:   make([]*node, 0, len(inv.nodes)),
 	}
 
 	for _, nd := range inv.nodes {
@@ -388,6 +421,14 @@ func (c *nullClient) RemoveHostnameFromDeployment(ctx context.Context, hostname
 	return errNotImplemented
 }
 
+func (c *nullClient) UpdateHostnameTLSStatus(ctx context.Context, leaseID mtypes.LeaseID, hostname string) (crd.ProviderHostTLSStatus, error) {
+	return crd.ProviderHostTLSStatus{}, errNotImplemented
+}
+
+func (c *nullClient) UpdateHostnameState(ctx context.Context, hostname string, state string, message string) error {
+	return errNotImplemented
+}
+
 func (c *nullClient) ObserveHostnameState(ctx context.Context) (<-chan ctypes.HostnameResourceEvent, error) {
 	return nil, errNotImplemented
 }
@@ -431,6 +472,10 @@ func (c *nullClient) Deploy(ctx context.Context, lid mtypes.LeaseID, mgroup *man
 	return nil
 }
 
+func (*nullClient) NodePorts(context.Context) ([]ctypes.NodePortAllocation, error) {
+	return nil, nil
+}
+
 func (*nullClient) ForwardedPortStatus(context.Context, mtypes.LeaseID) (map[string][]ctypes.ForwardedPortStatus, error) {
 	return nil, errNotImplemented
 }
@@ -537,10 +582,26 @@ func (c *nullClient) TeardownLease(_ context.Context, lid mtypes.LeaseID) error
 	return nil
 }
 
+func (c *nullClient) RestartLeasePods(context.Context, mtypes.LeaseID) error {
+	return nil
+}
+
+func (c *nullClient) LeaseVolumeSnapshots(context.Context, mtypes.LeaseID) ([]ctypes.VolumeSnapshot, error) {
+	return nil, nil
+}
+
+func (c *nullClient) RestoreVolumeSnapshot(context.Context, mtypes.LeaseID, string) error {
+	return errNotImplemented
+}
+
 func (c *nullClient) Deployments(context.Context) ([]ctypes.Deployment, error) {
 	return nil, nil
 }
 
+func (c *nullClient) LeaseNamespaces(context.Context) ([]mtypes.LeaseID, error) {
+	return nil, nil
+}
+
 func (c *nullClient) Inventory(context.Context) (ctypes.Inventory, error) {
 	inv := &inventory{
 		nodes: []*node{
@@ -582,6 +643,22 @@ func (c *nullClient) GetManifestGroup(context.Context, mtypes.LeaseID) (bool, cr
 	return false, crd.ManifestGroup{}, nil
 }
 
+func (c *nullClient) GetManifestStatus(context.Context, mtypes.LeaseID) (bool, crd.ManifestStatus, error) {
+	return false, crd.ManifestStatus{}, nil
+}
+
+func (c *nullClient) UpdateManifestStatus(context.Context, mtypes.LeaseID, crd.ManifestStatus) error {
+	return nil
+}
+
+func (c *nullClient) LastGoodGroup(context.Context, mtypes.LeaseID) (*manifest.Group, error) {
+	return nil, nil
+}
+
+func (c *nullClient) SaveLastGoodGroup(context.Context, mtypes.LeaseID, *manifest.Group) error {
+	return nil
+}
+
 func (c *nullClient) AllHostnames(context.Context) ([]ctypes.ActiveHostname, error) {
 	return nil, nil
 }
@@ -614,6 +691,14 @@ func (c *nullClient) PurgeDeclaredIP(ctx context.Context, lID mtypes.LeaseID, se
 	return errNotImplemented
 }
 
+func (c *nullClient) UpdateIPState(ctx context.Context, sharingKey string, proto manifest.ServiceProtocol, externalPort uint32, state string, message string) error {
+	return errNotImplemented
+}
+
+func (c *nullClient) DeclaredIPStates(ctx context.Context, leaseID mtypes.LeaseID) ([]ctypes.DeclaredIPState, error) {
+	return nil, errNotImplemented
+}
+
 func (c *nullClient) GetDeclaredIPs(ctx context.Context, leaseID mtypes.LeaseID) ([]akashtypes.ProviderLeasedIPSpec, error) {
 	return nil, errNotImplemented
 }
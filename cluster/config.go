@@ -13,11 +13,32 @@ type Config struct {
 	DeploymentIngressStaticHosts    bool
 	DeploymentIngressDomain         string
 	ClusterSettings                 map[interface{}]interface{}
+	Monitor                         MonitorConfig
+	MonitorOverrides                MonitorOverrides
+	// DeploymentRollbackTimeout is how long an updated manifest may stay unhealthy before
+	// the last healthy one is deployed again. Zero disables rollbacks
+	DeploymentRollbackTimeout time.Duration
+	// ReconcilePeriod is how often leases active on chain are compared with the cluster. Zero disables it
+	ReconcilePeriod time.Duration
+	// ReconcileGracePeriod is how long a lease may be orphaned or miss its deployment before it is acted upon
+	ReconcileGracePeriod time.Duration
+	// ReconcileTeardown tears down leases orphaned for longer than the grace period, they are only reported otherwise
+	ReconcileTeardown bool
+	// ReconcileMaxOrphanedPercent skips teardowns while more than this share of the deployed leases is orphaned.
+	// Zero disables the check
+	ReconcileMaxOrphanedPercent uint
+	// HostnameVerification requires tenants to prove they own custom hostnames through DNS
+	HostnameVerification HostnameVerificationConfig
+	// HostnameRulesPath is the file the hostname rules managed at runtime are stored in
+	HostnameRulesPath string
+	// OwnerQuotas caps the leased IPs and NodePort endpoints reserved per owner
+	OwnerQuotas OwnerQuotas
 }
 
 func NewDefaultConfig() Config {
 	return Config{
 		InventoryResourcePollPeriod:     time.Second * 5,
 		InventoryResourceDebugFrequency: 10,
+		Monitor:                         NewDefaultMonitorConfig(),
 	}
 }
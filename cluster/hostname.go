@@ -3,6 +3,7 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 
@@ -214,6 +215,18 @@ func canReserveHostnamesImpl(store map[string]hostnameID, hostnames []string, ow
 	chErr <- nil
 }
 
+func (sh *SimpleHostnames) HostnameRules() []clustertypes.HostnameRule {
+	return nil
+}
+
+func (sh *SimpleHostnames) SetHostnameRules(context.Context, []clustertypes.HostnameRule) ([]clustertypes.HostnameRuleViolation, error) {
+	return nil, nil
+}
+
+func (sh *SimpleHostnames) VerificationToken(sdktypes.Address) (string, error) {
+	return "", ErrHostnameVerificationDisabled
+}
+
 func (sh *SimpleHostnames) ReleaseHostnames(leaseID mtypes.LeaseID) error {
 	sh.lock.Lock()
 	defer sh.lock.Unlock()
@@ -253,6 +266,11 @@ type canReserveRequest struct {
 	ownerAddr sdktypes.Address
 }
 
+type setRulesRequest struct {
+	rules  []hostnameRule
+	result chan<- []clustertypes.HostnameRuleViolation
+}
+
 type prepareTransferRequest struct {
 	hostnames []string
 	hID       hostnameID
@@ -265,36 +283,57 @@ type hostnameService struct {
 	requests       chan reserveRequest
 	canRequest     chan canReserveRequest
 	prepareRequest chan prepareTransferRequest
+	rulesRequest   chan setRulesRequest
+	holdersRequest chan holdersRequest
 	releases       chan hostnameID
 	lc             lifecycle.Lifecycle
 
-	blockedHostnames []string
-	blockedDomains   []string
+	// rules holds the rules of --deployment-blocked-hostnames first, followed by the
+	// ones managed at runtime
+	rules       []hostnameRule
+	staticRules int
+	rulesPath   string
+	rulesLock   sync.RWMutex
+	// rulesWriteLock orders updates of the rules file
+	rulesWriteLock sync.Mutex
+
+	verifier *hostnameVerifier
 }
 
 const HostnameSeparator = '.'
 
 func newHostnameService(ctx context.Context, cfg Config, initialData map[string]mtypes.LeaseID) (*hostnameService, error) {
-	blockedHostnames := make([]string, 0)
-	blockedDomains := make([]string, 0)
-	for _, name := range cfg.BlockedHostnames {
-		if len(name) != 0 && name[0] == HostnameSeparator {
-			blockedDomains = append(blockedDomains, name)
-			blockedHostnames = append(blockedHostnames, name[1:])
-		} else {
-			blockedHostnames = append(blockedHostnames, name)
+	staticRules, err := compileHostnameRules(blockedHostnameRules(cfg.BlockedHostnames))
+	if err != nil {
+		return nil, err
+	}
+
+	var managedRules []clustertypes.HostnameRule
+	if cfg.HostnameRulesPath != "" {
+		managedRules, err = ReadHostnameRules(cfg.HostnameRulesPath)
+		if err != nil {
+			return nil, err
 		}
 	}
 
+	rules, err := compileHostnameRules(managedRules)
+	if err != nil {
+		return nil, err
+	}
+
 	hs := &hostnameService{
-		inUse:            make(map[string]hostnameID, len(initialData)),
-		blockedHostnames: blockedHostnames,
-		blockedDomains:   blockedDomains,
-		requests:         make(chan reserveRequest),
-		canRequest:       make(chan canReserveRequest),
-		releases:         make(chan hostnameID),
-		lc:               lifecycle.New(),
-		prepareRequest:   make(chan prepareTransferRequest),
+		inUse:          make(map[string]hostnameID, len(initialData)),
+		rules:          append(staticRules, rules...),
+		staticRules:    len(staticRules),
+		rulesPath:      cfg.HostnameRulesPath,
+		requests:       make(chan reserveRequest),
+		canRequest:     make(chan canReserveRequest),
+		releases:       make(chan hostnameID),
+		lc:             lifecycle.New(),
+		prepareRequest: make(chan prepareTransferRequest),
+		rulesRequest:   make(chan setRulesRequest),
+		holdersRequest: make(chan holdersRequest),
+		verifier:       newHostnameVerifier(cfg.HostnameVerification, cfg.DeploymentIngressDomain),
 	}
 	for k, v := range initialData {
 		hID, err := hostnameIDFromLeaseID(v)
@@ -329,6 +368,10 @@ loop:
 			releaseHostnamesImpl(hs.inUse, v)
 		case request := <-hs.prepareRequest:
 			prepareHostnamesImpl(hs.inUse, request.hostnames, request.hID, request.chErr)
+		case request := <-hs.rulesRequest:
+			request.result <- hs.setRules(request.rules)
+		case request := <-hs.holdersRequest:
+			request.result <- holdersImpl(hs.inUse, request.hostnames)
 
 		}
 	}
@@ -368,20 +411,89 @@ func (hs *hostnameService) PrepareHostnamesForTransfer(ctx context.Context, host
 	}
 }
 
-func (hs *hostnameService) isHostnameBlocked(hostname string) error {
-	for _, blockedHostname := range hs.blockedHostnames {
-		if blockedHostname == hostname {
-			return fmt.Errorf("%w: %q is blocked by this provider", ErrHostnameNotAllowed, hostname)
+func (hs *hostnameService) isHostnameBlocked(hostname string, owner sdktypes.Address) error {
+	hs.rulesLock.RLock()
+	defer hs.rulesLock.RUnlock()
+
+	return checkHostnameRules(hs.rules, hostname, owner)
+}
+
+// setRules replaces the rules managed at runtime and returns the hostnames in use they block
+func (hs *hostnameService) setRules(rules []hostnameRule) []clustertypes.HostnameRuleViolation {
+	hs.rulesLock.Lock()
+	hs.rules = append(hs.rules[:hs.staticRules:hs.staticRules], rules...)
+	hs.rulesLock.Unlock()
+
+	violations := make([]clustertypes.HostnameRuleViolation, 0)
+	for hostname, hID := range hs.inUse {
+		if hs.isHostnameBlocked(hostname, hID.owner) == nil {
+			continue
 		}
+
+		violations = append(violations, clustertypes.HostnameRuleViolation{
+			Hostname: hostname,
+			Owner:    hID.owner.String(),
+			DSeq:     hID.dseq,
+			GSeq:     hID.gseq,
+		})
 	}
 
-	for _, blockedDomain := range hs.blockedDomains {
-		if strings.HasSuffix(hostname, blockedDomain) {
-			return fmt.Errorf("%w: domain %q is blocked by this provider", ErrHostnameNotAllowed, hostname)
+	sort.Slice(violations, func(i, j int) bool {
+		return violations[i].Hostname < violations[j].Hostname
+	})
+
+	return violations
+}
+
+// HostnameRules returns the rules managed at runtime
+func (hs *hostnameService) HostnameRules() []clustertypes.HostnameRule {
+	hs.rulesLock.RLock()
+	defer hs.rulesLock.RUnlock()
+
+	result := make([]clustertypes.HostnameRule, 0, len(hs.rules)-hs.staticRules)
+	for _, rule := range hs.rules[hs.staticRules:] {
+		result = append(result, rule.HostnameRule)
+	}
+
+	return result
+}
+
+// SetHostnameRules replaces the rules managed at runtime, storing them in the rules file if one is
+// configured. Hostnames of running leases are not released when they become blocked, they are
+// returned for the provider to act upon.
+func (hs *hostnameService) SetHostnameRules(ctx context.Context, rules []clustertypes.HostnameRule) ([]clustertypes.HostnameRuleViolation, error) {
+	compiled, err := compileHostnameRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	hs.rulesWriteLock.Lock()
+	defer hs.rulesWriteLock.Unlock()
+
+	if hs.rulesPath != "" {
+		if err := writeHostnameRules(hs.rulesPath, rules); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	result := make(chan []clustertypes.HostnameRuleViolation, 1)
+
+	select {
+	case hs.rulesRequest <- setRulesRequest{rules: compiled, result: result}:
+	case <-hs.lc.ShuttingDown():
+		return nil, ErrNotRunning
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case violations := <-result:
+		return violations, nil
+	case <-hs.lc.ShuttingDown():
+		return nil, ErrNotRunning
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
 
 func (hs *hostnameService) ReserveHostnames(ctx context.Context, hostnames []string, leaseID mtypes.LeaseID) ([]string, error) {
@@ -390,22 +502,32 @@ func (hs *hostnameService) ReserveHostnames(ctx context.Context, hostnames []str
 		lowercaseHostnames[i] = strings.ToLower(hostname)
 	}
 
+	hID, err := hostnameIDFromLeaseID(leaseID)
+
+	if err != nil {
+		return nil, err
+	}
+
 	// check if hostname is blocked
 	for _, hostname := range lowercaseHostnames {
-		blockedErr := hs.isHostnameBlocked(hostname)
+		blockedErr := hs.isHostnameBlocked(hostname, hID.owner)
 		if blockedErr != nil {
 			return nil, blockedErr
 		}
 	}
 
-	chErr := make(chan error, 1)                  // Buffer of one so service does not block
-	chWithheldHostnames := make(chan []string, 1) // Buffer of one so service does not block
-
-	hID, err := hostnameIDFromLeaseID(leaseID)
-
+	unverified, err := hs.unverifiedHostnames(ctx, lowercaseHostnames, hID.owner)
 	if err != nil {
 		return nil, err
 	}
+
+	if err := hs.verifier.verify(ctx, unverified, hID.owner, hs.ownership(hID.owner, lowercaseHostnames, false)); err != nil {
+		return nil, err
+	}
+
+	chErr := make(chan error, 1)                  // Buffer of one so service does not block
+	chWithheldHostnames := make(chan []string, 1) // Buffer of one so service does not block
+
 	request := reserveRequest{
 		chErr:               chErr,
 		chReplacedHostnames: chWithheldHostnames,
@@ -457,12 +579,21 @@ func (hs *hostnameService) CanReserveHostnames(hostnames []string, ownerAddr sdk
 
 	// check if hostname is blocked
 	for _, hostname := range lowercaseHostnames {
-		blockedErr := hs.isHostnameBlocked(hostname)
+		blockedErr := hs.isHostnameBlocked(hostname, ownerAddr)
 		if blockedErr != nil {
 			return blockedErr
 		}
 	}
 
+	unverified, err := hs.unverifiedHostnames(context.Background(), lowercaseHostnames, ownerAddr)
+	if err != nil {
+		return err
+	}
+
+	if err := hs.verifier.verify(context.Background(), unverified, ownerAddr, hs.ownership(ownerAddr, lowercaseHostnames, true)); err != nil {
+		return err
+	}
+
 	request := canReserveRequest{ // do not actually reserve hostnames
 		hostnames: lowercaseHostnames,
 		result:    returnValue,
@@ -478,3 +609,101 @@ func (hs *hostnameService) CanReserveHostnames(hostnames []string, ownerAddr sdk
 
 	return <-returnValue
 }
+
+type holdersRequest struct {
+	hostnames []string
+	result    chan<- map[string]hostnameID
+}
+
+func holdersImpl(store map[string]hostnameID, hostnames []string) map[string]hostnameID {
+	result := make(map[string]hostnameID)
+	for _, hostname := range hostnames {
+		if hID, inUse := store[hostname]; inUse {
+			result[hostname] = hID
+		}
+	}
+
+	return result
+}
+
+// holders returns who holds the hostnames which are in use
+func (hs *hostnameService) holders(ctx context.Context, hostnames []string) (map[string]hostnameID, error) {
+	result := make(chan map[string]hostnameID, 1) // Buffer of one so service does not block
+
+	select {
+	case hs.holdersRequest <- holdersRequest{hostnames: hostnames, result: result}:
+	case <-hs.lc.ShuttingDown():
+		return nil, ErrNotRunning
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case holders := <-result:
+		return holders, nil
+	case <-hs.lc.ShuttingDown():
+		return nil, ErrNotRunning
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// unverifiedHostnames drops the hostnames owner already holds, they were verified when they were reserved
+func (hs *hostnameService) unverifiedHostnames(ctx context.Context, hostnames []string, owner sdktypes.Address) ([]string, error) {
+	if !hs.verifier.cfg.Enabled {
+		return hostnames, nil
+	}
+
+	holders, err := hs.holders(ctx, hostnames)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		if hID, held := holders[hostname]; !held || !hID.owner.Equals(owner) {
+			result = append(result, hostname)
+		}
+	}
+
+	return result, nil
+}
+
+// ownership accepts CNAME targets held by owner, and the hostnames of the provider domain requested
+// along with the verified ones, such as the ingress hostnames generated for the lease. When bidding
+// the lease does not exist yet and neither does its generated hostname, any hostname of the provider
+// domain nobody holds is accepted then.
+func (hs *hostnameService) ownership(owner sdktypes.Address, requested []string, bidding bool) hostnameOwnership {
+	return func(ctx context.Context, hostname string) (bool, error) {
+		if !hs.verifier.isProviderHostname(hostname) {
+			return false, nil
+		}
+
+		for _, candidate := range requested {
+			if candidate == hostname {
+				return true, nil
+			}
+		}
+
+		holders, err := hs.holders(ctx, []string{hostname})
+		if err != nil {
+			return false, err
+		}
+
+		hID, held := holders[hostname]
+		if !held {
+			return bidding, nil
+		}
+
+		return hID.owner.Equals(owner), nil
+	}
+}
+
+// VerificationToken returns the value of the TXT record proving the hostnames of owner belong to it
+func (hs *hostnameService) VerificationToken(ownerAddr sdktypes.Address) (string, error) {
+	if !hs.verifier.cfg.Enabled {
+		return "", ErrHostnameVerificationDisabled
+	}
+
+	return hs.verifier.token(ownerAddr), nil
+}
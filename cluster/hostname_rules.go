@@ -0,0 +1,207 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	clustertypes "github.com/akash-network/provider/cluster/types/v1beta2"
+)
+
+var ErrInvalidHostnameRule = errors.New("invalid hostname rule")
+
+// hostnameRule is a rule compiled for matching
+type hostnameRule struct {
+	clustertypes.HostnameRule
+	owner sdktypes.AccAddress
+	match func(hostname string) bool
+}
+
+func compileHostnameRule(rule clustertypes.HostnameRule) (hostnameRule, error) {
+	result := hostnameRule{HostnameRule: rule}
+	pattern := strings.ToLower(rule.Pattern)
+
+	if pattern == "" {
+		return hostnameRule{}, fmt.Errorf("%w: empty pattern", ErrInvalidHostnameRule)
+	}
+
+	switch rule.Action {
+	case clustertypes.HostnameRuleBlock:
+		if rule.Owner != "" {
+			return hostnameRule{}, fmt.Errorf("%w: owner is set on block rule %q", ErrInvalidHostnameRule, rule.Pattern)
+		}
+	case clustertypes.HostnameRuleAllow:
+		if rule.Owner != "" {
+			owner, err := sdktypes.AccAddressFromBech32(rule.Owner)
+			if err != nil {
+				return hostnameRule{}, fmt.Errorf("%w: owner %q: %s", ErrInvalidHostnameRule, rule.Owner, err)
+			}
+			result.owner = owner
+		}
+	default:
+		return hostnameRule{}, fmt.Errorf("%w: unknown action %q", ErrInvalidHostnameRule, rule.Action)
+	}
+
+	switch rule.Type {
+	case clustertypes.HostnameRuleExact:
+		result.match = func(hostname string) bool {
+			return hostname == pattern
+		}
+	case clustertypes.HostnameRuleDomain:
+		pattern = strings.TrimPrefix(pattern, string(HostnameSeparator))
+		result.match = func(hostname string) bool {
+			return hostname == pattern || strings.HasSuffix(hostname, string(HostnameSeparator)+pattern)
+		}
+	case clustertypes.HostnameRuleGlob:
+		// hostnames have no slash, so path.Match lets * match dots as well
+		if _, err := path.Match(pattern, ""); err != nil {
+			return hostnameRule{}, fmt.Errorf("%w: glob %q: %s", ErrInvalidHostnameRule, rule.Pattern, err)
+		}
+		result.match = func(hostname string) bool {
+			matched, _ := path.Match(pattern, hostname)
+			return matched
+		}
+	case clustertypes.HostnameRuleRegex:
+		// hostnames are matched lowercase, lowercasing the pattern would change escapes such as \D
+		re, err := regexp.Compile(fmt.Sprintf("(?i)^(?:%s)$", rule.Pattern))
+		if err != nil {
+			return hostnameRule{}, fmt.Errorf("%w: regex %q: %s", ErrInvalidHostnameRule, rule.Pattern, err)
+		}
+		result.match = re.MatchString
+	default:
+		return hostnameRule{}, fmt.Errorf("%w: unknown type %q", ErrInvalidHostnameRule, rule.Type)
+	}
+
+	return result, nil
+}
+
+func compileHostnameRules(rules []clustertypes.HostnameRule) ([]hostnameRule, error) {
+	result := make([]hostnameRule, 0, len(rules))
+	for _, rule := range rules {
+		compiled, err := compileHostnameRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, compiled)
+	}
+
+	return result, nil
+}
+
+// blockedHostnameRules converts the names of --deployment-blocked-hostnames to block rules.
+// Names with a leading dot block the domain and all its subdomains.
+func blockedHostnameRules(names []string) []clustertypes.HostnameRule {
+	result := make([]clustertypes.HostnameRule, 0, len(names))
+	for _, name := range names {
+		if len(name) == 0 {
+			continue
+		}
+
+		rule := clustertypes.HostnameRule{
+			Type:    clustertypes.HostnameRuleExact,
+			Pattern: name,
+			Action:  clustertypes.HostnameRuleBlock,
+		}
+
+		if name[0] == HostnameSeparator {
+			rule.Type = clustertypes.HostnameRuleDomain
+			rule.Pattern = name[1:]
+		}
+
+		result = append(result, rule)
+	}
+
+	return result
+}
+
+// checkHostnameRules returns an error if a block rule matches the hostname and no allow rule
+// applying to the owner does
+func checkHostnameRules(rules []hostnameRule, hostname string, owner sdktypes.Address) error {
+	var blockedBy *hostnameRule
+
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.match(hostname) {
+			continue
+		}
+
+		if rule.Action == clustertypes.HostnameRuleAllow {
+			if rule.owner.Empty() || (owner != nil && rule.owner.Equals(owner)) {
+				return nil
+			}
+			continue
+		}
+
+		if blockedBy == nil {
+			blockedBy = rule
+		}
+	}
+
+	switch {
+	case blockedBy == nil:
+		return nil
+	case blockedBy.Type == clustertypes.HostnameRuleExact:
+		return fmt.Errorf("%w: %q is blocked by this provider", ErrHostnameNotAllowed, hostname)
+	case blockedBy.Type == clustertypes.HostnameRuleDomain:
+		return fmt.Errorf("%w: domain %q is blocked by this provider", ErrHostnameNotAllowed, hostname)
+	default:
+		return fmt.Errorf("%w: %q matches %s %q blocked by this provider", ErrHostnameNotAllowed, hostname, blockedBy.Type, blockedBy.Pattern)
+	}
+}
+
+// ReadHostnameRules loads the rules managed at runtime from the YAML file at the given path.
+// A missing file holds no rules.
+func ReadHostnameRules(fpath string) ([]clustertypes.HostnameRule, error) {
+	buf, err := os.ReadFile(fpath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	rules := make([]clustertypes.HostnameRule, 0)
+	if err := yaml.Unmarshal(buf, &rules); err != nil {
+		return nil, err
+	}
+
+	if _, err := compileHostnameRules(rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// writeHostnameRules replaces the file at the given path, the rules are never left half written
+func writeHostnameRules(fpath string, rules []clustertypes.HostnameRule) error {
+	buf, err := yaml.Marshal(rules)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fpath), filepath.Base(fpath)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(buf); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), fpath)
+}
@@ -0,0 +1,105 @@
+package cluster
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/akash-network/node/testutil"
+
+	clustertypes "github.com/akash-network/provider/cluster/types/v1beta2"
+)
+
+func TestHostnameRuleMatching(t *testing.T) {
+	owner := testutil.AccAddress(t)
+	otherOwner := testutil.AccAddress(t)
+
+	rules, err := compileHostnameRules([]clustertypes.HostnameRule{
+		{Type: clustertypes.HostnameRuleGlob, Pattern: "*.example.com", Action: clustertypes.HostnameRuleBlock},
+		{Type: clustertypes.HostnameRuleRegex, Pattern: `.*PayPa1.*`, Action: clustertypes.HostnameRuleBlock},
+		{Type: clustertypes.HostnameRuleExact, Pattern: "WWW.Example.com", Action: clustertypes.HostnameRuleAllow},
+		{Type: clustertypes.HostnameRuleDomain, Pattern: "shop.example.com", Action: clustertypes.HostnameRuleAllow, Owner: owner.String()},
+	})
+	require.NoError(t, err)
+
+	require.ErrorIs(t, checkHostnameRules(rules, "a.b.example.com", owner), ErrHostnameNotAllowed)
+	require.ErrorIs(t, checkHostnameRules(rules, "login.paypa1.net", owner), ErrHostnameNotAllowed)
+	require.NoError(t, checkHostnameRules(rules, "example.com", owner))
+	require.NoError(t, checkHostnameRules(rules, "www.example.com", otherOwner))
+
+	// exceptions for an owner do not apply to other tenants
+	require.NoError(t, checkHostnameRules(rules, "eu.shop.example.com", owner))
+	require.ErrorIs(t, checkHostnameRules(rules, "eu.shop.example.com", otherOwner), ErrHostnameNotAllowed)
+}
+
+func TestInvalidHostnameRules(t *testing.T) {
+	for _, rule := range []clustertypes.HostnameRule{
+		{Type: clustertypes.HostnameRuleRegex, Pattern: "(", Action: clustertypes.HostnameRuleBlock},
+		{Type: clustertypes.HostnameRuleGlob, Pattern: "[", Action: clustertypes.HostnameRuleBlock},
+		{Type: "prefix", Pattern: "www", Action: clustertypes.HostnameRuleBlock},
+		{Type: clustertypes.HostnameRuleExact, Pattern: "example.com", Action: "deny"},
+		{Type: clustertypes.HostnameRuleExact, Pattern: "", Action: clustertypes.HostnameRuleBlock},
+		{Type: clustertypes.HostnameRuleExact, Pattern: "example.com", Action: clustertypes.HostnameRuleBlock, Owner: testutil.AccAddress(t).String()},
+		{Type: clustertypes.HostnameRuleExact, Pattern: "example.com", Action: clustertypes.HostnameRuleAllow, Owner: "akash1invalid"},
+	} {
+		_, err := compileHostnameRule(rule)
+		require.ErrorIs(t, err, ErrInvalidHostnameRule, rule)
+	}
+}
+
+func TestSetHostnameRules(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testWait)
+	defer cancel()
+
+	rulesPath := filepath.Join(t.TempDir(), "rules.yaml")
+	cfg := Config{BlockedHostnames: []string{".bobsdefi.com"}, HostnameRulesPath: rulesPath}
+
+	svc, err := newHostnameService(ctx, cfg, nil)
+	require.NoError(t, err)
+	require.Empty(t, svc.HostnameRules())
+
+	leaseID := testutil.LeaseID(t)
+	_, err = svc.ReserveHostnames(ctx, []string{"meow.com", "kittens.com"}, leaseID)
+	require.NoError(t, err)
+
+	owner, err := leaseID.DeploymentID().GetOwnerAddress()
+	require.NoError(t, err)
+
+	rules := []clustertypes.HostnameRule{
+		{Type: clustertypes.HostnameRuleGlob, Pattern: "*ttens.com", Action: clustertypes.HostnameRuleBlock},
+		{Type: clustertypes.HostnameRuleExact, Pattern: "accounts.bobsdefi.com", Action: clustertypes.HostnameRuleAllow, Owner: owner.String()},
+	}
+
+	violations, err := svc.SetHostnameRules(ctx, rules)
+	require.NoError(t, err)
+	require.Equal(t, []clustertypes.HostnameRuleViolation{{
+		Hostname: "kittens.com",
+		Owner:    owner.String(),
+		DSeq:     leaseID.DSeq,
+		GSeq:     leaseID.GSeq,
+	}}, violations)
+	require.Equal(t, rules, svc.HostnameRules())
+
+	// the running lease keeps the hostname, other leases are denied it
+	_, err = svc.ReserveHostnames(ctx, []string{"bigkittens.com"}, testutil.LeaseID(t))
+	require.ErrorIs(t, err, ErrHostnameNotAllowed)
+
+	// managed rules make exceptions to the ones of --deployment-blocked-hostnames
+	require.NoError(t, svc.CanReserveHostnames([]string{"accounts.bobsdefi.com"}, owner))
+	require.ErrorIs(t, svc.CanReserveHostnames([]string{"www.bobsdefi.com"}, owner), ErrHostnameNotAllowed)
+
+	_, err = svc.SetHostnameRules(ctx, []clustertypes.HostnameRule{{Type: clustertypes.HostnameRuleRegex, Pattern: "(", Action: clustertypes.HostnameRuleBlock}})
+	require.ErrorIs(t, err, ErrInvalidHostnameRule)
+	require.Equal(t, rules, svc.HostnameRules())
+
+	// the rules are reloaded from the file
+	stored, err := ReadHostnameRules(rulesPath)
+	require.NoError(t, err)
+	require.Equal(t, rules, stored)
+
+	reloaded, err := newHostnameService(ctx, cfg, nil)
+	require.NoError(t, err)
+	require.Equal(t, rules, reloaded.HostnameRules())
+}
@@ -0,0 +1,208 @@
+package cluster
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/pkg/errors"
+)
+
+// HostnameVerificationRecordPrefix is prepended to a hostname to get the name of the TXT record
+// holding the verification token of its owner
+const HostnameVerificationRecordPrefix = "_akash-verification"
+
+const (
+	hostnameVerificationTimeout = 10 * time.Second
+	// failed verifications are cached shortly so tenants can retry soon after fixing their records
+	hostnameVerificationFailureTTL = 30 * time.Second
+)
+
+var (
+	ErrHostnameVerificationDisabled = errors.New("hostname verification is disabled")
+	ErrHostnameNotVerified          = fmt.Errorf("%w: ownership not verified", ErrHostnameNotAllowed)
+)
+
+// HostnameResolver looks up the DNS records proving ownership of a hostname. *net.Resolver implements it.
+type HostnameResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+type HostnameVerificationConfig struct {
+	// Enabled requires hostnames outside the provider domain to be verified before they are reserved
+	Enabled bool
+	// Secret is the key the tokens of the owners are derived from
+	Secret string
+	// CacheTTL is how long a successful verification is trusted
+	CacheTTL time.Duration
+	// Resolver looks up the records, net.DefaultResolver when nil
+	Resolver HostnameResolver
+}
+
+// NewHostnameResolver returns a resolver querying the DNS server at address instead of the system ones
+func NewHostnameResolver(address string) HostnameResolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{}
+			return d.DialContext(ctx, network, address)
+		},
+	}
+}
+
+type hostnameVerificationKey struct {
+	hostname string
+	owner    string
+}
+
+type hostnameVerificationResult struct {
+	err     error
+	expires time.Time
+}
+
+// hostnameOwnership reports whether the hostname a CNAME points to belongs to the owner being verified
+type hostnameOwnership func(ctx context.Context, hostname string) (bool, error)
+
+// hostnameVerifier checks that the owner of a lease controls the DNS of the custom hostnames it requests.
+// Ownership is proven by a TXT record holding the token of the owner, or a CNAME to a hostname of the
+// provider domain held by the owner.
+type hostnameVerifier struct {
+	cfg      HostnameVerificationConfig
+	domain   string
+	resolver HostnameResolver
+	now      func() time.Time
+
+	lock  sync.Mutex
+	cache map[hostnameVerificationKey]hostnameVerificationResult
+}
+
+func newHostnameVerifier(cfg HostnameVerificationConfig, domain string) *hostnameVerifier {
+	resolver := cfg.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return &hostnameVerifier{
+		cfg:      cfg,
+		domain:   strings.ToLower(strings.TrimSuffix(domain, ".")),
+		resolver: resolver,
+		now:      time.Now,
+		cache:    make(map[hostnameVerificationKey]hostnameVerificationResult),
+	}
+}
+
+// token returns the value the TXT record of a hostname of owner must hold
+func (hv *hostnameVerifier) token(owner sdktypes.Address) string {
+	mac := hmac.New(sha256.New, []byte(hv.cfg.Secret))
+	_, _ = mac.Write(owner.Bytes())
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// isProviderHostname is true for hostnames the provider generates under its own domain
+func (hv *hostnameVerifier) isProviderHostname(hostname string) bool {
+	return hv.domain != "" && (hostname == hv.domain || strings.HasSuffix(hostname, "."+hv.domain))
+}
+
+func (hv *hostnameVerifier) verify(ctx context.Context, hostnames []string, owner sdktypes.Address, owns hostnameOwnership) error {
+	if !hv.cfg.Enabled {
+		return nil
+	}
+
+	for _, hostname := range hostnames {
+		if hv.isProviderHostname(hostname) {
+			continue
+		}
+
+		if err := hv.verifyHostname(ctx, hostname, owner, owns); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (hv *hostnameVerifier) verifyHostname(ctx context.Context, hostname string, owner sdktypes.Address, owns hostnameOwnership) error {
+	key := hostnameVerificationKey{hostname: hostname, owner: owner.String()}
+
+	hv.lock.Lock()
+	cached, exists := hv.cache[key]
+	hv.lock.Unlock()
+
+	if exists && hv.now().Before(cached.expires) {
+		return cached.err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, hostnameVerificationTimeout)
+	defer cancel()
+
+	verified, byCNAME, err := hv.lookup(ctx, hostname, owner, owns)
+	if err != nil {
+		// resolver failures say nothing about the ownership, they are not cached
+		return fmt.Errorf("verifying hostname %q: %w", hostname, err)
+	}
+
+	// the target of a CNAME changes hands with the reservations, it is checked every time
+	if byCNAME {
+		return nil
+	}
+
+	result := hostnameVerificationResult{
+		expires: hv.now().Add(hv.cfg.CacheTTL),
+	}
+
+	if !verified {
+		result.err = fmt.Errorf("%w: %q has neither a TXT record %s.%s with the owner token nor a CNAME to a hostname of the owner in %q",
+			ErrHostnameNotVerified, hostname, HostnameVerificationRecordPrefix, hostname, hv.domain)
+		result.expires = hv.now().Add(hostnameVerificationFailureTTL)
+	}
+
+	hv.lock.Lock()
+	hv.cache[key] = result
+	hv.lock.Unlock()
+
+	return result.err
+}
+
+// lookup reports whether the owner proved it controls hostname, and whether it did so with a CNAME
+func (hv *hostnameVerifier) lookup(ctx context.Context, hostname string, owner sdktypes.Address, owns hostnameOwnership) (bool, bool, error) {
+	records, err := hv.resolver.LookupTXT(ctx, fmt.Sprintf("%s.%s", HostnameVerificationRecordPrefix, hostname))
+	if err != nil && !isDNSNotFound(err) {
+		return false, false, err
+	}
+
+	token := hv.token(owner)
+	for _, record := range records {
+		if strings.TrimSpace(record) == token {
+			return true, false, nil
+		}
+	}
+
+	target, err := hv.resolver.LookupCNAME(ctx, hostname)
+	if err != nil {
+		if isDNSNotFound(err) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+
+	owned, err := owns(ctx, strings.ToLower(strings.TrimSuffix(target, ".")))
+	if err != nil {
+		return false, false, err
+	}
+
+	return owned, owned, nil
+}
+
+func isDNSNotFound(err error) bool {
+	dnsErr := &net.DNSError{}
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
@@ -0,0 +1,209 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/akash-network/node/testutil"
+)
+
+type fakeResolver struct {
+	txt     map[string][]string
+	cname   map[string]string
+	err     error
+	lookups int
+}
+
+func (r *fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	r.lookups++
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	records, exists := r.txt[name]
+	if !exists {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+
+	return records, nil
+}
+
+func (r *fakeResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+
+	target, exists := r.cname[host]
+	if !exists {
+		return host + ".", nil
+	}
+
+	return target, nil
+}
+
+func makeVerifiedHostnameScaffold(t *testing.T, resolver *fakeResolver) *scaffold {
+	ctx, cancel := context.WithTimeout(context.Background(), testWait)
+	svc, err := newHostnameService(ctx, Config{
+		DeploymentIngressDomain: "ingress.provider.com",
+		HostnameVerification: HostnameVerificationConfig{
+			Enabled:  true,
+			Secret:   "secret",
+			CacheTTL: time.Hour,
+			Resolver: resolver,
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	return &scaffold{
+		service: svc,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+func TestReserveUnverifiedHostname(t *testing.T) {
+	resolver := &fakeResolver{}
+	s := makeVerifiedHostnameScaffold(t, resolver)
+	defer s.cancel()
+
+	leaseID := testutil.LeaseID(t)
+
+	_, err := s.service.ReserveHostnames(s.ctx, []string{"meow.com"}, leaseID)
+	require.ErrorIs(t, err, ErrHostnameNotVerified)
+	require.ErrorIs(t, err, ErrHostnameNotAllowed)
+
+	// hostnames of the provider domain need no verification
+	result, err := s.service.ReserveHostnames(s.ctx, []string{"abcd.ingress.provider.com"}, leaseID)
+	require.NoError(t, err)
+	require.Len(t, result, 0)
+}
+
+func TestReserveHostnameVerifiedByTXT(t *testing.T) {
+	resolver := &fakeResolver{txt: map[string][]string{}}
+	s := makeVerifiedHostnameScaffold(t, resolver)
+	defer s.cancel()
+
+	leaseID := testutil.LeaseID(t)
+	owner, err := leaseID.DeploymentID().GetOwnerAddress()
+	require.NoError(t, err)
+
+	token, err := s.service.VerificationToken(owner)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	otherToken, err := s.service.VerificationToken(testutil.AccAddress(t))
+	require.NoError(t, err)
+	require.NotEqual(t, token, otherToken)
+
+	resolver.txt["_akash-verification.meow.com"] = []string{otherToken}
+	require.ErrorIs(t, s.service.CanReserveHostnames([]string{"meow.com"}, owner), ErrHostnameNotVerified)
+
+	// the failure is cached for a short while only
+	resolver.txt["_akash-verification.meow.com"] = []string{otherToken, token}
+	s.service.verifier.now = func() time.Time { return time.Now().Add(hostnameVerificationFailureTTL) }
+
+	result, err := s.service.ReserveHostnames(s.ctx, []string{"MEOW.com"}, leaseID)
+	require.NoError(t, err)
+	require.Len(t, result, 0)
+
+	// successes are cached for the configured TTL
+	lookups := resolver.lookups
+	delete(resolver.txt, "_akash-verification.meow.com")
+	require.NoError(t, s.service.CanReserveHostnames([]string{"meow.com"}, owner))
+	require.Equal(t, lookups, resolver.lookups)
+}
+
+func TestReserveHostnameVerifiedByCNAME(t *testing.T) {
+	resolver := &fakeResolver{cname: map[string]string{
+		"meow.com":   "abcd.ingress.provider.com.",
+		"cats.com":   "cats.otherprovider.com.",
+		"kitten.com": "efgh.ingress.provider.com.",
+		"puppy.com":  "abcd.ingress.provider.com.",
+		"bunny.com":  "ijkl.ingress.provider.com.",
+	}}
+	s := makeVerifiedHostnameScaffold(t, resolver)
+	defer s.cancel()
+
+	leaseID := testutil.LeaseID(t)
+	owner, err := leaseID.DeploymentID().GetOwnerAddress()
+	require.NoError(t, err)
+
+	// when bidding the generated hostname of the lease is not held yet
+	require.NoError(t, s.service.CanReserveHostnames([]string{"meow.com"}, owner))
+	require.ErrorIs(t, s.service.CanReserveHostnames([]string{"cats.com"}, owner), ErrHostnameNotVerified)
+
+	// the generated hostname of the lease is reserved along with the custom one
+	_, err = s.service.ReserveHostnames(s.ctx, []string{"abcd.ingress.provider.com", "meow.com"}, leaseID)
+	require.NoError(t, err)
+
+	// hostnames of the owner are valid targets for its other leases
+	_, err = s.service.ReserveHostnames(s.ctx, []string{"puppy.com"}, testutil.LeaseIDForAccount(t, owner, testutil.AccAddress(t)))
+	require.NoError(t, err)
+
+	// a hostname of the provider held by another owner is not
+	_, err = s.service.ReserveHostnames(s.ctx, []string{"efgh.ingress.provider.com"}, testutil.LeaseID(t))
+	require.NoError(t, err)
+	require.ErrorIs(t, s.service.CanReserveHostnames([]string{"kitten.com"}, owner), ErrHostnameNotVerified)
+
+	// nor one nobody holds once the lease exists
+	_, err = s.service.ReserveHostnames(s.ctx, []string{"bunny.com"}, leaseID)
+	require.ErrorIs(t, err, ErrHostnameNotVerified)
+}
+
+func TestReserveHeldHostnameSkipsVerification(t *testing.T) {
+	resolver := &fakeResolver{txt: map[string][]string{}}
+	s := makeVerifiedHostnameScaffold(t, resolver)
+	defer s.cancel()
+
+	leaseID := testutil.LeaseID(t)
+	owner, err := leaseID.DeploymentID().GetOwnerAddress()
+	require.NoError(t, err)
+
+	token, err := s.service.VerificationToken(owner)
+	require.NoError(t, err)
+
+	resolver.txt["_akash-verification.meow.com"] = []string{token}
+	_, err = s.service.ReserveHostnames(s.ctx, []string{"meow.com"}, leaseID)
+	require.NoError(t, err)
+
+	// the record is gone and the cached verification expired, the lease keeps its hostname
+	delete(resolver.txt, "_akash-verification.meow.com")
+	s.service.verifier.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	lookups := resolver.lookups
+	_, err = s.service.ReserveHostnames(s.ctx, []string{"meow.com"}, leaseID)
+	require.NoError(t, err)
+	require.Equal(t, lookups, resolver.lookups)
+}
+
+func TestHostnameVerificationResolverFailure(t *testing.T) {
+	resolver := &fakeResolver{err: errors.New("server misbehaving")}
+	s := makeVerifiedHostnameScaffold(t, resolver)
+	defer s.cancel()
+
+	ownerAddr := testutil.AccAddress(t)
+	err := s.service.CanReserveHostnames([]string{"meow.com"}, ownerAddr)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrHostnameNotAllowed)
+
+	// resolver failures are not cached
+	token, err := s.service.VerificationToken(ownerAddr)
+	require.NoError(t, err)
+
+	resolver.err = nil
+	resolver.txt = map[string][]string{"_akash-verification.meow.com": {token}}
+	require.NoError(t, s.service.CanReserveHostnames([]string{"meow.com"}, ownerAddr))
+}
+
+func TestHostnameVerificationDisabled(t *testing.T) {
+	s := makeHostnameScaffold(t, nil)
+	defer s.cancel()
+
+	_, err := s.service.VerificationToken(testutil.AccAddress(t))
+	require.ErrorIs(t, err, ErrHostnameVerificationDisabled)
+}
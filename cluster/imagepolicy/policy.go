@@ -0,0 +1,255 @@
+package imagepolicy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	manifest "github.com/akash-network/node/manifest/v2beta1"
+)
+
+const (
+	defaultRegistry  = "docker.io"
+	defaultNamespace = "library"
+	defaultTag       = "latest"
+)
+
+// registryAliases are other names of a registry an image may be referenced by
+var registryAliases = map[string]string{
+	"index.docker.io":      defaultRegistry,
+	"registry-1.docker.io": defaultRegistry,
+}
+
+var (
+	ErrImagePolicy         = errors.New("image policy")
+	ErrImageRejected       = fmt.Errorf("%w: image rejected", ErrImagePolicy)
+	ErrInvalidImage        = fmt.Errorf("%w: invalid image reference", ErrImagePolicy)
+	ErrInvalidImagePattern = fmt.Errorf("%w: invalid pattern", ErrImagePolicy)
+)
+
+// Policy decides which container images are allowed to run on the provider.
+// Allow lists are only enforced when non-empty, deny lists always take precedence.
+// Registries are matched exactly once lowercased and with the Docker Hub aliases
+// replaced by docker.io, repositories and tags are matched as globs
+// in the form understood by path.Match, where a repository is "registry/path"
+// such as "docker.io/library/nginx".
+type Policy struct {
+	AllowedRegistries   []string `yaml:"allowed_registries"`
+	DeniedRegistries    []string `yaml:"denied_registries"`
+	AllowedRepositories []string `yaml:"allowed_repositories"`
+	DeniedRepositories  []string `yaml:"denied_repositories"`
+	AllowedTags         []string `yaml:"allowed_tags"`
+	DeniedTags          []string `yaml:"denied_tags"`
+	RequireDigest       bool     `yaml:"require_digest"`
+}
+
+// Image is a parsed container image reference
+type Image struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// FullRepository returns the repository prefixed with its registry
+func (img Image) FullRepository() string {
+	return img.Registry + "/" + img.Repository
+}
+
+// ReadFile loads and validates a policy from the YAML file at the given path
+func ReadFile(fpath string) (*Policy, error) {
+	buf, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &Policy{}
+	if err := yaml.Unmarshal(buf, policy); err != nil {
+		return nil, err
+	}
+
+	if err := policy.ValidateBasic(); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// ValidateBasic checks all patterns of the policy are well-formed
+func (p *Policy) ValidateBasic() error {
+	if p == nil {
+		return nil
+	}
+
+	for _, patterns := range [][]string{p.AllowedRepositories, p.DeniedRepositories, p.AllowedTags, p.DeniedTags} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%w: %q", ErrInvalidImagePattern, pattern)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ParseImage splits an image reference into registry, repository, tag and digest
+// applying the same defaults docker does for short names. Registries are lowercased
+// and Docker Hub aliases are replaced by docker.io.
+func ParseImage(ref string) (Image, error) {
+	img := Image{}
+
+	name := strings.TrimSpace(ref)
+	if name == "" {
+		return Image{}, fmt.Errorf("%w: %q", ErrInvalidImage, ref)
+	}
+
+	if idx := strings.Index(name, "@"); idx != -1 {
+		img.Digest = name[idx+1:]
+		name = name[:idx]
+		if img.Digest == "" {
+			return Image{}, fmt.Errorf("%w: %q", ErrInvalidImage, ref)
+		}
+	}
+
+	if idx := strings.LastIndex(name, ":"); idx != -1 && !strings.Contains(name[idx+1:], "/") {
+		img.Tag = name[idx+1:]
+		name = name[:idx]
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || strings.EqualFold(parts[0], "localhost")) {
+		img.Registry = normalizeRegistry(parts[0])
+		img.Repository = parts[1]
+	} else {
+		img.Registry = defaultRegistry
+		img.Repository = name
+	}
+
+	// hostnames are not case sensitive and tags may hold uppercase letters, repositories may not
+	if strings.ToLower(img.Repository) != img.Repository {
+		return Image{}, fmt.Errorf("%w: %q", ErrInvalidImage, ref)
+	}
+
+	if img.Registry == defaultRegistry && !strings.Contains(img.Repository, "/") {
+		img.Repository = defaultNamespace + "/" + img.Repository
+	}
+
+	if img.Tag == "" && img.Digest == "" {
+		img.Tag = defaultTag
+	}
+
+	if img.Repository == "" || strings.HasSuffix(img.Repository, "/") || strings.Contains(img.Repository, "//") {
+		return Image{}, fmt.Errorf("%w: %q", ErrInvalidImage, ref)
+	}
+
+	return img, nil
+}
+
+// Check verifies a single image reference against the policy.
+// A nil policy allows every image
+func (p *Policy) Check(ref string) error {
+	if p == nil {
+		return nil
+	}
+
+	img, err := ParseImage(ref)
+	if err != nil {
+		return err
+	}
+
+	if p.RequireDigest && img.Digest == "" {
+		return fmt.Errorf("%w: %q is not pinned by digest", ErrImageRejected, ref)
+	}
+
+	if matchRegistry(p.DeniedRegistries, img.Registry) {
+		return fmt.Errorf("%w: registry %q of %q is denied", ErrImageRejected, img.Registry, ref)
+	}
+
+	if len(p.AllowedRegistries) != 0 && !matchRegistry(p.AllowedRegistries, img.Registry) {
+		return fmt.Errorf("%w: registry %q of %q is not allowed", ErrImageRejected, img.Registry, ref)
+	}
+
+	if matchGlob(p.DeniedRepositories, img.FullRepository()) {
+		return fmt.Errorf("%w: repository %q of %q is denied", ErrImageRejected, img.FullRepository(), ref)
+	}
+
+	if len(p.AllowedRepositories) != 0 && !matchGlob(p.AllowedRepositories, img.FullRepository()) {
+		return fmt.Errorf("%w: repository %q of %q is not allowed", ErrImageRejected, img.FullRepository(), ref)
+	}
+
+	// images pinned only by digest carry no tag to check
+	if img.Tag == "" {
+		return nil
+	}
+
+	if matchGlob(p.DeniedTags, img.Tag) {
+		return fmt.Errorf("%w: tag %q of %q is denied", ErrImageRejected, img.Tag, ref)
+	}
+
+	if len(p.AllowedTags) != 0 && !matchGlob(p.AllowedTags, img.Tag) {
+		return fmt.Errorf("%w: tag %q of %q is not allowed", ErrImageRejected, img.Tag, ref)
+	}
+
+	return nil
+}
+
+// CheckGroup verifies images of every service in the manifest group against the policy
+func (p *Policy) CheckGroup(group *manifest.Group) error {
+	if p == nil || group == nil {
+		return nil
+	}
+
+	for _, svc := range group.Services {
+		if err := p.Check(svc.Image); err != nil {
+			return fmt.Errorf("group %q service %q: %w", group.Name, svc.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// CheckManifest verifies images of every group in the manifest against the policy
+func (p *Policy) CheckManifest(m manifest.Manifest) error {
+	for i := range m {
+		if err := p.CheckGroup(&m[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func normalizeRegistry(registry string) string {
+	registry = strings.ToLower(registry)
+	if alias, exists := registryAliases[registry]; exists {
+		return alias
+	}
+
+	return registry
+}
+
+// matchRegistry matches registries of a policy the way ParseImage normalizes those of images
+func matchRegistry(list []string, registry string) bool {
+	for _, entry := range list {
+		if normalizeRegistry(entry) == registry {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchGlob(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		// patterns are checked in ValidateBasic, a malformed one never matches
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+
+	return false
+}
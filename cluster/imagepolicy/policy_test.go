@@ -0,0 +1,179 @@
+package imagepolicy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	manifest "github.com/akash-network/node/manifest/v2beta1"
+)
+
+func TestParseImage(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected Image
+	}{
+		{"nginx", Image{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{"nginx:1.23", Image{Registry: "docker.io", Repository: "library/nginx", Tag: "1.23"}},
+		{"akash/provider:0.1.0", Image{Registry: "docker.io", Repository: "akash/provider", Tag: "0.1.0"}},
+		{"ghcr.io/akash-network/provider:0.1.0", Image{Registry: "ghcr.io", Repository: "akash-network/provider", Tag: "0.1.0"}},
+		{"localhost:5000/app", Image{Registry: "localhost:5000", Repository: "app", Tag: "latest"}},
+		{"localhost/app:v1", Image{Registry: "localhost", Repository: "app", Tag: "v1"}},
+		{"nginx:V1", Image{Registry: "docker.io", Repository: "library/nginx", Tag: "V1"}},
+		{"org/app:1.0-RC1", Image{Registry: "docker.io", Repository: "org/app", Tag: "1.0-RC1"}},
+		{
+			"quay.io/org/app@sha256:0123456789abcdef",
+			Image{Registry: "quay.io", Repository: "org/app", Digest: "sha256:0123456789abcdef"},
+		},
+		{
+			"quay.io/org/app:v2@sha256:0123456789abcdef",
+			Image{Registry: "quay.io", Repository: "org/app", Tag: "v2", Digest: "sha256:0123456789abcdef"},
+		},
+	}
+
+	for _, test := range tests {
+		img, err := ParseImage(test.ref)
+		require.NoError(t, err, test.ref)
+		require.Equal(t, test.expected, img, test.ref)
+	}
+
+	for _, ref := range []string{"", "Nginx", "quay.io/Org/app", "quay.io/org/", "app@"} {
+		_, err := ParseImage(ref)
+		require.ErrorIs(t, err, ErrInvalidImage, ref)
+	}
+}
+
+func TestParseImageRegistryAliases(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected Image
+	}{
+		{"index.docker.io/nginx", Image{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{"registry-1.docker.io/akash/provider:0.1.0", Image{Registry: "docker.io", Repository: "akash/provider", Tag: "0.1.0"}},
+		{"Docker.IO/library/nginx", Image{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{"INDEX.docker.io/xmrig/xmrig", Image{Registry: "docker.io", Repository: "xmrig/xmrig", Tag: "latest"}},
+		{"GHCR.io/org/app:v1", Image{Registry: "ghcr.io", Repository: "org/app", Tag: "v1"}},
+		{"LocalHost:5000/app", Image{Registry: "localhost:5000", Repository: "app", Tag: "latest"}},
+	}
+
+	for _, test := range tests {
+		img, err := ParseImage(test.ref)
+		require.NoError(t, err, test.ref)
+		require.Equal(t, test.expected, img, test.ref)
+	}
+
+	// aliases do not get around the policy, in images or in the policy itself
+	policy := &Policy{
+		DeniedRegistries:   []string{"Registry-1.Docker.io"},
+		DeniedRepositories: []string{"docker.io/xmrig/*"},
+	}
+	require.ErrorIs(t, policy.Check("index.docker.io/xmrig/xmrig"), ErrImageRejected)
+	require.ErrorIs(t, policy.Check("nginx"), ErrImageRejected)
+
+	policy = &Policy{AllowedRegistries: []string{"index.docker.io"}}
+	require.NoError(t, policy.Check("nginx"))
+	require.ErrorIs(t, policy.Check("ghcr.io/org/app"), ErrImageRejected)
+}
+
+func TestPolicyNilAllowsEverything(t *testing.T) {
+	var policy *Policy
+	require.NoError(t, policy.Check("xmrig/xmrig"))
+	require.NoError(t, policy.CheckGroup(&manifest.Group{}))
+}
+
+func TestPolicyRegistries(t *testing.T) {
+	policy := &Policy{
+		AllowedRegistries: []string{"docker.io", "ghcr.io"},
+		DeniedRegistries:  []string{"ghcr.io"},
+	}
+
+	require.NoError(t, policy.Check("nginx"))
+	require.ErrorIs(t, policy.Check("ghcr.io/org/app"), ErrImageRejected)
+	require.ErrorIs(t, policy.Check("quay.io/org/app"), ErrImageRejected)
+}
+
+func TestPolicyRepositories(t *testing.T) {
+	policy := &Policy{
+		AllowedRepositories: []string{"docker.io/*/*", "ghcr.io/akash-network/*"},
+		DeniedRepositories:  []string{"docker.io/*/xmrig*"},
+	}
+
+	require.NoError(t, policy.Check("nginx:1.23"))
+	require.NoError(t, policy.Check("ghcr.io/akash-network/provider"))
+	require.ErrorIs(t, policy.Check("xmrig/xmrig:latest"), ErrImageRejected)
+	require.ErrorIs(t, policy.Check("ghcr.io/someone/provider"), ErrImageRejected)
+}
+
+func TestPolicyTags(t *testing.T) {
+	policy := &Policy{
+		DeniedTags: []string{"latest", "*-dev"},
+	}
+
+	require.NoError(t, policy.Check("nginx:1.23"))
+	require.ErrorIs(t, policy.Check("nginx"), ErrImageRejected)
+	require.ErrorIs(t, policy.Check("nginx:1.23-dev"), ErrImageRejected)
+
+	policy = &Policy{
+		AllowedTags: []string{"v*"},
+	}
+	require.NoError(t, policy.Check("org/app:v1"))
+	require.ErrorIs(t, policy.Check("org/app:1"), ErrImageRejected)
+	// digest-only references have no tag to check
+	require.NoError(t, policy.Check("org/app@sha256:0123456789abcdef"))
+}
+
+func TestPolicyRequireDigest(t *testing.T) {
+	policy := &Policy{
+		RequireDigest: true,
+	}
+
+	require.ErrorIs(t, policy.Check("nginx:1.23"), ErrImageRejected)
+	require.NoError(t, policy.Check("nginx@sha256:0123456789abcdef"))
+}
+
+func TestPolicyCheckGroup(t *testing.T) {
+	policy := &Policy{
+		DeniedRepositories: []string{"docker.io/*/xmrig*"},
+	}
+
+	group := &manifest.Group{
+		Name: "westcoast",
+		Services: []manifest.Service{
+			{Name: "web", Image: "nginx"},
+			{Name: "worker", Image: "xmrig/xmrig"},
+		},
+	}
+
+	err := policy.CheckGroup(group)
+	require.ErrorIs(t, err, ErrImageRejected)
+	require.Contains(t, err.Error(), `service "worker"`)
+
+	require.ErrorIs(t, policy.CheckManifest(manifest.Manifest{*group}), ErrImageRejected)
+}
+
+func TestReadFile(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "policy.yaml")
+
+	err := os.WriteFile(fpath, []byte(`
+denied_registries:
+  - registry.example.com
+denied_repositories:
+  - "docker.io/*/xmrig*"
+require_digest: true
+`), 0o600)
+	require.NoError(t, err)
+
+	policy, err := ReadFile(fpath)
+	require.NoError(t, err)
+	require.Equal(t, []string{"registry.example.com"}, policy.DeniedRegistries)
+	require.Equal(t, []string{"docker.io/*/xmrig*"}, policy.DeniedRepositories)
+	require.True(t, policy.RequireDigest)
+
+	err = os.WriteFile(fpath, []byte(`denied_tags: ["[latest"]`), 0o600)
+	require.NoError(t, err)
+
+	_, err = ReadFile(fpath)
+	require.ErrorIs(t, err, ErrInvalidImagePattern)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/boz/go-lifecycle"
 
 	"github.com/akash-network/node/pubsub"
+	"github.com/akash-network/node/sdl"
 	sdlutil "github.com/akash-network/node/sdl/util"
 	atypes "github.com/akash-network/node/types/v1beta2"
 	"github.com/akash-network/node/util/runner"
@@ -38,6 +39,7 @@ var (
 	errInventoryReservation     = errors.New("inventory error")
 	errNoLeasedIPsAvailable     = fmt.Errorf("%w: no leased IPs available", errInventoryReservation)
 	errInsufficientIPs          = fmt.Errorf("%w: insufficient number of IPs", errInventoryReservation)
+	errStorageShrink            = fmt.Errorf("%w: persistent volumes cannot shrink", errInventoryReservation)
 )
 
 var (
@@ -72,6 +74,7 @@ type inventoryService struct {
 	lookupch         chan inventoryRequest
 	reservech        chan inventoryRequest
 	unreservech      chan inventoryRequest
+	resizech         chan inventoryRequest
 	reservationCount int64
 
 	readych chan struct{}
@@ -80,6 +83,7 @@ type inventoryService struct {
 	lc  lifecycle.Lifecycle
 
 	availableExternalPorts uint
+	nodePorts              *nodePortTracker
 
 	ipOperator operatorclients.IPOperatorClient
 
@@ -110,10 +114,12 @@ func newInventoryService(
 		lookupch:               make(chan inventoryRequest),
 		reservech:              make(chan inventoryRequest),
 		unreservech:            make(chan inventoryRequest),
+		resizech:               make(chan inventoryRequest),
 		readych:                make(chan struct{}),
 		log:                    log.With("cmp", "inventory-service"),
 		lc:                     lifecycle.New(),
 		availableExternalPorts: config.InventoryExternalPortQuantity,
+		nodePorts:              newNodePortTracker(log.With("cmp", "inventory-service")),
 		ipOperator:             ipOperatorClient,
 		waiter:                 waiter,
 	}
@@ -197,6 +203,25 @@ func (is *inventoryService) unreserve(order mtypes.OrderID) error { // nolint:go
 	}
 }
 
+// resize grows the reservation of a lease to the persistent volumes of an update of its group,
+// provided the storage is available
+func (is *inventoryService) resize(order mtypes.OrderID, resources atypes.ResourceGroup) error {
+	ch := make(chan inventoryResponse, 1)
+	req := inventoryRequest{
+		order:     order,
+		resources: resources,
+		ch:        ch,
+	}
+
+	select {
+	case is.resizech <- req:
+		response := <-ch
+		return response.err
+	case <-is.lc.ShuttingDown():
+		return ErrNotRunning
+	}
+}
+
 func (is *inventoryService) status(ctx context.Context) (ctypes.InventoryStatus, error) {
 	ch := make(chan ctypes.InventoryStatus, 1)
 
@@ -361,6 +386,25 @@ func countPendingIPs(state *inventoryServiceState) uint {
 	return pending
 }
 
+// ownerUsage sums the leased IPs and NodePort endpoints of the reservations of the owner, the pending
+// ones included as they are held until the order closes
+func ownerUsage(state *inventoryServiceState, owner string) OwnerQuota {
+	usage := OwnerQuota{}
+	for _, entry := range state.reservations {
+		if entry.OrderID().Owner != owner {
+			continue
+		}
+		usage.LeasedIPs += entry.endpointQuantity
+		usage.Endpoints += reservationCountEndpoints(entry)
+	}
+
+	return usage
+}
+
+func hasUnusedIPs(available, inUse, pending, requested uint) bool {
+	return available >= inUse+pending+requested
+}
+
 func (is *inventoryService) handleRequest(req inventoryRequest, state *inventoryServiceState) {
 	// convert the resources to the committed amount
 	resourcesToCommit := is.resourcesToCommit(req.resources)
@@ -369,20 +413,37 @@ func (is *inventoryService) handleRequest(req inventoryRequest, state *inventory
 
 	is.log.Debug("reservation requested", "order", req.order, "resources", req.resources)
 
+	owner := req.order.Owner
+	quota := is.config.OwnerQuotas.ForOwner(owner)
+	requested := OwnerQuota{
+		LeasedIPs: reservation.endpointQuantity,
+		Endpoints: reservationCountEndpoints(reservation),
+	}
+	if err := quota.Check(owner, ownerUsage(state, owner), requested); err != nil {
+		is.log.Info("owner quota exceeded", "order", req.order, "err", err)
+		inventoryRequestsCounter.WithLabelValues("reserve", "owner-quota").Inc()
+		req.ch <- inventoryResponse{err: err}
+		return
+	}
+
 	if reservation.endpointQuantity != 0 {
 		if is.ipOperator == nil {
 			req.ch <- inventoryResponse{err: errNoLeasedIPsAvailable}
 			return
 		}
-		numIPUnused := state.ipAddrUsage.Available - state.ipAddrUsage.InUse
+		usage := state.ipAddrUsage
 		pending := countPendingIPs(state)
-		if reservation.endpointQuantity > (numIPUnused - pending) {
+		// Dual-stack leased IPs take an address of each family, an IPv6 only pool has no IPv4 addresses
+		checkIPv4 := usage.Available != 0 || usage.AvailableIPv6 == 0
+		if (checkIPv4 && !hasUnusedIPs(usage.Available, usage.InUse, pending, reservation.endpointQuantity)) ||
+			(usage.AvailableIPv6 != 0 && !hasUnusedIPs(usage.AvailableIPv6, usage.InUseIPv6, pending, reservation.endpointQuantity)) {
 			is.log.Info("insufficient number of IP addresses available", "order", req.order)
 			req.ch <- inventoryResponse{err: fmt.Errorf("%w: unable to reserve %d", errInsufficientIPs, reservation.endpointQuantity)}
 			return
 		}
 
-		is.log.Info("reservation used leased IPs", "used", reservation.endpointQuantity, "available", state.ipAddrUsage.Available, "in-use", state.ipAddrUsage.InUse, "pending", pending)
+		is.log.Info("reservation used leased IPs", "used", reservation.endpointQuantity, "available", usage.Available, "in-use", usage.InUse,
+			"available-ipv6", usage.AvailableIPv6, "in-use-ipv6", usage.InUseIPv6, "pending", pending)
 	} else {
 		reservation.ipsConfirmed = true // No IPs, just mark it as confirmed implicitly
 	}
@@ -402,6 +463,60 @@ func (is *inventoryService) handleRequest(req inventoryRequest, state *inventory
 
 }
 
+func (is *inventoryService) handleResize(req inventoryRequest, state *inventoryServiceState) {
+	for _, res := range state.reservations {
+		if !res.OrderID().Equals(req.order) || res.Resources().GetName() != req.resources.GetName() {
+			continue
+		}
+
+		resized, changed := is.resizedReservationStorage(res, req.resources)
+		if !changed {
+			req.ch <- inventoryResponse{value: res}
+			inventoryRequestsCounter.WithLabelValues("resize", "unchanged").Inc()
+			return
+		}
+
+		// the cluster keeps the storage of a shrunk volume, the deploy is refused as well
+		if volume, shrinks := reservationStorageShrinks(res.resources, resized); shrinks {
+			inventoryRequestsCounter.WithLabelValues("resize", "shrink").Inc()
+			req.ch <- inventoryResponse{err: fmt.Errorf("%w: volume %q", errStorageShrink, volume)}
+			return
+		}
+
+		if state.inventory == nil {
+			req.ch <- inventoryResponse{err: errInventoryNotAvailableYet}
+			return
+		}
+
+		available := state.inventory.Metrics().TotalAvailable.Storage
+		delta := reservationStorageDelta(res.resources, resized)
+		for class, size := range delta {
+			// ephemeral storage is checked on the node when the pods are scheduled
+			if class == storageClassEphemeral || size <= 0 {
+				continue
+			}
+
+			if size > available[class] {
+				is.log.Info("insufficient storage to resize reservation", "order", req.order, "class", class, "requested", size, "available", available[class])
+				inventoryRequestsCounter.WithLabelValues("resize", "insufficient-capacity").Inc()
+				req.ch <- inventoryResponse{err: fmt.Errorf("%w: storage class %q has %d bytes available, %d requested",
+					ctypes.ErrInsufficientCapacity, class, available[class], size)}
+				return
+			}
+		}
+
+		res.resources = resized
+		is.log.Info("reservation storage resized", "order", res.OrderID(), "resource-group", res.Resources().GetName(), "delta", delta)
+
+		req.ch <- inventoryResponse{value: res}
+		inventoryRequestsCounter.WithLabelValues("resize", "resized").Inc()
+		return
+	}
+
+	inventoryRequestsCounter.WithLabelValues("resize", "not-found").Inc()
+	req.ch <- inventoryResponse{err: errReservationNotFound}
+}
+
 func (is *inventoryService) run(ctx context.Context, reservationsArg []*reservation) {
 	defer is.lc.ShutdownCompleted()
 	defer is.sub.Close()
@@ -464,15 +579,13 @@ loop:
 
 					allocatedPrev := res.allocated
 					res.allocated = ev.Status == event.ClusterDeploymentDeployed
+					if res.allocated && ev.Group != nil {
+						is.resizeReservationStorage(res, ev.Group)
+					}
 					updateInventory()
 
 					if res.allocated != allocatedPrev {
-						externalPortCount := reservationCountEndpoints(res)
-						if ev.Status == event.ClusterDeploymentDeployed {
-							is.availableExternalPorts -= externalPortCount
-						} else {
-							is.availableExternalPorts += externalPortCount
-						}
+						is.updateAvailableExternalPorts(state)
 
 						is.log.Debug("reservation status update",
 							"order", res.OrderID(),
@@ -504,6 +617,9 @@ loop:
 			inventoryRequestsCounter.WithLabelValues("lookup", "not-found").Inc()
 			req.ch <- inventoryResponse{err: errReservationNotFound}
 
+		case req := <-is.resizech:
+			is.handleResize(req, state)
+
 		case req := <-is.unreservech:
 			is.log.Debug("unreserving capacity", "order", req.order)
 			// remove reservation
@@ -518,10 +634,9 @@ loop:
 				is.log.Info("removing reservation", "order", res.OrderID())
 
 				state.reservations = append(state.reservations[:idx], state.reservations[idx+1:]...)
-				// reclaim availableExternalPorts if unreserving allocated resources
-				if res.allocated {
-					is.availableExternalPorts += reservationCountEndpoints(res)
-				}
+				// reclaim the node ports of the lease without waiting for its services to be removed
+				is.nodePorts.release(req.order)
+				is.updateAvailableExternalPorts(state)
 
 				req.ch <- inventoryResponse{value: res}
 				is.log.Info("unreserve capacity complete", "order", req.order)
@@ -591,6 +706,11 @@ loop:
 				}
 			}
 
+			if runResult.nodePortsRead {
+				is.nodePorts.update(runResult.nodePorts)
+				is.updateAvailableExternalPorts(state)
+			}
+
 			if is.ipOperator != nil {
 				// Save IP address data
 				state.ipAddrUsage = runResult.ipResult
@@ -631,6 +751,8 @@ type runCheckResult struct {
 	inventoryResult ctypes.Inventory
 	ipResult        ipoptypes.IPAddressUsage
 	confirmedResult []mtypes.OrderID
+	nodePorts       []ctypes.NodePortAllocation
+	nodePortsRead   bool
 }
 
 func (is *inventoryService) runCheck(ctx context.Context, state *inventoryServiceState) <-chan runner.Result {
@@ -666,6 +788,14 @@ func (is *inventoryService) runCheck(ctx context.Context, state *inventoryServic
 			return runner.NewResult(nil, err)
 		}
 
+		retval.nodePorts, err = is.client.NodePorts(ctx)
+		if err != nil {
+			// Keep tracking the node ports last read, the inventory itself is still valid
+			is.log.Error("failed reading node port allocations", "error", err)
+		} else {
+			retval.nodePortsRead = true
+		}
+
 		if is.ipOperator != nil {
 			retval.ipResult, err = is.ipOperator.GetIPAddressUsage(ctx)
 			if err != nil {
@@ -692,6 +822,28 @@ func (is *inventoryService) runCheck(ctx context.Context, state *inventoryServic
 	})
 }
 
+// updateAvailableExternalPorts recounts the endpoints available from the node ports provisioned in the
+// cluster. Allocated reservations whose services have not been read yet count for the endpoints they requested
+func (is *inventoryService) updateAvailableExternalPorts(state *inventoryServiceState) {
+	inUse, orders := is.nodePorts.inUse()
+	for _, res := range state.reservations {
+		if !res.allocated {
+			continue
+		}
+		if _, tracked := orders[res.OrderID().String()]; tracked {
+			continue
+		}
+		inUse += reservationCountEndpoints(res)
+	}
+
+	if inUse > is.config.InventoryExternalPortQuantity {
+		is.availableExternalPorts = 0
+		return
+	}
+
+	is.availableExternalPorts = is.config.InventoryExternalPortQuantity - inUse
+}
+
 func (is *inventoryService) getStatus(state *inventoryServiceState) ctypes.InventoryStatus {
 	status := ctypes.InventoryStatus{}
 	if state.inventory == nil {
@@ -741,3 +893,138 @@ func reservationCountEndpoints(reservation *reservation) uint {
 
 	return externalPortCount
 }
+
+// resizeReservationStorage updates the volume sizes of a reservation to the ones of the deployed group
+// when a manifest update changed them, so the inventory accounts for the delta.
+func (is *inventoryService) resizeReservationStorage(res *reservation, group atypes.ResourceGroup) {
+	resized, changed := is.resizedReservationStorage(res, group)
+	if !changed {
+		return
+	}
+
+	is.log.Info("reservation storage resized", "order", res.OrderID(), "resource-group", group.GetName(),
+		"delta", reservationStorageDelta(res.resources, resized))
+	res.resources = resized
+}
+
+// resizedReservationStorage returns the resources of a reservation with the volume sizes of the group,
+// and whether any of them changed. Groups whose resources do not line up with the reservation ones
+// are ignored.
+func (is *inventoryService) resizedReservationStorage(res *reservation, group atypes.ResourceGroup) (dtypes.GroupSpec, bool) {
+	prev := res.resources.GetResources()
+	next := is.resourcesToCommit(group).GetResources()
+
+	if len(prev) != len(next) {
+		return dtypes.GroupSpec{}, false
+	}
+
+	// prices and requirements of the reservation are kept, only volume sizes change
+	spec, _ := res.resources.(dtypes.GroupSpec)
+
+	resized := dtypes.GroupSpec{
+		Name:         res.resources.GetName(),
+		Requirements: spec.Requirements,
+		Resources:    make([]dtypes.Resource, 0, len(prev)),
+	}
+
+	for idx, resource := range prev {
+		if resource.Count != next[idx].Count || len(resource.Resources.Storage) != len(next[idx].Resources.Storage) {
+			return dtypes.GroupSpec{}, false
+		}
+
+		storage := make(atypes.Volumes, 0, len(resource.Resources.Storage))
+		for vidx, volume := range resource.Resources.Storage {
+			if volume.Name != next[idx].Resources.Storage[vidx].Name {
+				return dtypes.GroupSpec{}, false
+			}
+
+			volume.Quantity = next[idx].Resources.Storage[vidx].Quantity
+			storage = append(storage, volume)
+		}
+
+		units := resource.Resources
+		units.Storage = storage
+
+		var price sdk.DecCoin
+		if idx < len(spec.Resources) {
+			price = spec.Resources[idx].Price
+		}
+
+		resized.Resources = append(resized.Resources, dtypes.Resource{
+			Resources: units,
+			Count:     resource.Count,
+			Price:     price,
+		})
+	}
+
+	if len(reservationStorageDelta(res.resources, resized)) == 0 {
+		return dtypes.GroupSpec{}, false
+	}
+
+	return resized, true
+}
+
+// reservationStorageDelta returns by storage class how much storage the next resources use compared to
+// the previous ones. classes with no change are omitted
+func reservationStorageDelta(prev, next atypes.ResourceGroup) map[string]int64 {
+	delta := make(map[string]int64)
+
+	sum := func(group atypes.ResourceGroup, sign int64) {
+		for _, resource := range group.GetResources() {
+			for _, volume := range resource.Resources.GetStorage() {
+				delta[storageClassOf(volume)] += sign * int64(volume.Quantity.Value()) * int64(resource.Count)
+			}
+		}
+	}
+
+	sum(prev, -1)
+	sum(next, 1)
+
+	for class, size := range delta {
+		if size == 0 {
+			delete(delta, class)
+		}
+	}
+
+	return delta
+}
+
+// reservationStorageShrinks returns the first persistent volume smaller in next than in prev, both
+// groups holding the same volumes
+func reservationStorageShrinks(prev, next atypes.ResourceGroup) (string, bool) {
+	nextResources := next.GetResources()
+
+	for idx, resource := range prev.GetResources() {
+		if idx >= len(nextResources) {
+			break
+		}
+
+		nextStorage := nextResources[idx].Resources.GetStorage()
+		for vidx, volume := range resource.Resources.GetStorage() {
+			if vidx >= len(nextStorage) || storageClassOf(volume) == storageClassEphemeral {
+				continue
+			}
+
+			if nextStorage[vidx].Quantity.Value() < volume.Quantity.Value() {
+				return volume.Name, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// storageClassEphemeral accounts for volumes which are not persistent in storage deltas
+const storageClassEphemeral = "ephemeral"
+
+func storageClassOf(volume atypes.Storage) string {
+	if persistent, valid := volume.Attributes.Find(sdl.StorageAttributePersistent).AsBool(); !valid || !persistent {
+		return storageClassEphemeral
+	}
+
+	if class, valid := volume.Attributes.Find(sdl.StorageAttributeClass).AsString(); valid {
+		return class
+	}
+
+	return sdl.StorageClassDefault
+}
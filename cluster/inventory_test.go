@@ -17,6 +17,7 @@ import (
 
 	manifest "github.com/akash-network/node/manifest/v2beta1"
 	"github.com/akash-network/node/pubsub"
+	"github.com/akash-network/node/sdl"
 	"github.com/akash-network/node/testutil"
 	"github.com/akash-network/node/types/unit"
 	types "github.com/akash-network/node/types/v1beta2"
@@ -130,6 +131,7 @@ func TestInventory_ClusterDeploymentNotDeployed(t *testing.T) {
 
 	clusterInv := newInventory("nodeA")
 
+	clusterClient.On("NodePorts", mock.Anything).Return(nil, nil)
 	clusterClient.On("Inventory", mock.Anything).Return(clusterInv, nil)
 
 	inv, err := newInventoryService(
@@ -213,6 +215,7 @@ func TestInventory_ClusterDeploymentDeployed(t *testing.T) {
 	clusterInv := newInventory("nodeA")
 
 	inventoryCalled := make(chan int, 1)
+	clusterClient.On("NodePorts", mock.Anything).Return(nil, nil)
 	clusterClient.On("Inventory", mock.Anything).Run(func(args mock.Arguments) {
 		inventoryCalled <- 0 // Value does not matter
 	}).Return(clusterInv, nil)
@@ -346,6 +349,7 @@ func makeInventoryScaffold(t *testing.T, leaseQty uint, inventoryCall bool, node
 	// Create an inventory set that has enough resources for the deployment
 	clusterInv := newInventory(nodes...)
 
+	cclient.On("NodePorts", mock.Anything).Return(nil, nil)
 	cclient.On("Inventory", mock.Anything).Run(func(args mock.Arguments) {
 		if scaffold.inventoryCalled != nil {
 			scaffold.inventoryCalled <- struct{}{}
@@ -449,6 +453,51 @@ func TestInventory_ReserveIPNoIPOperator(t *testing.T) {
 	<-inv.lc.Done()
 }
 
+func TestInventory_ReserveOwnerQuotaExceeded(t *testing.T) {
+	config := Config{
+		InventoryResourcePollPeriod:     5 * time.Second,
+		InventoryResourceDebugFrequency: 1,
+		InventoryExternalPortQuantity:   1000,
+		OwnerQuotas: OwnerQuotas{
+			Default: OwnerQuota{Endpoints: 1},
+		},
+	}
+	scaffold := makeInventoryScaffold(t, 10, false, "nodeA", "nodeB")
+	defer scaffold.bus.Close()
+
+	myLog := testutil.Logger(t)
+
+	subscriber, err := scaffold.bus.Subscribe()
+	require.NoError(t, err)
+	inv, err := newInventoryService(
+		config,
+		myLog,
+		scaffold.donech,
+		subscriber,
+		scaffold.clusterClient,
+		nil,                    // No IP operator client
+		waiter.NewNullWaiter(), // Do not need to wait in test
+		make([]ctypes.Deployment, 0))
+	require.NoError(t, err)
+	require.NotNil(t, inv)
+
+	group := makeGroupForInventoryTest(false, true, false)
+	reservation, err := inv.reserve(scaffold.leaseIDs[0].OrderID(), group)
+	require.NoError(t, err)
+	require.NotNil(t, reservation)
+
+	// the pending reservation holds the only endpoint the owner may have
+	orderID := scaffold.leaseIDs[0].OrderID()
+	orderID.DSeq++
+	reservation, err = inv.reserve(orderID, group)
+	require.ErrorIs(t, err, ctypes.ErrOwnerQuotaExceeded)
+	require.Nil(t, reservation)
+
+	// Shut everything down
+	close(scaffold.donech)
+	<-inv.lc.Done()
+}
+
 func TestInventory_ReserveIPUnavailableWithIPOperator(t *testing.T) {
 	config := Config{
 		InventoryResourcePollPeriod:     5 * time.Second,
@@ -494,6 +543,53 @@ func TestInventory_ReserveIPUnavailableWithIPOperator(t *testing.T) {
 	<-inv.lc.Done()
 }
 
+func TestInventory_ReserveIPv6UnavailableWithIPOperator(t *testing.T) {
+	config := Config{
+		InventoryResourcePollPeriod:     5 * time.Second,
+		InventoryResourceDebugFrequency: 1,
+		InventoryExternalPortQuantity:   1000,
+	}
+	scaffold := makeInventoryScaffold(t, 10, false, "nodeA")
+	defer scaffold.bus.Close()
+
+	myLog := testutil.Logger(t)
+
+	subscriber, err := scaffold.bus.Subscribe()
+	require.NoError(t, err)
+
+	mockIP := &mocks.IPOperatorClient{}
+
+	ipQty := testutil.RandRangeInt(1, 100)
+	mockIP.On("GetIPAddressUsage", mock.Anything).Return(ipoptypes.IPAddressUsage{
+		Available:     uint(ipQty + 1), // IPv4 addresses left
+		InUse:         uint(ipQty),
+		AvailableIPv6: uint(ipQty),
+		InUseIPv6:     uint(ipQty),
+	}, nil)
+	mockIP.On("Stop")
+
+	inv, err := newInventoryService(
+		config,
+		myLog,
+		scaffold.donech,
+		subscriber,
+		scaffold.clusterClient,
+		mockIP,
+		waiter.NewNullWaiter(), // Do not need to wait in test
+		make([]ctypes.Deployment, 0))
+	require.NoError(t, err)
+	require.NotNil(t, inv)
+
+	group := makeGroupForInventoryTest(false, false, true)
+	reservation, err := inv.reserve(scaffold.leaseIDs[0].OrderID(), group)
+	require.ErrorIs(t, err, errInsufficientIPs)
+	require.Nil(t, reservation)
+
+	// Shut everything down
+	close(scaffold.donech)
+	<-inv.lc.Done()
+}
+
 func TestInventory_ReserveIPAvailableWithIPOperator(t *testing.T) {
 	config := Config{
 		InventoryResourcePollPeriod:     4 * time.Second,
@@ -689,3 +785,149 @@ func TestInventory_OverReservations(t *testing.T) {
 	// No ports used yet
 	require.Equal(t, uint(1000-countOfRandomPortService), inv.availableExternalPorts)
 }
+
+func TestInventory_ResizeChecksStorage(t *testing.T) {
+	scaffold := makeInventoryScaffold(t, 1, true, "nodeA")
+	defer scaffold.bus.Close()
+	lid := scaffold.leaseIDs[0]
+
+	subscriber, err := scaffold.bus.Subscribe()
+	require.NoError(t, err)
+	defer subscriber.Close()
+
+	group := func(size uint64) dtypes.GroupSpec {
+		return dtypes.GroupSpec{
+			Name: "nameForGroup",
+			Resources: []dtypes.Resource{{
+				Count: 1,
+				Resources: types.ResourceUnits{
+					CPU:    &types.CPU{Units: types.NewResourceValue(100)},
+					Memory: &types.Memory{Quantity: types.NewResourceValue(1 * unit.Gi)},
+					Storage: types.Volumes{
+						{Name: "default", Quantity: types.NewResourceValue(1 * unit.Gi)},
+						{
+							Name:     "data",
+							Quantity: types.NewResourceValue(size),
+							Attributes: types.Attributes{
+								{Key: sdl.StorageAttributePersistent, Value: "true"},
+								{Key: sdl.StorageAttributeClass, Value: "beta2"},
+							},
+						},
+					},
+				},
+				Price: sdk.NewInt64DecCoin("uakt", 10),
+			}},
+		}
+	}
+
+	config := Config{
+		InventoryResourcePollPeriod:     5 * time.Second,
+		InventoryResourceDebugFrequency: 1,
+		InventoryExternalPortQuantity:   1000,
+	}
+
+	inv, err := newInventoryService(
+		config,
+		testutil.Logger(t),
+		scaffold.donech,
+		subscriber,
+		scaffold.clusterClient,
+		nil,                    // No IP operator client
+		waiter.NewNullWaiter(), // Do not need to wait in test
+		make([]ctypes.Deployment, 0))
+	require.NoError(t, err)
+
+	testutil.ChannelWaitForValueUpTo(t, scaffold.inventoryCalled, 30*time.Second)
+
+	_, err = inv.reserve(lid.OrderID(), group(10*unit.Gi))
+	require.NoError(t, err)
+
+	// the cluster has 502Gi of beta2 storage available
+	err = inv.resize(lid.OrderID(), group(600*unit.Gi))
+	require.ErrorIs(t, err, ctypes.ErrInsufficientCapacity)
+
+	require.NoError(t, inv.resize(lid.OrderID(), group(20*unit.Gi)))
+
+	reservation, err := inv.lookup(lid.OrderID(), group(20*unit.Gi))
+	require.NoError(t, err)
+	require.Equal(t, uint64(20*unit.Gi), reservation.Resources().GetResources()[0].Resources.Storage[1].Quantity.Value())
+
+	// the cluster keeps the storage of the volume, the reservation too
+	err = inv.resize(lid.OrderID(), group(15*unit.Gi))
+	require.ErrorIs(t, err, errStorageShrink)
+
+	reservation, err = inv.lookup(lid.OrderID(), group(20*unit.Gi))
+	require.NoError(t, err)
+	require.Equal(t, uint64(20*unit.Gi), reservation.Resources().GetResources()[0].Resources.Storage[1].Quantity.Value())
+
+	err = inv.resize(testutil.LeaseID(t).OrderID(), group(20*unit.Gi))
+	require.ErrorIs(t, err, errReservationNotFound)
+
+	close(scaffold.donech)
+	<-inv.lc.Done()
+}
+
+func TestResizedReservationStorage(t *testing.T) {
+	group := func(size uint64) dtypes.GroupSpec {
+		return dtypes.GroupSpec{
+			Name: "nameForGroup",
+			Resources: []dtypes.Resource{{
+				Count: 1,
+				Resources: types.ResourceUnits{
+					CPU:    &types.CPU{Units: types.NewResourceValue(100)},
+					Memory: &types.Memory{Quantity: types.NewResourceValue(1 * unit.Gi)},
+					Storage: types.Volumes{
+						{Name: "default", Quantity: types.NewResourceValue(1 * unit.Gi)},
+						{
+							Name:     "data",
+							Quantity: types.NewResourceValue(size),
+							Attributes: types.Attributes{
+								{Key: sdl.StorageAttributePersistent, Value: "true"},
+								{Key: sdl.StorageAttributeClass, Value: "beta2"},
+							},
+						},
+					},
+				},
+				Price: sdk.NewInt64DecCoin("uakt", 10),
+			}},
+		}
+	}
+
+	is := &inventoryService{}
+	res := newReservation(testutil.LeaseID(t).OrderID(), group(10*unit.Gi))
+
+	_, changed := is.resizedReservationStorage(res, group(10*unit.Gi))
+	require.False(t, changed)
+
+	resized, changed := is.resizedReservationStorage(res, group(20*unit.Gi))
+	require.True(t, changed)
+	require.Equal(t, uint64(20*unit.Gi), resized.Resources[0].Resources.Storage[1].Quantity.Value())
+	require.Equal(t, sdk.NewInt64DecCoin("uakt", 10), resized.Resources[0].Price)
+}
+
+func TestReservationStorageDelta(t *testing.T) {
+	group := func(size uint64) dtypes.GroupSpec {
+		return dtypes.GroupSpec{
+			Name: "default",
+			Resources: []dtypes.Resource{{
+				Count: 2,
+				Resources: types.ResourceUnits{
+					Storage: types.Volumes{
+						{Name: "default", Quantity: types.NewResourceValue(100)},
+						{
+							Name:     "data",
+							Quantity: types.NewResourceValue(size),
+							Attributes: types.Attributes{
+								{Key: sdl.StorageAttributePersistent, Value: "true"},
+								{Key: sdl.StorageAttributeClass, Value: "beta2"},
+							},
+						},
+					},
+				},
+			}},
+		}
+	}
+
+	require.Empty(t, reservationStorageDelta(group(1000), group(1000)))
+	require.Equal(t, map[string]int64{"beta2": 2000}, reservationStorageDelta(group(1000), group(2000)))
+}
@@ -37,6 +37,48 @@ func applyNS(ctx context.Context, kc kubernetes.Interface, b builder.NS) error {
 	return err
 }
 
+func applyResourceQuota(ctx context.Context, kc kubernetes.Interface, b builder.ResourceQuota) error {
+	obj, err := kc.CoreV1().ResourceQuotas(b.NS()).Get(ctx, b.Name(), metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "resource-quotas-get", err, errors.IsNotFound)
+
+	switch {
+	case err == nil:
+		obj, err = b.Update(obj)
+		if err == nil {
+			_, err = kc.CoreV1().ResourceQuotas(b.NS()).Update(ctx, obj, metav1.UpdateOptions{})
+			metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "resource-quotas-update", err)
+		}
+	case errors.IsNotFound(err):
+		obj, err = b.Create()
+		if err == nil {
+			_, err = kc.CoreV1().ResourceQuotas(b.NS()).Create(ctx, obj, metav1.CreateOptions{})
+			metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "resource-quotas-create", err)
+		}
+	}
+	return err
+}
+
+func applyLimitRange(ctx context.Context, kc kubernetes.Interface, b builder.LimitRange) error {
+	obj, err := kc.CoreV1().LimitRanges(b.NS()).Get(ctx, b.Name(), metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "limit-ranges-get", err, errors.IsNotFound)
+
+	switch {
+	case err == nil:
+		obj, err = b.Update(obj)
+		if err == nil {
+			_, err = kc.CoreV1().LimitRanges(b.NS()).Update(ctx, obj, metav1.UpdateOptions{})
+			metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "limit-ranges-update", err)
+		}
+	case errors.IsNotFound(err):
+		obj, err = b.Create()
+		if err == nil {
+			_, err = kc.CoreV1().LimitRanges(b.NS()).Create(ctx, obj, metav1.CreateOptions{})
+			metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "limit-ranges-create", err)
+		}
+	}
+	return err
+}
+
 // Apply list of Network Policies
 func applyNetPolicies(ctx context.Context, kc kubernetes.Interface, b builder.NetPol) error {
 	var err error
@@ -69,24 +111,6 @@ func applyNetPolicies(ctx context.Context, kc kubernetes.Interface, b builder.Ne
 	return err
 }
 
-// TODO: re-enable.  see #946
-// func applyRestrictivePodSecPoliciesToNS(ctx context.Context, kc kubernetes.Interface, p builder.PspRestricted) error {
-// 	obj, err := kc.PolicyV1beta1().PodSecurityPolicies().Get(ctx, p.Name(), metav1.GetOptions{})
-// 	switch {
-// 	case err == nil:
-// 		obj, err = p.Update(obj)
-// 		if err == nil {
-// 			_, err = kc.PolicyV1beta1().PodSecurityPolicies().Update(ctx, obj, metav1.UpdateOptions{})
-// 		}
-// 	case errors.IsNotFound(err):
-// 		obj, err = p.Create()
-// 		if err == nil {
-// 			_, err = kc.PolicyV1beta1().PodSecurityPolicies().Create(ctx, obj, metav1.CreateOptions{})
-// 		}
-// 	}
-// 	return err
-// }
-
 func applyDeployment(ctx context.Context, kc kubernetes.Interface, b builder.Deployment) error {
 	obj, err := kc.AppsV1().Deployments(b.NS()).Get(ctx, b.Name(), metav1.GetOptions{})
 	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "deployments-get", err, errors.IsNotFound)
@@ -116,12 +140,39 @@ func applyStatefulSet(ctx context.Context, kc kubernetes.Interface, b builder.St
 
 	switch {
 	case err == nil:
+		current := obj.DeepCopy()
 		obj, err = b.Update(obj)
+		if err != nil {
+			break
+		}
 
-		if err == nil {
+		var changes volumeClaimChanges
+		changes, err = diffVolumeClaimTemplates(current.Spec.VolumeClaimTemplates, obj.Spec.VolumeClaimTemplates)
+		if err != nil {
+			break
+		}
+
+		if !changes.any() {
 			_, err = kc.AppsV1().StatefulSets(b.NS()).Update(ctx, obj, metav1.UpdateOptions{})
 			metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "deployments-update", err)
+			break
+		}
+
+		// volume claim templates are immutable. grow the claims already bound to the pods,
+		// then replace the statefulset while leaving its pods running. the new statefulset
+		// adopts them and rolls them onto the new templates
+		if err = expandVolumeClaims(ctx, kc, current, changes.resized); err != nil {
+			break
+		}
+
+		if err = deleteStatefulSetOrphan(ctx, kc, current); err != nil {
+			break
+		}
 
+		obj, err = b.Create()
+		if err == nil {
+			_, err = kc.AppsV1().StatefulSets(b.NS()).Create(ctx, obj, metav1.CreateOptions{})
+			metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "deployments-create", err)
 		}
 	case errors.IsNotFound(err):
 		obj, err = b.Create()
@@ -33,6 +33,25 @@ const (
 	AkashLeaseProviderLabelName = "akash.network/lease.id.provider"
 	AkashLeaseManifestVersion   = "akash.network/manifest.version"
 	akashDeploymentPolicyName   = "akash-deployment-restrictions"
+
+	// AkashRetainedVolumeLabelName marks persistent volumes and volume snapshot contents kept after their
+	// lease was closed. They are labelled with the lease, service and volume they belonged to.
+	AkashRetainedVolumeLabelName        = "akash.network/retained"
+	AkashRetainedVolumeNameLabelName    = "akash.network/retained.volume"
+	AkashRetainedVolumeOrdinalLabelName = "akash.network/retained.ordinal"
+	// AkashRetainedUntilAnnotation is the RFC3339 time after which a retained volume or snapshot is deleted
+	AkashRetainedUntilAnnotation = "akash.network/retained-until"
+	// AkashRetainedReclaimPolicyAnnotation is the reclaim policy restored when a retained volume is claimed again
+	AkashRetainedReclaimPolicyAnnotation = "akash.network/retained-reclaim-policy"
+	// AkashLeaseAnnotation is the lease a hostname route belongs to, attributing the access logs
+	// and metrics of the ingress controller to it
+	AkashLeaseAnnotation = "akash.network/lease"
+	// AkashVolumeSnapshotTriggerLabelName records why a volume snapshot was taken
+	AkashVolumeSnapshotTriggerLabelName = "akash.network/snapshot.trigger"
+	// AkashVolumeSnapshotRestoreStateAnnotationName and AkashVolumeSnapshotRestoreMessageAnnotationName
+	// record the progress of the last restore of a volume snapshot
+	AkashVolumeSnapshotRestoreStateAnnotationName   = "akash.network/restore.state"
+	AkashVolumeSnapshotRestoreMessageAnnotationName = "akash.network/restore.message"
 )
 
 const runtimeClassNoneValue = "none"
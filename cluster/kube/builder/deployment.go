@@ -49,15 +49,16 @@ func (b *deployment) Create() (*appsv1.Deployment, error) { // nolint:golint,unp
 			Replicas: &replicas,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: b.labels(),
+					Labels:      b.labels(),
+					Annotations: b.podAnnotations(nil),
 				},
 				Spec: corev1.PodSpec{
-					RuntimeClassName: effectiveRuntimeClassName,
-					SecurityContext: &corev1.PodSecurityContext{
-						RunAsNonRoot: &falseValue,
-					},
+					RuntimeClassName:             effectiveRuntimeClassName,
+					NodeSelector:                 b.nodeSelector(),
+					SecurityContext:              b.podSecurityContext(),
 					AutomountServiceAccountToken: &falseValue,
-					Containers:                   []corev1.Container{b.container()},
+					Containers:                   b.containers(),
+					Volumes:                      b.volumes(),
 					ImagePullSecrets:             b.imagePullSecrets(),
 				},
 			},
@@ -73,8 +74,12 @@ func (b *deployment) Update(obj *appsv1.Deployment) (*appsv1.Deployment, error)
 	obj.Spec.Selector.MatchLabels = b.labels()
 	obj.Spec.Replicas = &replicas
 	obj.Spec.Template.Labels = b.labels()
-	obj.Spec.Template.Spec.Containers = []corev1.Container{b.container()}
+	obj.Spec.Template.Annotations = b.podAnnotations(obj.Spec.Template.Annotations)
+	obj.Spec.Template.Spec.Containers = b.containers()
 	obj.Spec.Template.Spec.ImagePullSecrets = b.imagePullSecrets()
+	obj.Spec.Template.Spec.NodeSelector = b.nodeSelector()
+	obj.Spec.Template.Spec.SecurityContext = b.podSecurityContext()
+	obj.Spec.Template.Spec.Volumes = b.volumes()
 
 	return obj, nil
 }
@@ -4,10 +4,16 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	maniv2beta1 "github.com/akash-network/node/manifest/v2beta1"
 	"github.com/akash-network/node/sdl"
 	"github.com/akash-network/node/testutil"
+	atypes "github.com/akash-network/node/types/v1beta2"
 
-	"github.com/stretchr/testify/require"
+	"github.com/akash-network/provider/cluster/bandwidth"
+	clusterUtil "github.com/akash-network/provider/cluster/util"
 )
 
 func TestDeploySetsEnvironmentVariables(t *testing.T) {
@@ -60,3 +66,118 @@ func TestDeploySetsEnvironmentVariables(t *testing.T) {
 	require.True(t, ok)
 	require.Equal(t, lid.Provider, value)
 }
+
+func TestDeploySetsArchNodeSelector(t *testing.T) {
+	log := testutil.Logger(t)
+	lid := testutil.LeaseID(t)
+	sdl, err := sdl.ReadFile("../../../testdata/deployment/deployment.yaml")
+	require.NoError(t, err)
+
+	mani, err := sdl.Manifest()
+	require.NoError(t, err)
+	group := mani.GetGroups()[0]
+	service := group.Services[0]
+
+	deploymentBuilder := NewDeployment(log, NewDefaultSettings(), lid, &group, &service)
+	kdeployment, err := deploymentBuilder.Create()
+	require.NoError(t, err)
+	require.Empty(t, kdeployment.Spec.Template.Spec.NodeSelector)
+
+	service.Resources.CPU.Attributes = atypes.Attributes{
+		{Key: clusterUtil.CPUAttributeArch, Value: "arm64"},
+	}
+
+	deploymentBuilder = NewDeployment(log, NewDefaultSettings(), lid, &group, &service)
+	kdeployment, err = deploymentBuilder.Create()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{corev1.LabelArchStable: "arm64"}, kdeployment.Spec.Template.Spec.NodeSelector)
+}
+
+func TestDeploySetsReadinessProbe(t *testing.T) {
+	log := testutil.Logger(t)
+	lid := testutil.LeaseID(t)
+	sdl, err := sdl.ReadFile("../../../testdata/deployment/deployment.yaml")
+	require.NoError(t, err)
+
+	mani, err := sdl.Manifest()
+	require.NoError(t, err)
+	group := mani.GetGroups()[0]
+	service := group.Services[0]
+	require.NotEmpty(t, service.Expose)
+
+	settings := NewDefaultSettings()
+	dbuilder := NewDeployment(log, settings, lid, &group, &service).(*deployment)
+	require.Len(t, dbuilder.containers(), 1)
+	require.Nil(t, dbuilder.container().ReadinessProbe)
+
+	settings.ReadinessProbesEnabled = true
+	dbuilder = NewDeployment(log, settings, lid, &group, &service).(*deployment)
+	containers := dbuilder.containers()
+	require.Len(t, containers, 1)
+	probe := containers[0].ReadinessProbe
+	require.NotNil(t, probe)
+	require.NotNil(t, probe.TCPSocket)
+	require.Equal(t, int(service.Expose[0].Port), probe.TCPSocket.Port.IntValue())
+
+	// every other TCP port is probed by a readiness container, UDP and repeated ports are not
+	first := service.Expose[0]
+	service.Expose = append(service.Expose,
+		maniv2beta1.ServiceExpose{Port: 8080, ExternalPort: 8080, Proto: maniv2beta1.TCP},
+		maniv2beta1.ServiceExpose{Port: 5353, ExternalPort: 5353, Proto: maniv2beta1.UDP},
+		maniv2beta1.ServiceExpose{Port: first.Port, ExternalPort: 8000, Proto: maniv2beta1.TCP},
+	)
+	dbuilder = NewDeployment(log, settings, lid, &group, &service).(*deployment)
+	kdeployment, err := dbuilder.Create()
+	require.NoError(t, err)
+	containers = kdeployment.Spec.Template.Spec.Containers
+	require.Len(t, containers, 2)
+	require.Equal(t, service.Name, containers[0].Name)
+	require.Equal(t, int(first.Port), containers[0].ReadinessProbe.TCPSocket.Port.IntValue())
+	require.Equal(t, "akash-readiness-8080", containers[1].Name)
+	require.Equal(t, DefaultReadinessProbeImage, containers[1].Image)
+	require.Equal(t, 8080, containers[1].ReadinessProbe.TCPSocket.Port.IntValue())
+	require.Equal(t, int64(readinessContainerCPU), containers[1].Resources.Limits.Cpu().MilliValue())
+
+	// UDP only services cannot be probed
+	for i := range service.Expose {
+		service.Expose[i].Proto = maniv2beta1.UDP
+	}
+	dbuilder = NewDeployment(log, settings, lid, &group, &service).(*deployment)
+	require.Len(t, dbuilder.containers(), 1)
+	require.Nil(t, dbuilder.container().ReadinessProbe)
+}
+
+func TestDeploySetsBandwidthAnnotations(t *testing.T) {
+	log := testutil.Logger(t)
+	lid := testutil.LeaseID(t)
+	sdl, err := sdl.ReadFile("../../../testdata/deployment/deployment.yaml")
+	require.NoError(t, err)
+
+	mani, err := sdl.Manifest()
+	require.NoError(t, err)
+	group := mani.GetGroups()[0]
+	service := group.Services[0]
+
+	obj, err := NewDeployment(log, NewDefaultSettings(), lid, &group, &service).Create()
+	require.NoError(t, err)
+	require.Empty(t, obj.Spec.Template.Annotations)
+
+	settings := NewDefaultSettings()
+	settings.BandwidthPolicy = &bandwidth.Policy{
+		Limits: bandwidth.Limits{Ingress: "10M", Egress: "5M"},
+	}
+
+	// annotations set by others are kept
+	obj.Spec.Template.Annotations = map[string]string{"kubectl.kubernetes.io/restartedAt": "now"}
+	obj, err = NewDeployment(log, settings, lid, &group, &service).Update(obj)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"kubectl.kubernetes.io/restartedAt": "now",
+		bandwidth.AnnotationIngress:         "10M",
+		bandwidth.AnnotationEgress:          "5M",
+	}, obj.Spec.Template.Annotations)
+
+	obj, err = NewDeployment(log, NewDefaultSettings(), lid, &group, &service).Update(obj)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"kubectl.kubernetes.io/restartedAt": "now"}, obj.Spec.Template.Annotations)
+}
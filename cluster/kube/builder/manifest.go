@@ -57,6 +57,8 @@ func (b *manifest) Update(obj *crd.Manifest) (*crd.Manifest, error) {
 	if err != nil {
 		return nil, err
 	}
+	// the last good group is recorded separately, once the deployed group is found healthy
+	m.Spec.LastGoodGroup = obj.Spec.LastGoodGroup
 	obj.Spec = m.Spec
 	obj.Labels = b.labels()
 	return obj, nil
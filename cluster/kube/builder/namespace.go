@@ -25,7 +25,7 @@ func BuildNS(settings Settings, lid mtypes.LeaseID, group *manitypes.Group) NS {
 }
 
 func (b *ns) labels() map[string]string {
-	return AppendLeaseLabels(b.lid, b.builder.labels())
+	return podSecurityLabels(b.settings.PodSecurityLevel, AppendLeaseLabels(b.lid, b.builder.labels()))
 }
 
 func (b *ns) Create() (*corev1.Namespace, error) { // nolint:golint,unparam
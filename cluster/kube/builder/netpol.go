@@ -2,6 +2,7 @@ package builder
 
 import (
 	"fmt"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
@@ -11,6 +12,8 @@ import (
 	manitypes "github.com/akash-network/node/manifest/v2beta1"
 	sdlutil "github.com/akash-network/node/sdl/util"
 	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+
+	"github.com/akash-network/provider/cluster/netpolicy"
 )
 
 type NetPol interface {
@@ -36,9 +39,6 @@ func (b *netPol) Create() ([]*netv1.NetworkPolicy, error) { // nolint:golint,unp
 		return []*netv1.NetworkPolicy{}, nil
 	}
 
-	const ingressLabelName = "app.kubernetes.io/name"
-	const ingressLabelValue = "ingress-nginx"
-
 	result := []*netv1.NetworkPolicy{
 		{
 
@@ -65,20 +65,9 @@ func (b *netPol) Create() ([]*netv1.NetworkPolicy, error) { // nolint:golint,unp
 							},
 						},
 					},
-					{ // Allow Network Connections from NGINX ingress controller
+					{ // Allow Network Connections from the ingress controller
 						From: []netv1.NetworkPolicyPeer{
-							{
-								NamespaceSelector: &metav1.LabelSelector{
-									MatchLabels: map[string]string{
-										ingressLabelName: ingressLabelValue,
-									},
-								},
-								PodSelector: &metav1.LabelSelector{
-									MatchLabels: map[string]string{
-										ingressLabelName: ingressLabelValue,
-									},
-								},
-							},
+							ingressControllerPeer(b.settings.NetworkPolicy.IngressControllerSelector()),
 						},
 					},
 				},
@@ -120,27 +109,14 @@ func (b *netPol) Create() ([]*netv1.NetworkPolicy, error) { // nolint:golint,unp
 							},
 						},
 					},
-					{ // Allow access to IPV4 Public addresses only
-						To: []netv1.NetworkPolicyPeer{
-							{
-								PodSelector:       nil,
-								NamespaceSelector: nil,
-								IPBlock: &netv1.IPBlock{
-									CIDR: "0.0.0.0/0",
-									Except: []string{
-										"10.0.0.0/8",
-										"192.168.0.0/16",
-										"172.16.0.0/12",
-									},
-								},
-							},
-						},
-					},
 				},
 			},
 		},
 	}
 
+	// Allow egress configured by the provider, public IPv4 addresses by default
+	result[0].Spec.Egress = append(result[0].Spec.Egress, egressRules(b.settings.NetworkPolicy.EgressRules())...)
+
 	for _, service := range b.group.Services {
 		// find all the ports that are exposed directly
 		ports := make([]netv1.NetworkPolicyPort, 0)
@@ -247,3 +223,70 @@ func (b *netPol) Update(obj *netv1.NetworkPolicy) (*netv1.NetworkPolicy, error)
 	obj.Labels = b.labels()
 	return obj, nil
 }
+
+func ingressControllerPeer(selector netpolicy.IngressController) netv1.NetworkPolicyPeer {
+	peer := netv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: selector.NamespaceLabels,
+		},
+	}
+
+	if len(selector.PodLabels) != 0 {
+		peer.PodSelector = &metav1.LabelSelector{
+			MatchLabels: selector.PodLabels,
+		}
+	}
+
+	return peer
+}
+
+func egressRules(rules []netpolicy.EgressRule) []netv1.NetworkPolicyEgressRule {
+	result := make([]netv1.NetworkPolicyEgressRule, 0, len(rules))
+
+	for _, rule := range rules {
+		peer := netv1.NetworkPolicyPeer{}
+		if rule.CIDR != "" {
+			peer.IPBlock = &netv1.IPBlock{
+				CIDR:   rule.CIDR,
+				Except: rule.Except,
+			}
+		}
+		if len(rule.NamespaceLabels) != 0 {
+			peer.NamespaceSelector = &metav1.LabelSelector{
+				MatchLabels: rule.NamespaceLabels,
+			}
+		}
+		if len(rule.PodLabels) != 0 {
+			peer.PodSelector = &metav1.LabelSelector{
+				MatchLabels: rule.PodLabels,
+			}
+		}
+
+		var ports []netv1.NetworkPolicyPort
+		for _, port := range rule.Ports {
+			protocol := tcpProtocol
+			if port.Protocol != "" {
+				protocol = corev1.Protocol(strings.ToUpper(port.Protocol))
+			}
+			portNumber := intstr.FromInt(int(port.Port))
+
+			entry := netv1.NetworkPolicyPort{
+				Protocol: &protocol,
+				Port:     &portNumber,
+			}
+			if port.EndPort != 0 {
+				endPort := port.EndPort
+				entry.EndPort = &endPort
+			}
+
+			ports = append(ports, entry)
+		}
+
+		result = append(result, netv1.NetworkPolicyEgressRule{
+			To:    []netv1.NetworkPolicyPeer{peer},
+			Ports: ports,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,93 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	netv1 "k8s.io/api/networking/v1"
+
+	"github.com/akash-network/node/sdl"
+	"github.com/akash-network/node/testutil"
+
+	"github.com/akash-network/provider/cluster/netpolicy"
+)
+
+func buildDefaultNetPolicy(t *testing.T, settings Settings) *netv1.NetworkPolicy {
+	lid := testutil.LeaseID(t)
+
+	sdl, err := sdl.ReadFile("../../../testdata/deployment/deployment.yaml")
+	require.NoError(t, err)
+
+	mani, err := sdl.Manifest()
+	require.NoError(t, err)
+
+	settings.NetworkPoliciesEnabled = true
+	policies, err := BuildNetPol(settings, lid, &mani.GetGroups()[0]).Create()
+	require.NoError(t, err)
+	require.NotEmpty(t, policies)
+	require.Equal(t, akashDeploymentPolicyName, policies[0].Name)
+
+	return policies[0]
+}
+
+func TestNetPolDefaults(t *testing.T) {
+	policy := buildDefaultNetPolicy(t, Settings{})
+
+	ingress := policy.Spec.Ingress[1].From[0]
+	require.Equal(t, "ingress-nginx", ingress.NamespaceSelector.MatchLabels["app.kubernetes.io/name"])
+	require.Equal(t, "ingress-nginx", ingress.PodSelector.MatchLabels["app.kubernetes.io/name"])
+
+	// same namespace, dns and public addresses
+	require.Len(t, policy.Spec.Egress, 3)
+	public := policy.Spec.Egress[2].To[0].IPBlock
+	require.Equal(t, "0.0.0.0/0", public.CIDR)
+	require.Contains(t, public.Except, "10.0.0.0/8")
+	require.Contains(t, public.Except, "169.254.169.254/32")
+}
+
+func TestNetPolConfigured(t *testing.T) {
+	policy := buildDefaultNetPolicy(t, Settings{
+		NetworkPolicy: &netpolicy.Policy{
+			IngressController: netpolicy.IngressController{
+				NamespaceLabels: map[string]string{"kubernetes.io/metadata.name": "traefik"},
+			},
+			Egress: []netpolicy.EgressRule{
+				{
+					CIDR:   "0.0.0.0/0",
+					Except: []string{"169.254.169.254/32"},
+					Ports:  []netpolicy.Port{{Port: 443}, {Protocol: "udp", Port: 30000, EndPort: 32767}},
+				},
+				{
+					NamespaceLabels: map[string]string{"kubernetes.io/metadata.name": "shared"},
+				},
+				{
+					NamespaceLabels: map[string]string{"kubernetes.io/metadata.name": "shared"},
+					PodLabels:       map[string]string{"app": "cache"},
+				},
+			},
+		},
+	})
+
+	ingress := policy.Spec.Ingress[1].From[0]
+	require.Equal(t, "traefik", ingress.NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"])
+	require.Nil(t, ingress.PodSelector)
+
+	require.Len(t, policy.Spec.Egress, 5)
+
+	public := policy.Spec.Egress[2]
+	require.Equal(t, []string{"169.254.169.254/32"}, public.To[0].IPBlock.Except)
+	require.Len(t, public.Ports, 2)
+	require.Equal(t, "TCP", string(*public.Ports[0].Protocol))
+	require.Equal(t, "UDP", string(*public.Ports[1].Protocol))
+	require.Equal(t, int32(32767), *public.Ports[1].EndPort)
+
+	shared := policy.Spec.Egress[3]
+	require.Nil(t, shared.To[0].IPBlock)
+	require.Equal(t, "shared", shared.To[0].NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"])
+	require.Empty(t, shared.Ports)
+	require.Nil(t, shared.To[0].PodSelector)
+
+	cache := policy.Spec.Egress[4]
+	require.Equal(t, "shared", cache.To[0].NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"])
+	require.Equal(t, "cache", cache.To[0].PodSelector.MatchLabels["app"])
+}
@@ -0,0 +1,345 @@
+package builder
+
+import (
+	"github.com/tendermint/tendermint/libs/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	manitypes "github.com/akash-network/node/manifest/v2beta1"
+	"github.com/akash-network/node/sdl"
+	sdlutil "github.com/akash-network/node/sdl/util"
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+)
+
+const (
+	akashLeaseQuotaName      = "akash-lease-quota"
+	akashLeaseLimitRangeName = "akash-lease-limits"
+)
+
+type ResourceQuota interface {
+	builderBase
+	Create() (*corev1.ResourceQuota, error)
+	Update(obj *corev1.ResourceQuota) (*corev1.ResourceQuota, error)
+}
+
+type LimitRange interface {
+	builderBase
+	Create() (*corev1.LimitRange, error)
+	Update(obj *corev1.LimitRange) (*corev1.LimitRange, error)
+}
+
+type resourceQuota struct {
+	builder
+	previous *manitypes.Group
+}
+
+type limitRange struct {
+	builder
+}
+
+var _ ResourceQuota = (*resourceQuota)(nil)
+var _ LimitRange = (*limitRange)(nil)
+
+// BuildResourceQuota caps the resources of the lease namespace to the ones paid for by the manifest group.
+// previous is the group being replaced by an update, its pods are accounted for until the rollout completed.
+func BuildResourceQuota(log log.Logger, settings Settings, lid mtypes.LeaseID, group *manitypes.Group, previous *manitypes.Group) ResourceQuota {
+	return &resourceQuota{
+		builder: builder{
+			log:      log.With("module", "kube-builder"),
+			settings: settings,
+			lid:      lid,
+			group:    group,
+		},
+		previous: previous,
+	}
+}
+
+// BuildLimitRange caps the resources of a single container or volume claim in the lease namespace to the
+// largest ones of the manifest group
+func BuildLimitRange(log log.Logger, settings Settings, lid mtypes.LeaseID, group *manitypes.Group) LimitRange {
+	return &limitRange{
+		builder: builder{
+			log:      log.With("module", "kube-builder"),
+			settings: settings,
+			lid:      lid,
+			group:    group,
+		},
+	}
+}
+
+func (b *resourceQuota) Name() string {
+	return akashLeaseQuotaName
+}
+
+func (b *resourceQuota) Create() (*corev1.ResourceQuota, error) { // nolint:golint,unparam
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.Name(),
+			Namespace: b.NS(),
+			Labels:    b.labels(),
+		},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: b.hard(),
+		},
+	}, nil
+}
+
+func (b *resourceQuota) Update(obj *corev1.ResourceQuota) (*corev1.ResourceQuota, error) { // nolint:golint,unparam
+	obj.Labels = b.labels()
+	obj.Spec.Hard = b.hard()
+	return obj, nil
+}
+
+// hard sums the resources of every service of the group. Deployments roll out by surging new pods
+// before removing old ones, the quota leaves room for the surge pods of every stateless service.
+// During an update pods of both groups may run at once, the quota then covers every one of them.
+func (b *resourceQuota) hard() corev1.ResourceList {
+	if b.previous == nil {
+		return b.groupQuota(b.group, true).resourceList()
+	}
+
+	total := b.groupQuota(b.group, false)
+	total.add(b.groupQuota(b.previous, false))
+
+	return total.resourceList()
+}
+
+// quotaTotals are the resources of the lease namespace accounted by the quota
+type quotaTotals struct {
+	pods, services, nodePorts, claims                   int64
+	cpuRequest, cpuLimit, memRequest, memLimit          int64
+	ephemeralRequest, ephemeralLimit, persistentStorage int64
+}
+
+func (b *resourceQuota) groupQuota(group *manitypes.Group, surge bool) quotaTotals {
+	var total quotaTotals
+
+	leasedIPServices := int64(1)
+	if b.settings.LeasedIPDualStack {
+		leasedIPServices = 2
+	}
+
+	for idx := range group.Services {
+		svc := &group.Services[idx]
+		res := workloadResourcesOf(b.settings, svc)
+
+		count := int64(svc.Count)
+		pods := count
+		if surge && res.persistentVolumes == 0 {
+			pods += rollingUpdateSurge(count)
+		}
+
+		total.pods += pods
+		total.cpuRequest += pods * res.cpuRequest
+		total.cpuLimit += pods * res.cpuLimit
+		total.memRequest += pods * res.memRequest
+		total.memLimit += pods * res.memLimit
+		total.ephemeralRequest += pods * res.ephemeralRequest
+		total.ephemeralLimit += pods * res.ephemeralLimit
+		total.claims += count * res.persistentVolumes
+		total.persistentStorage += count * res.persistentStorage
+
+		local := BuildService(b.log, b.settings, b.lid, group, svc, false)
+		if local.Any() {
+			total.services++
+		}
+
+		global := &service{
+			workload:        newWorkloadBuilder(b.log, b.settings, b.lid, group, svc),
+			requireNodePort: true,
+		}
+		if global.Any() {
+			total.services++
+			if ports, err := global.ports(); err == nil {
+				total.nodePorts += int64(len(ports))
+			}
+		}
+
+		// the IP operator exposes every leased IP endpoint with a LoadBalancer service of its own,
+		// which is also given a node port
+		for _, expose := range svc.Expose {
+			if !expose.Global || len(expose.IP) == 0 {
+				continue
+			}
+
+			total.services += leasedIPServices
+			total.nodePorts += leasedIPServices
+		}
+	}
+
+	return total
+}
+
+func (t *quotaTotals) add(other quotaTotals) {
+	t.pods += other.pods
+	t.services += other.services
+	t.nodePorts += other.nodePorts
+	t.claims += other.claims
+	t.cpuRequest += other.cpuRequest
+	t.cpuLimit += other.cpuLimit
+	t.memRequest += other.memRequest
+	t.memLimit += other.memLimit
+	t.ephemeralRequest += other.ephemeralRequest
+	t.ephemeralLimit += other.ephemeralLimit
+	t.persistentStorage += other.persistentStorage
+}
+
+func (t quotaTotals) resourceList() corev1.ResourceList {
+	return corev1.ResourceList{
+		corev1.ResourcePods:                     *resource.NewQuantity(t.pods, resource.DecimalSI),
+		corev1.ResourceServices:                 *resource.NewQuantity(t.services, resource.DecimalSI),
+		corev1.ResourceServicesNodePorts:        *resource.NewQuantity(t.nodePorts, resource.DecimalSI),
+		corev1.ResourcePersistentVolumeClaims:   *resource.NewQuantity(t.claims, resource.DecimalSI),
+		corev1.ResourceRequestsStorage:          *resource.NewQuantity(t.persistentStorage, resource.DecimalSI),
+		corev1.ResourceRequestsCPU:              *resource.NewScaledQuantity(t.cpuRequest, resource.Milli),
+		corev1.ResourceLimitsCPU:                *resource.NewScaledQuantity(t.cpuLimit, resource.Milli),
+		corev1.ResourceRequestsMemory:           *resource.NewQuantity(t.memRequest, resource.DecimalSI),
+		corev1.ResourceLimitsMemory:             *resource.NewQuantity(t.memLimit, resource.DecimalSI),
+		corev1.ResourceRequestsEphemeralStorage: *resource.NewQuantity(t.ephemeralRequest, resource.DecimalSI),
+		corev1.ResourceLimitsEphemeralStorage:   *resource.NewQuantity(t.ephemeralLimit, resource.DecimalSI),
+	}
+}
+
+func (b *limitRange) Name() string {
+	return akashLeaseLimitRangeName
+}
+
+func (b *limitRange) Create() (*corev1.LimitRange, error) { // nolint:golint,unparam
+	return &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.Name(),
+			Namespace: b.NS(),
+			Labels:    b.labels(),
+		},
+		Spec: corev1.LimitRangeSpec{
+			Limits: b.limits(),
+		},
+	}, nil
+}
+
+func (b *limitRange) Update(obj *corev1.LimitRange) (*corev1.LimitRange, error) { // nolint:golint,unparam
+	obj.Labels = b.labels()
+	obj.Spec.Limits = b.limits()
+	return obj, nil
+}
+
+// limits allows a container as large as the largest service of the group. Containers created without
+// resources, such as ones not managed by the provider, default to it and are accounted by the quota.
+func (b *limitRange) limits() []corev1.LimitRangeItem {
+	var largest workloadResources
+
+	for idx := range b.group.Services {
+		res := workloadResourcesOf(b.settings, &b.group.Services[idx])
+
+		largest.cpuRequest = maxInt64(largest.cpuRequest, res.cpuRequest)
+		largest.cpuLimit = maxInt64(largest.cpuLimit, res.cpuLimit)
+		largest.memRequest = maxInt64(largest.memRequest, res.memRequest)
+		largest.memLimit = maxInt64(largest.memLimit, res.memLimit)
+		largest.ephemeralRequest = maxInt64(largest.ephemeralRequest, res.ephemeralRequest)
+		largest.ephemeralLimit = maxInt64(largest.ephemeralLimit, res.ephemeralLimit)
+		largest.largestVolume = maxInt64(largest.largestVolume, res.largestVolume)
+	}
+
+	limits := corev1.ResourceList{
+		corev1.ResourceCPU:              *resource.NewScaledQuantity(largest.cpuLimit, resource.Milli),
+		corev1.ResourceMemory:           *resource.NewQuantity(largest.memLimit, resource.DecimalSI),
+		corev1.ResourceEphemeralStorage: *resource.NewQuantity(largest.ephemeralLimit, resource.DecimalSI),
+	}
+
+	requests := corev1.ResourceList{
+		corev1.ResourceCPU:              *resource.NewScaledQuantity(largest.cpuRequest, resource.Milli),
+		corev1.ResourceMemory:           *resource.NewQuantity(largest.memRequest, resource.DecimalSI),
+		corev1.ResourceEphemeralStorage: *resource.NewQuantity(largest.ephemeralRequest, resource.DecimalSI),
+	}
+
+	items := []corev1.LimitRangeItem{
+		{
+			Type:           corev1.LimitTypeContainer,
+			Max:            limits,
+			Default:        limits.DeepCopy(),
+			DefaultRequest: requests,
+		},
+	}
+
+	if largest.largestVolume > 0 {
+		items = append(items, corev1.LimitRangeItem{
+			Type: corev1.LimitTypePersistentVolumeClaim,
+			Max: corev1.ResourceList{
+				corev1.ResourceStorage: *resource.NewQuantity(largest.largestVolume, resource.DecimalSI),
+			},
+		})
+	}
+
+	return items
+}
+
+// workloadResources are the resources of a single replica of a service, as set on its containers and
+// volume claims by the workload builder
+type workloadResources struct {
+	cpuRequest, cpuLimit             int64
+	memRequest, memLimit             int64
+	ephemeralRequest, ephemeralLimit int64
+
+	persistentVolumes int64
+	persistentStorage int64
+	largestVolume     int64
+}
+
+func workloadResourcesOf(settings Settings, svc *manitypes.Service) workloadResources {
+	var res workloadResources
+
+	if cpu := svc.Resources.CPU; cpu != nil {
+		res.cpuRequest = int64(sdlutil.ComputeCommittedResources(settings.CPUCommitLevel, cpu.Units).Value())
+		res.cpuLimit = int64(cpu.Units.Value())
+	}
+
+	if mem := svc.Resources.Memory; mem != nil {
+		res.memRequest = int64(sdlutil.ComputeCommittedResources(settings.MemoryCommitLevel, mem.Quantity).Value())
+		res.memLimit = int64(mem.Quantity.Value())
+	}
+
+	ephemeralFound := false
+	for _, storage := range svc.Resources.Storage {
+		attr := storage.Attributes.Find(sdl.StorageAttributePersistent)
+		if persistent, _ := attr.AsBool(); persistent {
+			size := int64(storage.Quantity.Value())
+			res.persistentVolumes++
+			res.persistentStorage += size
+			res.largestVolume = maxInt64(res.largestVolume, size)
+			continue
+		}
+
+		// the container only gets the first ephemeral volume, see workload.container
+		if !ephemeralFound {
+			ephemeralFound = true
+			res.ephemeralRequest = int64(sdlutil.ComputeCommittedResources(settings.StorageCommitLevel, storage.Quantity).Value())
+			res.ephemeralLimit = int64(storage.Quantity.Value())
+		}
+	}
+
+	// every exposed TCP port after the first one is probed by a readiness container, see workload.containers
+	if ports := int64(len(readinessPorts(settings, svc))); ports > 1 {
+		res.cpuRequest += (ports - 1) * readinessContainerCPU
+		res.cpuLimit += (ports - 1) * readinessContainerCPU
+		res.memRequest += (ports - 1) * readinessContainerMemory
+		res.memLimit += (ports - 1) * readinessContainerMemory
+		res.ephemeralRequest += (ports - 1) * readinessContainerEphemeral
+		res.ephemeralLimit += (ports - 1) * readinessContainerEphemeral
+	}
+
+	return res
+}
+
+// rollingUpdateSurge is the number of extra pods a deployment of count replicas creates during a rollout
+// with the default max surge of 25%
+func rollingUpdateSurge(count int64) int64 {
+	return (count + 3) / 4
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,173 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	manitypes "github.com/akash-network/node/manifest/v2beta1"
+	"github.com/akash-network/node/sdl"
+	"github.com/akash-network/node/testutil"
+)
+
+func TestResourceQuotaStatelessService(t *testing.T) {
+	log := testutil.Logger(t)
+	lid := testutil.LeaseID(t)
+
+	sdl, err := sdl.ReadFile("../../../testdata/deployment/deployment.yaml")
+	require.NoError(t, err)
+
+	mani, err := sdl.Manifest()
+	require.NoError(t, err)
+
+	quota, err := BuildResourceQuota(log, Settings{}, lid, &mani.GetGroups()[0], nil).Create()
+	require.NoError(t, err)
+	require.Equal(t, LidNS(lid), quota.Namespace)
+
+	hard := quota.Spec.Hard
+
+	// one replica plus one surge pod during rollouts
+	require.Equal(t, int64(2), hard.Pods().Value())
+	require.Equal(t, int64(20), hard.Name(corev1.ResourceLimitsCPU, "").MilliValue())
+	require.Equal(t, int64(2*128*1024*1024), hard.Name(corev1.ResourceLimitsMemory, "").Value())
+	require.Equal(t, int64(2*512*1024*1024), hard.Name(corev1.ResourceLimitsEphemeralStorage, "").Value())
+	require.Equal(t, int64(1), hard.Name(corev1.ResourceServices, "").Value())
+	require.Equal(t, int64(0), hard.Name(corev1.ResourceServicesNodePorts, "").Value())
+	require.Equal(t, int64(0), hard.Name(corev1.ResourcePersistentVolumeClaims, "").Value())
+}
+
+func TestResourceQuotaPersistentService(t *testing.T) {
+	log := testutil.Logger(t)
+	lid := testutil.LeaseID(t)
+
+	sdl, err := sdl.ReadFile("../../../testdata/deployment/deployment-v2-storage-beta2.yaml")
+	require.NoError(t, err)
+
+	mani, err := sdl.Manifest()
+	require.NoError(t, err)
+
+	quota, err := BuildResourceQuota(log, Settings{}, lid, &mani.GetGroups()[0], nil).Create()
+	require.NoError(t, err)
+
+	hard := quota.Spec.Hard
+
+	// statefulsets replace pods one by one, no surge
+	require.Equal(t, int64(1), hard.Pods().Value())
+	require.Equal(t, int64(1), hard.Name(corev1.ResourcePersistentVolumeClaims, "").Value())
+	require.Equal(t, int64(128*1024*1024), hard.Name(corev1.ResourceRequestsStorage, "").Value())
+
+	limits, err := BuildLimitRange(log, Settings{}, lid, &mani.GetGroups()[0]).Create()
+	require.NoError(t, err)
+	require.Len(t, limits.Spec.Limits, 2)
+
+	container := limits.Spec.Limits[0]
+	require.Equal(t, corev1.LimitTypeContainer, container.Type)
+	require.Equal(t, int64(10), container.Max.Cpu().MilliValue())
+	require.Equal(t, int64(128*1024*1024), container.Default.Memory().Value())
+
+	claim := limits.Spec.Limits[1]
+	require.Equal(t, corev1.LimitTypePersistentVolumeClaim, claim.Type)
+	require.Equal(t, int64(128*1024*1024), claim.Max.Storage().Value())
+}
+
+func TestResourceQuotaUpdate(t *testing.T) {
+	log := testutil.Logger(t)
+	lid := testutil.LeaseID(t)
+
+	previousSDL, err := sdl.ReadFile("../../../testdata/deployment/deployment.yaml")
+	require.NoError(t, err)
+	previous, err := previousSDL.Manifest()
+	require.NoError(t, err)
+
+	nextSDL, err := sdl.ReadFile("../../../testdata/deployment/deployment-v2-storage-beta2.yaml")
+	require.NoError(t, err)
+	next, err := nextSDL.Manifest()
+	require.NoError(t, err)
+
+	quota, err := BuildResourceQuota(log, Settings{}, lid, &next.GetGroups()[0], &previous.GetGroups()[0]).Create()
+	require.NoError(t, err)
+
+	hard := quota.Spec.Hard
+
+	// the pods of both groups run until the rollout completed
+	require.Equal(t, int64(2), hard.Pods().Value())
+	require.Equal(t, int64(20), hard.Name(corev1.ResourceLimitsCPU, "").MilliValue())
+	require.Equal(t, int64(2*128*1024*1024), hard.Name(corev1.ResourceLimitsMemory, "").Value())
+	require.Equal(t, int64(2), hard.Name(corev1.ResourceServices, "").Value())
+	require.Equal(t, int64(1), hard.Name(corev1.ResourcePersistentVolumeClaims, "").Value())
+}
+
+func TestResourceQuotaLeasedIP(t *testing.T) {
+	log := testutil.Logger(t)
+	lid := testutil.LeaseID(t)
+
+	sdl, err := sdl.ReadFile("../../../testdata/deployment/deployment.yaml")
+	require.NoError(t, err)
+
+	mani, err := sdl.Manifest()
+	require.NoError(t, err)
+
+	group := mani.GetGroups()[0]
+	expose := &group.Services[0].Expose[0]
+	expose.Global = true
+	expose.IP = "myendpoint"
+
+	quota, err := BuildResourceQuota(log, Settings{}, lid, &group, nil).Create()
+	require.NoError(t, err)
+	single := quota.Spec.Hard
+
+	quota, err = BuildResourceQuota(log, Settings{LeasedIPDualStack: true}, lid, &group, nil).Create()
+	require.NoError(t, err)
+	dual := quota.Spec.Hard
+
+	// the IPv6 companion service of the endpoint takes a service and a node port more
+	services := single.Name(corev1.ResourceServices, "").Value()
+	nodePorts := single.Name(corev1.ResourceServicesNodePorts, "").Value()
+	require.Equal(t, services+1, dual.Name(corev1.ResourceServices, "").Value())
+	require.Equal(t, nodePorts+1, dual.Name(corev1.ResourceServicesNodePorts, "").Value())
+
+	expose.IP = ""
+	quota, err = BuildResourceQuota(log, Settings{}, lid, &group, nil).Create()
+	require.NoError(t, err)
+	require.Equal(t, services-1, quota.Spec.Hard.Name(corev1.ResourceServices, "").Value())
+	require.Equal(t, nodePorts-1, quota.Spec.Hard.Name(corev1.ResourceServicesNodePorts, "").Value())
+}
+
+func TestResourceQuotaReadinessContainers(t *testing.T) {
+	log := testutil.Logger(t)
+	lid := testutil.LeaseID(t)
+
+	sdl, err := sdl.ReadFile("../../../testdata/deployment/deployment.yaml")
+	require.NoError(t, err)
+
+	mani, err := sdl.Manifest()
+	require.NoError(t, err)
+
+	group := mani.GetGroups()[0]
+	svc := &group.Services[0]
+	svc.Expose = append(svc.Expose, manitypes.ServiceExpose{Port: 8080, ExternalPort: 8080, Proto: manitypes.TCP})
+
+	quota, err := BuildResourceQuota(log, Settings{}, lid, &group, nil).Create()
+	require.NoError(t, err)
+	unprobed := quota.Spec.Hard
+
+	quota, err = BuildResourceQuota(log, Settings{ReadinessProbesEnabled: true}, lid, &group, nil).Create()
+	require.NoError(t, err)
+	probed := quota.Spec.Hard
+
+	// each of the two pods runs a readiness container for the second port
+	require.Equal(t, unprobed.Name(corev1.ResourceLimitsCPU, "").MilliValue()+2*readinessContainerCPU,
+		probed.Name(corev1.ResourceLimitsCPU, "").MilliValue())
+	require.Equal(t, unprobed.Name(corev1.ResourceLimitsMemory, "").Value()+2*readinessContainerMemory,
+		probed.Name(corev1.ResourceLimitsMemory, "").Value())
+	require.Equal(t, unprobed.Name(corev1.ResourceRequestsEphemeralStorage, "").Value()+2*readinessContainerEphemeral,
+		probed.Name(corev1.ResourceRequestsEphemeralStorage, "").Value())
+}
+
+func TestRollingUpdateSurge(t *testing.T) {
+	require.Equal(t, int64(0), rollingUpdateSurge(0))
+	require.Equal(t, int64(1), rollingUpdateSurge(1))
+	require.Equal(t, int64(1), rollingUpdateSurge(4))
+	require.Equal(t, int64(2), rollingUpdateSurge(5))
+}
@@ -0,0 +1,146 @@
+package builder
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PodSecurityLevel is the Pod Security Admission level enforced on lease namespaces
+type PodSecurityLevel string
+
+const (
+	PodSecurityLevelNone       PodSecurityLevel = ""
+	PodSecurityLevelBaseline   PodSecurityLevel = "baseline"
+	PodSecurityLevelRestricted PodSecurityLevel = "restricted"
+)
+
+// SecurityProfile selects the security context of the containers of a lease
+type SecurityProfile string
+
+const (
+	// SecurityProfileDefault only forbids privileged containers and privilege escalation
+	SecurityProfileDefault SecurityProfile = "default"
+	// SecurityProfileHardened additionally applies the RuntimeDefault seccomp profile and drops all
+	// capabilities but binding to low ports
+	SecurityProfileHardened SecurityProfile = "hardened"
+	// SecurityProfileHardenedReadOnly additionally makes the root filesystem read-only, with an emptyDir on /tmp
+	SecurityProfileHardenedReadOnly SecurityProfile = "hardened-read-only"
+)
+
+const (
+	podSecurityEnforceLabelName = "pod-security.kubernetes.io/enforce"
+	podSecurityWarnLabelName    = "pod-security.kubernetes.io/warn"
+
+	tmpVolumeName = "tmp"
+	tmpMountPath  = "/tmp"
+)
+
+func (l PodSecurityLevel) valid() bool {
+	switch l {
+	case PodSecurityLevelNone, PodSecurityLevelBaseline, PodSecurityLevelRestricted:
+		return true
+	}
+	return false
+}
+
+func (p SecurityProfile) valid() bool {
+	switch p {
+	case "", SecurityProfileDefault, SecurityProfileHardened, SecurityProfileHardenedReadOnly:
+		return true
+	}
+	return false
+}
+
+func (p SecurityProfile) hardened() bool {
+	return p == SecurityProfileHardened || p == SecurityProfileHardenedReadOnly
+}
+
+// podSecurityLabels labels a namespace for Pod Security Admission to enforce the configured level
+func podSecurityLabels(level PodSecurityLevel, labels map[string]string) map[string]string {
+	if level == PodSecurityLevelNone {
+		return labels
+	}
+
+	labels[podSecurityEnforceLabelName] = string(level)
+	labels[podSecurityWarnLabelName] = string(level)
+	return labels
+}
+
+// runAsNonRoot is only required by the restricted level, images running as root are allowed otherwise
+func (b *workload) runAsNonRoot() *bool {
+	value := b.settings.PodSecurityLevel == PodSecurityLevelRestricted
+	return &value
+}
+
+func (b *workload) podSecurityContext() *corev1.PodSecurityContext {
+	return &corev1.PodSecurityContext{
+		RunAsNonRoot: b.runAsNonRoot(),
+	}
+}
+
+func (b *workload) containerSecurityContext() *corev1.SecurityContext {
+	falseValue := false
+
+	sc := &corev1.SecurityContext{
+		RunAsNonRoot:             b.runAsNonRoot(),
+		Privileged:               &falseValue,
+		AllowPrivilegeEscalation: &falseValue,
+	}
+
+	if !b.settings.SecurityProfile.hardened() {
+		return sc
+	}
+
+	sc.SeccompProfile = &corev1.SeccompProfile{
+		Type: corev1.SeccompProfileTypeRuntimeDefault,
+	}
+	sc.Capabilities = &corev1.Capabilities{
+		Drop: []corev1.Capability{"ALL"},
+		// allowed by the restricted level, keeps images serving on port 80 or 443 working
+		Add: []corev1.Capability{"NET_BIND_SERVICE"},
+	}
+
+	if b.readOnlyRootFilesystem() {
+		trueValue := true
+		sc.ReadOnlyRootFilesystem = &trueValue
+	}
+
+	return sc
+}
+
+func (b *workload) readOnlyRootFilesystem() bool {
+	return b.settings.SecurityProfile == SecurityProfileHardenedReadOnly
+}
+
+// tmpVolumeMounted tells if /tmp is backed by an emptyDir, which is the case with a read-only root
+// filesystem unless the service mounts one of its own volumes there
+func (b *workload) tmpVolumeMounted() bool {
+	if !b.readOnlyRootFilesystem() {
+		return false
+	}
+
+	if b.service.Params != nil {
+		for _, params := range b.service.Params.Storage {
+			if params.Mount == tmpMountPath {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// volumes lists the pod volumes not backed by a persistent volume claim
+func (b *workload) volumes() []corev1.Volume {
+	if !b.tmpVolumeMounted() {
+		return nil
+	}
+
+	return []corev1.Volume{
+		{
+			Name: tmpVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	}
+}
@@ -0,0 +1,83 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/akash-network/node/sdl"
+	"github.com/akash-network/node/testutil"
+)
+
+func TestDeploySetsHardenedSecurityContext(t *testing.T) {
+	log := testutil.Logger(t)
+	lid := testutil.LeaseID(t)
+
+	sdl, err := sdl.ReadFile("../../../testdata/deployment/deployment.yaml")
+	require.NoError(t, err)
+
+	mani, err := sdl.Manifest()
+	require.NoError(t, err)
+	group := &mani.GetGroups()[0]
+
+	settings := Settings{
+		PodSecurityLevel: PodSecurityLevelRestricted,
+		SecurityProfile:  SecurityProfileHardenedReadOnly,
+	}
+	require.NoError(t, ValidateSettings(settings))
+
+	obj, err := NewDeployment(log, settings, lid, group, &group.Services[0]).Create()
+	require.NoError(t, err)
+
+	pod := obj.Spec.Template.Spec
+	require.True(t, *pod.SecurityContext.RunAsNonRoot)
+	require.Len(t, pod.Volumes, 1)
+	require.NotNil(t, pod.Volumes[0].EmptyDir)
+
+	sc := pod.Containers[0].SecurityContext
+	require.True(t, *sc.RunAsNonRoot)
+	require.True(t, *sc.ReadOnlyRootFilesystem)
+	require.Equal(t, corev1.SeccompProfileTypeRuntimeDefault, sc.SeccompProfile.Type)
+	require.Equal(t, []corev1.Capability{"ALL"}, sc.Capabilities.Drop)
+	require.Contains(t, pod.Containers[0].VolumeMounts, corev1.VolumeMount{Name: tmpVolumeName, MountPath: tmpMountPath})
+
+	ns, err := BuildNS(settings, lid, group).Create()
+	require.NoError(t, err)
+	require.Equal(t, "restricted", ns.Labels[podSecurityEnforceLabelName])
+}
+
+func TestDeploySetsDefaultSecurityContext(t *testing.T) {
+	log := testutil.Logger(t)
+	lid := testutil.LeaseID(t)
+
+	sdl, err := sdl.ReadFile("../../../testdata/deployment/deployment.yaml")
+	require.NoError(t, err)
+
+	mani, err := sdl.Manifest()
+	require.NoError(t, err)
+	group := &mani.GetGroups()[0]
+
+	obj, err := NewDeployment(log, Settings{}, lid, group, &group.Services[0]).Create()
+	require.NoError(t, err)
+
+	pod := obj.Spec.Template.Spec
+	require.False(t, *pod.SecurityContext.RunAsNonRoot)
+	require.Empty(t, pod.Volumes)
+
+	sc := pod.Containers[0].SecurityContext
+	require.False(t, *sc.Privileged)
+	require.Nil(t, sc.SeccompProfile)
+	require.Nil(t, sc.ReadOnlyRootFilesystem)
+
+	ns, err := BuildNS(Settings{}, lid, group).Create()
+	require.NoError(t, err)
+	require.NotContains(t, ns.Labels, podSecurityEnforceLabelName)
+}
+
+func TestValidateSecuritySettings(t *testing.T) {
+	require.ErrorIs(t, ValidateSettings(Settings{PodSecurityLevel: "paranoid"}), ErrSettingsValidation)
+	require.ErrorIs(t, ValidateSettings(Settings{SecurityProfile: "paranoid"}), ErrSettingsValidation)
+	require.ErrorIs(t, ValidateSettings(Settings{PodSecurityLevel: PodSecurityLevelRestricted}), ErrSettingsValidation)
+	require.NoError(t, ValidateSettings(Settings{PodSecurityLevel: PodSecurityLevelBaseline}))
+}
@@ -2,11 +2,16 @@ package builder
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 
 	validation_util "github.com/akash-network/node/util/validation"
+
+	"github.com/akash-network/provider/cluster/bandwidth"
+	"github.com/akash-network/provider/cluster/imagepolicy"
+	"github.com/akash-network/provider/cluster/netpolicy"
 )
 
 // Settings configures k8s object generation such that it is customized to the
@@ -34,6 +39,30 @@ type Settings struct {
 	// NetworkPoliciesEnabled determines if NetworkPolicies should be installed.
 	NetworkPoliciesEnabled bool
 
+	// NetworkPolicy customizes the ingress controller and egress allowed by NetworkPolicies. nil uses the defaults
+	NetworkPolicy *netpolicy.Policy
+
+	// ReadinessProbesEnabled determines if a TCP readiness probe is generated for every exposed TCP port
+	// of each service. Services exposing only UDP ports are not probed.
+	ReadinessProbesEnabled bool
+
+	// ReadinessProbeImage is the image of the containers probing the exposed ports of a service beyond its first one
+	ReadinessProbeImage string
+
+	// ResourceQuotasEnabled determines if lease namespaces are capped by a ResourceQuota and LimitRange
+	// derived from the manifest group.
+	ResourceQuotasEnabled bool
+
+	// LeasedIPDualStack is set when the IP operator serves leased IPs dual-stack, with a second
+	// LoadBalancer service per endpoint accounted by the resource quota
+	LeasedIPDualStack bool
+
+	// PodSecurityLevel is enforced on lease namespaces by Pod Security Admission. Empty enforces nothing
+	PodSecurityLevel PodSecurityLevel
+
+	// SecurityProfile selects the security context of lease containers
+	SecurityProfile SecurityProfile
+
 	CPUCommitLevel     float64
 	MemoryCommitLevel  float64
 	StorageCommitLevel float64
@@ -42,6 +71,23 @@ type Settings struct {
 
 	// Name of the image pull secret to use in pod spec
 	DockerImagePullSecretsName string
+
+	// VolumeRetentionPeriod keeps the persistent volumes and teardown snapshots of closed leases for the
+	// given period, so the same owner may claim them again. Zero deletes them along with the lease namespace
+	VolumeRetentionPeriod time.Duration
+
+	// VolumeSnapshotClass enables snapshots of persistent volumes before manifest updates and lease teardown
+	// using the given VolumeSnapshotClass. Empty disables them
+	VolumeSnapshotClass string
+
+	// VolumeSnapshotsKept is the number of snapshots kept per volume, older ones are deleted. Zero keeps all of them
+	VolumeSnapshotsKept uint
+
+	// BandwidthPolicy limits the bandwidth of lease pods. nil leaves it unlimited
+	BandwidthPolicy *bandwidth.Policy
+
+	// ImagePolicy restricts the container images allowed to be deployed. nil allows everything
+	ImagePolicy *imagepolicy.Policy
 }
 
 var ErrSettingsValidation = errors.New("settings validation")
@@ -57,6 +103,23 @@ func ValidateSettings(settings Settings) error {
 		}
 	}
 
+	if settings.ReadinessProbesEnabled && settings.ReadinessProbeImage == "" {
+		return errors.Wrap(ErrSettingsValidation, "empty readiness probe image")
+	}
+
+	if !settings.PodSecurityLevel.valid() {
+		return fmt.Errorf("%w: invalid pod security level %q", ErrSettingsValidation, settings.PodSecurityLevel)
+	}
+
+	if !settings.SecurityProfile.valid() {
+		return fmt.Errorf("%w: invalid security profile %q", ErrSettingsValidation, settings.SecurityProfile)
+	}
+
+	// containers of the default profile keep their capabilities and seccomp unconfined
+	if settings.PodSecurityLevel == PodSecurityLevelRestricted && !settings.SecurityProfile.hardened() {
+		return fmt.Errorf("%w: pod security level %q requires a hardened security profile", ErrSettingsValidation, settings.PodSecurityLevel)
+	}
+
 	return nil
 }
 
@@ -66,6 +129,11 @@ func NewDefaultSettings() Settings {
 		DeploymentIngressStaticHosts:   false,
 		DeploymentIngressExposeLBHosts: false,
 		NetworkPoliciesEnabled:         false,
+		ReadinessProbesEnabled:         false,
+		ReadinessProbeImage:            DefaultReadinessProbeImage,
+		ResourceQuotasEnabled:          false,
+		PodSecurityLevel:               PodSecurityLevelNone,
+		SecurityProfile:                SecurityProfileDefault,
 	}
 }
 
@@ -8,11 +8,29 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	maniv2beta1 "github.com/akash-network/node/manifest/v2beta1"
 	"github.com/akash-network/node/sdl"
 	sdlutil "github.com/akash-network/node/sdl/util"
 	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+
+	"github.com/akash-network/provider/cluster/bandwidth"
+	clusterUtil "github.com/akash-network/provider/cluster/util"
+)
+
+const (
+	readinessProbeInitialDelaySeconds = 5
+	readinessProbePeriodSeconds       = 10
+	readinessProbeFailureThreshold    = 3
+
+	// DefaultReadinessProbeImage only has to keep running while its readiness probe checks a port of the service
+	DefaultReadinessProbeImage = "registry.k8s.io/pause:3.9"
+
+	readinessContainerPrefix    = "akash-readiness-"
+	readinessContainerCPU       = 10       // millicores
+	readinessContainerMemory    = 16 << 20 // bytes
+	readinessContainerEphemeral = 16 << 20 // bytes
 )
 
 type workloadBase interface {
@@ -42,8 +60,6 @@ func newWorkloadBuilder(log log.Logger, settings Settings, lid mtypes.LeaseID, g
 }
 
 func (b *workload) container() corev1.Container {
-	falseValue := false
-
 	kcontainer := corev1.Container{
 		Name:    b.service.Name,
 		Image:   b.service.Image,
@@ -54,11 +70,7 @@ func (b *workload) container() corev1.Container {
 			Requests: make(corev1.ResourceList),
 		},
 		ImagePullPolicy: corev1.PullIfNotPresent,
-		SecurityContext: &corev1.SecurityContext{
-			RunAsNonRoot:             &falseValue,
-			Privileged:               &falseValue,
-			AllowPrivilegeEscalation: &falseValue,
-		},
+		SecurityContext: b.containerSecurityContext(),
 	}
 
 	if cpu := b.service.Resources.CPU; cpu != nil {
@@ -95,6 +107,13 @@ func (b *workload) container() corev1.Container {
 		}
 	}
 
+	if b.tmpVolumeMounted() {
+		kcontainer.VolumeMounts = append(kcontainer.VolumeMounts, corev1.VolumeMount{
+			Name:      tmpVolumeName,
+			MountPath: tmpMountPath,
+		})
+	}
+
 	envVarsAdded := make(map[string]int)
 	for _, env := range b.service.Env {
 		parts := strings.SplitN(env, "=", 2)
@@ -114,9 +133,94 @@ func (b *workload) container() corev1.Container {
 		})
 	}
 
+	kcontainer.ReadinessProbe = b.readinessProbe()
+
 	return kcontainer
 }
 
+// containers returns the container of the service followed by a readiness container for every exposed
+// TCP port after the first one. Kubernetes allows one readiness probe per container, containers of a pod
+// share its network so each readiness container probes a port of the service container, and the pod is
+// only ready once every exposed port accepts connections.
+func (b *workload) containers() []corev1.Container {
+	containers := []corev1.Container{b.container()}
+
+	ports := readinessPorts(b.settings, b.service)
+	for i := 1; i < len(ports); i++ {
+		containers = append(containers, b.readinessContainer(ports[i]))
+	}
+
+	return containers
+}
+
+// readinessProbe probes the first exposed TCP port of the service, readinessContainer probes the other ones
+func (b *workload) readinessProbe() *corev1.Probe {
+	ports := readinessPorts(b.settings, b.service)
+	if len(ports) == 0 {
+		return nil
+	}
+
+	return tcpReadinessProbe(ports[0])
+}
+
+func (b *workload) readinessContainer(port uint16) corev1.Container {
+	resources := corev1.ResourceList{
+		corev1.ResourceCPU:              *resource.NewScaledQuantity(readinessContainerCPU, resource.Milli),
+		corev1.ResourceMemory:           *resource.NewQuantity(readinessContainerMemory, resource.BinarySI),
+		corev1.ResourceEphemeralStorage: *resource.NewQuantity(readinessContainerEphemeral, resource.BinarySI),
+	}
+
+	return corev1.Container{
+		Name:  fmt.Sprintf("%s%d", readinessContainerPrefix, port),
+		Image: b.settings.ReadinessProbeImage,
+		Resources: corev1.ResourceRequirements{
+			Limits:   resources,
+			Requests: resources.DeepCopy(),
+		},
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		SecurityContext: b.containerSecurityContext(),
+		ReadinessProbe:  tcpReadinessProbe(port),
+	}
+}
+
+func tcpReadinessProbe(port uint16) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.FromInt(int(port)),
+			},
+		},
+		InitialDelaySeconds: readinessProbeInitialDelaySeconds,
+		PeriodSeconds:       readinessProbePeriodSeconds,
+		FailureThreshold:    readinessProbeFailureThreshold,
+	}
+}
+
+// readinessPorts lists the distinct TCP ports exposed by the service, UDP ports cannot be probed for connections
+func readinessPorts(settings Settings, svc *maniv2beta1.Service) []uint16 {
+	if !settings.ReadinessProbesEnabled {
+		return nil
+	}
+
+	var ports []uint16
+	seen := make(map[uint16]struct{})
+
+	for _, expose := range svc.Expose {
+		if expose.Proto != maniv2beta1.TCP {
+			continue
+		}
+
+		if _, exists := seen[expose.Port]; exists {
+			continue
+		}
+
+		seen[expose.Port] = struct{}{}
+		ports = append(ports, expose.Port)
+	}
+
+	return ports
+}
+
 func (b *workload) persistentVolumeClaims() []corev1.PersistentVolumeClaim {
 	var pvcs []corev1.PersistentVolumeClaim // nolint:prealloc
 
@@ -156,6 +260,40 @@ func (b *workload) persistentVolumeClaims() []corev1.PersistentVolumeClaim {
 	return pvcs
 }
 
+// podAnnotations sets the bandwidth limits of the service on the given annotations, keeping the ones
+// set by others such as kubectl rollout restart
+func (b *workload) podAnnotations(annotations map[string]string) map[string]string {
+	limits := b.settings.BandwidthPolicy.LimitsFor(b.service.Resources).Annotations()
+
+	if annotations == nil {
+		if len(limits) == 0 {
+			return nil
+		}
+		annotations = make(map[string]string, len(limits))
+	}
+
+	delete(annotations, bandwidth.AnnotationIngress)
+	delete(annotations, bandwidth.AnnotationEgress)
+
+	for key, value := range limits {
+		annotations[key] = value
+	}
+
+	return annotations
+}
+
+// nodeSelector pins pods to nodes of the CPU architecture requested by the service, if any
+func (b *workload) nodeSelector() map[string]string {
+	arch, set := clusterUtil.GetCPUArch(b.service.Resources)
+	if !set {
+		return nil
+	}
+
+	return map[string]string{
+		corev1.LabelArchStable: arch,
+	}
+}
+
 func (b *workload) Name() string {
 	return b.service.Name
 }
@@ -3,7 +3,10 @@ package kube
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
@@ -15,6 +18,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
 	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
@@ -28,11 +32,13 @@ import (
 
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/retry"
 
 	"github.com/akash-network/provider/cluster"
 	"github.com/akash-network/provider/cluster/kube/builder"
 	"github.com/akash-network/provider/cluster/kube/clientcommon"
 	kubeclienterrors "github.com/akash-network/provider/cluster/kube/errors"
+	"github.com/akash-network/provider/cluster/kube/ipbackend"
 	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
 	crd "github.com/akash-network/provider/pkg/apis/akash.network/v2beta1"
 	akashclient "github.com/akash-network/provider/pkg/client/clientset/versioned"
@@ -54,10 +60,14 @@ var _ Client = (*client)(nil)
 type client struct {
 	kc                kubernetes.Interface
 	ac                akashclient.Interface
+	dc                dynamic.Interface
 	metc              metricsclient.Interface
 	ns                string
 	log               log.Logger
 	kubeContentConfig *restclient.Config
+	ingress           ingressBackend
+	// restores holds the claims being restored from a volume snapshot
+	restores sync.Map
 }
 
 func (c *client) String() string {
@@ -66,7 +76,7 @@ func (c *client) String() string {
 
 // NewClient returns new Kubernetes Client instance with provided logger, host and ns. Returns error in-case of failure
 // configPath may be the empty string
-func NewClient(ctx context.Context, log log.Logger, ns string, configPath string) (Client, error) {
+func NewClient(ctx context.Context, log log.Logger, ns string, configPath string, opts ...ClientOption) (Client, error) {
 	config, err := clientcommon.OpenKubeConfig(configPath, log)
 	if err != nil {
 		return nil, errors.Wrap(err, "kube: error building config flags")
@@ -93,14 +103,29 @@ func NewClient(ctx context.Context, log log.Logger, ns string, configPath string
 		return nil, errors.Wrap(err, "kube: error creating metrics client")
 	}
 
-	return &client{
+	dc, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "kube: error creating dynamic client")
+	}
+
+	c := &client{
 		kc:                kc,
 		ac:                mc,
+		dc:                dc,
 		metc:              metc,
 		ns:                ns,
 		log:               log.With("client", "kube"),
 		kubeContentConfig: config,
-	}, nil
+	}
+
+	opts = append([]ClientOption{WithIngressConfig(DefaultIngressConfig())}, opts...)
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
 }
 
 func (c *client) GetDeployments(ctx context.Context, dID dtypes.DeploymentID) ([]ctypes.Deployment, error) {
@@ -152,6 +177,95 @@ func (c *client) GetManifestGroup(ctx context.Context, lID mtypes.LeaseID) (bool
 	return true, obj.Spec.Group, nil
 }
 
+func (c *client) GetManifestStatus(ctx context.Context, lID mtypes.LeaseID) (bool, crd.ManifestStatus, error) {
+	leaseNamespace := builder.LidNS(lID)
+
+	obj, err := c.ac.AkashV2beta1().Manifests(c.ns).Get(ctx, leaseNamespace, metav1.GetOptions{})
+	if err != nil {
+		if kubeErrors.IsNotFound(err) {
+			c.log.Info("CRD manifest not found", "lease-ns", leaseNamespace)
+			return false, crd.ManifestStatus{}, nil
+		}
+
+		return false, crd.ManifestStatus{}, err
+	}
+
+	return true, obj.Status, nil
+}
+
+func (c *client) UpdateManifestStatus(ctx context.Context, lID mtypes.LeaseID, status crd.ManifestStatus) error {
+	leaseNamespace := builder.LidNS(lID)
+
+	obj, err := c.ac.AkashV2beta1().Manifests(c.ns).Get(ctx, leaseNamespace, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "akash-manifests-get", err)
+	if err != nil {
+		return err
+	}
+
+	obj.Status = status
+	_, err = c.ac.AkashV2beta1().Manifests(c.ns).Update(ctx, obj, metav1.UpdateOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "akash-manifests-update", err)
+
+	return err
+}
+
+func (c *client) LastGoodGroup(ctx context.Context, lID mtypes.LeaseID) (*manifest.Group, error) {
+	obj, err := c.ac.AkashV2beta1().Manifests(c.ns).Get(ctx, builder.LidNS(lID), metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "akash-manifests-get", err, kubeErrors.IsNotFound)
+	if err != nil {
+		if kubeErrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if obj.Spec.LastGoodGroup == nil {
+		return nil, nil
+	}
+
+	group, err := obj.Spec.LastGoodGroup.ToAkash()
+	if err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func (c *client) SaveLastGoodGroup(ctx context.Context, lID mtypes.LeaseID, group *manifest.Group) error {
+	lastGood, err := crd.ManifestGroupFromAkash(group)
+	if err != nil {
+		return err
+	}
+
+	// deploys update the spec concurrently
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := c.ac.AkashV2beta1().Manifests(c.ns).Get(ctx, builder.LidNS(lID), metav1.GetOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "akash-manifests-get", err)
+		if err != nil {
+			return err
+		}
+
+		obj.Spec.LastGoodGroup = &lastGood
+		_, err = c.ac.AkashV2beta1().Manifests(c.ns).Update(ctx, obj, metav1.UpdateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "akash-manifests-update", err)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// the rollout of the group completed, pods of the group it replaced are gone
+	if settings, valid := ctx.Value(builder.SettingsKey).(builder.Settings); valid && settings.ResourceQuotasEnabled {
+		if err := applyResourceQuota(ctx, c.kc, builder.BuildResourceQuota(c.log, settings, lID, group, nil)); err != nil {
+			c.log.Error("tightening namespace resource quota", "err", err, "lease", lID)
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (c *client) Deployments(ctx context.Context) ([]ctypes.Deployment, error) {
 	manifests, err := c.ac.AkashV2beta1().Manifests(c.ns).List(ctx, metav1.ListOptions{})
 	if err != nil {
@@ -170,6 +284,28 @@ func (c *client) Deployments(ctx context.Context) ([]ctypes.Deployment, error) {
 	return deployments, nil
 }
 
+func (c *client) LeaseNamespaces(ctx context.Context) ([]mtypes.LeaseID, error) {
+	namespaces, err := c.kc.CoreV1().Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: builder.AkashManagedLabelName + "=true",
+	})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "namespaces-list", err)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]mtypes.LeaseID, 0, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		lid, err := clientcommon.RecoverLeaseIDFromLabels(ns.Labels)
+		if err != nil {
+			c.log.Error("namespace without lease labels", "ns", ns.Name, "err", err)
+			continue
+		}
+		result = append(result, lid)
+	}
+
+	return result, nil
+}
+
 func (c *client) Deploy(ctx context.Context, lid mtypes.LeaseID, group *manifest.Group) error {
 	settingsI := ctx.Value(builder.SettingsKey)
 	if nil == settingsI {
@@ -180,24 +316,70 @@ func (c *client) Deploy(ctx context.Context, lid mtypes.LeaseID, group *manifest
 		return err
 	}
 
+	if err := settings.ImagePolicy.CheckGroup(group); err != nil {
+		c.log.Error("image policy", "err", err, "lease", lid)
+		return err
+	}
+
 	if err := applyNS(ctx, c.kc, builder.BuildNS(settings, lid, group)); err != nil {
 		c.log.Error("applying namespace", "err", err, "lease", lid)
 		return err
 	}
 
-	if err := applyNetPolicies(ctx, c.kc, builder.BuildNetPol(settings, lid, group)); err != nil { //
-		c.log.Error("applying namespace network policies", "err", err, "lease", lid)
+	tx := newDeployTransaction(c.log.With("lease", lid), c.kc, c.ac)
+	if err := tx.trackManifest(ctx, c.ns, builder.LidNS(lid)); err != nil {
+		c.log.Error("looking up manifest", "err", err, "lease", lid)
 		return err
 	}
 
+	if settings.ResourceQuotasEnabled {
+		// the quota is tightened to the group once the monitor found it healthy, see SaveLastGoodGroup
+		rqBuilder := builder.BuildResourceQuota(c.log, settings, lid, group, tx.replacedGroup(group))
+		if err := tx.trackResourceQuota(ctx, rqBuilder.NS(), rqBuilder.Name()); err != nil {
+			return c.failDeploy(tx, lid, err)
+		}
+		if err := applyResourceQuota(ctx, c.kc, rqBuilder); err != nil {
+			c.log.Error("applying namespace resource quota", "err", err, "lease", lid)
+			return c.failDeploy(tx, lid, err)
+		}
+
+		lrBuilder := builder.BuildLimitRange(c.log, settings, lid, group)
+		if err := tx.trackLimitRange(ctx, lrBuilder.NS(), lrBuilder.Name()); err != nil {
+			return c.failDeploy(tx, lid, err)
+		}
+		if err := applyLimitRange(ctx, c.kc, lrBuilder); err != nil {
+			c.log.Error("applying namespace limit range", "err", err, "lease", lid)
+			return c.failDeploy(tx, lid, err)
+		}
+	}
+
+	netPolBuilder := builder.BuildNetPol(settings, lid, group)
+	policies, err := netPolBuilder.Create()
+	if err != nil {
+		c.log.Error("building namespace network policies", "err", err, "lease", lid)
+		return c.failDeploy(tx, lid, err)
+	}
+	for _, pol := range policies {
+		if err := tx.trackNetworkPolicy(ctx, netPolBuilder.NS(), pol.Name); err != nil {
+			return c.failDeploy(tx, lid, err)
+		}
+	}
+	if err := applyNetPolicies(ctx, c.kc, netPolBuilder); err != nil { //
+		c.log.Error("applying namespace network policies", "err", err, "lease", lid)
+		return c.failDeploy(tx, lid, err)
+	}
+
 	if err := applyManifest(ctx, c.ac, builder.BuildManifest(c.log, settings, c.ns, lid, group)); err != nil {
 		c.log.Error("applying manifest", "err", err, "lease", lid)
-		return err
+		return c.failDeploy(tx, lid, err)
 	}
 
-	if err := cleanupStaleResources(ctx, c.kc, lid, group); err != nil {
-		c.log.Error("cleaning stale resources", "err", err, "lease", lid)
-		return err
+	if settings.VolumeSnapshotClass != "" {
+		// claims exist only when the lease is updated
+		if _, err := snapshotLeaseVolumes(ctx, c.kc, c.dc, lid, settings.VolumeSnapshotClass, ctypes.VolumeSnapshotTriggerUpdate, settings.VolumeSnapshotsKept); err != nil {
+			// the update goes on without a snapshot rather than failing with a broken snapshot class
+			c.log.Error("snapshotting volumes", "err", err, "lease", lid)
+		}
 	}
 
 	for svcIdx := range group.Services {
@@ -212,14 +394,29 @@ func (c *client) Deploy(ctx context.Context, lid mtypes.LeaseID, group *manifest
 		}
 
 		if persistent {
-			if err := applyStatefulSet(ctx, c.kc, builder.BuildStatefulSet(c.log, settings, lid, group, service)); err != nil {
+			statefulSetBuilder := builder.BuildStatefulSet(c.log, settings, lid, group, service)
+			if settings.VolumeRetentionPeriod > 0 {
+				if err := restoreRetainedVolumes(ctx, c.kc, lid, statefulSetBuilder); err != nil {
+					c.log.Error("restoring retained volumes", "err", err, "lease", lid, "service", service.Name)
+					return c.failDeploy(tx, lid, err)
+				}
+			}
+
+			if err := tx.trackStatefulSet(ctx, statefulSetBuilder.NS(), statefulSetBuilder.Name()); err != nil {
+				return c.failDeploy(tx, lid, err)
+			}
+			if err := applyStatefulSet(ctx, c.kc, statefulSetBuilder); err != nil {
 				c.log.Error("applying statefulSet", "err", err, "lease", lid, "service", service.Name)
-				return err
+				return c.failDeploy(tx, lid, err)
 			}
 		} else {
-			if err := applyDeployment(ctx, c.kc, builder.NewDeployment(c.log, settings, lid, group, service)); err != nil {
+			deploymentBuilder := builder.NewDeployment(c.log, settings, lid, group, service)
+			if err := tx.trackDeployment(ctx, deploymentBuilder.NS(), deploymentBuilder.Name()); err != nil {
+				return c.failDeploy(tx, lid, err)
+			}
+			if err := applyDeployment(ctx, c.kc, deploymentBuilder); err != nil {
 				c.log.Error("applying deployment", "err", err, "lease", lid, "service", service.Name)
-				return err
+				return c.failDeploy(tx, lid, err)
 			}
 		}
 
@@ -230,25 +427,93 @@ func (c *client) Deploy(ctx context.Context, lid mtypes.LeaseID, group *manifest
 
 		serviceBuilderLocal := builder.BuildService(c.log, settings, lid, group, service, false)
 		if serviceBuilderLocal.Any() {
+			if err := tx.trackService(ctx, serviceBuilderLocal.NS(), serviceBuilderLocal.Name()); err != nil {
+				return c.failDeploy(tx, lid, err)
+			}
 			if err := applyService(ctx, c.kc, serviceBuilderLocal); err != nil {
 				c.log.Error("applying local service", "err", err, "lease", lid, "service", service.Name)
-				return err
+				return c.failDeploy(tx, lid, err)
 			}
 		}
 
 		serviceBuilderGlobal := builder.BuildService(c.log, settings, lid, group, service, true)
 		if serviceBuilderGlobal.Any() {
+			if err := tx.trackService(ctx, serviceBuilderGlobal.NS(), serviceBuilderGlobal.Name()); err != nil {
+				return c.failDeploy(tx, lid, err)
+			}
 			if err := applyService(ctx, c.kc, serviceBuilderGlobal); err != nil {
 				c.log.Error("applying global service", "err", err, "lease", lid, "service", service.Name)
-				return err
+				return c.failDeploy(tx, lid, err)
 			}
 		}
 	}
 
+	// stale objects are deleted once everything else is applied, a deploy failing before
+	// this point leaves the services of the previous group in place for the revert
+	if err := cleanupStaleResources(ctx, c.kc, lid, group); err != nil {
+		c.log.Error("cleaning stale resources", "err", err, "lease", lid)
+		return err
+	}
+
+	// a previous failed or rolled back deploy is superseded by this one
+	if tx.previousStatus != (crd.ManifestStatus{}) {
+		if err := c.UpdateManifestStatus(ctx, lid, crd.ManifestStatus{}); err != nil {
+			c.log.Error("clearing manifest status", "err", err, "lease", lid)
+		}
+	}
+
 	return nil
 }
 
+// failDeploy reverts the objects applied by a deploy which failed with cause, if the lease
+// had been deployed before, and records the outcome on the Manifest CRD. cause is always returned.
+// The revert does not use the context of the deploy, which may be the reason it failed.
+func (c *client) failDeploy(tx *deployTransaction, lid mtypes.LeaseID, cause error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), deployRevertTimeout)
+	defer cancel()
+
+	status := crd.ManifestStatus{
+		State:   crd.ManifestStateFailed,
+		Message: fmt.Sprintf("deploy failed: %s", cause),
+	}
+
+	if tx.canRevert() {
+		if err := tx.revert(ctx); err != nil {
+			c.log.Error("reverting failed deploy", "err", err, "lease", lid)
+			status.Message = fmt.Sprintf("%s; %s", status.Message, err)
+		} else {
+			status.State = crd.ManifestStateRolledBack
+		}
+	}
+
+	err := c.UpdateManifestStatus(ctx, lid, status)
+	if err != nil && !kubeErrors.IsNotFound(err) {
+		c.log.Error("updating manifest status", "err", err, "lease", lid)
+	}
+
+	return cause
+}
+
 func (c *client) TeardownLease(ctx context.Context, lid mtypes.LeaseID) error {
+	if settings, valid := ctx.Value(builder.SettingsKey).(builder.Settings); valid && settings.VolumeSnapshotClass != "" {
+		// the lease is torn down even when its volumes could not be snapshot
+		names, err := snapshotLeaseVolumes(ctx, c.kc, c.dc, lid, settings.VolumeSnapshotClass, ctypes.VolumeSnapshotTriggerTeardown, settings.VolumeSnapshotsKept)
+		// without a retention period the snapshots follow the deletion policy of their class
+		if err == nil && settings.VolumeRetentionPeriod > 0 {
+			err = retainVolumeSnapshots(ctx, c.dc, lid, names, time.Now().Add(settings.VolumeRetentionPeriod))
+		}
+		if err != nil {
+			c.log.Error("teardown lease: unable to snapshot volumes", "lease", lid, "error", err)
+		}
+	}
+
+	if settings, valid := ctx.Value(builder.SettingsKey).(builder.Settings); valid && settings.VolumeRetentionPeriod > 0 {
+		if err := retainLeaseVolumes(ctx, c.kc, lid, time.Now().Add(settings.VolumeRetentionPeriod)); err != nil {
+			c.log.Error("teardown lease: unable to retain volumes", "lease", lid, "error", err)
+			return err
+		}
+	}
+
 	result := c.kc.CoreV1().Namespaces().Delete(ctx, builder.LidNS(lid), metav1.DeleteOptions{})
 
 	label := metricsutils.SuccessLabel
@@ -265,6 +530,108 @@ func (c *client) TeardownLease(ctx context.Context, lid mtypes.LeaseID) error {
 	return result
 }
 
+func (c *client) RestartLeasePods(ctx context.Context, lid mtypes.LeaseID) error {
+	pods, err := c.kc.CoreV1().Pods(builder.LidNS(lid)).List(ctx, metav1.ListOptions{
+		LabelSelector: builder.AkashManagedLabelName + "=true",
+	})
+	label := metricsutils.SuccessLabel
+	if err != nil {
+		label = metricsutils.FailLabel
+	}
+	kubeCallsCounter.WithLabelValues("pods-list", label).Inc()
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods.Items {
+		if isPodReady(pod) {
+			continue
+		}
+
+		err = c.kc.CoreV1().Pods(builder.LidNS(lid)).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		label = metricsutils.SuccessLabel
+		if err != nil && !kubeErrors.IsNotFound(err) {
+			label = metricsutils.FailLabel
+		}
+		kubeCallsCounter.WithLabelValues("pods-delete", label).Inc()
+		if err != nil && !kubeErrors.IsNotFound(err) {
+			c.log.Error("restart lease pods: unable to delete pod", "ns", builder.LidNS(lid), "pod", pod.Name, "err", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *client) LeaseVolumeSnapshots(ctx context.Context, lid mtypes.LeaseID) ([]ctypes.VolumeSnapshot, error) {
+	snapshots, err := listVolumeSnapshots(ctx, c.dc, builder.LidNS(lid))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ctypes.VolumeSnapshot, 0, len(snapshots))
+	for idx := range snapshots {
+		result = append(result, volumeSnapshotStatus(&snapshots[idx]))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+// RestoreVolumeSnapshot starts restoring the snapshot in the background once it was checked,
+// the progress is reported in the restore state of the snapshot
+func (c *client) RestoreVolumeSnapshot(ctx context.Context, lid mtypes.LeaseID, name string) error {
+	restore, err := prepareVolumeSnapshotRestore(ctx, c.kc, c.dc, lid, name)
+	if err != nil {
+		return err
+	}
+
+	ns := restore.claim.Namespace
+	key := ns + "/" + restore.claim.Name
+	if _, running := c.restores.LoadOrStore(key, name); running {
+		return fmt.Errorf("%w: %q", kubeclienterrors.ErrVolumeRestoreInProgress, restore.claim.Name)
+	}
+
+	if err := setVolumeSnapshotRestoreState(ctx, c.dc, ns, name, ctypes.VolumeSnapshotRestoring, ""); err != nil {
+		c.restores.Delete(key)
+		return err
+	}
+
+	go func() {
+		defer c.restores.Delete(key)
+
+		ctx, cancel := context.WithTimeout(context.Background(), volumeSnapshotRestoreDeadline)
+		defer cancel()
+
+		state := ctypes.VolumeSnapshotRestored
+		message := ""
+		if err := restoreVolumeSnapshot(ctx, c.kc, restore); err != nil {
+			c.log.Error("restoring volume snapshot", "lease", lid, "snapshot", name, "err", err)
+			state = ctypes.VolumeSnapshotRestoreFailed
+			message = err.Error()
+		}
+
+		if err := setVolumeSnapshotRestoreState(ctx, c.dc, ns, name, state, message); err != nil {
+			c.log.Error("recording volume snapshot restore state", "lease", lid, "snapshot", name, "err", err)
+		}
+	}()
+
+	return nil
+}
+
+func isPodReady(pod corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
 func kubeSelectorForLease(dst *strings.Builder, lID mtypes.LeaseID) {
 	_, _ = fmt.Fprintf(dst, "%s=%s", builder.AkashLeaseOwnerLabelName, lID.Owner)
 	_, _ = fmt.Fprintf(dst, ",%s=%d", builder.AkashLeaseDSeqLabelName, lID.DSeq)
@@ -385,6 +752,8 @@ func (c *client) LeaseLogs(ctx context.Context, lid mtypes.LeaseID,
 	streams := make([]*ctypes.ServiceLog, len(pods.Items))
 	for i, pod := range pods.Items {
 		stream, err := c.kc.CoreV1().Pods(builder.LidNS(lid)).GetLogs(pod.Name, &corev1.PodLogOptions{
+			// pods probing several ports run readiness containers next to the one of the service
+			Container:  pod.Labels[builder.AkashManifestServiceLabelName],
 			Follow:     follow,
 			TailLines:  tailLines,
 			Timestamps: false,
@@ -427,49 +796,169 @@ func (c *client) ForwardedPortStatus(ctx context.Context, leaseID mtypes.LeaseID
 
 	forwardedPorts := make(map[string][]ctypes.ForwardedPortStatus)
 
-	// Search for a Kubernetes service declared as nodeport
-	for _, service := range services.Items {
-		if service.Spec.Type == corev1.ServiceTypeNodePort {
-			serviceName := service.Name // Always suffixed during creation, so chop it off
-			deploymentName := serviceName[0 : len(serviceName)-len(builder.SuffixForNodePortServiceName)]
-
-			if 0 != len(service.Spec.Ports) {
-				portsForDeployment := make([]ctypes.ForwardedPortStatus, 0, len(service.Spec.Ports))
-				for _, port := range service.Spec.Ports {
-					// Check if the service is exposed via NodePort mechanism in the cluster
-					// This is a random port chosen by the cluster when the deployment is created
-					nodePort := port.NodePort
-					if nodePort > 0 {
-						// Record the actual port inside the container that is exposed
-						v := ctypes.ForwardedPortStatus{
-							Host:         settings.ClusterPublicHostname,
-							Port:         uint16(port.TargetPort.IntVal),
-							ExternalPort: uint16(nodePort),
-							Name:         deploymentName,
-						}
-
-						isValid := true
-						switch port.Protocol {
-						case corev1.ProtocolTCP:
-							v.Proto = manifest.TCP
-						case corev1.ProtocolUDP:
-							v.Proto = manifest.UDP
-						default:
-							isValid = false // Skip this, since the Protocol is set to something not supported by Akash
-						}
-						if isValid {
-							portsForDeployment = append(portsForDeployment, v)
-						}
-					}
+	// The IPv6 address of a dual-stack leased IP is assigned to a companion service
+	ipv6 := make(map[string]string)
+	for i := range services.Items {
+		service := &services.Items[i]
+		if ipbackend.IsIPv6Companion(service) {
+			ipv6[strings.TrimSuffix(service.Name, ipbackend.IPv6CompanionSuffix)] = loadBalancerIP(service)
+		}
+	}
+
+	// Search for a Kubernetes service declared as nodeport, or as load balancer of a leased IP
+	for i := range services.Items {
+		service := &services.Items[i]
+		if isLeasedIPService(service) && !ipbackend.IsIPv6Companion(service) {
+			name := service.Spec.Selector[builder.AkashManifestServiceLabelName]
+			for _, port := range service.Spec.Ports {
+				proto, err := manifest.ServiceProtocolFromKube(port.Protocol)
+				if err != nil {
+					continue
 				}
-				forwardedPorts[deploymentName] = portsForDeployment
+
+				forwardedPorts[name] = append(forwardedPorts[name], ctypes.ForwardedPortStatus{
+					Host:         loadBalancerIP(service),
+					IPv6:         ipv6[service.Name],
+					Port:         uint16(port.TargetPort.IntValue()),
+					ExternalPort: uint16(port.Port),
+					Proto:        proto,
+					Name:         name,
+				})
 			}
+			continue
 		}
+
+		if service.Spec.Type != corev1.ServiceTypeNodePort || 0 == len(service.Spec.Ports) {
+			continue
+		}
+
+		allocations := nodePortAllocations(leaseID, service)
+		portsForDeployment := make([]ctypes.ForwardedPortStatus, 0, len(allocations))
+		for _, allocation := range allocations {
+			// Record the actual port inside the container that is exposed
+			portsForDeployment = append(portsForDeployment, ctypes.ForwardedPortStatus{
+				Host:         settings.ClusterPublicHostname,
+				Port:         allocation.Port,
+				ExternalPort: allocation.NodePort,
+				Proto:        allocation.Proto,
+				Name:         allocation.Service,
+			})
+		}
+		name := nodePortServiceName(service)
+		forwardedPorts[name] = append(forwardedPorts[name], portsForDeployment...)
 	}
 
 	return forwardedPorts, nil
 }
 
+// isLeasedIPService is true for the load balancer services the IP operator creates for leased IPs
+func isLeasedIPService(service *corev1.Service) bool {
+	return service.Spec.Type == corev1.ServiceTypeLoadBalancer &&
+		service.Labels[builder.AkashServiceTarget] == builder.AkashMetalLB
+}
+
+// loadBalancerIP is the address assigned to a load balancer service, empty until it is assigned
+func loadBalancerIP(service *corev1.Service) string {
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if len(ingress.IP) != 0 {
+			return ingress.IP
+		}
+	}
+
+	return ""
+}
+
+func (c *client) NodePorts(ctx context.Context) ([]ctypes.NodePortAllocation, error) {
+	leases, err := c.LeaseNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	leaseByNS := make(map[string]mtypes.LeaseID, len(leases))
+	for _, lid := range leases {
+		leaseByNS[builder.LidNS(lid)] = lid
+	}
+
+	services, err := c.kc.CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		LabelSelector: builder.AkashManagedLabelName + "=true",
+	})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "services-list", err)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ctypes.NodePortAllocation, 0)
+	for i := range services.Items {
+		service := &services.Items[i]
+		if service.Spec.Type != corev1.ServiceTypeNodePort {
+			continue
+		}
+
+		lid, ok := leaseByNS[service.Namespace]
+		if !ok {
+			c.log.Debug("node port service outside of lease namespaces", "ns", service.Namespace, "service", service.Name)
+			continue
+		}
+
+		result = append(result, nodePortAllocations(lid, service)...)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].NodePort != result[j].NodePort {
+			return result[i].NodePort < result[j].NodePort
+		}
+		return result[i].Proto < result[j].Proto
+	})
+
+	return result, nil
+}
+
+// nodePortServiceName is the name of the manifest service a node port service exposes
+func nodePortServiceName(service *corev1.Service) string {
+	// Always suffixed during creation, so chop it off
+	return strings.TrimSuffix(service.Name, builder.SuffixForNodePortServiceName)
+}
+
+// nodePortAllocations returns the node ports provisioned for service, ordered by container port so
+// the lease status reports them in a stable order
+func nodePortAllocations(lid mtypes.LeaseID, service *corev1.Service) []ctypes.NodePortAllocation {
+	result := make([]ctypes.NodePortAllocation, 0, len(service.Spec.Ports))
+	for _, port := range service.Spec.Ports {
+		// Check if the service is exposed via NodePort mechanism in the cluster
+		// This is a random port chosen by the cluster when the deployment is created
+		if port.NodePort <= 0 {
+			continue
+		}
+
+		var proto manifest.ServiceProtocol
+		switch port.Protocol {
+		case corev1.ProtocolTCP:
+			proto = manifest.TCP
+		case corev1.ProtocolUDP:
+			proto = manifest.UDP
+		default:
+			continue // Skip this, since the Protocol is set to something not supported by Akash
+		}
+
+		result = append(result, ctypes.NodePortAllocation{
+			LeaseID:  lid,
+			Service:  nodePortServiceName(service),
+			Port:     uint16(port.TargetPort.IntVal),
+			NodePort: uint16(port.NodePort),
+			Proto:    proto,
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Port != result[j].Port {
+			return result[i].Port < result[j].Port
+		}
+		return result[i].Proto < result[j].Proto
+	})
+
+	return result
+}
+
 // todo: limit number of results and do pagination / streaming
 func (c *client) LeaseStatus(ctx context.Context, lid mtypes.LeaseID) (map[string]*ctypes.ServiceStatus, error) {
 	settingsI := ctx.Value(builder.SettingsKey)
@@ -485,6 +974,13 @@ func (c *client) LeaseStatus(ctx context.Context, lid mtypes.LeaseID) (map[strin
 	if err != nil {
 		return nil, err
 	}
+
+	for svcName, svcFailures := range c.podFailuresForLease(ctx, lid) {
+		if entry, ok := serviceStatus[svcName]; ok {
+			entry.Failures = svcFailures
+		}
+	}
+
 	labelSelector := &strings.Builder{}
 	kubeSelectorForLease(labelSelector, lid)
 	// Note: this is a separate call to the Kubernetes API to get this data. It could
@@ -503,6 +999,10 @@ func (c *client) LeaseStatus(ctx context.Context, lid mtypes.LeaseID) (map[strin
 		entry, ok := serviceStatus[ph.Spec.ServiceName]
 		if ok {
 			entry.URIs = append(entry.URIs, ph.Spec.Hostname)
+			entry.Hostnames = append(entry.Hostnames, hostnameState(ph))
+			if cert, valid := hostnameCertificate(ph); valid {
+				entry.Certificates = append(entry.Certificates, cert)
+			}
 		}
 	}
 
@@ -587,13 +1087,13 @@ func (c *client) ServiceStatus(ctx context.Context, lid mtypes.LeaseID, name str
 
 		result = &ctypes.ServiceStatus{
 			Name:               statefulset.Name,
-			Available:          statefulset.Status.CurrentReplicas,
+			Available:          statefulset.Status.ReadyReplicas,
 			Total:              statefulset.Status.Replicas,
 			ObservedGeneration: statefulset.Status.ObservedGeneration,
 			Replicas:           statefulset.Status.Replicas,
 			UpdatedReplicas:    statefulset.Status.UpdatedReplicas,
 			ReadyReplicas:      statefulset.Status.ReadyReplicas,
-			AvailableReplicas:  statefulset.Status.CurrentReplicas,
+			AvailableReplicas:  statefulset.Status.ReadyReplicas,
 		}
 	}
 
@@ -630,6 +1130,8 @@ exposeCheckLoop:
 		return nil, fmt.Errorf("%w: service %q", kubeclienterrors.ErrNoServiceForLease, name)
 	}
 
+	result.Failures = c.podFailuresForLease(ctx, lid)[name]
+
 	c.log.Debug("service result", "lease-ns", builder.LidNS(lid), "has-hostnames", hasHostnames)
 
 	if hasHostnames {
@@ -654,6 +1156,10 @@ exposeCheckLoop:
 			hosts := make([]string, 0, len(phs.Items))
 			for _, ph := range phs.Items {
 				hosts = append(hosts, ph.Spec.Hostname)
+				result.Hostnames = append(result.Hostnames, hostnameState(ph))
+				if cert, valid := hostnameCertificate(ph); valid {
+					result.Certificates = append(result.Certificates, cert)
+				}
 			}
 
 			result.URIs = hosts
@@ -730,13 +1236,13 @@ func (c *client) deploymentsForLease(ctx context.Context, lid mtypes.LeaseID) (m
 		for _, statefulset := range statefulsets.Items {
 			serviceStatus[statefulset.Name] = &ctypes.ServiceStatus{
 				Name:               statefulset.Name,
-				Available:          statefulset.Status.CurrentReplicas,
+				Available:          statefulset.Status.ReadyReplicas,
 				Total:              statefulset.Status.Replicas,
 				ObservedGeneration: statefulset.Status.ObservedGeneration,
 				Replicas:           statefulset.Status.Replicas,
 				UpdatedReplicas:    statefulset.Status.UpdatedReplicas,
 				ReadyReplicas:      statefulset.Status.ReadyReplicas,
-				AvailableReplicas:  statefulset.Status.CurrentReplicas,
+				AvailableReplicas:  statefulset.Status.ReadyReplicas,
 			}
 		}
 	}
@@ -45,6 +45,7 @@ func (c *client) DeclareHostname(ctx context.Context, lID mtypes.LeaseID, host s
 	foundEntry, err := c.ac.AkashV2beta1().ProviderHosts(c.ns).Get(ctx, host, metav1.GetOptions{})
 	exists := true
 	var resourceVersion string
+	var status crd.ProviderHostStatus
 
 	if err != nil {
 		if kubeErrors.IsNotFound(err) {
@@ -54,6 +55,7 @@ func (c *client) DeclareHostname(ctx context.Context, lID mtypes.LeaseID, host s
 		}
 	} else {
 		resourceVersion = foundEntry.ObjectMeta.ResourceVersion
+		status = foundEntry.Status
 	}
 
 	obj := crd.ProviderHost{
@@ -72,7 +74,15 @@ func (c *client) DeclareHostname(ctx context.Context, lID mtypes.LeaseID, host s
 			ServiceName:  serviceName,
 			ExternalPort: externalPort,
 		},
-		Status: crd.ProviderHostStatus{},
+		Status: status,
+	}
+
+	// the hostname operator applies the entry again whenever its spec changes
+	if !exists || foundEntry.Spec != obj.Spec {
+		now := metav1.Now()
+		obj.Status.State = crd.ProviderHostStatePending
+		obj.Status.Message = ""
+		obj.Status.LastUpdate = &now
 	}
 
 	c.log.Info("declaring hostname", "lease", lID, "service-name", serviceName, "external-port", externalPort, "host", host)
@@ -86,6 +96,41 @@ func (c *client) DeclareHostname(ctx context.Context, lID mtypes.LeaseID, host s
 	return err
 }
 
+func (c *client) UpdateHostnameState(ctx context.Context, hostname string, state string, message string) error {
+	obj, err := c.ac.AkashV2beta1().ProviderHosts(c.ns).Get(ctx, hostname, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	// only transitions are written, writing the status triggers another reconcile of the hostname
+	if obj.Status.State == state && obj.Status.Message == message {
+		return nil
+	}
+
+	now := metav1.Now()
+	obj.Status.State = state
+	obj.Status.Message = message
+	obj.Status.LastUpdate = &now
+
+	_, err = c.ac.AkashV2beta1().ProviderHosts(c.ns).Update(ctx, obj, metav1.UpdateOptions{})
+	return err
+}
+
+// hostnameState returns the state the hostname operator recorded for a hostname
+func hostnameState(ph crd.ProviderHost) ctypes.HostnameState {
+	result := ctypes.HostnameState{
+		Hostname: ph.Spec.Hostname,
+		State:    ph.Status.State,
+		Message:  ph.Status.Message,
+	}
+	if ph.Status.LastUpdate != nil {
+		lastUpdate := ph.Status.LastUpdate.Time
+		result.LastUpdate = &lastUpdate
+	}
+
+	return result
+}
+
 func (c *client) PurgeDeclaredHostname(ctx context.Context, lID mtypes.LeaseID, hostname string) error {
 	labelSelector := &strings.Builder{}
 	kubeSelectorForLease(labelSelector, lID)
@@ -41,6 +41,8 @@ func kubeNginxIngressAnnotations(directive ctypes.ConnectHostnameToDeploymentDir
 
 		fmt.Sprintf("%s/proxy-next-upstream-tries", root): strconv.Itoa(int(directive.NextTries)),
 		fmt.Sprintf("%s/proxy-body-size", root):           strconv.Itoa(int(directive.MaxBodySize)),
+		// access logs are attributed to the lease with the ingress annotations
+		fmt.Sprintf("%s/enable-access-log", root): "true",
 	}
 
 	nextTimeoutKey := fmt.Sprintf("%s/proxy-next-upstream-timeout", root)
@@ -72,45 +74,151 @@ func kubeNginxIngressAnnotations(directive ctypes.ConnectHostnameToDeploymentDir
 	return result
 }
 
+func kubeHAProxyIngressAnnotations(directive ctypes.ConnectHostnameToDeploymentDirective) map[string]string {
+	// For jcmoraisjr/haproxy-ingress
+	// https://haproxy-ingress.github.io/docs/configuration/keys/
+	const root = "haproxy-ingress.github.io"
+
+	// haproxy has a single inactivity timeout for the server side of the connection
+	serverTimeout := directive.ReadTimeout
+	if directive.SendTimeout > serverTimeout {
+		serverTimeout = directive.SendTimeout
+	}
+
+	bodySize := "unlimited"
+	if directive.MaxBodySize > 0 {
+		bodySize = strconv.Itoa(int(directive.MaxBodySize))
+	}
+
+	result := map[string]string{
+		fmt.Sprintf("%s/timeout-server", root):  fmt.Sprintf("%dms", serverTimeout),
+		fmt.Sprintf("%s/proxy-body-size", root): bodySize,
+	}
+
+	// haproxy has no overall limit on the time spent retrying, NextTimeout is not applied
+	retryOn := haproxyRetryOn(directive.NextCases)
+
+	retries := directive.NextTries
+	if len(retryOn) == 0 {
+		retries = 0
+		retryOn = append(retryOn, "none")
+	}
+
+	result[fmt.Sprintf("%s/config-backend", root)] = fmt.Sprintf("retries %d\nretry-on %s\noption redispatch", retries, strings.Join(retryOn, " "))
+
+	return result
+}
+
+// haproxyRetryAll are the keywords all-retryable-errors stands for, less junk-response and 0rtt-rejected
+// which have no counterpart in the next cases
+var haproxyRetryAll = []string{"conn-failure", "empty-response", "response-timeout", "500", "502", "503", "504"}
+
+// haproxyRetryOn translates the next cases of a service to the retry-on keywords of haproxy.
+// Status codes haproxy cannot retry on are dropped.
+func haproxyRetryOn(nextCases []string) []string {
+	result := make([]string, 0, len(nextCases))
+	seen := make(map[string]struct{})
+	add := func(keywords ...string) {
+		for _, keyword := range keywords {
+			if _, exists := seen[keyword]; !exists {
+				seen[keyword] = struct{}{}
+				result = append(result, keyword)
+			}
+		}
+	}
+
+	for _, v := range nextCases {
+		switch strings.TrimPrefix(v, "http_") {
+		case "error":
+			add("conn-failure", "empty-response")
+		case "timeout":
+			add("response-timeout")
+		case "404", "500", "502", "503", "504":
+			add(strings.TrimPrefix(v, "http_"))
+		}
+	}
+
+	for _, keyword := range haproxyRetryAll {
+		if _, exists := seen[keyword]; !exists {
+			return result
+		}
+	}
+
+	// all-retryable-errors replaces the keywords it stands for, 404 is kept
+	collapsed := []string{"all-retryable-errors"}
+	if _, exists := seen["404"]; exists {
+		collapsed = append(collapsed, "404")
+	}
+
+	return collapsed
+}
+
 func (c *client) ConnectHostnameToDeployment(ctx context.Context, directive ctypes.ConnectHostnameToDeploymentDirective) error {
+	return c.ingress.connect(ctx, directive)
+}
+
+func (c *client) RemoveHostnameFromDeployment(ctx context.Context, hostname string, leaseID mtypes.LeaseID, allowMissing bool) error {
+	return c.ingress.remove(ctx, hostname, leaseID, allowMissing)
+}
+
+func (c *client) GetHostnameDeploymentConnections(ctx context.Context) ([]ctypes.LeaseIDHostnameConnection, error) {
+	return c.ingress.connections(ctx)
+}
+
+// kubeIngressBackend routes hostnames with Ingress resources. Ingress controllers differ in
+// the annotations configuring the proxy.
+type kubeIngressBackend struct {
+	c           *client
+	className   string
+	annotations func(ctypes.ConnectHostnameToDeploymentDirective) map[string]string
+}
+
+func (b *kubeIngressBackend) connect(ctx context.Context, directive ctypes.ConnectHostnameToDeploymentDirective) error {
 	ingressName := directive.Hostname
 	ns := builder.LidNS(directive.LeaseID)
 	rules := ingressRules(directive.Hostname, directive.ServiceName, directive.ServicePort)
 
-	foundEntry, err := c.kc.NetworkingV1().Ingresses(ns).Get(ctx, ingressName, metav1.GetOptions{})
-	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "ingresses-get", err, kubeErrors.IsNotFound)
-
 	labels := make(map[string]string)
 	labels[builder.AkashManagedLabelName] = "true"
 	builder.AppendLeaseLabels(directive.LeaseID, labels)
 
-	ingressClassName := akashIngressClassName
+	annotations := routeAnnotations(directive.LeaseID, b.annotations(directive))
+	tls, err := b.c.ingressTLS(ctx, directive, annotations)
+	if err != nil {
+		return err
+	}
+
+	ingressClassName := b.className
 	obj := &netv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        ingressName,
 			Labels:      labels,
-			Annotations: kubeNginxIngressAnnotations(directive),
+			Annotations: annotations,
 		},
 		Spec: netv1.IngressSpec{
 			IngressClassName: &ingressClassName,
+			TLS:              tls,
 			Rules:            rules,
 		},
 	}
 
+	foundEntry, err := b.c.kc.NetworkingV1().Ingresses(ns).Get(ctx, ingressName, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "ingresses-get", err, kubeErrors.IsNotFound)
+
 	switch {
 	case err == nil:
 		obj.ResourceVersion = foundEntry.ResourceVersion
-		_, err = c.kc.NetworkingV1().Ingresses(ns).Update(ctx, obj, metav1.UpdateOptions{})
+		_, err = b.c.kc.NetworkingV1().Ingresses(ns).Update(ctx, obj, metav1.UpdateOptions{})
 		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "networking-ingresses-update", err)
 	case kubeErrors.IsNotFound(err):
-		_, err = c.kc.NetworkingV1().Ingresses(ns).Create(ctx, obj, metav1.CreateOptions{})
+		_, err = b.c.kc.NetworkingV1().Ingresses(ns).Create(ctx, obj, metav1.CreateOptions{})
 		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "networking-ingresses-create", err)
 	}
 
 	return err
 }
 
-func (c *client) RemoveHostnameFromDeployment(ctx context.Context, hostname string, leaseID mtypes.LeaseID, allowMissing bool) error {
+func (b *kubeIngressBackend) remove(ctx context.Context, hostname string, leaseID mtypes.LeaseID, allowMissing bool) error {
 	ns := builder.LidNS(leaseID)
 	labelSelector := &strings.Builder{}
 	kubeSelectorForLease(labelSelector, leaseID)
@@ -119,7 +227,7 @@ func (c *client) RemoveHostnameFromDeployment(ctx context.Context, hostname stri
 	_, _ = fmt.Fprintf(fieldSelector, "metadata.name=%s", hostname)
 
 	// This delete only works if the ingress exists & the labels match the lease ID given
-	err := c.kc.NetworkingV1().Ingresses(ns).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{
+	err := b.c.kc.NetworkingV1().Ingresses(ns).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{
 		TypeMeta:             metav1.TypeMeta{},
 		LabelSelector:        labelSelector.String(),
 		FieldSelector:        fieldSelector.String(),
@@ -139,6 +247,20 @@ func (c *client) RemoveHostnameFromDeployment(ctx context.Context, hostname stri
 	return err
 }
 
+func (b *kubeIngressBackend) tlsSecretName(ctx context.Context, leaseID mtypes.LeaseID, hostname string) (string, error) {
+	ingress, err := b.c.kc.NetworkingV1().Ingresses(builder.LidNS(leaseID)).Get(ctx, hostname, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "ingresses-get", err, kubeErrors.IsNotFound)
+	if err != nil {
+		return "", err
+	}
+
+	if len(ingress.Spec.TLS) == 0 {
+		return "", nil
+	}
+
+	return ingress.Spec.TLS[0].SecretName, nil
+}
+
 func ingressRules(hostname string, kubeServiceName string, kubeServicePort int32) []netv1.IngressRule {
 	// for some reason we need to pass a pointer to this
 	pathTypeForAll := netv1.PathTypePrefix
@@ -186,9 +308,9 @@ func (lh leaseIDHostnameConnection) GetServiceName() string {
 	return lh.serviceName
 }
 
-func (c *client) GetHostnameDeploymentConnections(ctx context.Context) ([]ctypes.LeaseIDHostnameConnection, error) {
+func (b *kubeIngressBackend) connections(ctx context.Context) ([]ctypes.LeaseIDHostnameConnection, error) {
 	ingressPager := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
-		return c.kc.NetworkingV1().Ingresses(metav1.NamespaceAll).List(ctx, opts)
+		return b.c.kc.NetworkingV1().Ingresses(metav1.NamespaceAll).List(ctx, opts)
 	})
 
 	results := make([]ctypes.LeaseIDHostnameConnection, 0)
@@ -0,0 +1,261 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	metricsutils "github.com/akash-network/node/util/metrics"
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+	kubeclienterrors "github.com/akash-network/provider/cluster/kube/errors"
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+	crd "github.com/akash-network/provider/pkg/apis/akash.network/v2beta1"
+)
+
+const (
+	certManagerClusterIssuerAnnotation = "cert-manager.io/cluster-issuer"
+
+	// wildcardTLSSecretName is the copy of the provider wildcard certificate in a lease namespace
+	wildcardTLSSecretName = "akash-wildcard-tls"
+)
+
+// certificateResource is accessed through the dynamic client, cert-manager is optional and
+// its types are not part of client-go
+var certificateResource = schema.GroupVersionResource{
+	Group:    "cert-manager.io",
+	Version:  "v1",
+	Resource: "certificates",
+}
+
+// hostnameTLSSecretName is the secret cert-manager stores the certificate of hostname in.
+// cert-manager names the Certificate it creates for an ingress after the secret.
+func hostnameTLSSecretName(hostname string) string {
+	return fmt.Sprintf("%s-tls", hostname)
+}
+
+// tlsSecret returns the secret holding the certificate of the directive hostname, empty when
+// TLS is disabled. The provider wildcard certificate is copied into the lease namespace.
+func (c *client) tlsSecret(ctx context.Context, directive ctypes.ConnectHostnameToDeploymentDirective) (string, error) {
+	switch {
+	case directive.TLS.WildcardSecret != "":
+		if err := copyWildcardTLSSecret(ctx, c, directive.TLS.WildcardSecret, builder.LidNS(directive.LeaseID)); err != nil {
+			return "", err
+		}
+		return wildcardTLSSecretName, nil
+	case directive.TLS.ClusterIssuer != "":
+		return hostnameTLSSecretName(directive.Hostname), nil
+	default:
+		return "", nil
+	}
+}
+
+// ingressTLS returns the TLS section of the Ingress of the directive hostname, adding the
+// annotations cert-manager requires
+func (c *client) ingressTLS(ctx context.Context, directive ctypes.ConnectHostnameToDeploymentDirective, annotations map[string]string) ([]netv1.IngressTLS, error) {
+	secretName, err := c.tlsSecret(ctx, directive)
+	if err != nil || secretName == "" {
+		return nil, err
+	}
+
+	if directive.TLS.WildcardSecret == "" {
+		annotations[certManagerClusterIssuerAnnotation] = directive.TLS.ClusterIssuer
+	}
+
+	return []netv1.IngressTLS{{
+		Hosts:      []string{directive.Hostname},
+		SecretName: secretName,
+	}}, nil
+}
+
+// newCertificate requests a certificate for the directive hostname from its cluster issuer,
+// for routes cert-manager does not watch
+func newCertificate(directive ctypes.ConnectHostnameToDeploymentDirective, secretName string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"spec": map[string]interface{}{
+			"secretName": secretName,
+			"dnsNames":   []interface{}{directive.Hostname},
+			"issuerRef": map[string]interface{}{
+				"kind": "ClusterIssuer",
+				"name": directive.TLS.ClusterIssuer,
+			},
+		},
+	}}
+	obj.SetName(secretName)
+	obj.SetNamespace(builder.LidNS(directive.LeaseID))
+	obj.SetLabels(routeLabels(directive.LeaseID))
+
+	return obj
+}
+
+// splitNamespacedName parses a namespace/name reference to an object
+func splitNamespacedName(value string) (string, string, error) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("%q is not namespace/name", value)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+func copyWildcardTLSSecret(ctx context.Context, c *client, source string, ns string) error {
+	srcNS, srcName, err := splitNamespacedName(source)
+	if err != nil {
+		return fmt.Errorf("%w: %q", kubeclienterrors.ErrInvalidWildcardTLSSecret, source)
+	}
+
+	src, err := c.kc.CoreV1().Secrets(srcNS).Get(ctx, srcName, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "secrets-get", err)
+	if err != nil {
+		return err
+	}
+
+	obj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   wildcardTLSSecretName,
+			Labels: map[string]string{builder.AkashManagedLabelName: "true"},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: src.Data,
+	}
+
+	current, err := c.kc.CoreV1().Secrets(ns).Get(ctx, wildcardTLSSecretName, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "secrets-get", err, kubeErrors.IsNotFound)
+
+	switch {
+	case err == nil:
+		obj.ResourceVersion = current.ResourceVersion
+		_, err = c.kc.CoreV1().Secrets(ns).Update(ctx, obj, metav1.UpdateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "secrets-update", err)
+	case kubeErrors.IsNotFound(err):
+		_, err = c.kc.CoreV1().Secrets(ns).Create(ctx, obj, metav1.CreateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "secrets-create", err)
+	}
+
+	return err
+}
+
+// hostnameTLSStatus inspects the certificate the route of hostname serves
+func (c *client) hostnameTLSStatus(ctx context.Context, leaseID mtypes.LeaseID, hostname string) (crd.ProviderHostTLSStatus, error) {
+	ns := builder.LidNS(leaseID)
+
+	secretName, err := c.ingress.tlsSecretName(ctx, leaseID, hostname)
+	if err != nil || secretName == "" {
+		return crd.ProviderHostTLSStatus{}, err
+	}
+
+	result := crd.ProviderHostTLSStatus{
+		SecretName: secretName,
+	}
+
+	if result.SecretName == wildcardTLSSecretName {
+		_, err = c.kc.CoreV1().Secrets(ns).Get(ctx, result.SecretName, metav1.GetOptions{})
+		metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "secrets-get", err, kubeErrors.IsNotFound)
+		switch {
+		case err == nil:
+			result.State = crd.ProviderHostTLSStateIssued
+		case kubeErrors.IsNotFound(err):
+			result.State = crd.ProviderHostTLSStateFailed
+			result.Message = "wildcard certificate secret is missing"
+		default:
+			return crd.ProviderHostTLSStatus{}, err
+		}
+
+		return result, nil
+	}
+
+	certificate, err := c.dc.Resource(certificateResource).Namespace(ns).Get(ctx, result.SecretName, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "certificates-get", err, kubeErrors.IsNotFound)
+	switch {
+	case err == nil:
+		result.State, result.Message = certificateState(certificate)
+	case kubeErrors.IsNotFound(err):
+		result.State = crd.ProviderHostTLSStatePending
+		result.Message = "waiting for cert-manager to request the certificate"
+	default:
+		return crd.ProviderHostTLSStatus{}, err
+	}
+
+	return result, nil
+}
+
+// certificateState maps the conditions of a cert-manager Certificate to a ProviderHost TLS state
+func certificateState(obj *unstructured.Unstructured) (string, string) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+
+	state := crd.ProviderHostTLSStatePending
+	message := ""
+
+	for _, item := range conditions {
+		condition, valid := item.(map[string]interface{})
+		if !valid {
+			continue
+		}
+
+		condType, _, _ := unstructured.NestedString(condition, "type")
+		status, _, _ := unstructured.NestedString(condition, "status")
+		reason, _, _ := unstructured.NestedString(condition, "reason")
+		msg, _, _ := unstructured.NestedString(condition, "message")
+
+		switch {
+		case condType == "Ready" && status == string(metav1.ConditionTrue):
+			return crd.ProviderHostTLSStateIssued, msg
+		case condType == "Issuing" && status == string(metav1.ConditionFalse) && reason == "Failed":
+			state = crd.ProviderHostTLSStateFailed
+			message = msg
+		case condType == "Ready" && state != crd.ProviderHostTLSStateFailed:
+			message = msg
+		}
+	}
+
+	return state, message
+}
+
+func (c *client) UpdateHostnameTLSStatus(ctx context.Context, leaseID mtypes.LeaseID, hostname string) (crd.ProviderHostTLSStatus, error) {
+	status, err := c.hostnameTLSStatus(ctx, leaseID, hostname)
+	if err != nil {
+		return crd.ProviderHostTLSStatus{}, err
+	}
+
+	obj, err := c.ac.AkashV2beta1().ProviderHosts(c.ns).Get(ctx, hostname, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "provider-hosts-get", err)
+	if err != nil {
+		return crd.ProviderHostTLSStatus{}, err
+	}
+
+	if obj.Status.TLS == status {
+		return status, nil
+	}
+
+	obj.Status.TLS = status
+	_, err = c.ac.AkashV2beta1().ProviderHosts(c.ns).Update(ctx, obj, metav1.UpdateOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "provider-hosts-update", err)
+	if err != nil {
+		return crd.ProviderHostTLSStatus{}, err
+	}
+
+	return status, nil
+}
+
+// hostnameCertificate returns the certificate state recorded for a hostname, if it is served with TLS
+func hostnameCertificate(ph crd.ProviderHost) (ctypes.HostnameCertificate, bool) {
+	if ph.Status.TLS.State == "" {
+		return ctypes.HostnameCertificate{}, false
+	}
+
+	return ctypes.HostnameCertificate{
+		Hostname: ph.Spec.Hostname,
+		State:    ph.Status.TLS.State,
+		Message:  ph.Status.TLS.Message,
+	}, true
+}
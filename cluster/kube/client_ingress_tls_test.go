@@ -0,0 +1,157 @@
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/akash-network/node/testutil"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+	kubeclienterrors "github.com/akash-network/provider/cluster/kube/errors"
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+	crd "github.com/akash-network/provider/pkg/apis/akash.network/v2beta1"
+	akashclient_fake "github.com/akash-network/provider/pkg/client/clientset/versioned/fake"
+)
+
+func certificate(ns, name string, conditions ...map[string]interface{}) *unstructured.Unstructured {
+	items := make([]interface{}, 0, len(conditions))
+	for _, condition := range conditions {
+		items = append(items, condition)
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata": map[string]interface{}{
+			"namespace": ns,
+			"name":      name,
+		},
+		"status": map[string]interface{}{
+			"conditions": items,
+		},
+	}}
+}
+
+func newTLSTestClient(t *testing.T, directive ctypes.ConnectHostnameToDeploymentDirective, objects ...runtime.Object) *client {
+	ph := &crd.ProviderHost{
+		ObjectMeta: metav1.ObjectMeta{Name: directive.Hostname, Namespace: testKubeClientNs},
+		Spec:       crd.ProviderHostSpec{Hostname: directive.Hostname, ServiceName: directive.ServiceName},
+	}
+
+	c := &client{
+		kc: kubefake.NewSimpleClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "wildcard", Namespace: "ingress"},
+			Data:       map[string][]byte{corev1.TLSCertKey: []byte("cert"), corev1.TLSPrivateKeyKey: []byte("key")},
+		}),
+		ac: akashclient_fake.NewSimpleClientset(ph),
+		dc: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{certificateResource: "CertificateList"}, objects...),
+		ns:  testKubeClientNs,
+		log: testutil.Logger(t),
+	}
+	require.NoError(t, WithIngressConfig(DefaultIngressConfig())(c))
+
+	return c
+}
+
+func TestConnectHostnameWithClusterIssuer(t *testing.T) {
+	ctx := context.Background()
+	directive := ctypes.ConnectHostnameToDeploymentDirective{
+		Hostname:    "app.example.com",
+		LeaseID:     testutil.LeaseID(t),
+		ServiceName: "web",
+		ServicePort: 80,
+		TLS:         ctypes.HostnameTLS{ClusterIssuer: "letsencrypt"},
+	}
+	ns := builder.LidNS(directive.LeaseID)
+
+	c := newTLSTestClient(t, directive)
+	require.NoError(t, c.ConnectHostnameToDeployment(ctx, directive))
+
+	ingress, err := c.kc.NetworkingV1().Ingresses(ns).Get(ctx, directive.Hostname, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "letsencrypt", ingress.Annotations[certManagerClusterIssuerAnnotation])
+	require.Len(t, ingress.Spec.TLS, 1)
+	require.Equal(t, []string{directive.Hostname}, ingress.Spec.TLS[0].Hosts)
+	require.Equal(t, "app.example.com-tls", ingress.Spec.TLS[0].SecretName)
+
+	// cert-manager did not pick up the ingress yet
+	status, err := c.UpdateHostnameTLSStatus(ctx, directive.LeaseID, directive.Hostname)
+	require.NoError(t, err)
+	require.Equal(t, crd.ProviderHostTLSStatePending, status.State)
+
+	_, err = c.dc.Resource(certificateResource).Namespace(ns).Create(ctx, certificate(ns, "app.example.com-tls",
+		map[string]interface{}{"type": "Ready", "status": "False", "message": "issuing"},
+		map[string]interface{}{"type": "Issuing", "status": "False", "reason": "Failed", "message": "challenge failed"},
+	), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	status, err = c.UpdateHostnameTLSStatus(ctx, directive.LeaseID, directive.Hostname)
+	require.NoError(t, err)
+	require.Equal(t, crd.ProviderHostTLSStateFailed, status.State)
+	require.Equal(t, "challenge failed", status.Message)
+
+	ph, err := c.ac.AkashV2beta1().ProviderHosts(testKubeClientNs).Get(ctx, directive.Hostname, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, status, ph.Status.TLS)
+
+	cert, valid := hostnameCertificate(*ph)
+	require.True(t, valid)
+	require.Equal(t, ctypes.HostnameCertificate{
+		Hostname: directive.Hostname,
+		State:    crd.ProviderHostTLSStateFailed,
+		Message:  "challenge failed",
+	}, cert)
+}
+
+func TestConnectHostnameWithWildcardSecret(t *testing.T) {
+	ctx := context.Background()
+	directive := ctypes.ConnectHostnameToDeploymentDirective{
+		Hostname:    "abcd.ingress.example.com",
+		LeaseID:     testutil.LeaseID(t),
+		ServiceName: "web",
+		ServicePort: 80,
+		TLS:         ctypes.HostnameTLS{WildcardSecret: "ingress/wildcard"},
+	}
+	ns := builder.LidNS(directive.LeaseID)
+
+	c := newTLSTestClient(t, directive)
+	require.NoError(t, c.ConnectHostnameToDeployment(ctx, directive))
+
+	ingress, err := c.kc.NetworkingV1().Ingresses(ns).Get(ctx, directive.Hostname, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotContains(t, ingress.Annotations, certManagerClusterIssuerAnnotation)
+	require.Len(t, ingress.Spec.TLS, 1)
+	require.Equal(t, wildcardTLSSecretName, ingress.Spec.TLS[0].SecretName)
+
+	secret, err := c.kc.CoreV1().Secrets(ns).Get(ctx, wildcardTLSSecretName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, corev1.SecretTypeTLS, secret.Type)
+	require.Equal(t, []byte("cert"), secret.Data[corev1.TLSCertKey])
+
+	status, err := c.UpdateHostnameTLSStatus(ctx, directive.LeaseID, directive.Hostname)
+	require.NoError(t, err)
+	require.Equal(t, crd.ProviderHostTLSStateIssued, status.State)
+
+	directive.TLS.WildcardSecret = "wildcard"
+	require.ErrorIs(t, c.ConnectHostnameToDeployment(ctx, directive), kubeclienterrors.ErrInvalidWildcardTLSSecret)
+}
+
+func TestCertificateState(t *testing.T) {
+	state, _ := certificateState(certificate("ns", "cert"))
+	require.Equal(t, crd.ProviderHostTLSStatePending, state)
+
+	state, msg := certificateState(certificate("ns", "cert",
+		map[string]interface{}{"type": "Ready", "status": "True", "message": "up to date"}))
+	require.Equal(t, crd.ProviderHostTLSStateIssued, state)
+	require.Equal(t, "up to date", msg)
+}
@@ -0,0 +1,154 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metricsutils "github.com/akash-network/node/util/metrics"
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+)
+
+const (
+	podFailureReasonOOMKilled     = "OOMKilled"
+	podFailureReasonUnschedulable = "Unschedulable"
+	podFailureReasonPVCPending    = "PVCPending"
+)
+
+// containerWaitingFailures are the reasons a container may be stuck in that it will not recover from by itself
+var containerWaitingFailures = map[string]struct{}{
+	"ErrImagePull":               {},
+	"ImagePullBackOff":           {},
+	"InvalidImageName":           {},
+	"CrashLoopBackOff":           {},
+	"CreateContainerConfigError": {},
+	"CreateContainerError":       {},
+	"RunContainerError":          {},
+}
+
+// podFailuresForLease diagnoses pods of the lease and returns failures keyed by service name.
+// The diagnosis is best effort, the status of a lease is still reported when pods cannot be listed.
+func (c *client) podFailuresForLease(ctx context.Context, lid mtypes.LeaseID) map[string][]ctypes.ServiceFailure {
+	pods, err := c.kc.CoreV1().Pods(builder.LidNS(lid)).List(ctx, metav1.ListOptions{})
+	label := metricsutils.SuccessLabel
+	if err != nil {
+		label = metricsutils.FailLabel
+	}
+	kubeCallsCounter.WithLabelValues("pods-list", label).Inc()
+	if err != nil {
+		c.log.Error("pods list", "err", err)
+		return nil
+	}
+
+	pvcs, err := c.kc.CoreV1().PersistentVolumeClaims(builder.LidNS(lid)).List(ctx, metav1.ListOptions{})
+	label = metricsutils.SuccessLabel
+	if err != nil {
+		label = metricsutils.FailLabel
+	}
+	kubeCallsCounter.WithLabelValues("persistent-volume-claims-list", label).Inc()
+
+	pendingClaims := make(map[string]struct{})
+	if err != nil {
+		// pods are still diagnosed, only pending claims are not told apart
+		c.log.Error("persistent volume claims list", "err", err)
+		pvcs = &corev1.PersistentVolumeClaimList{}
+	}
+	for _, pvc := range pvcs.Items {
+		if pvc.Status.Phase == corev1.ClaimPending {
+			pendingClaims[pvc.Name] = struct{}{}
+		}
+	}
+
+	result := make(map[string][]ctypes.ServiceFailure)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+
+		svcName, ok := pod.Labels[builder.AkashManifestServiceLabelName]
+		if !ok {
+			continue
+		}
+
+		if failure, failed := diagnosePod(pod, pendingClaims); failed {
+			result[svcName] = append(result[svcName], failure)
+		}
+	}
+
+	return result
+}
+
+// diagnosePod returns the most relevant reason the pod is not running, if any
+func diagnosePod(pod *corev1.Pod, pendingClaims map[string]struct{}) (ctypes.ServiceFailure, bool) {
+	failure := ctypes.ServiceFailure{
+		Pod: pod.Name,
+	}
+
+	if pod.Status.Phase == corev1.PodFailed {
+		failure.Reason = pod.Status.Reason
+		failure.Message = pod.Status.Message
+		if failure.Reason == "" {
+			failure.Reason = string(corev1.PodFailed)
+		}
+		return failure, true
+	}
+
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type != corev1.PodScheduled || cond.Status != corev1.ConditionFalse {
+			continue
+		}
+
+		for _, vol := range pod.Spec.Volumes {
+			if vol.PersistentVolumeClaim == nil {
+				continue
+			}
+
+			if _, pending := pendingClaims[vol.PersistentVolumeClaim.ClaimName]; pending {
+				failure.Reason = podFailureReasonPVCPending
+				failure.Message = fmt.Sprintf("persistent volume claim %q is pending", vol.PersistentVolumeClaim.ClaimName)
+				return failure, true
+			}
+		}
+
+		failure.Reason = podFailureReasonUnschedulable
+		failure.Message = cond.Message
+		return failure, true
+	}
+
+	statuses := make([]corev1.ContainerStatus, 0, len(pod.Status.InitContainerStatuses)+len(pod.Status.ContainerStatuses))
+	statuses = append(statuses, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+
+	for _, status := range statuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.Reason == podFailureReasonOOMKilled {
+			failure.Reason = podFailureReasonOOMKilled
+			failure.Message = fmt.Sprintf("container %q exceeded its memory limit", status.Name)
+			return failure, true
+		}
+
+		waiting := status.State.Waiting
+		if waiting == nil {
+			continue
+		}
+
+		if _, known := containerWaitingFailures[waiting.Reason]; !known {
+			continue
+		}
+
+		// a crash looping container is most useful reported by the reason it was last terminated with
+		if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.Reason == podFailureReasonOOMKilled {
+			failure.Reason = podFailureReasonOOMKilled
+			failure.Message = fmt.Sprintf("container %q exceeded its memory limit", status.Name)
+			return failure, true
+		}
+
+		failure.Reason = waiting.Reason
+		failure.Message = waiting.Message
+		return failure, true
+	}
+
+	return ctypes.ServiceFailure{}, false
+}
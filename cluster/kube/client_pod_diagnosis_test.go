@@ -0,0 +1,188 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/akash-network/node/testutil"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+)
+
+func TestDiagnosePod(t *testing.T) {
+	const claimName = "web-data-web-0"
+
+	pendingClaims := map[string]struct{}{
+		claimName: {},
+	}
+
+	tests := []struct {
+		name    string
+		status  corev1.PodStatus
+		volumes []corev1.Volume
+		reason  string
+		failed  bool
+	}{
+		{
+			name: "running",
+			status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "web",
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				}},
+			},
+		},
+		{
+			name: "image pull back off",
+			status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name: "web",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+						Reason:  "ImagePullBackOff",
+						Message: `Back-off pulling image "nginx:bad"`,
+					}},
+				}},
+			},
+			reason: "ImagePullBackOff",
+			failed: true,
+		},
+		{
+			name: "container creating",
+			status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "web",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+				}},
+			},
+		},
+		{
+			name: "crash loop",
+			status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:                 "web",
+					State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}},
+				}},
+			},
+			reason: "CrashLoopBackOff",
+			failed: true,
+		},
+		{
+			name: "crash loop out of memory",
+			status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:                 "web",
+					State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+				}},
+			},
+			reason: podFailureReasonOOMKilled,
+			failed: true,
+		},
+		{
+			name: "unschedulable",
+			status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				Conditions: []corev1.PodCondition{{
+					Type:    corev1.PodScheduled,
+					Status:  corev1.ConditionFalse,
+					Reason:  "Unschedulable",
+					Message: "0/3 nodes are available: 3 Insufficient cpu.",
+				}},
+			},
+			reason: podFailureReasonUnschedulable,
+			failed: true,
+		},
+		{
+			name: "pending volume claim",
+			status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				Conditions: []corev1.PodCondition{{
+					Type:   corev1.PodScheduled,
+					Status: corev1.ConditionFalse,
+					Reason: "Unschedulable",
+				}},
+			},
+			volumes: []corev1.Volume{{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+				},
+			}},
+			reason: podFailureReasonPVCPending,
+			failed: true,
+		},
+		{
+			name: "evicted",
+			status: corev1.PodStatus{
+				Phase:  corev1.PodFailed,
+				Reason: "Evicted",
+			},
+			reason: "Evicted",
+			failed: true,
+		},
+	}
+
+	for _, test := range tests {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-0"},
+			Spec:       corev1.PodSpec{Volumes: test.volumes},
+			Status:     test.status,
+		}
+
+		failure, failed := diagnosePod(pod, pendingClaims)
+		require.Equal(t, test.failed, failed, test.name)
+		if !test.failed {
+			continue
+		}
+
+		require.Equal(t, "web-0", failure.Pod, test.name)
+		require.Equal(t, test.reason, failure.Reason, test.name)
+	}
+}
+
+func TestPodFailuresForLeaseBestEffort(t *testing.T) {
+	ctx := context.Background()
+	lid := testutil.LeaseID(t)
+
+	kc := kubefake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-0",
+			Namespace: builder.LidNS(lid),
+			Labels:    map[string]string{builder.AkashManifestServiceLabelName: "web"},
+		},
+		Status: corev1.PodStatus{
+			Phase:  corev1.PodFailed,
+			Reason: "Evicted",
+		},
+	})
+	c := &client{kc: kc, log: testutil.Logger(t)}
+
+	// pods are diagnosed without telling pending claims apart
+	kc.PrependReactor("list", "persistentvolumeclaims", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("claims unavailable")
+	})
+
+	failures := c.podFailuresForLease(ctx, lid)
+	require.Len(t, failures["web"], 1)
+	require.Equal(t, "Evicted", failures["web"][0].Reason)
+
+	kc.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("pods unavailable")
+	})
+
+	require.Empty(t, c.podFailuresForLease(ctx, lid))
+}
@@ -13,17 +13,21 @@ import (
 	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
 
 	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/akash-network/provider/cluster/kube/builder"
+	"github.com/akash-network/provider/cluster/kube/ipbackend"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 
 	"github.com/akash-network/node/testutil"
 
@@ -31,6 +35,7 @@ import (
 	appsv1_mocks "github.com/akash-network/provider/testutil/kubernetes_mock/typed/apps/v1"
 	corev1_mocks "github.com/akash-network/provider/testutil/kubernetes_mock/typed/core/v1"
 
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
 	crd "github.com/akash-network/provider/pkg/apis/akash.network/v2beta1"
 	akashclient "github.com/akash-network/provider/pkg/client/clientset/versioned"
 	akashclient_fake "github.com/akash-network/provider/pkg/client/clientset/versioned/fake"
@@ -51,6 +56,17 @@ func clientForTest(t *testing.T, kc kubernetes.Interface, ac akashclient.Interfa
 	return result
 }
 
+// mockLeasePods makes the lease namespace report no pods and no persistent volume claims
+func mockLeasePods(coreV1Mock *corev1_mocks.CoreV1Interface, lid mtypes.LeaseID) {
+	podsMock := &corev1_mocks.PodInterface{}
+	coreV1Mock.On("Pods", builder.LidNS(lid)).Return(podsMock)
+	podsMock.On("List", mock.Anything, metav1.ListOptions{}).Return(&v1.PodList{}, nil)
+
+	pvcsMock := &corev1_mocks.PersistentVolumeClaimInterface{}
+	coreV1Mock.On("PersistentVolumeClaims", builder.LidNS(lid)).Return(pvcsMock)
+	pvcsMock.On("List", mock.Anything, metav1.ListOptions{}).Return(&v1.PersistentVolumeClaimList{}, nil)
+}
+
 func TestNewClientWithBogusIngressDomain(t *testing.T) {
 	settings := builder.Settings{
 		DeploymentIngressStaticHosts: true,
@@ -148,6 +164,7 @@ func TestLeaseStatusWithNoIngressNoService(t *testing.T) {
 
 	namespaceMock := &corev1_mocks.NamespaceInterface{}
 	coreV1Mock.On("Namespaces").Return(namespaceMock)
+	mockLeasePods(coreV1Mock, lid)
 	namespaceMock.On("Get", mock.Anything, builder.LidNS(lid), mock.Anything).Return(nil, nil)
 
 	statefulSetsMock := &appsv1_mocks.StatefulSetInterface{}
@@ -234,6 +251,7 @@ func TestLeaseStatusWithIngressOnly(t *testing.T) {
 
 	namespaceMock := &corev1_mocks.NamespaceInterface{}
 	coreV1Mock.On("Namespaces").Return(namespaceMock)
+	mockLeasePods(coreV1Mock, lid)
 	namespaceMock.On("Get", mock.Anything, builder.LidNS(lid), mock.Anything).Return(nil, nil)
 
 	statefulSetsMock := &appsv1_mocks.StatefulSetInterface{}
@@ -282,6 +300,8 @@ func TestLeaseStatusWithIngressOnly(t *testing.T) {
 	require.Equal(t, myIngressService.Name, "myingress")
 	require.Len(t, myIngressService.URIs, 1)
 	require.Equal(t, myIngressService.URIs[0], "mytesthost.dev")
+	require.Len(t, myIngressService.Hostnames, 1)
+	require.Equal(t, "mytesthost.dev", myIngressService.Hostnames[0].Hostname)
 
 	noIngressService, found := status["noingress"]
 	require.True(t, found)
@@ -296,6 +316,132 @@ func TestLeaseStatusWithIngressOnly(t *testing.T) {
 	require.Len(t, fps, 0)
 }
 
+func TestHostnameState(t *testing.T) {
+	lid := testutil.LeaseID(t)
+	ctx := context.Background()
+	clientInterface := clientForTest(t, &kubernetes_mocks.Interface{}, akashclient_fake.NewSimpleClientset())
+	c := clientInterface.(*client)
+
+	require.NoError(t, c.DeclareHostname(ctx, lid, "mytesthost.dev", "web", 80))
+	ph, err := c.ac.AkashV2beta1().ProviderHosts(testKubeClientNs).Get(ctx, "mytesthost.dev", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, crd.ProviderHostStatePending, ph.Status.State)
+	require.NotNil(t, ph.Status.LastUpdate)
+
+	require.NoError(t, c.UpdateHostnameState(ctx, "mytesthost.dev", crd.ProviderHostStateFailed, "ingress rejected"))
+	require.NoError(t, c.UpdateHostnameState(ctx, "mytesthost.dev", crd.ProviderHostStateActive, ""))
+
+	// declaring the same target keeps the state the hostname operator recorded
+	require.NoError(t, c.DeclareHostname(ctx, lid, "mytesthost.dev", "web", 80))
+	ph, err = c.ac.AkashV2beta1().ProviderHosts(testKubeClientNs).Get(ctx, "mytesthost.dev", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, crd.ProviderHostStateActive, ph.Status.State)
+	require.Empty(t, ph.Status.Message)
+
+	require.NoError(t, c.DeclareHostname(ctx, lid, "mytesthost.dev", "api", 80))
+	ph, err = c.ac.AkashV2beta1().ProviderHosts(testKubeClientNs).Get(ctx, "mytesthost.dev", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, crd.ProviderHostStatePending, ph.Status.State)
+}
+
+func TestManifestCloseReason(t *testing.T) {
+	ctx := context.Background()
+	lid := testutil.LeaseID(t)
+
+	akashMock := akashclient_fake.NewSimpleClientset(&crd.Manifest{
+		ObjectMeta: metav1.ObjectMeta{Name: builder.LidNS(lid), Namespace: testKubeClientNs},
+	})
+	c := clientForTest(t, &kubernetes_mocks.Interface{}, akashMock)
+
+	reason := `service "web": CrashLoopBackOff (2 pods): back-off restarting failed container`
+	require.NoError(t, c.UpdateManifestStatus(ctx, lid, crd.ManifestStatus{
+		State:   crd.ManifestStateClosed,
+		Message: reason,
+	}))
+
+	found, status, err := c.GetManifestStatus(ctx, lid)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, crd.ManifestStateClosed, status.State)
+	require.Equal(t, reason, status.Message)
+}
+
+func TestNodePortsOnlyManagedServices(t *testing.T) {
+	ctx := context.Background()
+	lid := testutil.LeaseID(t)
+	ns := builder.LidNS(lid)
+
+	managed := map[string]string{builder.AkashManagedLabelName: "true"}
+	nodePortService := func(namespace, name string, labels map[string]string, nodePort int32) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+			Spec: v1.ServiceSpec{
+				Type: v1.ServiceTypeNodePort,
+				Ports: []v1.ServicePort{{
+					Protocol:   v1.ProtocolTCP,
+					Port:       80,
+					TargetPort: intstr.FromInt(80),
+					NodePort:   nodePort,
+				}},
+			},
+		}
+	}
+
+	kc := kubefake.NewSimpleClientset(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   ns,
+			Labels: builder.AppendLeaseLabels(lid, map[string]string{builder.AkashManagedLabelName: "true"}),
+		}},
+		nodePortService(ns, "web"+builder.SuffixForNodePortServiceName, managed, 30080),
+		// created in the lease namespace by something other than the provider
+		nodePortService(ns, "debug", nil, 30081),
+		// managed label outside of any lease namespace
+		nodePortService("default", "other"+builder.SuffixForNodePortServiceName, managed, 30082),
+	)
+	c := clientForTest(t, kc, akashclient_fake.NewSimpleClientset())
+
+	allocations, err := c.NodePorts(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []ctypes.NodePortAllocation{{
+		LeaseID:  lid,
+		Service:  "web",
+		Port:     80,
+		NodePort: 30080,
+		Proto:    manifest.TCP,
+	}}, allocations)
+}
+
+func TestDeclaredIPStates(t *testing.T) {
+	lid := testutil.LeaseID(t)
+	ctx := context.Background()
+	clientInterface := clientForTest(t, &kubernetes_mocks.Interface{}, akashclient_fake.NewSimpleClientset())
+	c := clientInterface.(*client)
+
+	require.NoError(t, c.DeclareIP(ctx, lid, "web", 80, 8080, manifest.TCP, "akey", false))
+
+	states, err := c.DeclaredIPStates(ctx, lid)
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	require.Equal(t, crd.ProviderLeasedIPStatePending, states[0].State)
+	require.Equal(t, uint32(80), states[0].Port)
+	require.NotNil(t, states[0].LastUpdate)
+
+	require.NoError(t, c.UpdateIPState(ctx, "akey", manifest.TCP, 8080, crd.ProviderLeasedIPStateFailed, "pool exhausted"))
+
+	states, err = c.DeclaredIPStates(ctx, lid)
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	require.Equal(t, ctypes.DeclaredIPState{
+		ServiceName:  "web",
+		Port:         80,
+		ExternalPort: 8080,
+		Proto:        manifest.TCP,
+		State:        crd.ProviderLeasedIPStateFailed,
+		Message:      "pool exhausted",
+		LastUpdate:   states[0].LastUpdate,
+	}, states[0])
+}
+
 func TestLeaseStatusWithForwardedPortOnly(t *testing.T) {
 	lid := testutil.LeaseID(t)
 
@@ -308,6 +454,7 @@ func TestLeaseStatusWithForwardedPortOnly(t *testing.T) {
 
 	namespaceMock := &corev1_mocks.NamespaceInterface{}
 	coreV1Mock.On("Namespaces").Return(namespaceMock)
+	mockLeasePods(coreV1Mock, lid)
 	namespaceMock.On("Get", mock.Anything, builder.LidNS(lid), mock.Anything).Return(nil, nil)
 
 	statefulSetsMock := &appsv1_mocks.StatefulSetInterface{}
@@ -376,6 +523,48 @@ func TestLeaseStatusWithForwardedPortOnly(t *testing.T) {
 	require.Equal(t, int(ports[0].ExternalPort), expectedExternalPort)
 }
 
+func TestForwardedPortStatusDualStackLeasedIP(t *testing.T) {
+	lid := testutil.LeaseID(t)
+	ns := builder.LidNS(lid)
+
+	directive := ctypes.ClusterIPPassthroughDirective{
+		LeaseID:      lid,
+		ServiceName:  "web",
+		Port:         8080,
+		ExternalPort: 80,
+		SharingKey:   "akey",
+		Protocol:     manifest.TCP,
+	}
+
+	svc, err := ipbackend.LoadBalancerService(directive, nil)
+	require.NoError(t, err)
+	svc.Namespace = ns
+	svc.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "192.0.2.10"}}
+
+	svcIPv6, err := ipbackend.LoadBalancerServiceIPv6(directive, nil)
+	require.NoError(t, err)
+	svcIPv6.Namespace = ns
+	svcIPv6.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "2001:db8::10"}}
+
+	c := clientForTest(t, kubefake.NewSimpleClientset(svc, svcIPv6), akashclient_fake.NewSimpleClientset())
+
+	ctx := context.WithValue(context.Background(), builder.SettingsKey, builder.Settings{
+		ClusterPublicHostname: "meow.com",
+	})
+	fps, err := c.ForwardedPortStatus(ctx, lid)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]ctypes.ForwardedPortStatus{
+		"web": {{
+			Host:         "192.0.2.10",
+			IPv6:         "2001:db8::10",
+			Port:         8080,
+			ExternalPort: 80,
+			Proto:        manifest.TCP,
+			Name:         "web",
+		}},
+	}, fps)
+}
+
 func TestServiceStatusNoLease(t *testing.T) {
 	const serviceName = "foobar"
 	lid := testutil.LeaseID(t)
@@ -525,6 +714,7 @@ func TestServiceStatusWithIngress(t *testing.T) {
 
 	namespaceMock := &corev1_mocks.NamespaceInterface{}
 	coreV1Mock.On("Namespaces").Return(namespaceMock)
+	mockLeasePods(coreV1Mock, lid)
 	namespaceMock.On("Get", mock.Anything, builder.LidNS(lid), mock.Anything).Return(nil, nil)
 
 	deploymentsMock := &appsv1_mocks.DeploymentInterface{}
@@ -606,6 +796,7 @@ func TestServiceStatusWithNoManifest(t *testing.T) {
 
 	namespaceMock := &corev1_mocks.NamespaceInterface{}
 	coreV1Mock.On("Namespaces").Return(namespaceMock)
+	mockLeasePods(coreV1Mock, lid)
 	namespaceMock.On("Get", mock.Anything, builder.LidNS(lid), mock.Anything).Return(nil, nil)
 
 	deploymentsMock := &appsv1_mocks.DeploymentInterface{}
@@ -680,6 +871,7 @@ func TestServiceStatusWithoutIngress(t *testing.T) {
 
 	namespaceMock := &corev1_mocks.NamespaceInterface{}
 	coreV1Mock.On("Namespaces").Return(namespaceMock)
+	mockLeasePods(coreV1Mock, lid)
 	namespaceMock.On("Get", mock.Anything, builder.LidNS(lid), mock.Anything).Return(nil, nil)
 
 	deploymentsMock := &appsv1_mocks.DeploymentInterface{}
@@ -747,3 +939,77 @@ func TestServiceStatusWithoutIngress(t *testing.T) {
 	require.NotNil(t, status)
 	require.Len(t, status.URIs, 0)
 }
+
+func TestLastGoodGroup(t *testing.T) {
+	ctx := context.Background()
+	lid := testutil.LeaseID(t)
+
+	deployed := &manifest.Group{Name: "deployed"}
+	m, err := crd.NewManifest(testKubeClientNs, lid, deployed)
+	require.NoError(t, err)
+	akashMock := akashclient_fake.NewSimpleClientset(m)
+	c := clientForTest(t, &kubernetes_mocks.Interface{}, akashMock)
+
+	group, err := c.LastGoodGroup(ctx, lid)
+	require.NoError(t, err)
+	require.Nil(t, group)
+
+	require.NoError(t, c.SaveLastGoodGroup(ctx, lid, deployed))
+
+	// deploying an update keeps the group recorded as last good
+	obj, err := akashMock.AkashV2beta1().Manifests(testKubeClientNs).Get(ctx, builder.LidNS(lid), metav1.GetOptions{})
+	require.NoError(t, err)
+	obj, err = builder.BuildManifest(testutil.Logger(t), builder.Settings{}, testKubeClientNs, lid, &manifest.Group{Name: "update"}).Update(obj)
+	require.NoError(t, err)
+	_, err = akashMock.AkashV2beta1().Manifests(testKubeClientNs).Update(ctx, obj, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	group, err = c.LastGoodGroup(ctx, lid)
+	require.NoError(t, err)
+	require.NotNil(t, group)
+	require.Equal(t, "deployed", group.Name)
+
+	group, err = c.LastGoodGroup(ctx, testutil.LeaseID(t))
+	require.NoError(t, err)
+	require.Nil(t, group)
+}
+
+func TestSaveLastGoodGroupTightensResourceQuota(t *testing.T) {
+	lid := testutil.LeaseID(t)
+
+	group := &manifest.Group{
+		Name: "deployed",
+		Services: []manifest.Service{{
+			Name:  "web",
+			Image: "nginx",
+			Count: 1,
+			Resources: types.ResourceUnits{
+				CPU:    &types.CPU{Units: types.NewResourceValue(100)},
+				Memory: &types.Memory{Quantity: types.NewResourceValue(128 * 1024 * 1024)},
+			},
+		}},
+	}
+	m, err := crd.NewManifest(testKubeClientNs, lid, group)
+	require.NoError(t, err)
+
+	// sized for the pods of the group the update replaced
+	kc := kubefake.NewSimpleClientset(&v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "akash-lease-quota", Namespace: builder.LidNS(lid)},
+		Spec: v1.ResourceQuotaSpec{
+			Hard: v1.ResourceList{v1.ResourcePods: *resource.NewQuantity(4, resource.DecimalSI)},
+		},
+	})
+	c := clientForTest(t, kc, akashclient_fake.NewSimpleClientset(m))
+
+	settings := builder.NewDefaultSettings()
+	settings.ResourceQuotasEnabled = true
+	ctx := context.WithValue(context.Background(), builder.SettingsKey, settings)
+
+	require.NoError(t, c.SaveLastGoodGroup(ctx, lid, group))
+
+	quota, err := kc.CoreV1().ResourceQuotas(builder.LidNS(lid)).Get(ctx, "akash-lease-quota", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	// one replica plus one surge pod
+	require.Equal(t, int64(2), quota.Spec.Hard.Pods().Value())
+}
@@ -60,9 +60,7 @@ func (c *client) PurgeDeclaredIP(ctx context.Context, leaseID mtypes.LeaseID, se
 }
 
 func (c *client) DeclareIP(ctx context.Context, lID mtypes.LeaseID, serviceName string, port uint32, externalPort uint32, proto manifest.ServiceProtocol, sharingKey string, overwrite bool) error {
-	// Note: This interface expects sharing key to contain a value that is unique per deployment owner, in this
-	// case it is the bech32 address, or a derivative thereof
-	resourceName := strings.ToLower(fmt.Sprintf("%s-%s-%d", sharingKey, proto.ToString(), externalPort))
+	resourceName := leasedIPResourceName(sharingKey, proto, externalPort)
 
 	c.log.Debug("checking for resource", "resource-name", resourceName)
 	foundEntry, err := c.ac.AkashV2beta1().ProviderLeasedIPs(c.ns).Get(ctx, resourceName, metav1.GetOptions{})
@@ -99,7 +97,17 @@ func (c *client) DeclareIP(ctx context.Context, lID mtypes.LeaseID, serviceName
 			Protocol:     proto.ToString(),
 			Port:         port,
 		},
-		Status: akashtypes.ProviderLeasedIPStatus{},
+	}
+
+	// the IP operator applies the entry again whenever its spec changes
+	if exists && foundEntry.Spec == obj.Spec {
+		obj.Status = foundEntry.Status
+	} else {
+		now := metav1.Now()
+		obj.Status = akashtypes.ProviderLeasedIPStatus{
+			State:      akashtypes.ProviderLeasedIPStatePending,
+			LastUpdate: &now,
+		}
 	}
 
 	c.log.Info("declaring leased ip", "lease", lID,
@@ -119,6 +127,72 @@ func (c *client) DeclareIP(ctx context.Context, lID mtypes.LeaseID, serviceName
 	return err
 }
 
+// leasedIPResourceName names the ProviderLeasedIP of an external port. This expects sharing key to contain
+// a value that is unique per deployment owner, in this case it is the bech32 address, or a derivative thereof
+func leasedIPResourceName(sharingKey string, proto manifest.ServiceProtocol, externalPort uint32) string {
+	return strings.ToLower(fmt.Sprintf("%s-%s-%d", sharingKey, proto.ToString(), externalPort))
+}
+
+func (c *client) UpdateIPState(ctx context.Context, sharingKey string, proto manifest.ServiceProtocol, externalPort uint32, state string, message string) error {
+	resourceName := leasedIPResourceName(sharingKey, proto, externalPort)
+	obj, err := c.ac.AkashV2beta1().ProviderLeasedIPs(c.ns).Get(ctx, resourceName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	// only transitions are written, writing the status triggers another reconcile of the entry
+	if obj.Status.State == state && obj.Status.Message == message {
+		return nil
+	}
+
+	now := metav1.Now()
+	obj.Status = akashtypes.ProviderLeasedIPStatus{
+		State:      state,
+		Message:    message,
+		LastUpdate: &now,
+	}
+
+	_, err = c.ac.AkashV2beta1().ProviderLeasedIPs(c.ns).Update(ctx, obj, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *client) DeclaredIPStates(ctx context.Context, leaseID mtypes.LeaseID) ([]ctypes.DeclaredIPState, error) {
+	labelSelector := &strings.Builder{}
+	kubeSelectorForLease(labelSelector, leaseID)
+
+	results, err := c.ac.AkashV2beta1().ProviderLeasedIPs(c.ns).List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	retval := make([]ctypes.DeclaredIPState, 0, len(results.Items))
+	for _, item := range results.Items {
+		proto, err := manifest.ParseServiceProtocol(item.Spec.Protocol)
+		if err != nil {
+			return nil, err
+		}
+
+		state := ctypes.DeclaredIPState{
+			ServiceName:  item.Spec.ServiceName,
+			Port:         item.Spec.Port,
+			ExternalPort: item.Spec.ExternalPort,
+			Proto:        proto,
+			State:        item.Status.State,
+			Message:      item.Status.Message,
+		}
+		if item.Status.LastUpdate != nil {
+			lastUpdate := item.Status.LastUpdate.Time
+			state.LastUpdate = &lastUpdate
+		}
+
+		retval = append(retval, state)
+	}
+
+	return retval, nil
+}
+
 func (c *client) PurgeDeclaredIPs(ctx context.Context, lID mtypes.LeaseID) error {
 	labelSelector := &strings.Builder{}
 	_, err := fmt.Fprintf(labelSelector, "%s=true,", builder.AkashManagedLabelName)
@@ -0,0 +1,297 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/tendermint/tendermint/libs/log"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	manifest "github.com/akash-network/node/manifest/v2beta1"
+	metricsutils "github.com/akash-network/node/util/metrics"
+
+	crd "github.com/akash-network/provider/pkg/apis/akash.network/v2beta1"
+	crdapi "github.com/akash-network/provider/pkg/client/clientset/versioned"
+)
+
+// deployRevertTimeout bounds reverting a failed deploy
+const deployRevertTimeout = time.Minute
+
+// revertStep restores a single object applied by a deploy to the version it had before
+type revertStep struct {
+	kind   string
+	name   string
+	revert func(context.Context) error
+}
+
+// deployTransaction records the objects a deploy is about to apply, so they can be reverted
+// to their previous versions when a later step of the deploy fails. Objects which did not
+// exist before are deleted on revert.
+type deployTransaction struct {
+	log   log.Logger
+	kc    kubernetes.Interface
+	ac    crdapi.Interface
+	steps []revertStep
+
+	// the lease was deployed before, there is a previous version to go back to
+	previousManifest bool
+	// status of the Manifest CRD before the deploy
+	previousStatus crd.ManifestStatus
+	// group of the Manifest CRD before the deploy
+	previousGroup crd.ManifestGroup
+}
+
+func newDeployTransaction(log log.Logger, kc kubernetes.Interface, ac crdapi.Interface) *deployTransaction {
+	return &deployTransaction{
+		log: log,
+		kc:  kc,
+		ac:  ac,
+	}
+}
+
+// canRevert reports whether the lease had been deployed before this transaction
+func (tx *deployTransaction) canRevert() bool {
+	return tx.previousManifest
+}
+
+// replacedGroup returns the group deployed before when this transaction deploys a different one, nil otherwise
+func (tx *deployTransaction) replacedGroup(group *manifest.Group) *manifest.Group {
+	if !tx.previousManifest {
+		return nil
+	}
+
+	next, err := crd.ManifestGroupFromAkash(group)
+	if err == nil && reflect.DeepEqual(next, tx.previousGroup) {
+		return nil
+	}
+
+	previous, err := tx.previousGroup.ToAkash()
+	if err != nil {
+		tx.log.Error("reading previous manifest group", "err", err)
+		return nil
+	}
+
+	return &previous
+}
+
+// revert restores all tracked objects, latest first. Every step is attempted even when some fail.
+func (tx *deployTransaction) revert(ctx context.Context) error {
+	var failed []string
+
+	for idx := len(tx.steps) - 1; idx >= 0; idx-- {
+		step := tx.steps[idx]
+		if err := step.revert(ctx); err != nil {
+			tx.log.Error("reverting object", "kind", step.kind, "name", step.name, "err", err)
+			failed = append(failed, fmt.Sprintf("%s %q: %s", step.kind, step.name, err))
+		}
+	}
+
+	if len(failed) != 0 {
+		return fmt.Errorf("unable to revert %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// track records restore for an object which exists, or remove when the lookup returned not found
+func (tx *deployTransaction) track(kind, name string, err error, restore, remove func(context.Context) error) error {
+	switch {
+	case err == nil:
+		tx.steps = append(tx.steps, revertStep{kind: kind, name: name, revert: restore})
+	case errors.IsNotFound(err):
+		tx.steps = append(tx.steps, revertStep{kind: kind, name: name, revert: func(ctx context.Context) error {
+			if err := remove(ctx); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			return nil
+		}})
+	default:
+		return err
+	}
+
+	return nil
+}
+
+func (tx *deployTransaction) trackResourceQuota(ctx context.Context, ns, name string) error {
+	prev, err := tx.kc.CoreV1().ResourceQuotas(ns).Get(ctx, name, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "resource-quotas-get", err, errors.IsNotFound)
+
+	return tx.track("resource quota", name, err, func(ctx context.Context) error {
+		obj, err := tx.kc.CoreV1().ResourceQuotas(ns).Get(ctx, name, metav1.GetOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "resource-quotas-get", err)
+		if err != nil {
+			return err
+		}
+
+		obj.Labels = prev.Labels
+		obj.Spec = prev.Spec
+		_, err = tx.kc.CoreV1().ResourceQuotas(ns).Update(ctx, obj, metav1.UpdateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "resource-quotas-update", err)
+		return err
+	}, func(ctx context.Context) error {
+		err := tx.kc.CoreV1().ResourceQuotas(ns).Delete(ctx, name, metav1.DeleteOptions{})
+		metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "resource-quotas-delete", err, errors.IsNotFound)
+		return err
+	})
+}
+
+func (tx *deployTransaction) trackLimitRange(ctx context.Context, ns, name string) error {
+	prev, err := tx.kc.CoreV1().LimitRanges(ns).Get(ctx, name, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "limit-ranges-get", err, errors.IsNotFound)
+
+	return tx.track("limit range", name, err, func(ctx context.Context) error {
+		obj, err := tx.kc.CoreV1().LimitRanges(ns).Get(ctx, name, metav1.GetOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "limit-ranges-get", err)
+		if err != nil {
+			return err
+		}
+
+		obj.Labels = prev.Labels
+		obj.Spec = prev.Spec
+		_, err = tx.kc.CoreV1().LimitRanges(ns).Update(ctx, obj, metav1.UpdateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "limit-ranges-update", err)
+		return err
+	}, func(ctx context.Context) error {
+		err := tx.kc.CoreV1().LimitRanges(ns).Delete(ctx, name, metav1.DeleteOptions{})
+		metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "limit-ranges-delete", err, errors.IsNotFound)
+		return err
+	})
+}
+
+func (tx *deployTransaction) trackNetworkPolicy(ctx context.Context, ns, name string) error {
+	prev, err := tx.kc.NetworkingV1().NetworkPolicies(ns).Get(ctx, name, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "networking-policies-get", err, errors.IsNotFound)
+
+	return tx.track("network policy", name, err, func(ctx context.Context) error {
+		obj, err := tx.kc.NetworkingV1().NetworkPolicies(ns).Get(ctx, name, metav1.GetOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "networking-policies-get", err)
+		if err != nil {
+			return err
+		}
+
+		obj.Labels = prev.Labels
+		obj.Spec = prev.Spec
+		_, err = tx.kc.NetworkingV1().NetworkPolicies(ns).Update(ctx, obj, metav1.UpdateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "networking-policies-update", err)
+		return err
+	}, func(ctx context.Context) error {
+		err := tx.kc.NetworkingV1().NetworkPolicies(ns).Delete(ctx, name, metav1.DeleteOptions{})
+		metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "networking-policies-delete", err, errors.IsNotFound)
+		return err
+	})
+}
+
+func (tx *deployTransaction) trackManifest(ctx context.Context, ns, name string) error {
+	prev, err := tx.ac.AkashV2beta1().Manifests(ns).Get(ctx, name, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "akash-manifests-get", err, errors.IsNotFound)
+	tx.previousManifest = err == nil
+	if err == nil {
+		tx.previousStatus = prev.Status
+		tx.previousGroup = prev.Spec.Group
+	}
+
+	return tx.track("manifest", name, err, func(ctx context.Context) error {
+		obj, err := tx.ac.AkashV2beta1().Manifests(ns).Get(ctx, name, metav1.GetOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "akash-manifests-get", err)
+		if err != nil {
+			return err
+		}
+
+		obj.Labels = prev.Labels
+		obj.Spec = prev.Spec
+		_, err = tx.ac.AkashV2beta1().Manifests(ns).Update(ctx, obj, metav1.UpdateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "akash-manifests-update", err)
+		return err
+	}, func(ctx context.Context) error {
+		err := tx.ac.AkashV2beta1().Manifests(ns).Delete(ctx, name, metav1.DeleteOptions{})
+		metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "akash-manifests-delete", err, errors.IsNotFound)
+		return err
+	})
+}
+
+func (tx *deployTransaction) trackDeployment(ctx context.Context, ns, name string) error {
+	prev, err := tx.kc.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "deployments-get", err, errors.IsNotFound)
+
+	return tx.track("deployment", name, err, func(ctx context.Context) error {
+		obj, err := tx.kc.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "deployments-get", err)
+		if err != nil {
+			return err
+		}
+
+		obj.Labels = prev.Labels
+		obj.Spec = prev.Spec
+		_, err = tx.kc.AppsV1().Deployments(ns).Update(ctx, obj, metav1.UpdateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "deployments-update", err)
+		return err
+	}, func(ctx context.Context) error {
+		err := tx.kc.AppsV1().Deployments(ns).Delete(ctx, name, metav1.DeleteOptions{})
+		metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "deployments-delete", err, errors.IsNotFound)
+		return err
+	})
+}
+
+func (tx *deployTransaction) trackStatefulSet(ctx context.Context, ns, name string) error {
+	prev, err := tx.kc.AppsV1().StatefulSets(ns).Get(ctx, name, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "statefulset-get", err, errors.IsNotFound)
+
+	return tx.track("statefulset", name, err, func(ctx context.Context) error {
+		obj, err := tx.kc.AppsV1().StatefulSets(ns).Get(ctx, name, metav1.GetOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "statefulset-get", err)
+		if err != nil {
+			return err
+		}
+
+		obj.Labels = prev.Labels
+		obj.Spec = prev.Spec
+		_, err = tx.kc.AppsV1().StatefulSets(ns).Update(ctx, obj, metav1.UpdateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "statefulset-update", err)
+		if !errors.IsInvalid(err) {
+			return err
+		}
+
+		// volume claim templates changed, the statefulset was recreated by applyStatefulSet
+		if err := deleteStatefulSetOrphan(ctx, tx.kc, obj); err != nil {
+			return err
+		}
+
+		prev.ResourceVersion = ""
+		prev.UID = ""
+		_, err = tx.kc.AppsV1().StatefulSets(ns).Create(ctx, prev, metav1.CreateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "statefulset-create", err)
+		return err
+	}, func(ctx context.Context) error {
+		err := tx.kc.AppsV1().StatefulSets(ns).Delete(ctx, name, metav1.DeleteOptions{})
+		metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "statefulset-delete", err, errors.IsNotFound)
+		return err
+	})
+}
+
+func (tx *deployTransaction) trackService(ctx context.Context, ns, name string) error {
+	prev, err := tx.kc.CoreV1().Services(ns).Get(ctx, name, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "services-get", err, errors.IsNotFound)
+
+	return tx.track("service", name, err, func(ctx context.Context) error {
+		obj, err := tx.kc.CoreV1().Services(ns).Get(ctx, name, metav1.GetOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "services-get", err)
+		if err != nil {
+			return err
+		}
+
+		obj.Labels = prev.Labels
+		obj.Spec = prev.Spec
+		_, err = tx.kc.CoreV1().Services(ns).Update(ctx, obj, metav1.UpdateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "services-update", err)
+		return err
+	}, func(ctx context.Context) error {
+		err := tx.kc.CoreV1().Services(ns).Delete(ctx, name, metav1.DeleteOptions{})
+		metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "services-delete", err, errors.IsNotFound)
+		return err
+	})
+}
@@ -0,0 +1,176 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	manifest "github.com/akash-network/node/manifest/v2beta1"
+	"github.com/akash-network/node/testutil"
+	atypes "github.com/akash-network/node/types/v1beta2"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+	crd "github.com/akash-network/provider/pkg/apis/akash.network/v2beta1"
+	akashclient_fake "github.com/akash-network/provider/pkg/client/clientset/versioned/fake"
+)
+
+func TestDeployTransactionRevert(t *testing.T) {
+	ctx := context.Background()
+	lid := testutil.LeaseID(t)
+	ns := builder.LidNS(lid)
+
+	replicas := int32(1)
+	kc := kubefake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: ns},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	})
+	ac := akashclient_fake.NewSimpleClientset(&crd.Manifest{
+		ObjectMeta: metav1.ObjectMeta{Name: ns, Namespace: "lease"},
+		Spec:       crd.ManifestSpec{Group: crd.ManifestGroup{Name: "previous"}},
+		Status:     crd.ManifestStatus{State: crd.ManifestStateRolledBack},
+	})
+
+	tx := newDeployTransaction(testutil.Logger(t), kc, ac)
+	require.NoError(t, tx.trackManifest(ctx, "lease", ns))
+	require.NoError(t, tx.trackDeployment(ctx, ns, "web"))
+	require.NoError(t, tx.trackService(ctx, ns, "web"))
+	require.True(t, tx.canRevert())
+	require.Equal(t, crd.ManifestStateRolledBack, tx.previousStatus.State)
+
+	// the deploy changes the manifest and deployment and creates a service
+	manifest, err := ac.AkashV2beta1().Manifests("lease").Get(ctx, ns, metav1.GetOptions{})
+	require.NoError(t, err)
+	manifest.Spec.Group.Name = "next"
+	_, err = ac.AkashV2beta1().Manifests("lease").Update(ctx, manifest, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	deployment, err := kc.AppsV1().Deployments(ns).Get(ctx, "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	replicas = 3
+	deployment.Spec.Replicas = &replicas
+	_, err = kc.AppsV1().Deployments(ns).Update(ctx, deployment, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	_, err = kc.CoreV1().Services(ns).Create(ctx, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: ns},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, tx.revert(ctx))
+
+	manifest, err = ac.AkashV2beta1().Manifests("lease").Get(ctx, ns, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "previous", manifest.Spec.Group.Name)
+
+	deployment, err = kc.AppsV1().Deployments(ns).Get(ctx, "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), *deployment.Spec.Replicas)
+
+	services, err := kc.CoreV1().Services(ns).List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, services.Items)
+}
+
+func TestDeployTransactionFirstDeploy(t *testing.T) {
+	ctx := context.Background()
+	lid := testutil.LeaseID(t)
+
+	tx := newDeployTransaction(testutil.Logger(t), kubefake.NewSimpleClientset(), akashclient_fake.NewSimpleClientset())
+	require.NoError(t, tx.trackManifest(ctx, "lease", builder.LidNS(lid)))
+	require.False(t, tx.canRevert())
+
+	// nothing was created, deleting missing objects is not an error
+	require.NoError(t, tx.revert(ctx))
+}
+
+func TestDeployTransactionReplacedGroup(t *testing.T) {
+	ctx := context.Background()
+	lid := testutil.LeaseID(t)
+	ns := builder.LidNS(lid)
+
+	deployed := &manifest.Group{Name: "deployed"}
+	m, err := crd.NewManifest("lease", lid, deployed)
+	require.NoError(t, err)
+
+	tx := newDeployTransaction(testutil.Logger(t), kubefake.NewSimpleClientset(), akashclient_fake.NewSimpleClientset(m))
+	require.NoError(t, tx.trackManifest(ctx, "lease", ns))
+
+	// deploying the same group again replaces nothing
+	require.Nil(t, tx.replacedGroup(&manifest.Group{Name: "deployed"}))
+
+	replaced := tx.replacedGroup(&manifest.Group{Name: "update"})
+	require.NotNil(t, replaced)
+	require.Equal(t, "deployed", replaced.Name)
+
+	tx = newDeployTransaction(testutil.Logger(t), kubefake.NewSimpleClientset(), akashclient_fake.NewSimpleClientset())
+	require.NoError(t, tx.trackManifest(ctx, "lease", ns))
+	require.Nil(t, tx.replacedGroup(deployed))
+}
+
+func TestFailedDeployKeepsStaleResources(t *testing.T) {
+	lid := testutil.LeaseID(t)
+	ns := builder.LidNS(lid)
+
+	kc := kubefake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "old",
+			Namespace: ns,
+			Labels: map[string]string{
+				builder.AkashManagedLabelName:         "true",
+				builder.AkashManifestServiceLabelName: "old",
+				builder.AkashServiceTarget:            "true",
+			},
+		},
+	})
+	ac := akashclient_fake.NewSimpleClientset(&crd.Manifest{
+		ObjectMeta: metav1.ObjectMeta{Name: ns, Namespace: testKubeClientNs},
+		Spec:       crd.ManifestSpec{Group: crd.ManifestGroup{Name: "previous"}},
+	})
+
+	kc.PrependReactor("create", "services", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("service create failed")
+	})
+
+	group := &manifest.Group{
+		Name: "next",
+		Services: []manifest.Service{{
+			Name:  "web",
+			Image: "nginx",
+			Count: 1,
+			Resources: atypes.ResourceUnits{
+				CPU:    &atypes.CPU{Units: atypes.NewResourceValue(100)},
+				Memory: &atypes.Memory{Quantity: atypes.NewResourceValue(128 * 1024 * 1024)},
+			},
+			Expose: []manifest.ServiceExpose{{Port: 80, ExternalPort: 80, Proto: manifest.TCP}},
+		}},
+	}
+
+	ctx := context.WithValue(context.Background(), builder.SettingsKey, builder.NewDefaultSettings())
+
+	c := clientForTest(t, kc, ac)
+	require.Error(t, c.Deploy(ctx, lid, group))
+
+	for _, action := range kc.Actions() {
+		require.NotEqual(t, "delete-collection", action.GetVerb(), "stale resources are not cleaned up by a failed deploy")
+	}
+
+	_, err := kc.AppsV1().Deployments(ns).Get(ctx, "old", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	_, err = kc.AppsV1().Deployments(ns).Get(ctx, "web", metav1.GetOptions{})
+	require.True(t, kubeErrors.IsNotFound(err), "deployment created by the failed deploy is reverted")
+
+	obj, err := ac.AkashV2beta1().Manifests(testKubeClientNs).Get(ctx, ns, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "previous", obj.Spec.Group.Name)
+	require.Equal(t, crd.ManifestStateRolledBack, obj.Status.State)
+}
@@ -15,4 +15,10 @@ var (
 	ErrInvalidHostnameConnection = fmt.Errorf("%w: invalid hostname connection", ErrKubeClient)
 	ErrNotConfiguredWithSettings = fmt.Errorf("%w: not configured with settings in the context passed to function", ErrKubeClient)
 	ErrAlreadyExists             = fmt.Errorf("%w: resource already exists", ErrKubeClient)
+	ErrUnsupportedStorageChange  = fmt.Errorf("%w: unsupported persistent storage change", ErrKubeClient)
+	ErrVolumeSnapshotNotFound    = fmt.Errorf("%w: volume snapshot not found", ErrKubeClient)
+	ErrVolumeSnapshotNotReady    = fmt.Errorf("%w: volume snapshot is not ready to use", ErrKubeClient)
+	ErrVolumeRestoreInProgress   = fmt.Errorf("%w: volume is already being restored", ErrKubeClient)
+	ErrInvalidWildcardTLSSecret  = fmt.Errorf("%w: wildcard tls secret must be namespace/name", ErrKubeClient)
+	ErrInvalidIngressConfig      = fmt.Errorf("%w: invalid ingress config", ErrKubeClient)
 )
@@ -0,0 +1,199 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/pager"
+
+	metricsutils "github.com/akash-network/node/util/metrics"
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+	"github.com/akash-network/provider/cluster/kube/clientcommon"
+	kubeclienterrors "github.com/akash-network/provider/cluster/kube/errors"
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+)
+
+const (
+	// IngressBackendNginx routes hostnames with Ingress resources served by kubernetes/ingress-nginx
+	IngressBackendNginx = "nginx"
+	// IngressBackendHAProxy routes hostnames with Ingress resources served by haproxy-ingress
+	IngressBackendHAProxy = "haproxy"
+	// IngressBackendTraefik routes hostnames with Traefik IngressRoute resources
+	IngressBackendTraefik = "traefik"
+	// IngressBackendGatewayAPI routes hostnames with Gateway API HTTPRoute resources
+	IngressBackendGatewayAPI = "gateway-api"
+)
+
+// IngressConfig selects the ingress controller hostnames of leases are routed by
+type IngressConfig struct {
+	Backend string
+	// ClassName is the ingress class of Ingress and IngressRoute resources
+	ClassName string
+	// Gateway is the namespace/name of the Gateway HTTPRoutes attach to
+	Gateway string
+}
+
+// DefaultIngressConfig routes hostnames through ingress-nginx
+func DefaultIngressConfig() IngressConfig {
+	return IngressConfig{
+		Backend:   IngressBackendNginx,
+		ClassName: akashIngressClassName,
+	}
+}
+
+// ingressBackend creates the resources of an ingress controller routing the hostname of
+// a lease to one of its services. The resources of a hostname are named after it.
+type ingressBackend interface {
+	// connect creates or updates the route of the directive hostname
+	connect(ctx context.Context, directive ctypes.ConnectHostnameToDeploymentDirective) error
+	// remove deletes the route of hostname, if it belongs to the lease
+	remove(ctx context.Context, hostname string, leaseID mtypes.LeaseID, allowMissing bool) error
+	// connections lists the routes of all leases
+	connections(ctx context.Context) ([]ctypes.LeaseIDHostnameConnection, error)
+	// tlsSecretName returns the secret with the certificate served for hostname, empty when TLS is disabled
+	tlsSecretName(ctx context.Context, leaseID mtypes.LeaseID, hostname string) (string, error)
+}
+
+func newIngressBackend(c *client, cfg IngressConfig) (ingressBackend, error) {
+	if cfg.ClassName == "" {
+		cfg.ClassName = akashIngressClassName
+	}
+
+	switch cfg.Backend {
+	case IngressBackendNginx, "":
+		return &kubeIngressBackend{c: c, className: cfg.ClassName, annotations: kubeNginxIngressAnnotations}, nil
+	case IngressBackendHAProxy:
+		return &kubeIngressBackend{c: c, className: cfg.ClassName, annotations: kubeHAProxyIngressAnnotations}, nil
+	case IngressBackendTraefik:
+		return &traefikIngressBackend{c: c, className: cfg.ClassName}, nil
+	case IngressBackendGatewayAPI:
+		gwNS, gwName, err := splitNamespacedName(cfg.Gateway)
+		if err != nil {
+			return nil, fmt.Errorf("%w: gateway %q", kubeclienterrors.ErrInvalidIngressConfig, cfg.Gateway)
+		}
+		return &gatewayIngressBackend{c: c, gatewayNS: gwNS, gatewayName: gwName}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown backend %q", kubeclienterrors.ErrInvalidIngressConfig, cfg.Backend)
+	}
+}
+
+// ClientOption configures optional behaviour of the client returned by NewClient
+type ClientOption func(*client) error
+
+// WithIngressConfig routes hostnames through the given ingress controller instead of ingress-nginx
+func WithIngressConfig(cfg IngressConfig) ClientOption {
+	return func(c *client) error {
+		backend, err := newIngressBackend(c, cfg)
+		if err != nil {
+			return err
+		}
+
+		c.ingress = backend
+		return nil
+	}
+}
+
+// applyUnstructured creates obj or updates it when it exists already
+func applyUnstructured(ctx context.Context, dc dynamic.Interface, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	resources := dc.Resource(gvr).Namespace(obj.GetNamespace())
+
+	current, err := resources.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, gvr.Resource+"-get", err, kubeErrors.IsNotFound)
+
+	switch {
+	case err == nil:
+		obj.SetResourceVersion(current.GetResourceVersion())
+		_, err = resources.Update(ctx, obj, metav1.UpdateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, gvr.Resource+"-update", err)
+	case kubeErrors.IsNotFound(err):
+		_, err = resources.Create(ctx, obj, metav1.CreateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, gvr.Resource+"-create", err)
+	}
+
+	return err
+}
+
+func deleteUnstructured(ctx context.Context, dc dynamic.Interface, gvr schema.GroupVersionResource, ns string, name string) error {
+	err := dc.Resource(gvr).Namespace(ns).Delete(ctx, name, metav1.DeleteOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, gvr.Resource+"-delete", err, kubeErrors.IsNotFound)
+	if kubeErrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// leaseRoute returns the route of hostname, or nil if it does not exist or belongs to another lease
+func leaseRoute(ctx context.Context, dc dynamic.Interface, gvr schema.GroupVersionResource, hostname string, leaseID mtypes.LeaseID) (*unstructured.Unstructured, error) {
+	obj, err := dc.Resource(gvr).Namespace(builder.LidNS(leaseID)).Get(ctx, hostname, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, gvr.Resource+"-get", err, kubeErrors.IsNotFound)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := clientcommon.RecoverLeaseIDFromLabels(obj.GetLabels())
+	if err != nil || !owner.Equals(leaseID) {
+		return nil, nil
+	}
+
+	return obj, nil
+}
+
+// listRoutes calls fn with every route of the leases, recovering the lease from the route labels
+func listRoutes(ctx context.Context, dc dynamic.Interface, gvr schema.GroupVersionResource, fn func(mtypes.LeaseID, *unstructured.Unstructured) error) error {
+	routePager := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		return dc.Resource(gvr).Namespace(metav1.NamespaceAll).List(ctx, opts)
+	})
+
+	return routePager.EachListItem(ctx,
+		metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=true", builder.AkashManagedLabelName)},
+		func(obj runtime.Object) error {
+			route := obj.(*unstructured.Unstructured)
+			leaseID, err := clientcommon.RecoverLeaseIDFromLabels(route.GetLabels())
+			if err != nil {
+				return err
+			}
+
+			return fn(leaseID, route)
+		})
+}
+
+// routeLabels are set on every resource an ingress backend creates for a hostname
+func routeLabels(leaseID mtypes.LeaseID) map[string]string {
+	labels := map[string]string{
+		builder.AkashManagedLabelName: "true",
+	}
+	builder.AppendLeaseLabels(leaseID, labels)
+
+	return labels
+}
+
+// routeAnnotations are merged into the annotations of the route an ingress backend creates for a hostname
+func routeAnnotations(leaseID mtypes.LeaseID, annotations map[string]string) map[string]string {
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[builder.AkashLeaseAnnotation] = leaseID.String()
+
+	return annotations
+}
+
+// backendPort reads the port number of a route backend, which decodes as int64 or float64
+func backendPort(backend map[string]interface{}) (int32, bool) {
+	switch port := backend["port"].(type) {
+	case int64:
+		return int32(port), true
+	case float64:
+		return int32(port), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,201 @@
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/akash-network/node/testutil"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+	kubeclienterrors "github.com/akash-network/provider/cluster/kube/errors"
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+)
+
+func newIngressTestClient(t *testing.T, cfg IngressConfig) *client {
+	c := &client{
+		kc: kubefake.NewSimpleClientset(),
+		dc: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{
+				certificateResource:             "CertificateList",
+				traefikIngressRouteResource:     "IngressRouteList",
+				traefikMiddlewareResource:       "MiddlewareList",
+				traefikServersTransportResource: "ServersTransportList",
+				httpRouteResource:               "HTTPRouteList",
+			}),
+		ns:  testKubeClientNs,
+		log: testutil.Logger(t),
+	}
+	require.NoError(t, WithIngressConfig(cfg)(c))
+
+	return c
+}
+
+func testHostnameDirective(t *testing.T) ctypes.ConnectHostnameToDeploymentDirective {
+	return ctypes.ConnectHostnameToDeploymentDirective{
+		Hostname:    "app.example.com",
+		LeaseID:     testutil.LeaseID(t),
+		ServiceName: "web",
+		ServicePort: 80,
+		ReadTimeout: 60000,
+		SendTimeout: 90000,
+		NextTries:   3,
+		NextCases:   []string{"error", "http_503"},
+		MaxBodySize: 1048576,
+	}
+}
+
+func TestInvalidIngressConfig(t *testing.T) {
+	c := &client{}
+
+	require.ErrorIs(t, WithIngressConfig(IngressConfig{Backend: "envoy"})(c), kubeclienterrors.ErrInvalidIngressConfig)
+	require.ErrorIs(t, WithIngressConfig(IngressConfig{Backend: IngressBackendGatewayAPI, Gateway: "gateway"})(c), kubeclienterrors.ErrInvalidIngressConfig)
+}
+
+func TestNginxIngressAttribution(t *testing.T) {
+	ctx := context.Background()
+	directive := testHostnameDirective(t)
+
+	c := newIngressTestClient(t, IngressConfig{Backend: IngressBackendNginx})
+	require.NoError(t, c.ConnectHostnameToDeployment(ctx, directive))
+
+	ingress, err := c.kc.NetworkingV1().Ingresses(builder.LidNS(directive.LeaseID)).Get(ctx, directive.Hostname, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, directive.LeaseID.String(), ingress.Annotations[builder.AkashLeaseAnnotation])
+	require.Equal(t, "true", ingress.Annotations["nginx.ingress.kubernetes.io/enable-access-log"])
+	require.Equal(t, "60", ingress.Annotations["nginx.ingress.kubernetes.io/proxy-read-timeout"])
+}
+
+func TestHAProxyIngressAnnotations(t *testing.T) {
+	directive := testHostnameDirective(t)
+
+	annotations := kubeHAProxyIngressAnnotations(directive)
+	require.Equal(t, "90000ms", annotations["haproxy-ingress.github.io/timeout-server"])
+	require.Equal(t, "1048576", annotations["haproxy-ingress.github.io/proxy-body-size"])
+	require.Equal(t, "retries 3\nretry-on conn-failure empty-response 503\noption redispatch", annotations["haproxy-ingress.github.io/config-backend"])
+
+	directive.NextCases = []string{"off"}
+	directive.MaxBodySize = 0
+
+	annotations = kubeHAProxyIngressAnnotations(directive)
+	require.Equal(t, "unlimited", annotations["haproxy-ingress.github.io/proxy-body-size"])
+	require.Equal(t, "retries 0\nretry-on none\noption redispatch", annotations["haproxy-ingress.github.io/config-backend"])
+}
+
+func TestHAProxyRetryOn(t *testing.T) {
+	tests := []struct {
+		nextCases []string
+		expected  []string
+	}{
+		{nil, []string{}},
+		{[]string{"off"}, []string{}},
+		{[]string{"error", "timeout"}, []string{"conn-failure", "empty-response", "response-timeout"}},
+		{[]string{"404", "http_500", "502"}, []string{"404", "500", "502"}},
+		{[]string{"403", "429", "non_idempotent", "503"}, []string{"503"}},
+		{[]string{"503", "http_503"}, []string{"503"}},
+		{[]string{"error", "timeout", "500", "502", "503", "504"}, []string{"all-retryable-errors"}},
+		{[]string{"error", "timeout", "500", "502", "503", "504", "404"}, []string{"all-retryable-errors", "404"}},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, haproxyRetryOn(test.nextCases), test.nextCases)
+	}
+}
+
+func TestTraefikIngressBackend(t *testing.T) {
+	ctx := context.Background()
+	directive := testHostnameDirective(t)
+	directive.TLS = ctypes.HostnameTLS{ClusterIssuer: "letsencrypt"}
+	ns := builder.LidNS(directive.LeaseID)
+
+	c := newIngressTestClient(t, IngressConfig{Backend: IngressBackendTraefik})
+	require.NoError(t, c.ConnectHostnameToDeployment(ctx, directive))
+
+	route, err := c.dc.Resource(traefikIngressRouteResource).Namespace(ns).Get(ctx, directive.Hostname, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, akashIngressClassName, route.GetAnnotations()["kubernetes.io/ingress.class"])
+	require.Equal(t, directive.LeaseID.String(), route.GetAnnotations()[builder.AkashLeaseAnnotation])
+
+	routes, _, _ := unstructured.NestedSlice(route.Object, "spec", "routes")
+	require.Len(t, routes, 1)
+	middlewares, _, _ := unstructured.NestedSlice(routes[0].(map[string]interface{}), "middlewares")
+	require.Len(t, middlewares, 2)
+
+	retry, err := c.dc.Resource(traefikMiddlewareResource).Namespace(ns).Get(ctx, directive.Hostname+traefikRetrySuffix, metav1.GetOptions{})
+	require.NoError(t, err)
+	attempts, _, _ := unstructured.NestedInt64(retry.Object, "spec", "retry", "attempts")
+	require.Equal(t, int64(3), attempts)
+
+	transport, err := c.dc.Resource(traefikServersTransportResource).Namespace(ns).Get(ctx, directive.Hostname, metav1.GetOptions{})
+	require.NoError(t, err)
+	timeout, _, _ := unstructured.NestedString(transport.Object, "spec", "forwardingTimeouts", "responseHeaderTimeout")
+	require.Equal(t, "60000ms", timeout)
+
+	_, err = c.dc.Resource(certificateResource).Namespace(ns).Get(ctx, hostnameTLSSecretName(directive.Hostname), metav1.GetOptions{})
+	require.NoError(t, err)
+
+	secretName, err := c.ingress.tlsSecretName(ctx, directive.LeaseID, directive.Hostname)
+	require.NoError(t, err)
+	require.Equal(t, hostnameTLSSecretName(directive.Hostname), secretName)
+
+	// retries disabled by the service remove the middleware
+	directive.NextCases = []string{"off"}
+	require.NoError(t, c.ConnectHostnameToDeployment(ctx, directive))
+	_, err = c.dc.Resource(traefikMiddlewareResource).Namespace(ns).Get(ctx, directive.Hostname+traefikRetrySuffix, metav1.GetOptions{})
+	require.Error(t, err)
+
+	connections, err := c.GetHostnameDeploymentConnections(ctx)
+	require.NoError(t, err)
+	require.Len(t, connections, 1)
+	require.Equal(t, directive.Hostname, connections[0].GetHostname())
+	require.Equal(t, directive.ServiceName, connections[0].GetServiceName())
+	require.Equal(t, directive.ServicePort, connections[0].GetExternalPort())
+	require.True(t, connections[0].GetLeaseID().Equals(directive.LeaseID))
+
+	require.NoError(t, c.RemoveHostnameFromDeployment(ctx, directive.Hostname, directive.LeaseID, false))
+	for _, gvr := range []schema.GroupVersionResource{traefikIngressRouteResource, traefikMiddlewareResource, traefikServersTransportResource, certificateResource} {
+		list, err := c.dc.Resource(gvr).Namespace(ns).List(ctx, metav1.ListOptions{})
+		require.NoError(t, err)
+		require.Empty(t, list.Items, gvr.Resource)
+	}
+}
+
+func TestGatewayIngressBackend(t *testing.T) {
+	ctx := context.Background()
+	directive := testHostnameDirective(t)
+	ns := builder.LidNS(directive.LeaseID)
+
+	c := newIngressTestClient(t, IngressConfig{Backend: IngressBackendGatewayAPI, Gateway: "gateway/akash"})
+	require.NoError(t, c.ConnectHostnameToDeployment(ctx, directive))
+
+	route, err := c.dc.Resource(httpRouteResource).Namespace(ns).Get(ctx, directive.Hostname, metav1.GetOptions{})
+	require.NoError(t, err)
+
+	parents, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	require.Equal(t, []interface{}{map[string]interface{}{"name": "akash", "namespace": "gateway"}}, parents)
+	hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	require.Equal(t, []string{directive.Hostname}, hostnames)
+	require.Equal(t, directive.LeaseID.String(), route.GetAnnotations()[builder.AkashLeaseAnnotation])
+
+	connections, err := c.GetHostnameDeploymentConnections(ctx)
+	require.NoError(t, err)
+	require.Len(t, connections, 1)
+	require.Equal(t, directive.Hostname, connections[0].GetHostname())
+	require.Equal(t, directive.ServicePort, connections[0].GetExternalPort())
+
+	// another lease does not own the route
+	require.NoError(t, c.RemoveHostnameFromDeployment(ctx, directive.Hostname, testutil.LeaseID(t), true))
+	_, err = c.dc.Resource(httpRouteResource).Namespace(ns).Get(ctx, directive.Hostname, metav1.GetOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, c.RemoveHostnameFromDeployment(ctx, directive.Hostname, directive.LeaseID, false))
+	_, err = c.dc.Resource(httpRouteResource).Namespace(ns).Get(ctx, directive.Hostname, metav1.GetOptions{})
+	require.Error(t, err)
+}
@@ -0,0 +1,134 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+	kubeclienterrors "github.com/akash-network/provider/cluster/kube/errors"
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+)
+
+var httpRouteResource = schema.GroupVersionResource{
+	Group:    "gateway.networking.k8s.io",
+	Version:  "v1",
+	Resource: "httproutes",
+}
+
+// gatewayIngressBackend routes hostnames with Gateway API HTTPRoutes attached to a shared Gateway.
+// The Gateway must allow routes from lease namespaces. TLS terminates on the listeners of the
+// Gateway, the TLS settings of the directive are not applied.
+type gatewayIngressBackend struct {
+	c           *client
+	gatewayNS   string
+	gatewayName string
+}
+
+func (b *gatewayIngressBackend) connect(ctx context.Context, directive ctypes.ConnectHostnameToDeploymentDirective) error {
+	// HTTPRoute has no standard body size limit nor retries, only the timeout waiting for the
+	// service to respond is applied
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "HTTPRoute",
+		"spec": map[string]interface{}{
+			"parentRefs": []interface{}{
+				map[string]interface{}{
+					"name":      b.gatewayName,
+					"namespace": b.gatewayNS,
+				},
+			},
+			"hostnames": []interface{}{directive.Hostname},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"matches": []interface{}{
+						map[string]interface{}{
+							"path": map[string]interface{}{
+								"type":  "PathPrefix",
+								"value": "/",
+							},
+						},
+					},
+					"backendRefs": []interface{}{
+						map[string]interface{}{
+							"name": directive.ServiceName,
+							"port": int64(directive.ServicePort),
+						},
+					},
+					"timeouts": map[string]interface{}{
+						"backendRequest": fmt.Sprintf("%dms", directive.ReadTimeout),
+					},
+				},
+			},
+		},
+	}}
+	obj.SetName(directive.Hostname)
+	obj.SetNamespace(builder.LidNS(directive.LeaseID))
+	obj.SetLabels(routeLabels(directive.LeaseID))
+	obj.SetAnnotations(routeAnnotations(directive.LeaseID, nil))
+
+	return applyUnstructured(ctx, b.c.dc, httpRouteResource, obj)
+}
+
+func (b *gatewayIngressBackend) remove(ctx context.Context, hostname string, leaseID mtypes.LeaseID, allowMissing bool) error {
+	route, err := leaseRoute(ctx, b.c.dc, httpRouteResource, hostname, leaseID)
+	if err != nil {
+		if allowMissing && kubeErrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if route == nil {
+		return nil
+	}
+
+	return deleteUnstructured(ctx, b.c.dc, httpRouteResource, builder.LidNS(leaseID), hostname)
+}
+
+func (b *gatewayIngressBackend) connections(ctx context.Context) ([]ctypes.LeaseIDHostnameConnection, error) {
+	results := make([]ctypes.LeaseIDHostnameConnection, 0)
+
+	err := listRoutes(ctx, b.c.dc, httpRouteResource, func(leaseID mtypes.LeaseID, route *unstructured.Unstructured) error {
+		rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+		if len(rules) != 1 {
+			return fmt.Errorf("%w: invalid number of rules %d", kubeclienterrors.ErrInvalidHostnameConnection, len(rules))
+		}
+
+		rule, _ := rules[0].(map[string]interface{})
+		backends, _, _ := unstructured.NestedSlice(rule, "backendRefs")
+		if len(backends) != 1 {
+			return fmt.Errorf("%w: invalid number of backends %d", kubeclienterrors.ErrInvalidHostnameConnection, len(backends))
+		}
+
+		backend, _ := backends[0].(map[string]interface{})
+		port, valid := backendPort(backend)
+		if !valid {
+			return fmt.Errorf("%w: invalid backend port", kubeclienterrors.ErrInvalidHostnameConnection)
+		}
+		serviceName, _, _ := unstructured.NestedString(backend, "name")
+
+		results = append(results, leaseIDHostnameConnection{
+			leaseID:      leaseID,
+			hostname:     route.GetName(),
+			externalPort: port,
+			serviceName:  serviceName,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (b *gatewayIngressBackend) tlsSecretName(context.Context, mtypes.LeaseID, string) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,245 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+	kubeclienterrors "github.com/akash-network/provider/cluster/kube/errors"
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+)
+
+const (
+	traefikAPIVersion = "traefik.io/v1alpha1"
+
+	traefikBufferingSuffix = "-buffering"
+	traefikRetrySuffix     = "-retry"
+)
+
+var (
+	traefikIngressRouteResource = schema.GroupVersionResource{
+		Group:    "traefik.io",
+		Version:  "v1alpha1",
+		Resource: "ingressroutes",
+	}
+	traefikMiddlewareResource = schema.GroupVersionResource{
+		Group:    "traefik.io",
+		Version:  "v1alpha1",
+		Resource: "middlewares",
+	}
+	traefikServersTransportResource = schema.GroupVersionResource{
+		Group:    "traefik.io",
+		Version:  "v1alpha1",
+		Resource: "serverstransports",
+	}
+)
+
+// traefikIngressBackend routes hostnames with Traefik IngressRoute resources. The HTTP options
+// of the service are applied by a buffering and a retry Middleware and a ServersTransport,
+// all named after the hostname.
+type traefikIngressBackend struct {
+	c         *client
+	className string
+}
+
+func newTraefikObject(kind string, name string, leaseID mtypes.LeaseID, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": traefikAPIVersion,
+		"kind":       kind,
+		"spec":       spec,
+	}}
+	obj.SetName(name)
+	obj.SetNamespace(builder.LidNS(leaseID))
+	obj.SetLabels(routeLabels(leaseID))
+
+	return obj
+}
+
+func (b *traefikIngressBackend) connect(ctx context.Context, directive ctypes.ConnectHostnameToDeploymentDirective) error {
+	secretName, err := b.c.tlsSecret(ctx, directive)
+	if err != nil {
+		return err
+	}
+
+	if secretName != "" && directive.TLS.WildcardSecret == "" {
+		// cert-manager does not watch IngressRoutes, the certificate is requested explicitly
+		if err := applyUnstructured(ctx, b.c.dc, certificateResource, newCertificate(directive, secretName)); err != nil {
+			return err
+		}
+	}
+
+	ns := builder.LidNS(directive.LeaseID)
+	middlewares := make([]interface{}, 0, 2)
+
+	bufferingName := directive.Hostname + traefikBufferingSuffix
+	if directive.MaxBodySize > 0 {
+		err := applyUnstructured(ctx, b.c.dc, traefikMiddlewareResource, newTraefikObject("Middleware", bufferingName, directive.LeaseID, map[string]interface{}{
+			"buffering": map[string]interface{}{
+				"maxRequestBodyBytes": int64(directive.MaxBodySize),
+			},
+		}))
+		if err != nil {
+			return err
+		}
+		middlewares = append(middlewares, map[string]interface{}{"name": bufferingName})
+	} else if err := deleteUnstructured(ctx, b.c.dc, traefikMiddlewareResource, ns, bufferingName); err != nil {
+		return err
+	}
+
+	// Traefik retries on network errors only, the response codes of NextCases and NextTimeout
+	// have no equivalent
+	retryName := directive.Hostname + traefikRetrySuffix
+	if directive.NextTries > 0 && !isNextCasesOff(directive.NextCases) {
+		err := applyUnstructured(ctx, b.c.dc, traefikMiddlewareResource, newTraefikObject("Middleware", retryName, directive.LeaseID, map[string]interface{}{
+			"retry": map[string]interface{}{
+				"attempts": int64(directive.NextTries),
+			},
+		}))
+		if err != nil {
+			return err
+		}
+		middlewares = append(middlewares, map[string]interface{}{"name": retryName})
+	} else if err := deleteUnstructured(ctx, b.c.dc, traefikMiddlewareResource, ns, retryName); err != nil {
+		return err
+	}
+
+	// Traefik has no timeout between two writes to the service, SendTimeout is not applied
+	err = applyUnstructured(ctx, b.c.dc, traefikServersTransportResource, newTraefikObject("ServersTransport", directive.Hostname, directive.LeaseID, map[string]interface{}{
+		"forwardingTimeouts": map[string]interface{}{
+			"responseHeaderTimeout": fmt.Sprintf("%dms", directive.ReadTimeout),
+		},
+	}))
+	if err != nil {
+		return err
+	}
+
+	spec := map[string]interface{}{
+		"routes": []interface{}{
+			map[string]interface{}{
+				"match":       fmt.Sprintf("Host(`%s`)", directive.Hostname),
+				"kind":        "Rule",
+				"middlewares": middlewares,
+				"services": []interface{}{
+					map[string]interface{}{
+						"name":             directive.ServiceName,
+						"port":             int64(directive.ServicePort),
+						"serversTransport": directive.Hostname,
+					},
+				},
+			},
+		},
+	}
+
+	if secretName != "" {
+		spec["tls"] = map[string]interface{}{
+			"secretName": secretName,
+		}
+	}
+
+	route := newTraefikObject("IngressRoute", directive.Hostname, directive.LeaseID, spec)
+	route.SetAnnotations(routeAnnotations(directive.LeaseID, map[string]string{
+		"kubernetes.io/ingress.class": b.className,
+	}))
+
+	return applyUnstructured(ctx, b.c.dc, traefikIngressRouteResource, route)
+}
+
+func (b *traefikIngressBackend) remove(ctx context.Context, hostname string, leaseID mtypes.LeaseID, allowMissing bool) error {
+	route, err := leaseRoute(ctx, b.c.dc, traefikIngressRouteResource, hostname, leaseID)
+	if err != nil {
+		if allowMissing && kubeErrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if route == nil {
+		return nil
+	}
+
+	ns := builder.LidNS(leaseID)
+	if err := deleteUnstructured(ctx, b.c.dc, traefikIngressRouteResource, ns, hostname); err != nil {
+		return err
+	}
+
+	for _, name := range []string{hostname + traefikBufferingSuffix, hostname + traefikRetrySuffix} {
+		if err := deleteUnstructured(ctx, b.c.dc, traefikMiddlewareResource, ns, name); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteUnstructured(ctx, b.c.dc, traefikServersTransportResource, ns, hostname); err != nil {
+		return err
+	}
+
+	if secretName, _, _ := unstructured.NestedString(route.Object, "spec", "tls", "secretName"); secretName == hostnameTLSSecretName(hostname) {
+		return deleteUnstructured(ctx, b.c.dc, certificateResource, ns, secretName)
+	}
+
+	return nil
+}
+
+func (b *traefikIngressBackend) connections(ctx context.Context) ([]ctypes.LeaseIDHostnameConnection, error) {
+	results := make([]ctypes.LeaseIDHostnameConnection, 0)
+
+	err := listRoutes(ctx, b.c.dc, traefikIngressRouteResource, func(leaseID mtypes.LeaseID, route *unstructured.Unstructured) error {
+		routes, _, _ := unstructured.NestedSlice(route.Object, "spec", "routes")
+		if len(routes) != 1 {
+			return fmt.Errorf("%w: invalid number of routes %d", kubeclienterrors.ErrInvalidHostnameConnection, len(routes))
+		}
+
+		rule, _ := routes[0].(map[string]interface{})
+		services, _, _ := unstructured.NestedSlice(rule, "services")
+		if len(services) != 1 {
+			return fmt.Errorf("%w: invalid number of services %d", kubeclienterrors.ErrInvalidHostnameConnection, len(services))
+		}
+
+		service, _ := services[0].(map[string]interface{})
+		port, valid := backendPort(service)
+		if !valid {
+			return fmt.Errorf("%w: invalid service port", kubeclienterrors.ErrInvalidHostnameConnection)
+		}
+		serviceName, _, _ := unstructured.NestedString(service, "name")
+
+		results = append(results, leaseIDHostnameConnection{
+			leaseID:      leaseID,
+			hostname:     route.GetName(),
+			externalPort: port,
+			serviceName:  serviceName,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (b *traefikIngressBackend) tlsSecretName(ctx context.Context, leaseID mtypes.LeaseID, hostname string) (string, error) {
+	route, err := leaseRoute(ctx, b.c.dc, traefikIngressRouteResource, hostname, leaseID)
+	if err != nil || route == nil {
+		return "", err
+	}
+
+	secretName, _, _ := unstructured.NestedString(route.Object, "spec", "tls", "secretName")
+	return secretName, nil
+}
+
+// isNextCasesOff is true when the service disabled passing requests to the next upstream
+func isNextCasesOff(cases []string) bool {
+	for _, v := range cases {
+		if v == "off" {
+			return true
+		}
+	}
+
+	return false
+}
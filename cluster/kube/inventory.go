@@ -21,6 +21,7 @@ import (
 
 	"github.com/akash-network/provider/cluster/kube/builder"
 	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+	clusterUtil "github.com/akash-network/provider/cluster/util"
 )
 
 const (
@@ -83,6 +84,17 @@ func (nd *node) allowsStorageClasses(volumes types.Volumes) bool {
 	return true
 }
 
+// allowsArch checks if the node runs the CPU architecture the resources have been requested for.
+// resources without an arch requirement are allowed on any node
+func (nd *node) allowsArch(res types.ResourceUnits) bool {
+	arch, set := clusterUtil.GetCPUArch(res)
+	if !set {
+		return true
+	}
+
+	return nd.arch == arch
+}
+
 func (inv *inventory) Adjust(reservation ctypes.Reservation) error {
 	resources := make([]types.Resources, len(reservation.Resources().GetResources()))
 	copy(resources, reservation.Resources().GetResources())
@@ -93,6 +105,13 @@ nodes:
 	for nodeName := range currInventory.nodes {
 		for i := len(resources) - 1; i >= 0; i-- {
 			res := resources[i].Resources
+
+			// node of a different architecture can't run this group of replicas
+			// but still may fit the rest of the resources
+			if !currInventory.nodes[nodeName].allowsArch(res) {
+				continue
+			}
+
 			for ; resources[i].Count > 0; resources[i].Count-- {
 				nd := currInventory.nodes[nodeName]
 
@@ -198,6 +217,7 @@ func (inv *inventory) Metrics() ctypes.InventoryMetrics {
 	for nodeName, nd := range inv.nodes {
 		invNode := ctypes.InventoryNode{
 			Name: nodeName,
+			Arch: nd.arch,
 			Allocatable: ctypes.InventoryNodeMetric{
 				CPU:              uint64(nd.cpu.allocatable.MilliValue()),
 				Memory:           uint64(nd.memory.allocatable.Value()),
@@ -626,3 +626,56 @@ func multipleReplicasGenReservations(cpuUnits uint64, count uint32) *testReserva
 		},
 	}
 }
+
+func TestInventoryArchMismatch(t *testing.T) {
+	s := makeInventoryScaffold()
+
+	nodes := multipleReplicasGenNodes()
+	for i := range nodes {
+		nodes[i].Status.NodeInfo.Architecture = "amd64"
+	}
+	nodes[3].Status.NodeInfo.Architecture = "arm64"
+
+	nodeList := &v1.NodeList{
+		Items: nodes,
+	}
+
+	podList := &v1.PodList{Items: []v1.Pod{}}
+
+	s.nodeInterfaceMock.On("List", mock.Anything, mock.Anything).Return(nodeList, nil)
+	s.podInterfaceMock.On("List", mock.Anything, mock.Anything).Return(podList, nil)
+
+	clientInterface := clientForTest(t, s.kmock, s.amock)
+	inv, err := clientInterface.Inventory(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, inv)
+
+	archReservation := func(arch string, cpuUnits uint64, count uint32) *testReservation {
+		res := multipleReplicasGenReservations(cpuUnits, count)
+		res.resources.Resources[0].Resources.CPU.Attributes = atypes.Attributes{
+			{Key: "arch", Value: arch},
+		}
+		return res
+	}
+
+	// only one arm64 node, so the second replica can't be placed
+	err = inv.Adjust(archReservation("arm64", 100000, 2))
+	require.ErrorIs(t, err, ctypes.ErrInsufficientCapacity)
+
+	err = inv.Adjust(archReservation("arm64", 100000, 1))
+	require.NoError(t, err)
+
+	// no riscv64 nodes at all
+	err = inv.Adjust(archReservation("riscv64", 1000, 1))
+	require.ErrorIs(t, err, ctypes.ErrInsufficientCapacity)
+
+	// the biggest amd64 node has 119525m
+	err = inv.Adjust(archReservation("amd64", 119525, 1))
+	require.NoError(t, err)
+
+	archs := make(map[string]int)
+	for _, nd := range inv.Metrics().Nodes {
+		archs[nd.Arch]++
+	}
+	require.Equal(t, map[string]int{"amd64": 3, "arm64": 1}, archs)
+}
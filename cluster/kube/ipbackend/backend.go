@@ -0,0 +1,352 @@
+package ipbackend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	manifest "github.com/akash-network/node/manifest/v2beta1"
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/pager"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+	"github.com/akash-network/provider/cluster/kube/clientcommon"
+	kubeclienterrors "github.com/akash-network/provider/cluster/kube/errors"
+	"github.com/akash-network/provider/cluster/types/v1beta2"
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+)
+
+const (
+	BackendMetalLB    = "metallb"
+	BackendStaticPool = "static-pool"
+
+	// IPv6CompanionLabelName marks the service requesting the IPv6 address of a dual-stack endpoint.
+	// A load balancer assigns all the addresses of a service from one pool, so each family gets its
+	// own service when the families are served by separate pools.
+	IPv6CompanionLabelName = "akash.network/ipv6-companion"
+
+	// IPv6CompanionSuffix is appended to the name of the service an IPv6 companion accompanies
+	IPv6CompanionSuffix = "-ipv6"
+)
+
+var (
+	ErrIPBackend           = errors.New("ip backend error")
+	ErrInvalidLeaseService = fmt.Errorf("%w: lease service error", ErrIPBackend)
+)
+
+// Client assigns the leased IPs of the deployments through LoadBalancer services
+type Client interface {
+	GetIPAddressUsage(ctx context.Context) (uint, uint, error)
+	// GetIPv6AddressUsage returns zeroes when no IPv6 pool is configured
+	GetIPv6AddressUsage(ctx context.Context) (uint, uint, error)
+	GetIPAddressStatusForLease(ctx context.Context, leaseID mtypes.LeaseID) ([]v1beta2.IPLeaseState, error)
+
+	CreateIPPassthrough(ctx context.Context, directive ctypes.ClusterIPPassthroughDirective) error
+	PurgeIPPassthrough(ctx context.Context, directive ctypes.ClusterIPPassthroughDirective) error
+	GetIPPassthroughs(ctx context.Context) ([]v1beta2.IPPassthrough, error)
+	DetectPoolChanges(ctx context.Context) (<-chan struct{}, error)
+
+	Stop()
+}
+
+// ResourceName is the name of the service exposing the port of a directive
+func ResourceName(directive ctypes.ClusterIPPassthroughDirective) string {
+	return strings.ToLower(fmt.Sprintf("%s-ip-%d-%v", directive.ServiceName, directive.ExternalPort, directive.Protocol))
+}
+
+// ResourceNameIPv6 is the name of the service requesting the IPv6 address of a directive
+func ResourceNameIPv6(directive ctypes.ClusterIPPassthroughDirective) string {
+	return ResourceName(directive) + IPv6CompanionSuffix
+}
+
+// LoadBalancerService returns the service exposing the port of a directive, backends add the annotations
+// requesting the address
+func LoadBalancerService(directive ctypes.ClusterIPPassthroughDirective, annotations map[string]string) (*corev1.Service, error) {
+	var proto corev1.Protocol
+
+	switch directive.Protocol {
+	case manifest.TCP:
+		proto = corev1.ProtocolTCP
+	case manifest.UDP:
+		proto = corev1.ProtocolUDP
+	default:
+		return nil, fmt.Errorf("%w unknown protocol %v", kubeclienterrors.ErrInternalError, directive.Protocol)
+	}
+
+	portName := ResourceName(directive)
+
+	labels := make(map[string]string)
+	builder.AppendLeaseLabels(directive.LeaseID, labels)
+	labels[builder.AkashManagedLabelName] = "true"
+	// all backends keep the label of the first one, so services are found after switching backend
+	labels[builder.AkashServiceTarget] = builder.AkashMetalLB
+
+	selector := map[string]string{
+		builder.AkashManagedLabelName:         "true",
+		builder.AkashManifestServiceLabelName: directive.ServiceName,
+	}
+
+	port := corev1.ServicePort{
+		Name:       portName,
+		Protocol:   proto,
+		Port:       int32(directive.ExternalPort),
+		TargetPort: intstr.FromInt(int(directive.Port)),
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        portName,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				port,
+			},
+			Selector: selector,
+			Type:     corev1.ServiceTypeLoadBalancer,
+		},
+		Status: corev1.ServiceStatus{},
+	}, nil
+}
+
+// LoadBalancerServiceIPv6 returns the IPv6 companion of the service exposing the port of a directive
+func LoadBalancerServiceIPv6(directive ctypes.ClusterIPPassthroughDirective, annotations map[string]string) (*corev1.Service, error) {
+	svc, err := LoadBalancerService(directive, annotations)
+	if err != nil {
+		return nil, err
+	}
+
+	svc.Name = ResourceNameIPv6(directive)
+	svc.Labels[IPv6CompanionLabelName] = "true"
+	SetIPFamily(svc, corev1.IPv6Protocol)
+
+	return svc, nil
+}
+
+// SetIPFamily makes svc a single stack service of the given family
+func SetIPFamily(svc *corev1.Service, family corev1.IPFamily) {
+	policy := corev1.IPFamilyPolicySingleStack
+	svc.Spec.IPFamilyPolicy = &policy
+	svc.Spec.IPFamilies = []corev1.IPFamily{family}
+}
+
+// ServiceIPFamily returns the family of a single stack service, services created before the family
+// was set are IPv4
+func ServiceIPFamily(svc *corev1.Service) corev1.IPFamily {
+	if len(svc.Spec.IPFamilies) == 0 {
+		return corev1.IPv4Protocol
+	}
+
+	return svc.Spec.IPFamilies[0]
+}
+
+// IsIPv6Companion is true for the services requesting the IPv6 address of a dual-stack endpoint
+func IsIPv6Companion(svc *corev1.Service) bool {
+	return svc.Labels[IPv6CompanionLabelName] == "true"
+}
+
+// EachService calls fn for the leased IP services in namespace ns, or in all namespaces if it is empty
+func EachService(ctx context.Context, kc kubernetes.Interface, ns string, fn func(service *corev1.Service) error) error {
+	servicePager := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		return kc.CoreV1().Services(ns).List(ctx, opts)
+	})
+
+	labelSelector := fmt.Sprintf("%s=true,%s=%s", builder.AkashManagedLabelName, builder.AkashServiceTarget, builder.AkashMetalLB)
+
+	return servicePager.EachListItem(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector,
+	},
+		func(obj runtime.Object) error {
+			return fn(obj.(*corev1.Service))
+		})
+}
+
+// LeaseStates returns the state of the services of a lease, ipOf returns the address assigned to a service.
+// The address of an IPv6 companion is reported on the state of the service it accompanies.
+func LeaseStates(ctx context.Context, kc kubernetes.Interface, leaseID mtypes.LeaseID, sharingKeyAnnotation string, ipOf func(service *corev1.Service) (string, error)) ([]v1beta2.IPLeaseState, error) {
+	type assigned struct {
+		service *corev1.Service
+		ip      string
+	}
+
+	services := make([]assigned, 0)
+	ipv6 := make(map[string]string)
+
+	err := EachService(ctx, kc, builder.LidNS(leaseID), func(service *corev1.Service) error {
+		ip, err := ipOf(service)
+		if err != nil {
+			return err
+		}
+
+		if IsIPv6Companion(service) {
+			ipv6[strings.TrimSuffix(service.Name, IPv6CompanionSuffix)] = ip
+			return nil
+		}
+
+		if len(service.Spec.Ports) != 1 {
+			return fmt.Errorf("%w: service %q has %d port specs and is invalid", ErrInvalidLeaseService, service.ObjectMeta.Name, len(service.Spec.Ports))
+		}
+
+		services = append(services, assigned{service: service, ip: ip})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]v1beta2.IPLeaseState, 0, len(services))
+	for _, entry := range services {
+		service := entry.service
+		port := service.Spec.Ports[0]
+
+		proto, err := manifest.ServiceProtocolFromKube(port.Protocol)
+		if err != nil {
+			return nil, fmt.Errorf("%w: service %q has invalid protocol %v", ErrInvalidLeaseService, service.ObjectMeta.Name, err)
+		}
+
+		selectedServiceName := service.Spec.Selector[builder.AkashManifestServiceLabelName]
+		// Note: don't care about node port here, even if it is assigned
+		// Note: service.Name is a procedurally generated thing that doesn't mean anything to the end user
+		result = append(result, ipLeaseState{
+			leaseID:      leaseID,
+			ip:           entry.ip,
+			ipv6:         ipv6[service.Name],
+			serviceName:  selectedServiceName,
+			externalPort: uint32(port.Port),
+			port:         uint32(port.TargetPort.IntValue()),
+			sharingKey:   service.ObjectMeta.Annotations[sharingKeyAnnotation],
+			protocol:     proto,
+		})
+	}
+
+	return result, nil
+}
+
+// Passthrough returns the passthrough a service was created for, nil for services not belonging to a lease
+// and for IPv6 companions
+func Passthrough(service *corev1.Service, sharingKeyAnnotation string) (v1beta2.IPPassthrough, error) {
+	_, hasOwner := service.ObjectMeta.Labels[builder.AkashLeaseOwnerLabelName]
+	if !hasOwner {
+		// Not a service related to a running deployment, so probably internal services
+		return nil, nil
+	}
+
+	if IsIPv6Companion(service) {
+		// Accompanies the service the passthrough was created for
+		return nil, nil
+	}
+
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return nil, fmt.Errorf("%w: resource %q wrong type in service definition %v", ErrIPBackend, service.ObjectMeta.Name, service.Spec.Type)
+	}
+
+	ports := service.Spec.Ports
+	const expectedNumberOfPorts = 1
+	if len(ports) != expectedNumberOfPorts {
+		return nil, fmt.Errorf("%w: resource %q  wrong number of ports in load balancer service definition. expected %d, got %d", ErrIPBackend, service.ObjectMeta.Name, expectedNumberOfPorts, len(ports))
+	}
+
+	portDefn := ports[0]
+	proto := portDefn.Protocol
+	port := portDefn.Port
+
+	leaseID, err := clientcommon.RecoverLeaseIDFromLabels(service.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("%w: service %q has invalid leease labels %v", err, service.ObjectMeta.Name, service.Labels)
+	}
+
+	mproto, err := manifest.ServiceProtocolFromKube(proto)
+	if err != nil {
+		return nil, fmt.Errorf("%w: service %q has invalid protocol %v", err, service.ObjectMeta.Name, proto)
+	}
+
+	serviceSelector := service.Spec.Selector
+	serviceName := serviceSelector[builder.AkashManifestServiceLabelName]
+	if len(serviceName) == 0 {
+		return nil, fmt.Errorf("%w: service has empty selector", ErrIPBackend)
+	}
+
+	return ipPassthrough{
+		lID:          leaseID,
+		serviceName:  serviceName,
+		externalPort: uint32(port),
+		sharingKey:   service.ObjectMeta.Annotations[sharingKeyAnnotation],
+		protocol:     mproto,
+	}, nil
+}
+
+type ipLeaseState struct {
+	leaseID      mtypes.LeaseID
+	ip           string
+	ipv6         string
+	serviceName  string
+	externalPort uint32
+	port         uint32
+	sharingKey   string
+	protocol     manifest.ServiceProtocol
+}
+
+func (ipls ipLeaseState) GetLeaseID() mtypes.LeaseID {
+	return ipls.leaseID
+}
+func (ipls ipLeaseState) GetIP() string {
+	return ipls.ip
+}
+func (ipls ipLeaseState) GetIPv6() string {
+	return ipls.ipv6
+}
+func (ipls ipLeaseState) GetServiceName() string {
+	return ipls.serviceName
+}
+func (ipls ipLeaseState) GetExternalPort() uint32 {
+	return ipls.externalPort
+}
+func (ipls ipLeaseState) GetPort() uint32 {
+	return ipls.port
+}
+func (ipls ipLeaseState) GetSharingKey() string {
+	return ipls.sharingKey
+}
+func (ipls ipLeaseState) GetProtocol() manifest.ServiceProtocol {
+	return ipls.protocol
+}
+
+type ipPassthrough struct {
+	lID          mtypes.LeaseID
+	serviceName  string
+	port         uint32
+	externalPort uint32
+	sharingKey   string
+	protocol     manifest.ServiceProtocol
+}
+
+func (ev ipPassthrough) GetLeaseID() mtypes.LeaseID {
+	return ev.lID
+}
+
+func (ev ipPassthrough) GetServiceName() string {
+	return ev.serviceName
+}
+
+func (ev ipPassthrough) GetPort() uint32 {
+	return ev.port
+}
+
+func (ev ipPassthrough) GetExternalPort() uint32 {
+	return ev.externalPort
+}
+
+func (ev ipPassthrough) GetSharingKey() string {
+	return ev.sharingKey
+}
+
+func (ev ipPassthrough) GetProtocol() manifest.ServiceProtocol {
+	return ev.protocol
+}
@@ -0,0 +1,468 @@
+package ippool
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+	"github.com/tendermint/tendermint/libs/log"
+	corev1 "k8s.io/api/core/v1"
+	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+	"github.com/akash-network/provider/cluster/kube/clientcommon"
+	"github.com/akash-network/provider/cluster/kube/ipbackend"
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+	akashtypes "github.com/akash-network/provider/pkg/apis/akash.network/v2beta1"
+	akashclient "github.com/akash-network/provider/pkg/client/clientset/versioned"
+)
+
+const (
+	// AssignmentLoadBalancerIP requests the address through Service.spec.loadBalancerIP
+	AssignmentLoadBalancerIP = "load-balancer-ip"
+	// AssignmentKubeVIP requests the address through the annotation of kube-vip
+	AssignmentKubeVIP = "kube-vip"
+	// AssignmentCilium requests the address through the annotations of Cilium LB-IPAM
+	AssignmentCilium = "cilium"
+
+	sharingKeyAnnotation    = "akash.network/ip-sharing-key"
+	kubeVIPAnnotation       = "kube-vip.io/loadbalancerIPs"
+	ciliumIPsAnnotation     = "io.cilium/lb-ipam-ips"
+	ciliumSharingAnnotation = "io.cilium/lb-ipam-sharing-key"
+)
+
+var (
+	errStaticPool    = fmt.Errorf("%w: static pool", ipbackend.ErrIPBackend)
+	ErrInvalidConfig = fmt.Errorf("%w: invalid config", errStaticPool)
+	ErrPoolExhausted = fmt.Errorf("%w: no free address left", errStaticPool)
+)
+
+type Config struct {
+	// Pools are the CIDRs the addresses are allocated from, endpoints are dual-stack when both IPv4
+	// and IPv6 CIDRs are given
+	Pools []string
+	// Assignment is how services request the allocated address
+	Assignment string
+	// Namespace holds the allocations
+	Namespace string
+}
+
+type client struct {
+	kube kubernetes.Interface
+	ac   akashclient.Interface
+	log  log.Logger
+
+	pools      map[corev1.IPFamily][]*net.IPNet
+	families   []corev1.IPFamily
+	assignment string
+	ns         string
+}
+
+func (c *client) String() string {
+	return fmt.Sprintf("static IP pool client %p", c)
+}
+
+func NewClient(configPath string, logger log.Logger, cfg Config) (ipbackend.Client, error) {
+	config, err := clientcommon.OpenKubeConfig(configPath, logger)
+	if err != nil {
+		return nil, fmt.Errorf("%w: creating kubernetes client", err)
+	}
+	config.RateLimiter = flowcontrol.NewFakeAlwaysRateLimiter()
+
+	kc, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: creating kubernetes client", err)
+	}
+
+	ac, err := akashclient.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: creating akash client", err)
+	}
+
+	return newClient(kc, ac, logger, cfg)
+}
+
+func newClient(kc kubernetes.Interface, ac akashclient.Interface, logger log.Logger, cfg Config) (*client, error) {
+	if len(cfg.Pools) == 0 {
+		return nil, fmt.Errorf("%w: no pool configured", ErrInvalidConfig)
+	}
+
+	pools := make(map[corev1.IPFamily][]*net.IPNet)
+	for _, cidr := range cfg.Pools {
+		_, pool, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("%w: pool %q: %s", ErrInvalidConfig, cidr, err)
+		}
+		family := addressFamily(pool.IP)
+		pools[family] = append(pools[family], pool)
+	}
+
+	// The first family is the one of the service named after the directive, IPv4 when both are pooled
+	families := make([]corev1.IPFamily, 0, 2)
+	for _, family := range []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol} {
+		if len(pools[family]) != 0 {
+			families = append(families, family)
+		}
+	}
+
+	switch cfg.Assignment {
+	case "":
+		cfg.Assignment = AssignmentLoadBalancerIP
+	case AssignmentLoadBalancerIP, AssignmentKubeVIP, AssignmentCilium:
+	default:
+		return nil, fmt.Errorf("%w: unknown assignment %q", ErrInvalidConfig, cfg.Assignment)
+	}
+
+	return &client{
+		kube:       kc,
+		ac:         ac,
+		log:        logger.With("client", "static-pool"),
+		pools:      pools,
+		families:   families,
+		assignment: cfg.Assignment,
+		ns:         cfg.Namespace,
+	}, nil
+}
+
+func (c *client) Stop() {}
+
+// DetectPoolChanges never signals, the pools are only changed by restarting the operator
+func (c *client) DetectPoolChanges(ctx context.Context) (<-chan struct{}, error) {
+	output := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		close(output)
+	}()
+
+	return output, nil
+}
+
+func (c *client) GetIPAddressUsage(ctx context.Context) (uint, uint, error) {
+	return c.usage(ctx, corev1.IPv4Protocol)
+}
+
+func (c *client) GetIPv6AddressUsage(ctx context.Context) (uint, uint, error) {
+	return c.usage(ctx, corev1.IPv6Protocol)
+}
+
+func (c *client) usage(ctx context.Context, family corev1.IPFamily) (uint, uint, error) {
+	allocations, err := c.ac.AkashV2beta1().ProviderIPAllocations(c.ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return math.MaxUint32, math.MaxUint32, err
+	}
+
+	inUse := uint(0)
+	for _, allocation := range allocations.Items {
+		if addressFamily(net.ParseIP(allocation.Spec.IP)) == family {
+			inUse++
+		}
+	}
+
+	total := uint64(0)
+	for _, pool := range c.pools[family] {
+		total += poolSize(pool)
+		if total >= math.MaxUint32 {
+			total = math.MaxUint32
+			break
+		}
+	}
+
+	return inUse, uint(total), nil
+}
+
+func (c *client) GetIPAddressStatusForLease(ctx context.Context, leaseID mtypes.LeaseID) ([]ctypes.IPLeaseState, error) {
+	allocations, err := c.allocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return ipbackend.LeaseStates(ctx, c.kube, leaseID, sharingKeyAnnotation, func(service *corev1.Service) (string, error) {
+		sharingKey := service.ObjectMeta.Annotations[sharingKeyAnnotation]
+		allocation, exists := allocations[allocationKey(sharingKey, ipbackend.ServiceIPFamily(service))]
+		if !exists {
+			return "", fmt.Errorf("%w: service %q has no address allocated", ipbackend.ErrInvalidLeaseService, service.ObjectMeta.Name)
+		}
+
+		return allocation.Spec.IP, nil
+	})
+}
+
+func (c *client) GetIPPassthroughs(ctx context.Context) ([]ctypes.IPPassthrough, error) {
+	result := make([]ctypes.IPPassthrough, 0)
+	err := ipbackend.EachService(ctx, c.kube, metav1.NamespaceAll, func(service *corev1.Service) error {
+		v, err := ipbackend.Passthrough(service, sharingKeyAnnotation)
+		if err != nil || v == nil {
+			return err
+		}
+
+		result = append(result, v)
+		return nil
+	})
+
+	return result, err
+}
+
+func (c *client) CreateIPPassthrough(ctx context.Context, directive ctypes.ClusterIPPassthroughDirective) error {
+	for i, family := range c.families {
+		allocation, err := c.allocate(ctx, directive, family)
+		if err != nil {
+			return err
+		}
+
+		annotations := map[string]string{
+			sharingKeyAnnotation: directive.SharingKey,
+		}
+
+		var svc *corev1.Service
+		if i == 0 {
+			svc, err = ipbackend.LoadBalancerService(directive, annotations)
+		} else {
+			svc, err = ipbackend.LoadBalancerServiceIPv6(directive, annotations)
+		}
+		if err != nil {
+			return err
+		}
+		ipbackend.SetIPFamily(svc, family)
+
+		switch c.assignment {
+		case AssignmentKubeVIP:
+			annotations[kubeVIPAnnotation] = allocation.Spec.IP
+		case AssignmentCilium:
+			annotations[ciliumIPsAnnotation] = allocation.Spec.IP
+			annotations[ciliumSharingAnnotation] = directive.SharingKey
+		default:
+			svc.Spec.LoadBalancerIP = allocation.Spec.IP
+		}
+
+		err = c.applyService(ctx, directive, svc, allocation.Spec.IP)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *client) applyService(ctx context.Context, directive ctypes.ClusterIPPassthroughDirective, svc *corev1.Service, ip string) error {
+	ns := builder.LidNS(directive.LeaseID)
+	foundEntry, err := c.kube.CoreV1().Services(ns).Get(ctx, svc.Name, metav1.GetOptions{})
+
+	exists := true
+	if err != nil {
+		if !kubeErrors.IsNotFound(err) {
+			return err
+		}
+		exists = false
+	}
+
+	c.log.Debug("creating static pool service",
+		"service", directive.ServiceName,
+		"port", directive.Port,
+		"external-port", directive.ExternalPort,
+		"sharing-key", directive.SharingKey,
+		"ip", ip,
+		"exists", exists)
+	if exists {
+		svc.ResourceVersion = foundEntry.ResourceVersion
+		_, err = c.kube.CoreV1().Services(ns).Update(ctx, svc, metav1.UpdateOptions{})
+	} else {
+		_, err = c.kube.CoreV1().Services(ns).Create(ctx, svc, metav1.CreateOptions{})
+	}
+
+	return err
+}
+
+func (c *client) PurgeIPPassthrough(ctx context.Context, directive ctypes.ClusterIPPassthroughDirective) error {
+	ns := builder.LidNS(directive.LeaseID)
+	for _, resourceName := range []string{ipbackend.ResourceName(directive), ipbackend.ResourceNameIPv6(directive)} {
+		err := c.kube.CoreV1().Services(ns).Delete(ctx, resourceName, metav1.DeleteOptions{})
+		if err != nil && !kubeErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	// the addresses are released once no service shares them anymore
+	inUse := false
+	err := ipbackend.EachService(ctx, c.kube, metav1.NamespaceAll, func(service *corev1.Service) error {
+		if service.ObjectMeta.Annotations[sharingKeyAnnotation] == directive.SharingKey {
+			inUse = true
+		}
+		return nil
+	})
+	if err != nil || inUse {
+		return err
+	}
+
+	allocations, err := c.allocations(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, family := range c.families {
+		allocation, exists := allocations[allocationKey(directive.SharingKey, family)]
+		if !exists {
+			continue
+		}
+
+		c.log.Info("releasing address", "ip", allocation.Spec.IP, "sharing-key", directive.SharingKey)
+		err = c.ac.AkashV2beta1().ProviderIPAllocations(c.ns).Delete(ctx, allocation.Name, metav1.DeleteOptions{})
+		if err != nil && !kubeErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// allocations returns the allocations by sharing key and address family
+func (c *client) allocations(ctx context.Context) (map[string]akashtypes.ProviderIPAllocation, error) {
+	list, err := c.ac.AkashV2beta1().ProviderIPAllocations(c.ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]akashtypes.ProviderIPAllocation, len(list.Items))
+	for _, item := range list.Items {
+		result[allocationKey(item.Spec.SharingKey, addressFamily(net.ParseIP(item.Spec.IP)))] = item
+	}
+
+	return result, nil
+}
+
+// allocate returns the allocation of the sharing key of the directive in the given family, taking the first
+// free address of the pools of the family if there is none yet
+func (c *client) allocate(ctx context.Context, directive ctypes.ClusterIPPassthroughDirective, family corev1.IPFamily) (akashtypes.ProviderIPAllocation, error) {
+	allocations, err := c.allocations(ctx)
+	if err != nil {
+		return akashtypes.ProviderIPAllocation{}, err
+	}
+
+	if allocation, exists := allocations[allocationKey(directive.SharingKey, family)]; exists {
+		return allocation, nil
+	}
+
+	used := make(map[string]struct{}, len(allocations))
+	for _, allocation := range allocations {
+		used[allocation.Spec.IP] = struct{}{}
+	}
+
+	labels := map[string]string{
+		builder.AkashManagedLabelName: "true",
+	}
+	builder.AppendLeaseLabels(directive.LeaseID, labels)
+
+	for _, pool := range c.pools[family] {
+		for ip := firstAddress(pool); ip != nil; ip = nextAddress(pool, ip) {
+			if _, taken := used[ip.String()]; taken {
+				continue
+			}
+
+			obj := &akashtypes.ProviderIPAllocation{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   allocationName(ip),
+					Labels: labels,
+				},
+				Spec: akashtypes.ProviderIPAllocationSpec{
+					LeaseID:    akashtypes.LeaseIDFromAkash(directive.LeaseID),
+					SharingKey: directive.SharingKey,
+					IP:         ip.String(),
+				},
+			}
+
+			// the name of the allocation is derived from the address, so concurrent allocations
+			// of an address fail to create it
+			result, err := c.ac.AkashV2beta1().ProviderIPAllocations(c.ns).Create(ctx, obj, metav1.CreateOptions{})
+			if err != nil {
+				if kubeErrors.IsAlreadyExists(err) {
+					continue
+				}
+				return akashtypes.ProviderIPAllocation{}, err
+			}
+
+			c.log.Info("allocated address", "ip", obj.Spec.IP, "lease", directive.LeaseID, "sharing-key", directive.SharingKey)
+			return *result, nil
+		}
+	}
+
+	return akashtypes.ProviderIPAllocation{}, fmt.Errorf("%w: %s", ErrPoolExhausted, family)
+}
+
+func allocationKey(sharingKey string, family corev1.IPFamily) string {
+	return fmt.Sprintf("%s/%s", sharingKey, family)
+}
+
+func addressFamily(ip net.IP) corev1.IPFamily {
+	if ip.To4() != nil {
+		return corev1.IPv4Protocol
+	}
+
+	return corev1.IPv6Protocol
+}
+
+func allocationName(ip net.IP) string {
+	return "ip-" + strings.NewReplacer(".", "-", ":", "-").Replace(ip.String())
+}
+
+// hasNetworkAddresses is true for IPv4 pools where the network and broadcast addresses are reserved
+func hasNetworkAddresses(pool *net.IPNet) bool {
+	ones, bits := pool.Mask.Size()
+	return bits == 8*net.IPv4len && ones < 31
+}
+
+func poolSize(pool *net.IPNet) uint64 {
+	ones, bits := pool.Mask.Size()
+	if bits-ones >= 64 {
+		return math.MaxUint64
+	}
+
+	size := uint64(1) << uint(bits-ones)
+	if hasNetworkAddresses(pool) {
+		size -= 2
+	}
+
+	return size
+}
+
+func firstAddress(pool *net.IPNet) net.IP {
+	ip := pool.IP.Mask(pool.Mask)
+	if hasNetworkAddresses(pool) {
+		return nextAddress(pool, ip)
+	}
+
+	return ip
+}
+
+// nextAddress returns the address after ip in the pool, nil once the pool is exhausted
+func nextAddress(pool *net.IPNet, ip net.IP) net.IP {
+	result := make(net.IP, len(ip))
+	copy(result, ip)
+
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i]++
+		if result[i] != 0 {
+			break
+		}
+	}
+
+	if !pool.Contains(result) || (hasNetworkAddresses(pool) && isBroadcast(pool, result)) {
+		return nil
+	}
+
+	return result
+}
+
+func isBroadcast(pool *net.IPNet, ip net.IP) bool {
+	for i := range ip {
+		if ip[i]|pool.Mask[i] != 0xff {
+			return false
+		}
+	}
+
+	return true
+}
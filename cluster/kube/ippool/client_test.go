@@ -0,0 +1,194 @@
+package ippool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	manifest "github.com/akash-network/node/manifest/v2beta1"
+	"github.com/akash-network/node/testutil"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+	"github.com/akash-network/provider/cluster/kube/ipbackend"
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+	akashclient_fake "github.com/akash-network/provider/pkg/client/clientset/versioned/fake"
+)
+
+func newTestClient(t *testing.T, cfg Config) *client {
+	cfg.Namespace = "lease"
+	c, err := newClient(kubefake.NewSimpleClientset(), akashclient_fake.NewSimpleClientset(), testutil.Logger(t), cfg)
+	require.NoError(t, err)
+
+	return c
+}
+
+func testDirective(t *testing.T, serviceName string, externalPort uint32, sharingKey string) ctypes.ClusterIPPassthroughDirective {
+	return ctypes.ClusterIPPassthroughDirective{
+		LeaseID:      testutil.LeaseID(t),
+		ServiceName:  serviceName,
+		Port:         8080,
+		ExternalPort: externalPort,
+		SharingKey:   sharingKey,
+		Protocol:     manifest.TCP,
+	}
+}
+
+func TestStaticPoolAllocation(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, Config{Pools: []string{"10.0.0.0/30"}})
+
+	inUse, total, err := c.GetIPAddressUsage(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint(0), inUse)
+	require.Equal(t, uint(2), total)
+
+	web := testDirective(t, "web", 80, "owner-web")
+	require.NoError(t, c.CreateIPPassthrough(ctx, web))
+
+	// services sharing a key share the address
+	ssh := web
+	ssh.ServiceName = "ssh"
+	ssh.ExternalPort = 22
+	require.NoError(t, c.CreateIPPassthrough(ctx, ssh))
+
+	db := testDirective(t, "db", 5432, "owner-db")
+	require.NoError(t, c.CreateIPPassthrough(ctx, db))
+
+	svc, err := c.kube.CoreV1().Services(builder.LidNS(web.LeaseID)).Get(ctx, ipbackend.ResourceName(ssh), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1", svc.Spec.LoadBalancerIP)
+
+	svc, err = c.kube.CoreV1().Services(builder.LidNS(db.LeaseID)).Get(ctx, ipbackend.ResourceName(db), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.2", svc.Spec.LoadBalancerIP)
+
+	inUse, _, err = c.GetIPAddressUsage(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint(2), inUse)
+
+	states, err := c.GetIPAddressStatusForLease(ctx, web.LeaseID)
+	require.NoError(t, err)
+	require.Len(t, states, 2)
+	for _, state := range states {
+		require.Equal(t, "10.0.0.1", state.GetIP())
+		require.Equal(t, "owner-web", state.GetSharingKey())
+	}
+
+	passthroughs, err := c.GetIPPassthroughs(ctx)
+	require.NoError(t, err)
+	require.Len(t, passthroughs, 3)
+
+	// the network and broadcast addresses are never handed out
+	err = c.CreateIPPassthrough(ctx, testDirective(t, "api", 443, "owner-api"))
+	require.ErrorIs(t, err, ErrPoolExhausted)
+
+	// the address is released with the last service using it
+	require.NoError(t, c.PurgeIPPassthrough(ctx, web))
+	inUse, _, err = c.GetIPAddressUsage(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint(2), inUse)
+
+	require.NoError(t, c.PurgeIPPassthrough(ctx, ssh))
+	inUse, _, err = c.GetIPAddressUsage(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint(1), inUse)
+
+	api := testDirective(t, "api", 443, "owner-api")
+	require.NoError(t, c.CreateIPPassthrough(ctx, api))
+
+	states, err = c.GetIPAddressStatusForLease(ctx, api.LeaseID)
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	require.Equal(t, "10.0.0.1", states[0].GetIP())
+}
+
+func TestStaticPoolAssignment(t *testing.T) {
+	ctx := context.Background()
+
+	for _, assignment := range []string{AssignmentKubeVIP, AssignmentCilium} {
+		c := newTestClient(t, Config{Pools: []string{"2001:db8::/127"}, Assignment: assignment})
+
+		directive := testDirective(t, "web", 80, "owner-web")
+		require.NoError(t, c.CreateIPPassthrough(ctx, directive))
+
+		svc, err := c.kube.CoreV1().Services(builder.LidNS(directive.LeaseID)).Get(ctx, ipbackend.ResourceName(directive), metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Empty(t, svc.Spec.LoadBalancerIP)
+
+		switch assignment {
+		case AssignmentKubeVIP:
+			require.Equal(t, "2001:db8::", svc.Annotations[kubeVIPAnnotation])
+		case AssignmentCilium:
+			require.Equal(t, "2001:db8::", svc.Annotations[ciliumIPsAnnotation])
+			require.Equal(t, "owner-web", svc.Annotations[ciliumSharingAnnotation])
+		}
+
+		allocation, err := c.ac.AkashV2beta1().ProviderIPAllocations("lease").Get(ctx, "ip-2001-db8--", metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "owner-web", allocation.Spec.SharingKey)
+	}
+}
+
+func TestStaticPoolDualStack(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, Config{Pools: []string{"10.0.0.0/30", "2001:db8::/126"}})
+
+	web := testDirective(t, "web", 80, "owner-web")
+	require.NoError(t, c.CreateIPPassthrough(ctx, web))
+
+	ns := builder.LidNS(web.LeaseID)
+	svc, err := c.kube.CoreV1().Services(ns).Get(ctx, ipbackend.ResourceName(web), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1", svc.Spec.LoadBalancerIP)
+	require.Equal(t, []corev1.IPFamily{corev1.IPv4Protocol}, svc.Spec.IPFamilies)
+
+	svc, err = c.kube.CoreV1().Services(ns).Get(ctx, ipbackend.ResourceNameIPv6(web), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "2001:db8::", svc.Spec.LoadBalancerIP)
+	require.Equal(t, []corev1.IPFamily{corev1.IPv6Protocol}, svc.Spec.IPFamilies)
+
+	inUse, total, err := c.GetIPAddressUsage(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint(1), inUse)
+	require.Equal(t, uint(2), total)
+
+	inUse, total, err = c.GetIPv6AddressUsage(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint(1), inUse)
+	require.Equal(t, uint(4), total)
+
+	states, err := c.GetIPAddressStatusForLease(ctx, web.LeaseID)
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	require.Equal(t, "10.0.0.1", states[0].GetIP())
+	require.Equal(t, "2001:db8::", states[0].GetIPv6())
+
+	// the IPv6 companion is not a passthrough of its own
+	passthroughs, err := c.GetIPPassthroughs(ctx)
+	require.NoError(t, err)
+	require.Len(t, passthroughs, 1)
+
+	require.NoError(t, c.PurgeIPPassthrough(ctx, web))
+	_, err = c.kube.CoreV1().Services(ns).Get(ctx, ipbackend.ResourceNameIPv6(web), metav1.GetOptions{})
+	require.True(t, kubeErrors.IsNotFound(err))
+
+	inUse, _, err = c.GetIPv6AddressUsage(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint(0), inUse)
+}
+
+func TestStaticPoolInvalidConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{},
+		{Pools: []string{"10.0.0.0"}},
+		{Pools: []string{"10.0.0.0/24"}, Assignment: "metallb"},
+	} {
+		_, err := newClient(kubefake.NewSimpleClientset(), akashclient_fake.NewSimpleClientset(), testutil.Logger(t), cfg)
+		require.ErrorIs(t, err, ErrInvalidConfig)
+	}
+}
@@ -9,29 +9,23 @@ import (
 	"math"
 	"net"
 	"net/http"
-	"strings"
 	"sync"
 
-	manifest "github.com/akash-network/node/manifest/v2beta1"
 	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
 	"github.com/prometheus/common/expfmt"
 	"github.com/tendermint/tendermint/libs/log"
 	corev1 "k8s.io/api/core/v1"
 	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/pager"
 	"k8s.io/client-go/util/flowcontrol"
 
 	"github.com/akash-network/provider/cluster/kube/builder"
 	"github.com/akash-network/provider/cluster/kube/clientcommon"
+	"github.com/akash-network/provider/cluster/kube/ipbackend"
 	"github.com/akash-network/provider/cluster/types/v1beta2"
 	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
 	clusterutil "github.com/akash-network/provider/cluster/util"
-
-	kubeclienterrors "github.com/akash-network/provider/cluster/kube/errors"
 )
 
 const (
@@ -47,21 +41,11 @@ const (
 )
 
 var (
-	errMetalLB             = errors.New("metal lb error")
-	errInvalidLeaseService = fmt.Errorf("%w lease service error", errMetalLB)
+	errMetalLB             = fmt.Errorf("%w: metal lb", ipbackend.ErrIPBackend)
+	errInvalidLeaseService = ipbackend.ErrInvalidLeaseService
 )
 
-type Client interface {
-	GetIPAddressUsage(ctx context.Context) (uint, uint, error)
-	GetIPAddressStatusForLease(ctx context.Context, leaseID mtypes.LeaseID) ([]v1beta2.IPLeaseState, error)
-
-	CreateIPPassthrough(ctx context.Context, directive ctypes.ClusterIPPassthroughDirective) error
-	PurgeIPPassthrough(ctx context.Context, directive ctypes.ClusterIPPassthroughDirective) error
-	GetIPPassthroughs(ctx context.Context) ([]v1beta2.IPPassthrough, error)
-	DetectPoolChanges(ctx context.Context) (<-chan struct{}, error)
-
-	Stop()
-}
+type Client = ipbackend.Client
 
 type client struct {
 	kube kubernetes.Interface
@@ -72,14 +56,17 @@ type client struct {
 	client clusterutil.ServiceClient
 	l      sync.Locker
 
-	poolName string
+	poolName     string
+	poolNameIPv6 string
 }
 
 func (c *client) String() string {
 	return fmt.Sprintf("metal LB client %p", c)
 }
 
-func NewClient(configPath string, logger log.Logger, poolName string, endpoint *net.SRV) (Client, error) {
+// NewClient returns a client assigning the leased IPs from the MetalLB pool poolName. Endpoints are dual-stack
+// when poolNameIPv6 names the MetalLB pool of the IPv6 addresses.
+func NewClient(configPath string, logger log.Logger, poolName string, poolNameIPv6 string, endpoint *net.SRV) (Client, error) {
 	config, err := clientcommon.OpenKubeConfig(configPath, logger)
 	if err != nil {
 		return nil, fmt.Errorf("%w: creating kubernetes client", err)
@@ -102,11 +89,12 @@ func NewClient(configPath string, logger log.Logger, poolName string, endpoint *
 	}
 
 	return &client{
-		sda:      sda,
-		kube:     kc,
-		poolName: poolName,
-		l:        &sync.Mutex{},
-		log:      logger.With("client", "metallb"),
+		sda:          sda,
+		kube:         kc,
+		poolName:     poolName,
+		poolNameIPv6: poolNameIPv6,
+		l:            &sync.Mutex{},
+		log:          logger.With("client", "metallb"),
 	}, nil
 
 }
@@ -135,6 +123,18 @@ can get stuff like this to access metal lb metrics
 */
 
 func (c *client) GetIPAddressUsage(ctx context.Context) (uint, uint, error) {
+	return c.poolUsage(ctx, c.poolName)
+}
+
+func (c *client) GetIPv6AddressUsage(ctx context.Context) (uint, uint, error) {
+	if len(c.poolNameIPv6) == 0 {
+		return 0, 0, nil
+	}
+
+	return c.poolUsage(ctx, c.poolNameIPv6)
+}
+
+func (c *client) poolUsage(ctx context.Context, poolName string) (uint, uint, error) {
 	err := c.setupClient(ctx)
 	if err != nil {
 		return math.MaxUint32, math.MaxUint32, err
@@ -207,7 +207,7 @@ func (c *client) GetIPAddressUsage(ctx context.Context) (uint, uint, error) {
 				// Record all pool names found, for debugging purposes
 				poolsFound[labelEntry.GetValue()] = struct{}{}
 
-				if labelEntry.GetValue() != c.poolName {
+				if labelEntry.GetValue() != poolName {
 					continue
 				}
 
@@ -222,143 +222,80 @@ func (c *client) GetIPAddressUsage(ctx context.Context) (uint, uint, error) {
 		if len(poolsFound) == 0 {
 			c.log.Debug("no pools configured on Metal LB")
 		} else {
-			c.log.Debug("pools configured on Metal LB, but none matching", "configured-pool-name", c.poolName, "quantity-configured", len(poolsFound))
+			c.log.Debug("pools configured on Metal LB, but none matching", "configured-pool-name", poolName, "quantity-configured", len(poolsFound))
 		}
 	}
 
 	return inUse, available, nil
 }
 
-type ipLeaseState struct {
-	leaseID      mtypes.LeaseID
-	ip           string
-	serviceName  string
-	externalPort uint32
-	port         uint32
-	sharingKey   string
-	protocol     manifest.ServiceProtocol
-}
+func (c *client) GetIPAddressStatusForLease(ctx context.Context, leaseID mtypes.LeaseID) ([]v1beta2.IPLeaseState, error) {
+	return ipbackend.LeaseStates(ctx, c.kube, leaseID, metalLbAllowSharedIP, func(service *corev1.Service) (string, error) {
+		loadBalancerIngress := service.Status.LoadBalancer.Ingress
+		// Logs something like this : │ load balancer status                         cmp=provider client=kube service=web-ip-80-tcp lb-ingress="[{IP:24.0.0.1 Hostname: Ports:[]}]"
+		c.log.Debug("load balancer status", "service", service.ObjectMeta.Name, "lb-ingress", loadBalancerIngress)
+
+		// There is no mechanism that would assign more than one IP to a single service entry
+		if len(loadBalancerIngress) != 1 {
+			return "", fmt.Errorf("%w: service %q has %d load balancers and is invalid", errInvalidLeaseService, service.ObjectMeta.Name, len(loadBalancerIngress))
+		}
 
-func (ipls ipLeaseState) GetLeaseID() mtypes.LeaseID {
-	return ipls.leaseID
-}
-func (ipls ipLeaseState) GetIP() string {
-	return ipls.ip
-}
-func (ipls ipLeaseState) GetServiceName() string {
-	return ipls.serviceName
-}
-func (ipls ipLeaseState) GetExternalPort() uint32 {
-	return ipls.externalPort
-}
-func (ipls ipLeaseState) GetPort() uint32 {
-	return ipls.port
-}
-func (ipls ipLeaseState) GetSharingKey() string {
-	return ipls.sharingKey
-}
-func (ipls ipLeaseState) GetProtocol() manifest.ServiceProtocol {
-	return ipls.protocol
+		return loadBalancerIngress[0].IP, nil
+	})
 }
 
-func (c *client) GetIPAddressStatusForLease(ctx context.Context, leaseID mtypes.LeaseID) ([]v1beta2.IPLeaseState, error) {
-	ns := builder.LidNS(leaseID)
-	servicePager := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
-		return c.kube.CoreV1().Services(ns).List(ctx, opts)
-	})
+func (c *client) PurgeIPPassthrough(ctx context.Context, directive ctypes.ClusterIPPassthroughDirective) error {
+	ns := builder.LidNS(directive.LeaseID)
+	for _, resourceName := range []string{ipbackend.ResourceName(directive), ipbackend.ResourceNameIPv6(directive)} {
+		err := c.kube.CoreV1().Services(ns).Delete(ctx, resourceName, metav1.DeleteOptions{})
+		if err != nil && !kubeErrors.IsNotFound(err) {
+			return err
+		}
+	}
 
-	labelSelector := &strings.Builder{}
+	return nil
+}
 
-	_, err := fmt.Fprintf(labelSelector, "%s=true", builder.AkashManagedLabelName)
-	if err != nil {
-		return nil, err
+func (c *client) CreateIPPassthrough(ctx context.Context, directive ctypes.ClusterIPPassthroughDirective) error {
+	annotations := map[string]string{
+		metalLbAllowSharedIP: directive.SharingKey,
 	}
-	_, err = fmt.Fprintf(labelSelector, ",%s=%s", builder.AkashServiceTarget, builder.AkashMetalLB)
-	if err != nil {
-		return nil, err
+	// Specify pool annotation if we're not using the default
+	if c.poolName != defaultMetalLBPoolName {
+		annotations[metalLbPoolAnnotation] = c.poolName
 	}
 
-	result := make([]v1beta2.IPLeaseState, 0)
-	err = servicePager.EachListItem(ctx, metav1.ListOptions{
-		LabelSelector: labelSelector.String(),
-	},
-		func(obj runtime.Object) error {
-			service := obj.(*corev1.Service)
-
-			loadBalancerIngress := service.Status.LoadBalancer.Ingress
-			// Logs something like this : │ load balancer status                         cmp=provider client=kube service=web-ip-80-tcp lb-ingress="[{IP:24.0.0.1 Hostname: Ports:[]}]"
-			c.log.Debug("load balancer status", "service", service.ObjectMeta.Name, "lb-ingress", loadBalancerIngress)
-
-			// There is no mechanism that would assign more than one IP to a single service entry
-			if len(loadBalancerIngress) != 1 {
-				return fmt.Errorf("%w: service %q has %d load balancers and is invalid", errInvalidLeaseService, service.ObjectMeta.Name, len(loadBalancerIngress))
-			}
-
-			ingress := loadBalancerIngress[0]
-
-			if len(service.Spec.Ports) != 1 {
-				return fmt.Errorf("%w: service %q has %d port specs and is invalid", errInvalidLeaseService, service.ObjectMeta.Name, len(service.Spec.Ports))
-			}
-			port := service.Spec.Ports[0]
-
-			proto, err := manifest.ServiceProtocolFromKube(port.Protocol)
-			if err != nil {
-				return fmt.Errorf("%w: service %q has invalid protocol %v", errInvalidLeaseService, service.ObjectMeta.Name, err)
-			}
-
-			selectedServiceName := service.Spec.Selector[builder.AkashManifestServiceLabelName]
-			// Note: don't care about node port here, even if it is assigned
-			// Note: service.Name is a procedurally generated thing that doesn't mean anything to the end user
-			result = append(result, ipLeaseState{
-				leaseID:      leaseID,
-				ip:           ingress.IP,
-				serviceName:  selectedServiceName,
-				externalPort: uint32(port.Port),
-				port:         uint32(port.TargetPort.IntValue()),
-				sharingKey:   service.ObjectMeta.Annotations[metalLbAllowSharedIP],
-				protocol:     proto,
-			})
-
-			return nil
-		})
+	svc, err := ipbackend.LoadBalancerService(directive, annotations)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return result, nil
-}
+	if len(c.poolNameIPv6) == 0 {
+		return c.applyService(ctx, directive, svc)
+	}
 
-func (c *client) PurgeIPPassthrough(ctx context.Context, directive ctypes.ClusterIPPassthroughDirective) error {
-	ns := builder.LidNS(directive.LeaseID)
-	resourceName := createIPPassthroughResourceName(directive)
-	err := c.kube.CoreV1().Services(ns).Delete(ctx, resourceName, metav1.DeleteOptions{})
-	if err != nil && kubeErrors.IsNotFound(err) {
-		return nil
+	// Dual-stack, the IPv4 service keeps its name and the IPv6 address is requested by a companion
+	// service on the IPv6 pool
+	ipbackend.SetIPFamily(svc, corev1.IPv4Protocol)
+	err = c.applyService(ctx, directive, svc)
+	if err != nil {
+		return err
 	}
 
-	return err
-}
+	svc, err = ipbackend.LoadBalancerServiceIPv6(directive, map[string]string{
+		metalLbAllowSharedIP:  directive.SharingKey,
+		metalLbPoolAnnotation: c.poolNameIPv6,
+	})
+	if err != nil {
+		return err
+	}
 
-func createIPPassthroughResourceName(directive ctypes.ClusterIPPassthroughDirective) string {
-	return strings.ToLower(fmt.Sprintf("%s-ip-%d-%v", directive.ServiceName, directive.ExternalPort, directive.Protocol))
+	return c.applyService(ctx, directive, svc)
 }
 
-func (c *client) CreateIPPassthrough(ctx context.Context, directive ctypes.ClusterIPPassthroughDirective) error {
-	var proto corev1.Protocol
-
-	switch directive.Protocol {
-	case manifest.TCP:
-		proto = corev1.ProtocolTCP
-	case manifest.UDP:
-		proto = corev1.ProtocolUDP
-	default:
-		return fmt.Errorf("%w unknown protocol %v", kubeclienterrors.ErrInternalError, directive.Protocol)
-	}
-
+func (c *client) applyService(ctx context.Context, directive ctypes.ClusterIPPassthroughDirective, svc *corev1.Service) error {
 	ns := builder.LidNS(directive.LeaseID)
-	portName := createIPPassthroughResourceName(directive)
-
-	foundEntry, err := c.kube.CoreV1().Services(ns).Get(ctx, portName, metav1.GetOptions{})
+	foundEntry, err := c.kube.CoreV1().Services(ns).Get(ctx, svc.Name, metav1.GetOptions{})
 
 	exists := true
 	if err != nil {
@@ -369,57 +306,18 @@ func (c *client) CreateIPPassthrough(ctx context.Context, directive ctypes.Clust
 		}
 	}
 
-	labels := make(map[string]string)
-	builder.AppendLeaseLabels(directive.LeaseID, labels)
-	labels[builder.AkashManagedLabelName] = "true"
-	labels[builder.AkashServiceTarget] = builder.AkashMetalLB
-
-	selector := map[string]string{
-		builder.AkashManagedLabelName:         "true",
-		builder.AkashManifestServiceLabelName: directive.ServiceName,
-	}
-	annotations := map[string]string{
-		metalLbAllowSharedIP: directive.SharingKey,
-	}
-	// Specify pool annotation if we're not using the default
-	if c.poolName != defaultMetalLBPoolName {
-		annotations[metalLbPoolAnnotation] = c.poolName
-	}
-
-	port := corev1.ServicePort{
-		Name:       portName,
-		Protocol:   proto,
-		Port:       int32(directive.ExternalPort),
-		TargetPort: intstr.FromInt(int(directive.Port)),
-	}
-
-	svc := corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        portName,
-			Labels:      labels,
-			Annotations: annotations,
-		},
-		Spec: corev1.ServiceSpec{
-			Ports: []corev1.ServicePort{
-				port,
-			},
-			Selector: selector,
-			Type:     corev1.ServiceTypeLoadBalancer,
-		},
-		Status: corev1.ServiceStatus{},
-	}
-
 	c.log.Debug("creating metal-lb service",
 		"service", directive.ServiceName,
 		"port", directive.Port,
 		"external-port", directive.ExternalPort,
 		"sharing-key", directive.SharingKey,
+		"ip-families", svc.Spec.IPFamilies,
 		"exists", exists)
 	if exists {
 		svc.ResourceVersion = foundEntry.ResourceVersion
-		_, err = c.kube.CoreV1().Services(ns).Update(ctx, &svc, metav1.UpdateOptions{})
+		_, err = c.kube.CoreV1().Services(ns).Update(ctx, svc, metav1.UpdateOptions{})
 	} else {
-		_, err = c.kube.CoreV1().Services(ns).Create(ctx, &svc, metav1.CreateOptions{})
+		_, err = c.kube.CoreV1().Services(ns).Create(ctx, svc, metav1.CreateOptions{})
 	}
 
 	if err != nil {
@@ -430,78 +328,16 @@ func (c *client) CreateIPPassthrough(ctx context.Context, directive ctypes.Clust
 }
 
 func (c *client) GetIPPassthroughs(ctx context.Context) ([]v1beta2.IPPassthrough, error) {
-	servicePager := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
-		return c.kube.CoreV1().Services(metav1.NamespaceAll).List(ctx, opts)
-	})
-
-	labelSelector := &strings.Builder{}
-
-	_, err := fmt.Fprintf(labelSelector, "%s=true", builder.AkashManagedLabelName)
-	if err != nil {
-		return nil, err
-	}
-	_, err = fmt.Fprintf(labelSelector, ",%s=%s", builder.AkashServiceTarget, builder.AkashMetalLB)
-	if err != nil {
-		return nil, err
-	}
-
 	result := make([]v1beta2.IPPassthrough, 0)
-	err = servicePager.EachListItem(ctx,
-		metav1.ListOptions{
-			LabelSelector: labelSelector.String(),
-		},
-		func(obj runtime.Object) error {
-			service := obj.(*corev1.Service)
-
-			_, hasOwner := service.ObjectMeta.Labels[builder.AkashLeaseOwnerLabelName]
-			if !hasOwner {
-				// Not a service related to a running deployment, so probably internal services
-				return nil
-			}
-
-			if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
-				return fmt.Errorf("%w: resource %q wrong type in service definition %v", errMetalLB, service.ObjectMeta.Name, service.Spec.Type)
-			}
-
-			ports := service.Spec.Ports
-			const expectedNumberOfPorts = 1
-			if len(ports) != expectedNumberOfPorts {
-				return fmt.Errorf("%w: resource %q  wrong number of ports in load balancer service definition. expected %d, got %d", errMetalLB, service.ObjectMeta.Name, expectedNumberOfPorts, len(ports))
-			}
-
-			portDefn := ports[0]
-			proto := portDefn.Protocol
-			port := portDefn.Port
-
-			leaseID, err := clientcommon.RecoverLeaseIDFromLabels(service.Labels)
-			if err != nil {
-				return fmt.Errorf("%w: service %q has invalid leease labels %v", err, service.ObjectMeta.Name, service.Labels)
-			}
-
-			mproto, err := manifest.ServiceProtocolFromKube(proto)
-			if err != nil {
-				return fmt.Errorf("%w: service %q has invalid protocol %v", err, service.ObjectMeta.Name, proto)
-			}
-
-			serviceSelector := service.Spec.Selector
-			serviceName := serviceSelector[builder.AkashManifestServiceLabelName]
-			if len(serviceName) == 0 {
-				return fmt.Errorf("%w: service has empty selector", errMetalLB)
-			}
-
-			sharingKey := service.ObjectMeta.Annotations[metalLbAllowSharedIP]
-
-			v := ipPassthrough{
-				lID:          leaseID,
-				serviceName:  serviceName,
-				externalPort: uint32(port),
-				sharingKey:   sharingKey,
-				protocol:     mproto,
-			}
+	err := ipbackend.EachService(ctx, c.kube, metav1.NamespaceAll, func(service *corev1.Service) error {
+		v, err := ipbackend.Passthrough(service, metalLbAllowSharedIP)
+		if err != nil || v == nil {
+			return err
+		}
 
-			result = append(result, v)
-			return nil
-		})
+		result = append(result, v)
+		return nil
+	})
 
 	return result, err
 }
@@ -537,36 +373,3 @@ func (c *client) DetectPoolChanges(ctx context.Context) (<-chan struct{}, error)
 
 	return output, nil
 }
-
-type ipPassthrough struct {
-	lID          mtypes.LeaseID
-	serviceName  string
-	port         uint32
-	externalPort uint32
-	sharingKey   string
-	protocol     manifest.ServiceProtocol
-}
-
-func (ev ipPassthrough) GetLeaseID() mtypes.LeaseID {
-	return ev.lID
-}
-
-func (ev ipPassthrough) GetServiceName() string {
-	return ev.serviceName
-}
-
-func (ev ipPassthrough) GetPort() uint32 {
-	return ev.port
-}
-
-func (ev ipPassthrough) GetExternalPort() uint32 {
-	return ev.externalPort
-}
-
-func (ev ipPassthrough) GetSharingKey() string {
-	return ev.sharingKey
-}
-
-func (ev ipPassthrough) GetProtocol() manifest.ServiceProtocol {
-	return ev.protocol
-}
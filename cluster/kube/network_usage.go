@@ -0,0 +1,215 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tendermint/tendermint/libs/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	metricsutils "github.com/akash-network/node/util/metrics"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+)
+
+var (
+	leaseNetworkReceiveBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "provider_lease_network_receive_bytes_total",
+		Help: "Bytes received by the pods of a lease, as reported by kubelets",
+	}, []string{"owner", "dseq", "gseq", "oseq"})
+
+	leaseNetworkTransmitBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "provider_lease_network_transmit_bytes_total",
+		Help: "Bytes transmitted by the pods of a lease, as reported by kubelets",
+	}, []string{"owner", "dseq", "gseq", "oseq"})
+)
+
+// statsSummary is the subset of the kubelet stats summary holding pod network counters
+type statsSummary struct {
+	Pods []struct {
+		PodRef struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+			UID       string `json:"uid"`
+		} `json:"podRef"`
+		Network *struct {
+			RxBytes *uint64 `json:"rxBytes"`
+			TxBytes *uint64 `json:"txBytes"`
+		} `json:"network"`
+	} `json:"pods"`
+}
+
+type podNetworkBytes struct {
+	rx, tx uint64
+	// node and namespace the pod was last seen on
+	node      string
+	namespace string
+}
+
+// NetworkUsageCollector periodically reads the network counters kubelets report for the pods
+// of lease namespaces and accumulates them into per lease metrics
+type NetworkUsageCollector struct {
+	log    log.Logger
+	kc     kubernetes.Interface
+	period time.Duration
+
+	// last counters seen per pod UID, kubelet counters restart with the pod sandbox
+	pods map[string]podNetworkBytes
+	// metric labels of the leases seen in the last collection, by namespace
+	leases map[string][]string
+
+	fetchSummary func(ctx context.Context, node string) ([]byte, error)
+}
+
+func NewNetworkUsageCollector(log log.Logger, kc kubernetes.Interface, period time.Duration) *NetworkUsageCollector {
+	c := &NetworkUsageCollector{
+		log:    log.With("cmp", "network-usage-collector"),
+		kc:     kc,
+		period: period,
+		pods:   make(map[string]podNetworkBytes),
+		leases: make(map[string][]string),
+	}
+	c.fetchSummary = c.fetchNodeSummary
+
+	return c
+}
+
+// Run collects network usage every period until the context is done
+func (c *NetworkUsageCollector) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.period)
+	defer ticker.Stop()
+
+	for {
+		if err := c.collect(ctx); err != nil && ctx.Err() == nil {
+			c.log.Error("collecting network usage", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *NetworkUsageCollector) collect(ctx context.Context) error {
+	namespaces, err := c.kc.CoreV1().Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: builder.AkashManagedLabelName + "=true",
+	})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "namespaces-list", err)
+	if err != nil {
+		return err
+	}
+
+	leases := make(map[string][]string, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		leases[ns.Name] = []string{
+			ns.Labels[builder.AkashLeaseOwnerLabelName],
+			ns.Labels[builder.AkashLeaseDSeqLabelName],
+			ns.Labels[builder.AkashLeaseGSeqLabelName],
+			ns.Labels[builder.AkashLeaseOSeqLabelName],
+		}
+	}
+
+	nodes, err := c.kc.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "nodes-list", err)
+	if err != nil {
+		return err
+	}
+
+	summaries := make(map[string]statsSummary, len(nodes.Items))
+	unread := make(map[string]struct{})
+	for _, node := range nodes.Items {
+		buf, err := c.fetchSummary(ctx, node.Name)
+		if err != nil {
+			// keep accounting the other nodes, this one is picked up again next period
+			c.log.Error("fetching node stats summary", "node", node.Name, "err", err)
+			unread[node.Name] = struct{}{}
+			continue
+		}
+
+		summary := statsSummary{}
+		if err := json.Unmarshal(buf, &summary); err != nil {
+			c.log.Error("decoding node stats summary", "node", node.Name, "err", err)
+			unread[node.Name] = struct{}{}
+			continue
+		}
+		summaries[node.Name] = summary
+	}
+
+	c.observe(leases, summaries, unread)
+
+	return nil
+}
+
+// observe adds the traffic of lease pods since the previous collection to the lease counters. The
+// counters of pods on the unread nodes are kept, so their traffic is not counted again once the
+// node is read.
+func (c *NetworkUsageCollector) observe(leases map[string][]string, summaries map[string]statsSummary, unread map[string]struct{}) {
+	seen := make(map[string]podNetworkBytes)
+
+	for uid, pod := range c.pods {
+		if _, isUnread := unread[pod.node]; !isUnread {
+			continue
+		}
+		if _, isLease := leases[pod.namespace]; isLease {
+			seen[uid] = pod
+		}
+	}
+
+	for node, summary := range summaries {
+		for _, pod := range summary.Pods {
+			labels, isLease := leases[pod.PodRef.Namespace]
+			if !isLease || pod.Network == nil || pod.Network.RxBytes == nil || pod.Network.TxBytes == nil {
+				continue
+			}
+
+			current := podNetworkBytes{
+				rx:        *pod.Network.RxBytes,
+				tx:        *pod.Network.TxBytes,
+				node:      node,
+				namespace: pod.PodRef.Namespace,
+			}
+			prev := c.pods[pod.PodRef.UID]
+			seen[pod.PodRef.UID] = current
+
+			leaseNetworkReceiveBytes.WithLabelValues(labels...).Add(float64(counterDelta(prev.rx, current.rx)))
+			leaseNetworkTransmitBytes.WithLabelValues(labels...).Add(float64(counterDelta(prev.tx, current.tx)))
+		}
+	}
+
+	// forget leases which have been torn down
+	for ns, labels := range c.leases {
+		if _, exists := leases[ns]; !exists {
+			leaseNetworkReceiveBytes.DeleteLabelValues(labels...)
+			leaseNetworkTransmitBytes.DeleteLabelValues(labels...)
+		}
+	}
+
+	c.pods = seen
+	c.leases = leases
+}
+
+func counterDelta(prev, current uint64) uint64 {
+	if current < prev {
+		// counter restarted
+		return current
+	}
+	return current - prev
+}
+
+func (c *NetworkUsageCollector) fetchNodeSummary(ctx context.Context, node string) ([]byte, error) {
+	buf, err := c.kc.CoreV1().RESTClient().Get().
+		Resource("nodes").
+		Name(node).
+		SubResource("proxy").
+		Suffix("stats", "summary").
+		DoRaw(ctx)
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "nodes-stats-summary", err)
+
+	return buf, err
+}
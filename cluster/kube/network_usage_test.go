@@ -0,0 +1,122 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/akash-network/node/testutil"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+)
+
+func TestNetworkUsageCollector(t *testing.T) {
+	ctx := context.Background()
+	lid := testutil.LeaseID(t)
+	ns := builder.LidNS(lid)
+
+	kc := kubefake.NewSimpleClientset(
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   ns,
+				Labels: builder.AppendLeaseLabels(lid, map[string]string{builder.AkashManagedLabelName: "true"}),
+			},
+		},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+	)
+
+	summary := func(rx, tx uint64) []byte {
+		return []byte(fmt.Sprintf(`{"pods": [
+			{"podRef": {"name": "web-0", "namespace": %q, "uid": "uid-1"}, "network": {"rxBytes": %d, "txBytes": %d}},
+			{"podRef": {"name": "coredns", "namespace": "kube-system", "uid": "uid-2"}, "network": {"rxBytes": 1000, "txBytes": 1000}}
+		]}`, ns, rx, tx))
+	}
+
+	var current []byte
+	collector := NewNetworkUsageCollector(testutil.Logger(t), kc, time.Minute)
+	collector.fetchSummary = func(context.Context, string) ([]byte, error) {
+		return current, nil
+	}
+
+	labels := []string{lid.Owner, fmt.Sprint(lid.DSeq), fmt.Sprint(lid.GSeq), fmt.Sprint(lid.OSeq)}
+
+	current = summary(100, 10)
+	require.NoError(t, collector.collect(ctx))
+	require.Equal(t, float64(100), promtestutil.ToFloat64(leaseNetworkReceiveBytes.WithLabelValues(labels...)))
+	require.Equal(t, float64(10), promtestutil.ToFloat64(leaseNetworkTransmitBytes.WithLabelValues(labels...)))
+
+	current = summary(150, 30)
+	require.NoError(t, collector.collect(ctx))
+	require.Equal(t, float64(150), promtestutil.ToFloat64(leaseNetworkReceiveBytes.WithLabelValues(labels...)))
+	require.Equal(t, float64(30), promtestutil.ToFloat64(leaseNetworkTransmitBytes.WithLabelValues(labels...)))
+
+	// pod sandbox restarted, its counters too
+	current = summary(20, 5)
+	require.NoError(t, collector.collect(ctx))
+	require.Equal(t, float64(170), promtestutil.ToFloat64(leaseNetworkReceiveBytes.WithLabelValues(labels...)))
+	require.Equal(t, float64(35), promtestutil.ToFloat64(leaseNetworkTransmitBytes.WithLabelValues(labels...)))
+}
+
+func TestNetworkUsageCollectorNodeFailure(t *testing.T) {
+	ctx := context.Background()
+	lid := testutil.LeaseID(t)
+	ns := builder.LidNS(lid)
+
+	kc := kubefake.NewSimpleClientset(
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   ns,
+				Labels: builder.AppendLeaseLabels(lid, map[string]string{builder.AkashManagedLabelName: "true"}),
+			},
+		},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}},
+	)
+
+	summary := func(uid string, rx, tx uint64) []byte {
+		return []byte(fmt.Sprintf(`{"pods": [
+			{"podRef": {"name": %q, "namespace": %q, "uid": %q}, "network": {"rxBytes": %d, "txBytes": %d}}
+		]}`, uid, ns, uid, rx, tx))
+	}
+
+	node2Fails := false
+	counters := map[string]uint64{"node1": 100, "node2": 1000}
+	collector := NewNetworkUsageCollector(testutil.Logger(t), kc, time.Minute)
+	collector.fetchSummary = func(_ context.Context, node string) ([]byte, error) {
+		if node == "node2" && node2Fails {
+			return nil, errors.New("kubelet unavailable")
+		}
+		return summary("uid-"+node, counters[node], counters[node]), nil
+	}
+
+	labels := []string{lid.Owner, fmt.Sprint(lid.DSeq), fmt.Sprint(lid.GSeq), fmt.Sprint(lid.OSeq)}
+
+	require.NoError(t, collector.collect(ctx))
+	require.Equal(t, float64(1100), promtestutil.ToFloat64(leaseNetworkReceiveBytes.WithLabelValues(labels...)))
+
+	// node2 can't be read, its pod served traffic meanwhile
+	node2Fails = true
+	counters["node1"] = 150
+	counters["node2"] = 1200
+	require.NoError(t, collector.collect(ctx))
+	require.Equal(t, float64(1150), promtestutil.ToFloat64(leaseNetworkReceiveBytes.WithLabelValues(labels...)))
+
+	// only the traffic since node2 was last read is added
+	node2Fails = false
+	require.NoError(t, collector.collect(ctx))
+	require.Equal(t, float64(1350), promtestutil.ToFloat64(leaseNetworkReceiveBytes.WithLabelValues(labels...)))
+	require.Equal(t, float64(1350), promtestutil.ToFloat64(leaseNetworkTransmitBytes.WithLabelValues(labels...)))
+}
+
+func TestCounterDelta(t *testing.T) {
+	require.Equal(t, uint64(5), counterDelta(10, 15))
+	require.Equal(t, uint64(3), counterDelta(10, 3))
+}
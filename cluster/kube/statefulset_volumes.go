@@ -0,0 +1,229 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	metricsutils "github.com/akash-network/node/util/metrics"
+
+	kubeclienterrors "github.com/akash-network/provider/cluster/kube/errors"
+)
+
+const (
+	annotationDefaultStorageClass = "storageclass.kubernetes.io/is-default-class"
+
+	statefulSetDeletePollPeriod = time.Second
+	statefulSetDeleteTimeout    = time.Minute
+)
+
+// volumeClaimChanges lists how the volume claim templates of a manifest update differ from the
+// ones of the running statefulset
+type volumeClaimChanges struct {
+	resized map[string]resource.Quantity
+	added   []string
+	removed []string
+}
+
+func (c volumeClaimChanges) any() bool {
+	return len(c.resized) != 0 || len(c.added) != 0 || len(c.removed) != 0
+}
+
+// diffVolumeClaimTemplates compares the volume claim templates of a statefulset with the desired ones.
+// Volumes may be added, removed or grown. Shrinking a volume or moving it to another storage class
+// would lose data and is rejected.
+func diffVolumeClaimTemplates(current, desired []corev1.PersistentVolumeClaim) (volumeClaimChanges, error) {
+	changes := volumeClaimChanges{
+		resized: make(map[string]resource.Quantity),
+	}
+
+	existing := make(map[string]corev1.PersistentVolumeClaim, len(current))
+	for _, pvc := range current {
+		existing[pvc.Name] = pvc
+	}
+
+	for _, pvc := range desired {
+		prev, found := existing[pvc.Name]
+		if !found {
+			changes.added = append(changes.added, pvc.Name)
+			continue
+		}
+		delete(existing, pvc.Name)
+
+		prevClass := volumeClaimStorageClass(prev)
+		class := volumeClaimStorageClass(pvc)
+		if prevClass != class {
+			return volumeClaimChanges{}, fmt.Errorf("%w: volume %q cannot move from storage class %q to %q",
+				kubeclienterrors.ErrUnsupportedStorageChange, pvc.Name, prevClass, class)
+		}
+
+		prevSize := prev.Spec.Resources.Requests[corev1.ResourceStorage]
+		size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+
+		switch size.Cmp(prevSize) {
+		case -1:
+			return volumeClaimChanges{}, fmt.Errorf("%w: volume %q cannot shrink from %s to %s",
+				kubeclienterrors.ErrUnsupportedStorageChange, pvc.Name, prevSize.String(), size.String())
+		case 1:
+			changes.resized[pvc.Name] = size
+		}
+	}
+
+	for name := range existing {
+		changes.removed = append(changes.removed, name)
+	}
+	sort.Strings(changes.removed)
+
+	return changes, nil
+}
+
+func volumeClaimStorageClass(pvc corev1.PersistentVolumeClaim) string {
+	if pvc.Spec.StorageClassName == nil {
+		return ""
+	}
+	return *pvc.Spec.StorageClassName
+}
+
+// expandVolumeClaims grows the claims statefulset pods have been bound to, including the claims kept for
+// replicas scaled down since. Every storage class involved is checked to allow expansion before any claim
+// is touched.
+func expandVolumeClaims(ctx context.Context, kc kubernetes.Interface, sts *appsv1.StatefulSet, resized map[string]resource.Quantity) error {
+	if len(resized) == 0 {
+		return nil
+	}
+
+	for _, tmpl := range sts.Spec.VolumeClaimTemplates {
+		if _, grow := resized[tmpl.Name]; !grow {
+			continue
+		}
+
+		if err := checkStorageClassExpansion(ctx, kc, tmpl.Spec.StorageClassName); err != nil {
+			return fmt.Errorf("volume %q: %w", tmpl.Name, err)
+		}
+	}
+
+	// claims of pods not scheduled yet are created from the new template
+	claims, err := kc.CoreV1().PersistentVolumeClaims(sts.Namespace).List(ctx, metav1.ListOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "persistent-volume-claims-list", err)
+	if err != nil {
+		return err
+	}
+
+	for _, tmpl := range sts.Spec.VolumeClaimTemplates {
+		size, grow := resized[tmpl.Name]
+		if !grow {
+			continue
+		}
+
+		// name given by the statefulset controller to claims created from the template is
+		// <template>-<statefulset>-<ordinal>
+		prefix := fmt.Sprintf("%s-%s-", tmpl.Name, sts.Name)
+
+		for idx := range claims.Items {
+			pvc := &claims.Items[idx]
+			if !strings.HasPrefix(pvc.Name, prefix) {
+				continue
+			}
+			if _, err := strconv.Atoi(strings.TrimPrefix(pvc.Name, prefix)); err != nil {
+				continue
+			}
+
+			current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+			if current.Cmp(size) >= 0 {
+				continue
+			}
+
+			if pvc.Spec.Resources.Requests == nil {
+				pvc.Spec.Resources.Requests = make(corev1.ResourceList)
+			}
+			pvc.Spec.Resources.Requests[corev1.ResourceStorage] = size
+
+			_, err = kc.CoreV1().PersistentVolumeClaims(sts.Namespace).Update(ctx, pvc, metav1.UpdateOptions{})
+			metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "persistent-volume-claims-update", err)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkStorageClassExpansion returns an error unless the storage class, or the cluster default one
+// when no class is given, allows volume expansion
+func checkStorageClassExpansion(ctx context.Context, kc kubernetes.Interface, className *string) error {
+	if className == nil {
+		classes, err := kc.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "storage-classes-list", err)
+		if err != nil {
+			return err
+		}
+
+		for _, class := range classes.Items {
+			if class.Annotations[annotationDefaultStorageClass] != "true" {
+				continue
+			}
+
+			if class.AllowVolumeExpansion == nil || !*class.AllowVolumeExpansion {
+				return fmt.Errorf("%w: default storage class %q does not allow volume expansion",
+					kubeclienterrors.ErrUnsupportedStorageChange, class.Name)
+			}
+			return nil
+		}
+
+		return fmt.Errorf("%w: no default storage class to expand volume with", kubeclienterrors.ErrUnsupportedStorageChange)
+	}
+
+	class, err := kc.StorageV1().StorageClasses().Get(ctx, *className, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "storage-classes-get", err)
+	if err != nil {
+		return err
+	}
+
+	if class.AllowVolumeExpansion == nil || !*class.AllowVolumeExpansion {
+		return fmt.Errorf("%w: storage class %q does not allow volume expansion",
+			kubeclienterrors.ErrUnsupportedStorageChange, class.Name)
+	}
+
+	return nil
+}
+
+// deleteStatefulSetOrphan deletes the statefulset without its pods and waits for it to be gone,
+// so it can be created again under the same name
+func deleteStatefulSetOrphan(ctx context.Context, kc kubernetes.Interface, sts *appsv1.StatefulSet) error {
+	propagation := metav1.DeletePropagationOrphan
+
+	err := kc.AppsV1().StatefulSets(sts.Namespace).Delete(ctx, sts.Name, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "deployments-delete", err, errors.IsNotFound)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, statefulSetDeleteTimeout)
+	defer cancel()
+
+	return wait.PollImmediateUntil(statefulSetDeletePollPeriod, func() (bool, error) {
+		_, err := kc.AppsV1().StatefulSets(sts.Namespace).Get(ctx, sts.Name, metav1.GetOptions{})
+		metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "deployments-get", err, errors.IsNotFound)
+		switch {
+		case errors.IsNotFound(err):
+			return true, nil
+		case err != nil:
+			return false, err
+		}
+		return false, nil
+	}, ctx.Done())
+}
@@ -0,0 +1,171 @@
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	manifest "github.com/akash-network/node/manifest/v2beta1"
+	"github.com/akash-network/node/sdl"
+	"github.com/akash-network/node/testutil"
+	atypes "github.com/akash-network/node/types/v1beta2"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+	kubeclienterrors "github.com/akash-network/provider/cluster/kube/errors"
+)
+
+func persistentGroup(size uint64, class string) *manifest.Group {
+	return &manifest.Group{
+		Name: "default",
+		Services: []manifest.Service{{
+			Name:  "db",
+			Image: "postgres",
+			Count: 1,
+			Resources: atypes.ResourceUnits{
+				CPU:    &atypes.CPU{Units: atypes.NewResourceValue(100)},
+				Memory: &atypes.Memory{Quantity: atypes.NewResourceValue(128 * 1024 * 1024)},
+				Storage: atypes.Volumes{
+					{
+						Name:     "default",
+						Quantity: atypes.NewResourceValue(1024 * 1024 * 1024),
+					},
+					{
+						Name:     "data",
+						Quantity: atypes.NewResourceValue(size),
+						Attributes: atypes.Attributes{
+							{Key: sdl.StorageAttributePersistent, Value: "true"},
+							{Key: sdl.StorageAttributeClass, Value: class},
+						},
+					},
+				},
+			},
+			Params: &manifest.ServiceParams{
+				Storage: []manifest.StorageParams{{Name: "data", Mount: "/var/lib/data"}},
+			},
+		}},
+	}
+}
+
+func volumeClaim(name string, size int64, class string) corev1.PersistentVolumeClaim {
+	return corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &class,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: *resource.NewQuantity(size, resource.DecimalSI),
+				},
+			},
+		},
+	}
+}
+
+func TestDiffVolumeClaimTemplates(t *testing.T) {
+	current := []corev1.PersistentVolumeClaim{
+		volumeClaim("db-data", 1000, "beta2"),
+		volumeClaim("db-logs", 1000, "beta2"),
+	}
+
+	changes, err := diffVolumeClaimTemplates(current, current)
+	require.NoError(t, err)
+	require.False(t, changes.any())
+
+	changes, err = diffVolumeClaimTemplates(current, []corev1.PersistentVolumeClaim{
+		volumeClaim("db-data", 2000, "beta2"),
+		volumeClaim("db-cache", 1000, "beta2"),
+	})
+	require.NoError(t, err)
+	require.True(t, changes.any())
+	require.Len(t, changes.resized, 1)
+	size := changes.resized["db-data"]
+	require.Equal(t, int64(2000), size.Value())
+	require.Equal(t, []string{"db-cache"}, changes.added)
+	require.Equal(t, []string{"db-logs"}, changes.removed)
+
+	_, err = diffVolumeClaimTemplates(current, []corev1.PersistentVolumeClaim{
+		volumeClaim("db-data", 500, "beta2"),
+	})
+	require.ErrorIs(t, err, kubeclienterrors.ErrUnsupportedStorageChange)
+	require.Contains(t, err.Error(), "cannot shrink")
+
+	_, err = diffVolumeClaimTemplates(current, []corev1.PersistentVolumeClaim{
+		volumeClaim("db-data", 1000, "beta3"),
+	})
+	require.ErrorIs(t, err, kubeclienterrors.ErrUnsupportedStorageChange)
+}
+
+func TestApplyStatefulSetExpandsVolumes(t *testing.T) {
+	ctx := context.Background()
+	log := testutil.Logger(t)
+	lid := testutil.LeaseID(t)
+	settings := builder.NewDefaultSettings()
+
+	allowExpansion := true
+	kc := kubefake.NewSimpleClientset(&storagev1.StorageClass{
+		ObjectMeta:           metav1.ObjectMeta{Name: "beta2"},
+		AllowVolumeExpansion: &allowExpansion,
+	})
+
+	group := persistentGroup(1000, "beta2")
+	require.NoError(t, applyStatefulSet(ctx, kc, builder.BuildStatefulSet(log, settings, lid, group, &group.Services[0])))
+
+	ns := builder.LidNS(lid)
+	pvc := volumeClaim("db-data-db-0", 1000, "beta2")
+	_, err := kc.CoreV1().PersistentVolumeClaims(ns).Create(ctx, &pvc, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// kept from a replica scaled down since
+	pvc = volumeClaim("db-data-db-3", 1000, "beta2")
+	_, err = kc.CoreV1().PersistentVolumeClaims(ns).Create(ctx, &pvc, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	group = persistentGroup(2000, "beta2")
+	require.NoError(t, applyStatefulSet(ctx, kc, builder.BuildStatefulSet(log, settings, lid, group, &group.Services[0])))
+
+	sts, err := kc.AppsV1().StatefulSets(ns).Get(ctx, "db", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, sts.Spec.VolumeClaimTemplates, 1)
+	size := sts.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage]
+	require.Equal(t, int64(2000), size.Value())
+
+	for _, name := range []string{"db-data-db-0", "db-data-db-3"} {
+		claim, err := kc.CoreV1().PersistentVolumeClaims(ns).Get(ctx, name, metav1.GetOptions{})
+		require.NoError(t, err)
+		size = claim.Spec.Resources.Requests[corev1.ResourceStorage]
+		require.Equal(t, int64(2000), size.Value(), name)
+	}
+
+	group = persistentGroup(500, "beta2")
+	err = applyStatefulSet(ctx, kc, builder.BuildStatefulSet(log, settings, lid, group, &group.Services[0]))
+	require.ErrorIs(t, err, kubeclienterrors.ErrUnsupportedStorageChange)
+}
+
+func TestApplyStatefulSetRejectsExpansionOfFixedClass(t *testing.T) {
+	ctx := context.Background()
+	log := testutil.Logger(t)
+	lid := testutil.LeaseID(t)
+	settings := builder.NewDefaultSettings()
+
+	kc := kubefake.NewSimpleClientset(&storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "beta2"},
+	})
+
+	group := persistentGroup(1000, "beta2")
+	require.NoError(t, applyStatefulSet(ctx, kc, builder.BuildStatefulSet(log, settings, lid, group, &group.Services[0])))
+
+	group = persistentGroup(2000, "beta2")
+	err := applyStatefulSet(ctx, kc, builder.BuildStatefulSet(log, settings, lid, group, &group.Services[0]))
+	require.ErrorIs(t, err, kubeclienterrors.ErrUnsupportedStorageChange)
+
+	// statefulset is left untouched
+	sts, err := kc.AppsV1().StatefulSets(builder.LidNS(lid)).Get(ctx, "db", metav1.GetOptions{})
+	require.NoError(t, err)
+	size := sts.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage]
+	require.Equal(t, int64(1000), size.Value())
+}
@@ -0,0 +1,393 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tendermint/tendermint/libs/log"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	metricsutils "github.com/akash-network/node/util/metrics"
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+)
+
+// retainLeaseVolumes detaches the persistent volumes bound to the claims of a lease from their lifecycle,
+// so they outlive the namespace until the given time. Volumes are labelled with the lease owner, service,
+// volume name and replica ordinal they belonged to.
+func retainLeaseVolumes(ctx context.Context, kc kubernetes.Interface, lid mtypes.LeaseID, until time.Time) error {
+	ns := builder.LidNS(lid)
+
+	claims, err := kc.CoreV1().PersistentVolumeClaims(ns).List(ctx, metav1.ListOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "persistent-volume-claims-list", err)
+	if err != nil {
+		return err
+	}
+
+	for _, claim := range claims.Items {
+		if claim.Spec.VolumeName == "" {
+			continue
+		}
+
+		service, volume, ordinal, valid := parseStatefulSetClaimName(claim)
+		if !valid {
+			continue
+		}
+
+		pv, err := kc.CoreV1().PersistentVolumes().Get(ctx, claim.Spec.VolumeName, metav1.GetOptions{})
+		metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "persistent-volumes-get", err, errors.IsNotFound)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if pv.Labels[builder.AkashRetainedVolumeLabelName] == "true" {
+			// already retained by a previous attempt
+			continue
+		}
+
+		if pv.Labels == nil {
+			pv.Labels = make(map[string]string)
+		}
+		if pv.Annotations == nil {
+			pv.Annotations = make(map[string]string)
+		}
+
+		builder.AppendLeaseLabels(lid, pv.Labels)
+		pv.Labels[builder.AkashRetainedVolumeLabelName] = "true"
+		pv.Labels[builder.AkashManifestServiceLabelName] = service
+		pv.Labels[builder.AkashRetainedVolumeNameLabelName] = volume
+		pv.Labels[builder.AkashRetainedVolumeOrdinalLabelName] = strconv.Itoa(ordinal)
+		pv.Annotations[builder.AkashRetainedUntilAnnotation] = until.UTC().Format(time.RFC3339)
+		pv.Annotations[builder.AkashRetainedReclaimPolicyAnnotation] = string(pv.Spec.PersistentVolumeReclaimPolicy)
+		pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimRetain
+
+		_, err = kc.CoreV1().PersistentVolumes().Update(ctx, pv, metav1.UpdateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "persistent-volumes-update", err)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseStatefulSetClaimName splits the name the statefulset controller gives claims, which is
+// <service>-<volume>-<service>-<ordinal>
+func parseStatefulSetClaimName(claim corev1.PersistentVolumeClaim) (string, string, int, bool) {
+	service := claim.Labels[builder.AkashManifestServiceLabelName]
+	if service == "" {
+		return "", "", 0, false
+	}
+
+	idx := strings.LastIndex(claim.Name, "-")
+	if idx == -1 {
+		return "", "", 0, false
+	}
+
+	ordinal, err := strconv.Atoi(claim.Name[idx+1:])
+	if err != nil {
+		return "", "", 0, false
+	}
+
+	template := strings.TrimSuffix(claim.Name[:idx], "-"+service)
+	if template == claim.Name[:idx] || !strings.HasPrefix(template, service+"-") {
+		return "", "", 0, false
+	}
+
+	return service, strings.TrimPrefix(template, service+"-"), ordinal, true
+}
+
+// restoreRetainedVolumes binds volumes retained from a previous lease of the same owner to the claims
+// the statefulset is about to create. A volume is reused for the same service, volume name and replica
+// ordinal when it is large enough for the claim. Claims which already exist are left alone.
+func restoreRetainedVolumes(ctx context.Context, kc kubernetes.Interface, lid mtypes.LeaseID, b builder.StatefulSet) error {
+	sts, err := b.Create()
+	if err != nil {
+		return err
+	}
+
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+
+	for _, tmpl := range sts.Spec.VolumeClaimTemplates {
+		volume := strings.TrimPrefix(tmpl.Name, sts.Name+"-")
+
+		for ordinal := int32(0); ordinal < replicas; ordinal++ {
+			name := fmt.Sprintf("%s-%s-%d", tmpl.Name, sts.Name, ordinal)
+
+			_, err := kc.CoreV1().PersistentVolumeClaims(b.NS()).Get(ctx, name, metav1.GetOptions{})
+			metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "persistent-volume-claims-get", err, errors.IsNotFound)
+			if err == nil {
+				continue
+			}
+			if !errors.IsNotFound(err) {
+				return err
+			}
+
+			pv, err := findRetainedVolume(ctx, kc, lid.Owner, sts.Name, volume, int(ordinal), tmpl)
+			if err != nil {
+				return err
+			}
+			if pv == nil {
+				continue
+			}
+
+			if err := bindRetainedVolume(ctx, kc, pv, b.NS(), name, statefulSetClaimLabels(lid, sts, tmpl), tmpl); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func findRetainedVolume(ctx context.Context, kc kubernetes.Interface, owner, service, volume string, ordinal int, tmpl corev1.PersistentVolumeClaim) (*corev1.PersistentVolume, error) {
+	selector := labels.SelectorFromSet(labels.Set{
+		builder.AkashRetainedVolumeLabelName:        "true",
+		builder.AkashLeaseOwnerLabelName:            owner,
+		builder.AkashManifestServiceLabelName:       service,
+		builder.AkashRetainedVolumeNameLabelName:    volume,
+		builder.AkashRetainedVolumeOrdinalLabelName: strconv.Itoa(ordinal),
+	})
+
+	pvs, err := kc.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "persistent-volumes-list", err)
+	if err != nil {
+		return nil, err
+	}
+
+	request := tmpl.Spec.Resources.Requests[corev1.ResourceStorage]
+
+	// prefer the most recently retained volume
+	var found *corev1.PersistentVolume
+	for idx := range pvs.Items {
+		pv := &pvs.Items[idx]
+
+		capacity := pv.Spec.Capacity[corev1.ResourceStorage]
+		if capacity.Cmp(request) < 0 {
+			continue
+		}
+
+		if tmpl.Spec.StorageClassName != nil && *tmpl.Spec.StorageClassName != pv.Spec.StorageClassName {
+			continue
+		}
+
+		if found == nil || pv.Annotations[builder.AkashRetainedUntilAnnotation] > found.Annotations[builder.AkashRetainedUntilAnnotation] {
+			found = pv
+		}
+	}
+
+	return found, nil
+}
+
+// statefulSetClaimLabels returns the labels of the claims the statefulset controller creates from tmpl, along
+// with the lease labels. The service label is what finds the claim again when the lease is closed.
+func statefulSetClaimLabels(lid mtypes.LeaseID, sts *appsv1.StatefulSet, tmpl corev1.PersistentVolumeClaim) map[string]string {
+	result := make(map[string]string, len(tmpl.Labels))
+	for k, v := range tmpl.Labels {
+		result[k] = v
+	}
+
+	if sts.Spec.Selector != nil {
+		for k, v := range sts.Spec.Selector.MatchLabels {
+			result[k] = v
+		}
+	}
+
+	result[builder.AkashManagedLabelName] = "true"
+	result[builder.AkashManifestServiceLabelName] = sts.Name
+
+	return builder.AppendLeaseLabels(lid, result)
+}
+
+func bindRetainedVolume(ctx context.Context, kc kubernetes.Interface, pv *corev1.PersistentVolume, ns, name string, claimLabels map[string]string, tmpl corev1.PersistentVolumeClaim) error {
+	// pre-bind the volume to the claim, the claim of the closed lease is gone
+	pv.Spec.ClaimRef = &corev1.ObjectReference{
+		Kind:       "PersistentVolumeClaim",
+		APIVersion: "v1",
+		Namespace:  ns,
+		Name:       name,
+	}
+
+	policy := corev1.PersistentVolumeReclaimPolicy(pv.Annotations[builder.AkashRetainedReclaimPolicyAnnotation])
+	if policy == "" {
+		policy = corev1.PersistentVolumeReclaimDelete
+	}
+	pv.Spec.PersistentVolumeReclaimPolicy = policy
+
+	delete(pv.Labels, builder.AkashRetainedVolumeLabelName)
+	delete(pv.Annotations, builder.AkashRetainedUntilAnnotation)
+	delete(pv.Annotations, builder.AkashRetainedReclaimPolicyAnnotation)
+
+	_, err := kc.CoreV1().PersistentVolumes().Update(ctx, pv, metav1.UpdateOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "persistent-volumes-update", err)
+	if err != nil {
+		return err
+	}
+
+	className := pv.Spec.StorageClassName
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    claimLabels,
+		},
+		Spec: *tmpl.Spec.DeepCopy(),
+	}
+	claim.Spec.VolumeName = pv.Name
+	claim.Spec.StorageClassName = &className
+
+	_, err = kc.CoreV1().PersistentVolumeClaims(ns).Create(ctx, claim, metav1.CreateOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "persistent-volume-claims-create", err)
+
+	return err
+}
+
+// RetainedVolumeCollector deletes retained persistent volumes and volume snapshot contents once their
+// retention period expired
+type RetainedVolumeCollector struct {
+	log    log.Logger
+	kc     kubernetes.Interface
+	dc     dynamic.Interface
+	period time.Duration
+}
+
+// NewRetainedVolumeCollector returns a collector of retained volumes, snapshot contents are only
+// collected when dc is not nil
+func NewRetainedVolumeCollector(log log.Logger, kc kubernetes.Interface, dc dynamic.Interface, period time.Duration) *RetainedVolumeCollector {
+	return &RetainedVolumeCollector{
+		log:    log.With("cmp", "retained-volume-collector"),
+		kc:     kc,
+		dc:     dc,
+		period: period,
+	}
+}
+
+// Run deletes expired volumes every period until the context is done
+func (c *RetainedVolumeCollector) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.period)
+	defer ticker.Stop()
+
+	for {
+		if err := c.collect(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			c.log.Error("collecting retained volumes", "err", err)
+		}
+
+		if c.dc != nil {
+			if err := c.collectSnapshotContents(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				c.log.Error("collecting retained volume snapshots", "err", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *RetainedVolumeCollector) collect(ctx context.Context, now time.Time) error {
+	pvs, err := c.kc.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{
+		LabelSelector: builder.AkashRetainedVolumeLabelName + "=true",
+	})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "persistent-volumes-list", err)
+	if err != nil {
+		return err
+	}
+
+	for idx := range pvs.Items {
+		pv := &pvs.Items[idx]
+
+		until, err := time.Parse(time.RFC3339, pv.Annotations[builder.AkashRetainedUntilAnnotation])
+		if err != nil {
+			c.log.Error("retained volume with invalid expiry", "volume", pv.Name, "err", err)
+			continue
+		}
+
+		if now.Before(until) {
+			continue
+		}
+
+		c.log.Info("retention expired, deleting volume", "volume", pv.Name, "owner", pv.Labels[builder.AkashLeaseOwnerLabelName])
+
+		// the released volume and its storage are reclaimed by the persistent volume controller
+		delete(pv.Labels, builder.AkashRetainedVolumeLabelName)
+		pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimDelete
+
+		_, err = c.kc.CoreV1().PersistentVolumes().Update(ctx, pv, metav1.UpdateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "persistent-volumes-update", err)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *RetainedVolumeCollector) collectSnapshotContents(ctx context.Context, now time.Time) error {
+	contents, err := c.dc.Resource(volumeSnapshotContentResource).List(ctx, metav1.ListOptions{
+		LabelSelector: builder.AkashRetainedVolumeLabelName + "=true",
+	})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "volume-snapshot-contents-list", err, errors.IsNotFound)
+	if errors.IsNotFound(err) {
+		// the snapshot CRDs are not installed
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for idx := range contents.Items {
+		content := &contents.Items[idx]
+
+		until, err := time.Parse(time.RFC3339, content.GetAnnotations()[builder.AkashRetainedUntilAnnotation])
+		if err != nil {
+			c.log.Error("retained volume snapshot with invalid expiry", "content", content.GetName(), "err", err)
+			continue
+		}
+
+		if now.Before(until) {
+			continue
+		}
+
+		c.log.Info("retention expired, deleting volume snapshot", "content", content.GetName(), "owner", content.GetLabels()[builder.AkashLeaseOwnerLabelName])
+
+		// the snapshot in the storage backend is deleted along with its content
+		if err := unstructured.SetNestedField(content.Object, volumeSnapshotDeletionPolicyDelete, "spec", "deletionPolicy"); err != nil {
+			return err
+		}
+
+		_, err = c.dc.Resource(volumeSnapshotContentResource).Update(ctx, content, metav1.UpdateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "volume-snapshot-contents-update", err)
+		if err != nil {
+			return err
+		}
+
+		err = c.dc.Resource(volumeSnapshotContentResource).Delete(ctx, content.GetName(), metav1.DeleteOptions{})
+		metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "volume-snapshot-contents-delete", err, errors.IsNotFound)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
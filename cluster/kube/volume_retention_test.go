@@ -0,0 +1,150 @@
+package kube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/akash-network/node/testutil"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+)
+
+func TestParseStatefulSetClaimName(t *testing.T) {
+	claim := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "db-wal-data-db-2",
+			Labels: map[string]string{builder.AkashManifestServiceLabelName: "db"},
+		},
+	}
+
+	service, volume, ordinal, valid := parseStatefulSetClaimName(claim)
+	require.True(t, valid)
+	require.Equal(t, "db", service)
+	require.Equal(t, "wal-data", volume)
+	require.Equal(t, 2, ordinal)
+
+	claim.Name = "other-db-2"
+	_, _, _, valid = parseStatefulSetClaimName(claim)
+	require.False(t, valid)
+}
+
+func TestRetainAndRestoreLeaseVolumes(t *testing.T) {
+	ctx := context.Background()
+	log := testutil.Logger(t)
+	lid := testutil.LeaseID(t)
+	ns := builder.LidNS(lid)
+
+	claim := volumeClaim("db-data-db-0", 1000, "beta2")
+	claim.Namespace = ns
+	claim.Labels = map[string]string{builder.AkashManifestServiceLabelName: "db"}
+	claim.Spec.VolumeName = "pv-1"
+
+	kc := kubefake.NewSimpleClientset(&claim, &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity: corev1.ResourceList{
+				corev1.ResourceStorage: *resource.NewQuantity(1000, resource.DecimalSI),
+			},
+			StorageClassName:              "beta2",
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+			ClaimRef:                      &corev1.ObjectReference{Namespace: ns, Name: claim.Name, UID: "uid-1"},
+		},
+	})
+
+	until := time.Now().Add(time.Hour)
+	require.NoError(t, retainLeaseVolumes(ctx, kc, lid, until))
+
+	pv, err := kc.CoreV1().PersistentVolumes().Get(ctx, "pv-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, corev1.PersistentVolumeReclaimRetain, pv.Spec.PersistentVolumeReclaimPolicy)
+	require.Equal(t, "true", pv.Labels[builder.AkashRetainedVolumeLabelName])
+	require.Equal(t, lid.Owner, pv.Labels[builder.AkashLeaseOwnerLabelName])
+	require.Equal(t, "data", pv.Labels[builder.AkashRetainedVolumeNameLabelName])
+	require.Equal(t, "0", pv.Labels[builder.AkashRetainedVolumeOrdinalLabelName])
+
+	// lease namespace is deleted
+	require.NoError(t, kc.CoreV1().PersistentVolumeClaims(ns).Delete(ctx, claim.Name, metav1.DeleteOptions{}))
+
+	// not expired yet
+	collector := NewRetainedVolumeCollector(log, kc, nil, time.Minute)
+	require.NoError(t, collector.collect(ctx, time.Now()))
+
+	// the owner deploys again
+	next := lid
+	next.DSeq++
+	group := persistentGroup(1000, "beta2")
+	require.NoError(t, restoreRetainedVolumes(ctx, kc, next, builder.BuildStatefulSet(log, builder.Settings{}, next, group, &group.Services[0])))
+
+	restored, err := kc.CoreV1().PersistentVolumeClaims(builder.LidNS(next)).Get(ctx, "db-data-db-0", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "pv-1", restored.Spec.VolumeName)
+
+	pv, err = kc.CoreV1().PersistentVolumes().Get(ctx, "pv-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, corev1.PersistentVolumeReclaimDelete, pv.Spec.PersistentVolumeReclaimPolicy)
+	require.Equal(t, builder.LidNS(next), pv.Spec.ClaimRef.Namespace)
+	require.Empty(t, pv.Spec.ClaimRef.UID)
+	require.NotContains(t, pv.Labels, builder.AkashRetainedVolumeLabelName)
+	require.Equal(t, "true", restored.Labels[builder.AkashManagedLabelName])
+	require.Equal(t, "db", restored.Labels[builder.AkashManifestServiceLabelName])
+	require.Equal(t, next.Owner, restored.Labels[builder.AkashLeaseOwnerLabelName])
+
+	// the restored volume is retained again when the second lease is closed
+	require.NoError(t, retainLeaseVolumes(ctx, kc, next, until.Add(time.Hour)))
+
+	pv, err = kc.CoreV1().PersistentVolumes().Get(ctx, "pv-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, corev1.PersistentVolumeReclaimRetain, pv.Spec.PersistentVolumeReclaimPolicy)
+	require.Equal(t, "true", pv.Labels[builder.AkashRetainedVolumeLabelName])
+	require.Equal(t, "data", pv.Labels[builder.AkashRetainedVolumeNameLabelName])
+	require.Equal(t, "0", pv.Labels[builder.AkashRetainedVolumeOrdinalLabelName])
+	require.Equal(t, string(corev1.PersistentVolumeReclaimDelete), pv.Annotations[builder.AkashRetainedReclaimPolicyAnnotation])
+
+	require.NoError(t, kc.CoreV1().PersistentVolumeClaims(builder.LidNS(next)).Delete(ctx, restored.Name, metav1.DeleteOptions{}))
+
+	third := next
+	third.DSeq++
+	require.NoError(t, restoreRetainedVolumes(ctx, kc, third, builder.BuildStatefulSet(log, builder.Settings{}, third, group, &group.Services[0])))
+
+	restored, err = kc.CoreV1().PersistentVolumeClaims(builder.LidNS(third)).Get(ctx, "db-data-db-0", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "pv-1", restored.Spec.VolumeName)
+}
+
+func TestRetainedVolumeCollectorDeletesExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	retained := func(name string, until time.Time) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      map[string]string{builder.AkashRetainedVolumeLabelName: "true"},
+				Annotations: map[string]string{builder.AkashRetainedUntilAnnotation: until.UTC().Format(time.RFC3339)},
+			},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+			},
+		}
+	}
+
+	kc := kubefake.NewSimpleClientset(retained("expired", now.Add(-time.Minute)), retained("kept", now.Add(time.Hour)))
+
+	collector := NewRetainedVolumeCollector(testutil.Logger(t), kc, nil, time.Minute)
+	require.NoError(t, collector.collect(ctx, now))
+
+	pv, err := kc.CoreV1().PersistentVolumes().Get(ctx, "expired", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, corev1.PersistentVolumeReclaimDelete, pv.Spec.PersistentVolumeReclaimPolicy)
+
+	pv, err = kc.CoreV1().PersistentVolumes().Get(ctx, "kept", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, corev1.PersistentVolumeReclaimRetain, pv.Spec.PersistentVolumeReclaimPolicy)
+}
@@ -0,0 +1,546 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tendermint/tendermint/libs/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	metricsutils "github.com/akash-network/node/util/metrics"
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+	kubeclienterrors "github.com/akash-network/provider/cluster/kube/errors"
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+)
+
+const (
+	volumeSnapshotAPIGroup = "snapshot.storage.k8s.io"
+	volumeSnapshotKind     = "VolumeSnapshot"
+
+	volumeSnapshotDeletionPolicyRetain = "Retain"
+	volumeSnapshotDeletionPolicyDelete = "Delete"
+
+	volumeSnapshotPollPeriod  = time.Second
+	volumeSnapshotBindTimeout = time.Minute
+	volumeRestoreTimeout      = 2 * time.Minute
+
+	// volumeSnapshotRestoreDeadline bounds a restore running in the background, which scales
+	// the statefulset down, waits for the claim to be deleted and scales it back up
+	volumeSnapshotRestoreDeadline = 2*volumeRestoreTimeout + time.Minute
+)
+
+// volumeSnapshotResource is accessed through the dynamic client, the snapshot CRDs are
+// installed along with the CSI snapshot controller and are not part of client-go
+var volumeSnapshotResource = schema.GroupVersionResource{
+	Group:    volumeSnapshotAPIGroup,
+	Version:  "v1",
+	Resource: "volumesnapshots",
+}
+
+// volumeSnapshotContentResource holds the data of a snapshot, it is cluster scoped and outlives
+// the lease namespace when its deletion policy is Retain
+var volumeSnapshotContentResource = schema.GroupVersionResource{
+	Group:    volumeSnapshotAPIGroup,
+	Version:  "v1",
+	Resource: "volumesnapshotcontents",
+}
+
+// snapshotLeaseVolumes takes a snapshot of every bound persistent volume claim of the lease
+// and returns the names of the snapshots created
+func snapshotLeaseVolumes(ctx context.Context, kc kubernetes.Interface, dc dynamic.Interface, lid mtypes.LeaseID, class string, trigger string, keep uint) ([]string, error) {
+	ns := builder.LidNS(lid)
+
+	claims, err := kc.CoreV1().PersistentVolumeClaims(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: builder.AkashManagedLabelName + "=true",
+	})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "persistent-volume-claims-list", err)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	names := make([]string, 0, len(claims.Items))
+
+	for _, claim := range claims.Items {
+		if claim.Status.Phase != corev1.ClaimBound {
+			continue
+		}
+
+		name, err := createVolumeSnapshot(ctx, dc, claim, class, trigger, now)
+		if err != nil {
+			return names, err
+		}
+		names = append(names, name)
+
+		if err := pruneVolumeSnapshots(ctx, dc, ns, claim.Name, keep); err != nil {
+			return names, err
+		}
+	}
+
+	return names, nil
+}
+
+func createVolumeSnapshot(ctx context.Context, dc dynamic.Interface, claim corev1.PersistentVolumeClaim, class string, trigger string, now time.Time) (string, error) {
+	name := fmt.Sprintf("%s-%s-%d", claim.Name, trigger, now.Unix())
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": volumeSnapshotResource.GroupVersion().String(),
+			"kind":       volumeSnapshotKind,
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": claim.Namespace,
+				"labels": map[string]interface{}{
+					builder.AkashManagedLabelName:               "true",
+					builder.AkashManifestServiceLabelName:       claim.Labels[builder.AkashManifestServiceLabelName],
+					builder.AkashVolumeSnapshotTriggerLabelName: trigger,
+				},
+			},
+			"spec": map[string]interface{}{
+				"volumeSnapshotClassName": class,
+				"source": map[string]interface{}{
+					"persistentVolumeClaimName": claim.Name,
+				},
+			},
+		},
+	}
+
+	_, err := dc.Resource(volumeSnapshotResource).Namespace(claim.Namespace).Create(ctx, obj, metav1.CreateOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "volume-snapshots-create", err, errors.IsAlreadyExists)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// pruneVolumeSnapshots deletes the oldest snapshots of the claim so no more than keep remain
+func pruneVolumeSnapshots(ctx context.Context, dc dynamic.Interface, ns string, claim string, keep uint) error {
+	if keep == 0 {
+		return nil
+	}
+
+	snapshots, err := listVolumeSnapshots(ctx, dc, ns)
+	if err != nil {
+		return err
+	}
+
+	var owned []*unstructured.Unstructured // nolint:prealloc
+	for idx := range snapshots {
+		if volumeSnapshotClaim(&snapshots[idx]) == claim {
+			owned = append(owned, &snapshots[idx])
+		}
+	}
+
+	if uint(len(owned)) <= keep {
+		return nil
+	}
+
+	sort.Slice(owned, func(i, j int) bool {
+		return owned[i].GetCreationTimestamp().After(owned[j].GetCreationTimestamp().Time)
+	})
+
+	for _, obj := range owned[keep:] {
+		err := dc.Resource(volumeSnapshotResource).Namespace(ns).Delete(ctx, obj.GetName(), metav1.DeleteOptions{})
+		metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "volume-snapshots-delete", err, errors.IsNotFound)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func listVolumeSnapshots(ctx context.Context, dc dynamic.Interface, ns string) ([]unstructured.Unstructured, error) {
+	list, err := dc.Resource(volumeSnapshotResource).Namespace(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: builder.AkashManagedLabelName + "=true",
+	})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "volume-snapshots-list", err)
+	if err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
+func volumeSnapshotClaim(obj *unstructured.Unstructured) string {
+	claim, _, _ := unstructured.NestedString(obj.Object, "spec", "source", "persistentVolumeClaimName")
+	return claim
+}
+
+func volumeSnapshotReady(obj *unstructured.Unstructured) bool {
+	ready, _, _ := unstructured.NestedBool(obj.Object, "status", "readyToUse")
+	return ready
+}
+
+func volumeSnapshotStatus(obj *unstructured.Unstructured) ctypes.VolumeSnapshot {
+	result := ctypes.VolumeSnapshot{
+		Name:       obj.GetName(),
+		Service:    obj.GetLabels()[builder.AkashManifestServiceLabelName],
+		Trigger:    obj.GetLabels()[builder.AkashVolumeSnapshotTriggerLabelName],
+		ReadyToUse: volumeSnapshotReady(obj),
+		CreatedAt:  obj.GetCreationTimestamp().UTC(),
+	}
+
+	result.RestoreSize, _, _ = unstructured.NestedString(obj.Object, "status", "restoreSize")
+	result.RestoreState = obj.GetAnnotations()[builder.AkashVolumeSnapshotRestoreStateAnnotationName]
+	result.RestoreMessage = obj.GetAnnotations()[builder.AkashVolumeSnapshotRestoreMessageAnnotationName]
+
+	claim := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   volumeSnapshotClaim(obj),
+			Labels: obj.GetLabels(),
+		},
+	}
+	if _, volume, ordinal, valid := parseStatefulSetClaimName(claim); valid {
+		result.Volume = volume
+		result.Replica = ordinal
+	}
+
+	return result
+}
+
+// retainVolumeSnapshots sets the deletion policy of the content of the given snapshots to Retain until
+// the given time, so the data is kept once the lease namespace and the snapshots in it are deleted. The
+// content is labeled with the lease it belongs to and deleted by the RetainedVolumeCollector once expired.
+// Contents whose class already retains them never expire.
+func retainVolumeSnapshots(ctx context.Context, dc dynamic.Interface, lid mtypes.LeaseID, names []string, until time.Time) error {
+	ns := builder.LidNS(lid)
+
+	ctx, cancel := context.WithTimeout(ctx, volumeSnapshotBindTimeout)
+	defer cancel()
+
+	contents := make(map[string]*unstructured.Unstructured, len(names))
+
+	// the snapshot controller binds a content to every snapshot shortly after it is created
+	err := wait.PollImmediateUntil(volumeSnapshotPollPeriod, func() (bool, error) {
+		for _, name := range names {
+			if _, exists := contents[name]; exists {
+				continue
+			}
+
+			obj, err := dc.Resource(volumeSnapshotResource).Namespace(ns).Get(ctx, name, metav1.GetOptions{})
+			metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "volume-snapshots-get", err)
+			if err != nil {
+				return false, err
+			}
+
+			if content, _, _ := unstructured.NestedString(obj.Object, "status", "boundVolumeSnapshotContentName"); content != "" {
+				contents[name] = obj
+			}
+		}
+
+		return len(contents) == len(names), nil
+	}, ctx.Done())
+	if err != nil {
+		return err
+	}
+
+	for _, snapshot := range contents {
+		name, _, _ := unstructured.NestedString(snapshot.Object, "status", "boundVolumeSnapshotContentName")
+
+		content, err := dc.Resource(volumeSnapshotContentResource).Get(ctx, name, metav1.GetOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "volume-snapshot-contents-get", err)
+		if err != nil {
+			return err
+		}
+
+		// contents the class already retains are kept as the provider configured them
+		policy, _, _ := unstructured.NestedString(content.Object, "spec", "deletionPolicy")
+		expires := policy != volumeSnapshotDeletionPolicyRetain
+		if expires {
+			if err := unstructured.SetNestedField(content.Object, volumeSnapshotDeletionPolicyRetain, "spec", "deletionPolicy"); err != nil {
+				return err
+			}
+
+			annotations := content.GetAnnotations()
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			annotations[builder.AkashRetainedUntilAnnotation] = until.UTC().Format(time.RFC3339)
+			content.SetAnnotations(annotations)
+		}
+
+		labels := content.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		for key, val := range snapshot.GetLabels() {
+			labels[key] = val
+		}
+		builder.AppendLeaseLabels(lid, labels)
+		if expires {
+			labels[builder.AkashRetainedVolumeLabelName] = "true"
+		}
+		content.SetLabels(labels)
+
+		_, err = dc.Resource(volumeSnapshotContentResource).Update(ctx, content, metav1.UpdateOptions{})
+		metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "volume-snapshot-contents-update", err)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// volumeSnapshotRestore is a restore of a snapshot checked by prepareVolumeSnapshotRestore
+type volumeSnapshotRestore struct {
+	snapshot string
+	service  string
+	claim    *corev1.PersistentVolumeClaim
+}
+
+// prepareVolumeSnapshotRestore checks the snapshot can be restored and looks up the claim it replaces
+func prepareVolumeSnapshotRestore(ctx context.Context, kc kubernetes.Interface, dc dynamic.Interface, lid mtypes.LeaseID, name string) (volumeSnapshotRestore, error) {
+	ns := builder.LidNS(lid)
+
+	obj, err := dc.Resource(volumeSnapshotResource).Namespace(ns).Get(ctx, name, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "volume-snapshots-get", err, errors.IsNotFound)
+	if errors.IsNotFound(err) || (err == nil && obj.GetLabels()[builder.AkashManagedLabelName] != "true") {
+		return volumeSnapshotRestore{}, fmt.Errorf("%w: %q", kubeclienterrors.ErrVolumeSnapshotNotFound, name)
+	}
+	if err != nil {
+		return volumeSnapshotRestore{}, err
+	}
+
+	if !volumeSnapshotReady(obj) {
+		return volumeSnapshotRestore{}, fmt.Errorf("%w: %q", kubeclienterrors.ErrVolumeSnapshotNotReady, name)
+	}
+
+	claim, err := kc.CoreV1().PersistentVolumeClaims(ns).Get(ctx, volumeSnapshotClaim(obj), metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "persistent-volume-claims-get", err)
+	if err != nil {
+		return volumeSnapshotRestore{}, err
+	}
+
+	service, _, _, valid := parseStatefulSetClaimName(*claim)
+	if !valid {
+		return volumeSnapshotRestore{}, fmt.Errorf("%w: %q is not a volume of a service", kubeclienterrors.ErrVolumeSnapshotNotFound, name)
+	}
+
+	return volumeSnapshotRestore{
+		snapshot: name,
+		service:  service,
+		claim:    claim,
+	}, nil
+}
+
+// restoreVolumeSnapshot replaces the claim a snapshot was taken from with a new one provisioned from
+// the snapshot. The statefulset owning the claim is scaled down while the claim is replaced.
+func restoreVolumeSnapshot(ctx context.Context, kc kubernetes.Interface, restore volumeSnapshotRestore) error {
+	ns := restore.claim.Namespace
+
+	replicas, err := scaleStatefulSet(ctx, kc, ns, restore.service, 0)
+	if err != nil {
+		return err
+	}
+
+	// bring the service back even if replacing the claim failed
+	defer func() {
+		_, _ = scaleStatefulSet(context.Background(), kc, ns, restore.service, replicas)
+	}()
+
+	return replaceVolumeClaim(ctx, kc, restore.claim, restore.snapshot)
+}
+
+// setVolumeSnapshotRestoreState records the progress of a restore on the snapshot, where the tenant
+// reads it from when listing the snapshots of the lease
+func setVolumeSnapshotRestoreState(ctx context.Context, dc dynamic.Interface, ns string, name string, state string, message string) error {
+	obj, err := dc.Resource(volumeSnapshotResource).Namespace(ns).Get(ctx, name, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "volume-snapshots-get", err)
+	if err != nil {
+		return err
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[builder.AkashVolumeSnapshotRestoreStateAnnotationName] = state
+	annotations[builder.AkashVolumeSnapshotRestoreMessageAnnotationName] = message
+	obj.SetAnnotations(annotations)
+
+	_, err = dc.Resource(volumeSnapshotResource).Namespace(ns).Update(ctx, obj, metav1.UpdateOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "volume-snapshots-update", err)
+
+	return err
+}
+
+// scaleStatefulSet sets the replicas of the statefulset and returns the previous value
+func scaleStatefulSet(ctx context.Context, kc kubernetes.Interface, ns string, name string, replicas int32) (int32, error) {
+	sts, err := kc.AppsV1().StatefulSets(ns).Get(ctx, name, metav1.GetOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "statefulset-get", err)
+	if err != nil {
+		return 0, err
+	}
+
+	previous := int32(1)
+	if sts.Spec.Replicas != nil {
+		previous = *sts.Spec.Replicas
+	}
+
+	sts.Spec.Replicas = &replicas
+	_, err = kc.AppsV1().StatefulSets(ns).Update(ctx, sts, metav1.UpdateOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "statefulset-update", err)
+	if err != nil {
+		return 0, err
+	}
+
+	return previous, nil
+}
+
+func replaceVolumeClaim(ctx context.Context, kc kubernetes.Interface, claim *corev1.PersistentVolumeClaim, snapshot string) error {
+	err := kc.CoreV1().PersistentVolumeClaims(claim.Namespace).Delete(ctx, claim.Name, metav1.DeleteOptions{})
+	metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "persistent-volume-claims-delete", err, errors.IsNotFound)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	// the claim is protected until the pods using it are gone
+	waitCtx, cancel := context.WithTimeout(ctx, volumeRestoreTimeout)
+	defer cancel()
+
+	err = wait.PollImmediateUntil(volumeSnapshotPollPeriod, func() (bool, error) {
+		_, err := kc.CoreV1().PersistentVolumeClaims(claim.Namespace).Get(waitCtx, claim.Name, metav1.GetOptions{})
+		metricsutils.IncCounterVecWithLabelValuesFiltered(kubeCallsCounter, "persistent-volume-claims-get", err, errors.IsNotFound)
+		switch {
+		case errors.IsNotFound(err):
+			return true, nil
+		case err != nil:
+			return false, err
+		}
+		return false, nil
+	}, waitCtx.Done())
+	if err != nil {
+		return err
+	}
+
+	apiGroup := volumeSnapshotAPIGroup
+	restored := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      claim.Name,
+			Namespace: claim.Namespace,
+			Labels:    claim.Labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      claim.Spec.AccessModes,
+			Resources:        claim.Spec.Resources,
+			StorageClassName: claim.Spec.StorageClassName,
+			VolumeMode:       claim.Spec.VolumeMode,
+			DataSource: &corev1.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     volumeSnapshotKind,
+				Name:     snapshot,
+			},
+		},
+	}
+
+	_, err = kc.CoreV1().PersistentVolumeClaims(claim.Namespace).Create(ctx, restored, metav1.CreateOptions{})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "persistent-volume-claims-create", err)
+
+	return err
+}
+
+// VolumeSnapshotScheduler takes a snapshot of every persistent volume of every lease once per interval
+type VolumeSnapshotScheduler struct {
+	log      log.Logger
+	kc       kubernetes.Interface
+	dc       dynamic.Interface
+	class    string
+	interval time.Duration
+	keep     uint
+}
+
+func NewVolumeSnapshotScheduler(log log.Logger, kc kubernetes.Interface, dc dynamic.Interface, class string, interval time.Duration, keep uint) *VolumeSnapshotScheduler {
+	return &VolumeSnapshotScheduler{
+		log:      log.With("cmp", "volume-snapshot-scheduler"),
+		kc:       kc,
+		dc:       dc,
+		class:    class,
+		interval: interval,
+		keep:     keep,
+	}
+}
+
+// Run checks for volumes due for a snapshot until the context is done
+func (s *VolumeSnapshotScheduler) Run(ctx context.Context) error {
+	// volumes are checked more often than they are snapshot, so new leases are not
+	// left a whole interval without a backup
+	period := s.interval / 10
+	if period < time.Minute {
+		period = time.Minute
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		if err := s.schedule(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			s.log.Error("scheduling volume snapshots", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *VolumeSnapshotScheduler) schedule(ctx context.Context, now time.Time) error {
+	claims, err := s.kc.CoreV1().PersistentVolumeClaims(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		LabelSelector: builder.AkashManagedLabelName + "=true",
+	})
+	metricsutils.IncCounterVecWithLabelValues(kubeCallsCounter, "persistent-volume-claims-list", err)
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := listVolumeSnapshots(ctx, s.dc, metav1.NamespaceAll)
+	if err != nil {
+		return err
+	}
+
+	latest := make(map[string]time.Time)
+	for idx := range snapshots {
+		obj := &snapshots[idx]
+		key := obj.GetNamespace() + "/" + volumeSnapshotClaim(obj)
+		if created := obj.GetCreationTimestamp().Time; created.After(latest[key]) {
+			latest[key] = created
+		}
+	}
+
+	for _, claim := range claims.Items {
+		if claim.Status.Phase != corev1.ClaimBound {
+			continue
+		}
+
+		if last, exists := latest[claim.Namespace+"/"+claim.Name]; exists && now.Sub(last) < s.interval {
+			continue
+		}
+
+		name, err := createVolumeSnapshot(ctx, s.dc, claim, s.class, ctypes.VolumeSnapshotTriggerScheduled, now)
+		if err != nil {
+			return err
+		}
+		s.log.Debug("volume snapshot created", "ns", claim.Namespace, "snapshot", name)
+
+		if err := pruneVolumeSnapshots(ctx, s.dc, claim.Namespace, claim.Name, s.keep); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
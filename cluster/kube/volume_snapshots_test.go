@@ -0,0 +1,274 @@
+package kube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/akash-network/node/testutil"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+	kubeclienterrors "github.com/akash-network/provider/cluster/kube/errors"
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+)
+
+func newFakeSnapshotClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	dc := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			volumeSnapshotResource:        "VolumeSnapshotList",
+			volumeSnapshotContentResource: "VolumeSnapshotContentList",
+		}, objects...)
+
+	// stamp created objects the way the API server does
+	dc.PrependReactor("create", volumeSnapshotResource.Resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		obj.SetCreationTimestamp(metav1.Now())
+		return false, nil, nil
+	})
+
+	return dc
+}
+
+func boundLeaseClaim(ns string) corev1.PersistentVolumeClaim {
+	claim := volumeClaim("db-data-db-0", 1000, "beta2")
+	claim.Namespace = ns
+	claim.Labels = map[string]string{
+		builder.AkashManagedLabelName:         "true",
+		builder.AkashManifestServiceLabelName: "db",
+	}
+	claim.Status.Phase = corev1.ClaimBound
+
+	return claim
+}
+
+func TestSnapshotLeaseVolumes(t *testing.T) {
+	ctx := context.Background()
+	lid := testutil.LeaseID(t)
+	ns := builder.LidNS(lid)
+
+	claim := boundLeaseClaim(ns)
+	pending := volumeClaim("db-logs-db-0", 1000, "beta2")
+	pending.Namespace = ns
+	pending.Labels = claim.Labels
+
+	kc := kubefake.NewSimpleClientset(&claim, &pending)
+
+	// an older snapshot of the same claim
+	old := &unstructured.Unstructured{}
+	old.SetAPIVersion(volumeSnapshotResource.GroupVersion().String())
+	old.SetKind(volumeSnapshotKind)
+	old.SetNamespace(ns)
+	old.SetName("db-data-db-0-scheduled-1")
+	old.SetLabels(map[string]string{builder.AkashManagedLabelName: "true"})
+	old.SetCreationTimestamp(metav1.NewTime(time.Unix(1, 0)))
+	require.NoError(t, unstructured.SetNestedField(old.Object, "db-data-db-0", "spec", "source", "persistentVolumeClaimName"))
+
+	dc := newFakeSnapshotClient(old)
+
+	names, err := snapshotLeaseVolumes(ctx, kc, dc, lid, "csi-snapclass", ctypes.VolumeSnapshotTriggerUpdate, 1)
+	require.NoError(t, err)
+	require.Len(t, names, 1)
+
+	snapshots, err := listVolumeSnapshots(ctx, dc, ns)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1, "oldest snapshot is pruned")
+
+	obj, err := dc.Resource(volumeSnapshotResource).Namespace(ns).Get(ctx, names[0], metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "db-data-db-0", volumeSnapshotClaim(obj))
+
+	class, _, _ := unstructured.NestedString(obj.Object, "spec", "volumeSnapshotClassName")
+	require.Equal(t, "csi-snapclass", class)
+
+	status := volumeSnapshotStatus(obj)
+	require.Equal(t, "db", status.Service)
+	require.Equal(t, "data", status.Volume)
+	require.Equal(t, 0, status.Replica)
+	require.Equal(t, ctypes.VolumeSnapshotTriggerUpdate, status.Trigger)
+	require.False(t, status.ReadyToUse)
+}
+
+func TestRestoreVolumeSnapshot(t *testing.T) {
+	ctx := context.Background()
+	lid := testutil.LeaseID(t)
+	ns := builder.LidNS(lid)
+
+	claim := boundLeaseClaim(ns)
+	replicas := int32(2)
+	kc := kubefake.NewSimpleClientset(&claim, &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: ns},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+	})
+	dc := newFakeSnapshotClient()
+
+	names, err := snapshotLeaseVolumes(ctx, kc, dc, lid, "csi-snapclass", ctypes.VolumeSnapshotTriggerScheduled, 0)
+	require.NoError(t, err)
+	require.Len(t, names, 1)
+
+	c := &client{kc: kc, dc: dc, log: testutil.Logger(t)}
+
+	err = c.RestoreVolumeSnapshot(ctx, lid, names[0])
+	require.ErrorIs(t, err, kubeclienterrors.ErrVolumeSnapshotNotReady)
+
+	err = c.RestoreVolumeSnapshot(ctx, lid, "missing")
+	require.ErrorIs(t, err, kubeclienterrors.ErrVolumeSnapshotNotFound)
+
+	obj, err := dc.Resource(volumeSnapshotResource).Namespace(ns).Get(ctx, names[0], metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NoError(t, unstructured.SetNestedField(obj.Object, true, "status", "readyToUse"))
+	_, err = dc.Resource(volumeSnapshotResource).Namespace(ns).Update(ctx, obj, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	// a second restore of the claim is refused while the first one runs
+	c.restores.Store(ns+"/"+claim.Name, "other")
+	err = c.RestoreVolumeSnapshot(ctx, lid, names[0])
+	require.ErrorIs(t, err, kubeclienterrors.ErrVolumeRestoreInProgress)
+	c.restores.Delete(ns + "/" + claim.Name)
+
+	require.NoError(t, c.RestoreVolumeSnapshot(ctx, lid, names[0]))
+
+	require.Eventually(t, func() bool {
+		snapshots, err := c.LeaseVolumeSnapshots(ctx, lid)
+		return err == nil && len(snapshots) == 1 && snapshots[0].RestoreState == ctypes.VolumeSnapshotRestored
+	}, 10*time.Second, 10*time.Millisecond)
+
+	restored, err := kc.CoreV1().PersistentVolumeClaims(ns).Get(ctx, claim.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, restored.Spec.DataSource)
+	require.Equal(t, volumeSnapshotKind, restored.Spec.DataSource.Kind)
+	require.Equal(t, names[0], restored.Spec.DataSource.Name)
+	require.Equal(t, claim.Spec.Resources, restored.Spec.Resources)
+
+	sts, err := kc.AppsV1().StatefulSets(ns).Get(ctx, "db", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), *sts.Spec.Replicas)
+}
+
+func TestRetainVolumeSnapshots(t *testing.T) {
+	ctx := context.Background()
+	lid := testutil.LeaseID(t)
+	ns := builder.LidNS(lid)
+
+	claim := boundLeaseClaim(ns)
+	kc := kubefake.NewSimpleClientset(&claim)
+
+	content := &unstructured.Unstructured{}
+	content.SetAPIVersion(volumeSnapshotContentResource.GroupVersion().String())
+	content.SetKind("VolumeSnapshotContent")
+	content.SetName("snapcontent-1")
+	require.NoError(t, unstructured.SetNestedField(content.Object, "Delete", "spec", "deletionPolicy"))
+
+	dc := newFakeSnapshotClient(content)
+
+	// bind the content the way the snapshot controller does
+	dc.PrependReactor("create", volumeSnapshotResource.Resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		return false, nil, unstructured.SetNestedField(obj.Object, "snapcontent-1", "status", "boundVolumeSnapshotContentName")
+	})
+
+	names, err := snapshotLeaseVolumes(ctx, kc, dc, lid, "csi-snapclass", ctypes.VolumeSnapshotTriggerTeardown, 0)
+	require.NoError(t, err)
+	require.Len(t, names, 1)
+
+	until := time.Now().Add(time.Hour)
+	require.NoError(t, retainVolumeSnapshots(ctx, dc, lid, names, until))
+
+	retained, err := dc.Resource(volumeSnapshotContentResource).Get(ctx, "snapcontent-1", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	policy, _, _ := unstructured.NestedString(retained.Object, "spec", "deletionPolicy")
+	require.Equal(t, "Retain", policy)
+	require.Equal(t, lid.Owner, retained.GetLabels()[builder.AkashLeaseOwnerLabelName])
+	require.Equal(t, "db", retained.GetLabels()[builder.AkashManifestServiceLabelName])
+	require.Equal(t, ctypes.VolumeSnapshotTriggerTeardown, retained.GetLabels()[builder.AkashVolumeSnapshotTriggerLabelName])
+	require.Equal(t, "true", retained.GetLabels()[builder.AkashRetainedVolumeLabelName])
+
+	// the lease namespace and its snapshots are deleted
+	require.NoError(t, dc.Resource(volumeSnapshotResource).Namespace(ns).Delete(ctx, names[0], metav1.DeleteOptions{}))
+
+	collector := NewRetainedVolumeCollector(testutil.Logger(t), kc, dc, time.Minute)
+	require.NoError(t, collector.collectSnapshotContents(ctx, until.Add(-time.Minute)))
+
+	_, err = dc.Resource(volumeSnapshotContentResource).Get(ctx, "snapcontent-1", metav1.GetOptions{})
+	require.NoError(t, err, "retention did not expire yet")
+
+	require.NoError(t, collector.collectSnapshotContents(ctx, until))
+
+	_, err = dc.Resource(volumeSnapshotContentResource).Get(ctx, "snapcontent-1", metav1.GetOptions{})
+	require.True(t, kubeErrors.IsNotFound(err))
+}
+
+func TestRetainVolumeSnapshotsKeepsRetainClass(t *testing.T) {
+	ctx := context.Background()
+	lid := testutil.LeaseID(t)
+	ns := builder.LidNS(lid)
+
+	claim := boundLeaseClaim(ns)
+	kc := kubefake.NewSimpleClientset(&claim)
+
+	content := &unstructured.Unstructured{}
+	content.SetAPIVersion(volumeSnapshotContentResource.GroupVersion().String())
+	content.SetKind("VolumeSnapshotContent")
+	content.SetName("snapcontent-1")
+	require.NoError(t, unstructured.SetNestedField(content.Object, "Retain", "spec", "deletionPolicy"))
+
+	dc := newFakeSnapshotClient(content)
+	dc.PrependReactor("create", volumeSnapshotResource.Resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		return false, nil, unstructured.SetNestedField(obj.Object, "snapcontent-1", "status", "boundVolumeSnapshotContentName")
+	})
+
+	names, err := snapshotLeaseVolumes(ctx, kc, dc, lid, "csi-snapclass", ctypes.VolumeSnapshotTriggerTeardown, 0)
+	require.NoError(t, err)
+	require.NoError(t, retainVolumeSnapshots(ctx, dc, lid, names, time.Now()))
+
+	collector := NewRetainedVolumeCollector(testutil.Logger(t), kc, dc, time.Minute)
+	require.NoError(t, collector.collectSnapshotContents(ctx, time.Now().Add(time.Hour)))
+
+	retained, err := dc.Resource(volumeSnapshotContentResource).Get(ctx, "snapcontent-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotContains(t, retained.GetLabels(), builder.AkashRetainedVolumeLabelName)
+	require.Equal(t, lid.Owner, retained.GetLabels()[builder.AkashLeaseOwnerLabelName])
+}
+
+func TestVolumeSnapshotSchedulerInterval(t *testing.T) {
+	ctx := context.Background()
+	log := testutil.Logger(t)
+	lid := testutil.LeaseID(t)
+	ns := builder.LidNS(lid)
+
+	claim := boundLeaseClaim(ns)
+	kc := kubefake.NewSimpleClientset(&claim)
+	dc := newFakeSnapshotClient()
+
+	scheduler := NewVolumeSnapshotScheduler(log, kc, dc, "csi-snapclass", time.Hour, 0)
+
+	now := time.Now()
+	require.NoError(t, scheduler.schedule(ctx, now))
+
+	snapshots, err := listVolumeSnapshots(ctx, dc, ns)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+
+	require.NoError(t, scheduler.schedule(ctx, now.Add(time.Minute)))
+	snapshots, err = listVolumeSnapshots(ctx, dc, ns)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1, "interval did not pass yet")
+
+	require.NoError(t, scheduler.schedule(ctx, now.Add(time.Hour)))
+	snapshots, err = listVolumeSnapshots(ctx, dc, ns)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+}
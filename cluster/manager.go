@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
@@ -23,6 +24,7 @@ import (
 	"github.com/akash-network/provider/cluster/util"
 	clusterutil "github.com/akash-network/provider/cluster/util"
 	"github.com/akash-network/provider/event"
+	crd "github.com/akash-network/provider/pkg/apis/akash.network/v2beta1"
 	"github.com/akash-network/provider/session"
 
 	"github.com/avast/retry-go"
@@ -41,6 +43,11 @@ const uncleanShutdownGracePeriod = 30 * time.Second
 
 type deploymentState string
 
+type rollbackRequest struct {
+	group  *manifest.Group
+	reason string
+}
+
 var (
 	deploymentCounter = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "provider_deployment",
@@ -69,6 +76,15 @@ type deploymentManager struct {
 	teardownch       chan struct{}
 	currentHostnames map[string]struct{}
 
+	// lastGoodGroup is the most recent group the monitor found healthy
+	lastGoodGroup *manifest.Group
+	healthych     chan *manifest.Group
+	rollbackch    chan rollbackRequest
+	// rollbackReason is set while the deployed group is the result of a rollback
+	rollbackReason string
+	// statusRolledBack tracks if the Manifest CRD status currently reports a rollback
+	statusRolledBack bool
+
 	log             log.Logger
 	lc              lifecycle.Lifecycle
 	hostnameService clustertypes.HostnameServiceClient
@@ -91,6 +107,8 @@ func newDeploymentManager(s *service, lease mtypes.LeaseID, mgroup *manifest.Gro
 		wg:                  sync.WaitGroup{},
 		updatech:            make(chan *manifest.Group),
 		teardownch:          make(chan struct{}),
+		healthych:           make(chan *manifest.Group),
+		rollbackch:          make(chan rollbackRequest),
 		log:                 logger,
 		lc:                  lifecycle.New(),
 		hostnameService:     s.HostnameService(),
@@ -152,6 +170,7 @@ func (dm *deploymentManager) run(ctx context.Context) {
 	defer dm.lc.ShutdownCompleted()
 	var shutdownErr error
 
+	dm.loadLastGoodGroup(ctx)
 	runch := dm.startDeploy(ctx)
 
 	defer func() {
@@ -172,11 +191,34 @@ loop:
 
 		case mgroup := <-dm.updatech:
 			dm.mgroup = mgroup
+			dm.rollbackReason = ""
 			newch := dm.handleUpdate(ctx)
 			if newch != nil {
 				runch = newch
 			}
 
+		case mgroup := <-dm.healthych:
+			if mgroup == dm.mgroup && mgroup != dm.lastGoodGroup {
+				dm.lastGoodGroup = mgroup
+				dm.saveLastGoodGroup(ctx, mgroup)
+			}
+
+		case req := <-dm.rollbackch:
+			// ignore requests of monitors which were watching a group since replaced
+			if req.group != dm.mgroup || dm.lastGoodGroup == nil || dm.state != dsDeployComplete {
+				dm.log.Debug("ignoring rollback request", "state", dm.state)
+				break
+			}
+
+			dm.log.Error("manifest update failed, rolling back to previous group", "reason", req.reason)
+			deploymentCounter.WithLabelValues("rollback", "start").Inc()
+
+			dm.mgroup = dm.lastGoodGroup
+			dm.rollbackReason = req.reason
+			dm.publishRollback(req)
+
+			runch = dm.startDeploy(ctx)
+
 		case result := <-runch:
 			runch = nil
 			if result != nil {
@@ -274,6 +316,18 @@ func (dm *deploymentManager) startDeploy(ctx context.Context) <-chan error {
 	dm.stopMonitor()
 	dm.state = dsDeployActive
 
+	// record a rollback on the Manifest CRD, or clear it once a new group was deployed
+	var manifestStatus *crd.ManifestStatus
+	if dm.rollbackReason != "" {
+		manifestStatus = &crd.ManifestStatus{
+			State:   crd.ManifestStateRolledBack,
+			Message: dm.rollbackReason,
+		}
+	} else if dm.statusRolledBack {
+		manifestStatus = &crd.ManifestStatus{}
+	}
+	dm.statusRolledBack = dm.rollbackReason != ""
+
 	chErr := make(chan error, 1)
 
 	go func() {
@@ -283,6 +337,12 @@ func (dm *deploymentManager) startDeploy(ctx context.Context) <-chan error {
 			return
 		}
 
+		if manifestStatus != nil {
+			if err := dm.client.UpdateManifestStatus(ctx, dm.lease, *manifestStatus); err != nil {
+				dm.log.Error("failed updating manifest status", "err", err)
+			}
+		}
+
 		if len(hostnames) != 0 {
 			// Some hostnames have been withheld
 			dm.log.Info("hostnames withheld from deployment", "cnt", len(hostnames), "lease", dm.lease)
@@ -309,6 +369,65 @@ func (dm *deploymentManager) startDeploy(ctx context.Context) <-chan error {
 	return chErr
 }
 
+// loadLastGoodGroup restores the group last found healthy, which was recorded on the Manifest CRD
+// before the provider restarted or the manager was created again
+func (dm *deploymentManager) loadLastGoodGroup(ctx context.Context) {
+	group, err := dm.client.LastGoodGroup(ctx, dm.lease)
+	if err != nil {
+		dm.log.Error("failed reading last good group", "err", err)
+		return
+	}
+
+	if group == nil {
+		return
+	}
+
+	// the group deployed is the known good one, no rollback is armed for it
+	if sameManifestGroup(group, dm.mgroup) {
+		dm.lastGoodGroup = dm.mgroup
+		return
+	}
+
+	dm.lastGoodGroup = group
+}
+
+func (dm *deploymentManager) saveLastGoodGroup(ctx context.Context, mgroup *manifest.Group) {
+	dm.wg.Add(1)
+	go func() {
+		defer dm.wg.Done()
+		if err := dm.client.SaveLastGoodGroup(util.ApplyToContext(ctx, dm.config.ClusterSettings), dm.lease, mgroup); err != nil {
+			dm.log.Error("failed recording last good group", "err", err)
+		}
+	}()
+}
+
+// sameManifestGroup compares groups in the form they are stored in on the Manifest CRD
+func sameManifestGroup(a *manifest.Group, b *manifest.Group) bool {
+	crdA, err := crd.ManifestGroupFromAkash(a)
+	if err != nil {
+		return false
+	}
+	crdB, err := crd.ManifestGroupFromAkash(b)
+	if err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(crdA, crdB)
+}
+
+func (dm *deploymentManager) publishRollback(req rollbackRequest) {
+	groupCopy := *req.group
+	err := dm.bus.Publish(event.ClusterDeployment{
+		LeaseID: dm.lease,
+		Group:   &groupCopy,
+		Status:  event.ClusterDeploymentRolledBack,
+		Reason:  req.reason,
+	})
+	if err != nil {
+		dm.log.Error("failed publishing event", "err", err)
+	}
+}
+
 func (dm *deploymentManager) startTeardown() <-chan error {
 	dm.stopMonitor()
 	dm.state = dsTeardownActive
@@ -355,7 +474,7 @@ func (dm *deploymentManager) doDeploy(ctx context.Context) ([]string, []string,
 
 	// Either reserve the hostnames, or confirm that they already are held
 	allHostnames := sdlutil.AllHostnamesOfManifestGroup(*dm.mgroup)
-	withheldHostnames, err := dm.hostnameService.ReserveHostnames(ctx, allHostnames, dm.lease)
+	withheldHostnames, err := dm.hostnameService.ReserveHostnames(ctx, dm.hostnamesToReserve(allHostnames), dm.lease)
 
 	if err != nil {
 		deploymentCounter.WithLabelValues("reserve-hostnames", "err").Inc()
@@ -406,8 +525,7 @@ func (dm *deploymentManager) doDeploy(ctx context.Context) ([]string, []string,
 		for _, expose := range service.Expose {
 			if sdlutil.ShouldBeIngress(expose) {
 				if dm.config.DeploymentIngressStaticHosts {
-					uid := sdlutil.IngressHost(dm.lease, service.Name)
-					host := fmt.Sprintf("%s.%s", uid, dm.config.DeploymentIngressDomain)
+					host := dm.staticHostname(service.Name)
 					hosts[host] = expose
 					hostToServiceName[host] = service.Name
 				}
@@ -499,8 +617,10 @@ func (dm *deploymentManager) doTeardown(ctx context.Context) error {
 	teardownResults := make(chan error, teardownActivityCount)
 
 	go func() {
+		// settings decide if persistent volumes are retained
+		teardownCtx := util.ApplyToContext(ctx, dm.config.ClusterSettings)
 		result := retry.Do(func() error {
-			err := dm.client.TeardownLease(ctx, dm.lease)
+			err := dm.client.TeardownLease(teardownCtx, dm.lease)
 			if err != nil {
 				dm.log.Error("lease teardown failed", "err", err)
 			}
@@ -562,6 +682,40 @@ func (dm *deploymentManager) doTeardown(ctx context.Context) error {
 	return firstError
 }
 
+// staticHostname is the ingress hostname generated for a service of the lease
+func (dm *deploymentManager) staticHostname(service string) string {
+	return fmt.Sprintf("%s.%s", sdlutil.IngressHost(dm.lease, service), dm.config.DeploymentIngressDomain)
+}
+
+// hostnamesToReserve adds the generated ingress hostnames to the ones of the manifest when custom hostnames
+// are verified, a CNAME to a generated hostname held by the owner proves ownership of a custom one
+func (dm *deploymentManager) hostnamesToReserve(manifestHostnames []string) []string {
+	if !dm.config.HostnameVerification.Enabled {
+		return manifestHostnames
+	}
+
+	return append(dm.staticHostnames(), manifestHostnames...)
+}
+
+// staticHostnames returns the ingress hostnames generated for the services of the lease exposed over http
+func (dm *deploymentManager) staticHostnames() []string {
+	result := make([]string, 0)
+	if !dm.config.DeploymentIngressStaticHosts {
+		return result
+	}
+
+	for _, service := range dm.mgroup.Services {
+		for _, expose := range service.Expose {
+			if sdlutil.ShouldBeIngress(expose) {
+				result = append(result, dm.staticHostname(service.Name))
+				break
+			}
+		}
+	}
+
+	return result
+}
+
 func (dm *deploymentManager) checkLeaseActive(ctx context.Context) error {
 
 	var lease *mtypes.QueryLeaseResponse
@@ -0,0 +1,84 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	manifest "github.com/akash-network/node/manifest/v2beta1"
+	"github.com/akash-network/node/testutil"
+
+	"github.com/akash-network/provider/cluster/mocks"
+)
+
+func TestLoadLastGoodGroup(t *testing.T) {
+	lid := testutil.LeaseID(t)
+
+	tests := []struct {
+		name     string
+		recorded *manifest.Group
+		err      error
+		deployed bool
+		missing  bool
+	}{
+		{name: "deployed group is the last good one", recorded: &manifest.Group{Name: "web"}, deployed: true},
+		{name: "update since the last good group", recorded: &manifest.Group{Name: "previous"}},
+		{name: "nothing recorded", missing: true},
+		{name: "read fails", err: errors.New("unavailable"), missing: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &mocks.Client{}
+			client.On("LastGoodGroup", mock.Anything, lid).Return(test.recorded, test.err)
+
+			dm := &deploymentManager{
+				client: client,
+				lease:  lid,
+				mgroup: &manifest.Group{Name: "web"},
+				log:    testutil.Logger(t),
+			}
+			dm.loadLastGoodGroup(context.Background())
+
+			switch {
+			case test.missing:
+				require.Nil(t, dm.lastGoodGroup)
+			case test.deployed:
+				// no rollback is armed for the group running already
+				require.Same(t, dm.mgroup, dm.lastGoodGroup)
+			default:
+				require.Same(t, test.recorded, dm.lastGoodGroup)
+			}
+		})
+	}
+}
+
+func TestHostnamesToReserve(t *testing.T) {
+	lid := testutil.LeaseID(t)
+
+	group := &manifest.Group{
+		Services: []manifest.Service{{
+			Name:   "web",
+			Expose: []manifest.ServiceExpose{{Port: 80, ExternalPort: 80, Proto: manifest.TCP, Global: true}},
+		}},
+	}
+
+	dm := &deploymentManager{
+		lease:  lid,
+		mgroup: group,
+		config: Config{
+			DeploymentIngressStaticHosts: true,
+			DeploymentIngressDomain:      "ingress.example.com",
+		},
+	}
+
+	custom := []string{"www.example.org"}
+	require.Equal(t, custom, dm.hostnamesToReserve(custom))
+
+	// generated hostnames are held for CNAME proofs only when hostnames are verified
+	dm.config.HostnameVerification.Enabled = true
+	require.Equal(t, []string{dm.staticHostname("web"), "www.example.org"}, dm.hostnamesToReserve(custom))
+}
@@ -94,6 +94,29 @@ func (_m *Client) DeclareIP(ctx context.Context, lID typesv1beta2.LeaseID, servi
 	return r0
 }
 
+// DeclaredIPStates provides a mock function with given fields: ctx, leaseID
+func (_m *Client) DeclaredIPStates(ctx context.Context, leaseID typesv1beta2.LeaseID) ([]v1beta2.DeclaredIPState, error) {
+	ret := _m.Called(ctx, leaseID)
+
+	var r0 []v1beta2.DeclaredIPState
+	if rf, ok := ret.Get(0).(func(context.Context, typesv1beta2.LeaseID) []v1beta2.DeclaredIPState); ok {
+		r0 = rf(ctx, leaseID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]v1beta2.DeclaredIPState)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, typesv1beta2.LeaseID) error); ok {
+		r1 = rf(ctx, leaseID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Deploy provides a mock function with given fields: ctx, lID, mgroup
 func (_m *Client) Deploy(ctx context.Context, lID typesv1beta2.LeaseID, mgroup *v2beta1.Group) error {
 	ret := _m.Called(ctx, lID, mgroup)
@@ -251,6 +274,34 @@ func (_m *Client) GetManifestGroup(_a0 context.Context, _a1 typesv1beta2.LeaseID
 	return r0, r1, r2
 }
 
+// GetManifestStatus provides a mock function with given fields: _a0, _a1
+func (_m *Client) GetManifestStatus(_a0 context.Context, _a1 typesv1beta2.LeaseID) (bool, akash_networkv2beta1.ManifestStatus, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, typesv1beta2.LeaseID) bool); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 akash_networkv2beta1.ManifestStatus
+	if rf, ok := ret.Get(1).(func(context.Context, typesv1beta2.LeaseID) akash_networkv2beta1.ManifestStatus); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Get(1).(akash_networkv2beta1.ManifestStatus)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, typesv1beta2.LeaseID) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // Inventory provides a mock function with given fields: _a0
 func (_m *Client) Inventory(_a0 context.Context) (v1beta2.Inventory, error) {
 	ret := _m.Called(_a0)
@@ -297,6 +348,29 @@ func (_m *Client) KubeVersion() (*version.Info, error) {
 	return r0, r1
 }
 
+// LastGoodGroup provides a mock function with given fields: _a0, _a1
+func (_m *Client) LastGoodGroup(_a0 context.Context, _a1 typesv1beta2.LeaseID) (*v2beta1.Group, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *v2beta1.Group
+	if rf, ok := ret.Get(0).(func(context.Context, typesv1beta2.LeaseID) *v2beta1.Group); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v2beta1.Group)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, typesv1beta2.LeaseID) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // LeaseEvents provides a mock function with given fields: _a0, _a1, _a2, _a3
 func (_m *Client) LeaseEvents(_a0 context.Context, _a1 typesv1beta2.LeaseID, _a2 string, _a3 bool) (v1beta2.EventsWatcher, error) {
 	ret := _m.Called(_a0, _a1, _a2, _a3)
@@ -343,6 +417,29 @@ func (_m *Client) LeaseLogs(_a0 context.Context, _a1 typesv1beta2.LeaseID, _a2 s
 	return r0, r1
 }
 
+// LeaseNamespaces provides a mock function with given fields: _a0
+func (_m *Client) LeaseNamespaces(_a0 context.Context) ([]typesv1beta2.LeaseID, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []typesv1beta2.LeaseID
+	if rf, ok := ret.Get(0).(func(context.Context) []typesv1beta2.LeaseID); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]typesv1beta2.LeaseID)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // LeaseStatus provides a mock function with given fields: _a0, _a1
 func (_m *Client) LeaseStatus(_a0 context.Context, _a1 typesv1beta2.LeaseID) (map[string]*v1beta2.ServiceStatus, error) {
 	ret := _m.Called(_a0, _a1)
@@ -366,6 +463,52 @@ func (_m *Client) LeaseStatus(_a0 context.Context, _a1 typesv1beta2.LeaseID) (ma
 	return r0, r1
 }
 
+// LeaseVolumeSnapshots provides a mock function with given fields: _a0, _a1
+func (_m *Client) LeaseVolumeSnapshots(_a0 context.Context, _a1 typesv1beta2.LeaseID) ([]v1beta2.VolumeSnapshot, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []v1beta2.VolumeSnapshot
+	if rf, ok := ret.Get(0).(func(context.Context, typesv1beta2.LeaseID) []v1beta2.VolumeSnapshot); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]v1beta2.VolumeSnapshot)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, typesv1beta2.LeaseID) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NodePorts provides a mock function with given fields: _a0
+func (_m *Client) NodePorts(_a0 context.Context) ([]v1beta2.NodePortAllocation, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []v1beta2.NodePortAllocation
+	if rf, ok := ret.Get(0).(func(context.Context) []v1beta2.NodePortAllocation); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]v1beta2.NodePortAllocation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ObserveHostnameState provides a mock function with given fields: ctx
 func (_m *Client) ObserveHostnameState(ctx context.Context) (<-chan v1beta2.HostnameResourceEvent, error) {
 	ret := _m.Called(ctx)
@@ -482,6 +625,48 @@ func (_m *Client) RemoveHostnameFromDeployment(ctx context.Context, hostname str
 	return r0
 }
 
+// RestartLeasePods provides a mock function with given fields: _a0, _a1
+func (_m *Client) RestartLeasePods(_a0 context.Context, _a1 typesv1beta2.LeaseID) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, typesv1beta2.LeaseID) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestoreVolumeSnapshot provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Client) RestoreVolumeSnapshot(_a0 context.Context, _a1 typesv1beta2.LeaseID, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, typesv1beta2.LeaseID, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveLastGoodGroup provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Client) SaveLastGoodGroup(_a0 context.Context, _a1 typesv1beta2.LeaseID, _a2 *v2beta1.Group) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, typesv1beta2.LeaseID, *v2beta1.Group) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ServiceStatus provides a mock function with given fields: _a0, _a1, _a2
 func (_m *Client) ServiceStatus(_a0 context.Context, _a1 typesv1beta2.LeaseID, _a2 string) (*v1beta2.ServiceStatus, error) {
 	ret := _m.Called(_a0, _a1, _a2)
@@ -519,6 +704,69 @@ func (_m *Client) TeardownLease(_a0 context.Context, _a1 typesv1beta2.LeaseID) e
 	return r0
 }
 
+// UpdateHostnameState provides a mock function with given fields: ctx, hostname, state, message
+func (_m *Client) UpdateHostnameState(ctx context.Context, hostname string, state string, message string) error {
+	ret := _m.Called(ctx, hostname, state, message)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, hostname, state, message)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateHostnameTLSStatus provides a mock function with given fields: ctx, leaseID, hostname
+func (_m *Client) UpdateHostnameTLSStatus(ctx context.Context, leaseID typesv1beta2.LeaseID, hostname string) (akash_networkv2beta1.ProviderHostTLSStatus, error) {
+	ret := _m.Called(ctx, leaseID, hostname)
+
+	var r0 akash_networkv2beta1.ProviderHostTLSStatus
+	if rf, ok := ret.Get(0).(func(context.Context, typesv1beta2.LeaseID, string) akash_networkv2beta1.ProviderHostTLSStatus); ok {
+		r0 = rf(ctx, leaseID, hostname)
+	} else {
+		r0 = ret.Get(0).(akash_networkv2beta1.ProviderHostTLSStatus)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, typesv1beta2.LeaseID, string) error); ok {
+		r1 = rf(ctx, leaseID, hostname)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateIPState provides a mock function with given fields: ctx, sharingKey, proto, externalPort, state, message
+func (_m *Client) UpdateIPState(ctx context.Context, sharingKey string, proto v2beta1.ServiceProtocol, externalPort uint32, state string, message string) error {
+	ret := _m.Called(ctx, sharingKey, proto, externalPort, state, message)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, v2beta1.ServiceProtocol, uint32, string, string) error); ok {
+		r0 = rf(ctx, sharingKey, proto, externalPort, state, message)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateManifestStatus provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Client) UpdateManifestStatus(_a0 context.Context, _a1 typesv1beta2.LeaseID, _a2 akash_networkv2beta1.ManifestStatus) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, typesv1beta2.LeaseID, akash_networkv2beta1.ManifestStatus) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewClient creates a new instance of Client. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
 func NewClient(t testing.TB) *Client {
 	mock := &Client{}
@@ -11,6 +11,8 @@ import (
 	types "github.com/cosmos/cosmos-sdk/types"
 
 	typesv1beta2 "github.com/akash-network/node/x/market/types/v1beta2"
+
+	v1beta2 "github.com/akash-network/provider/cluster/types/v1beta2"
 )
 
 // HostnameServiceClient is an autogenerated mock type for the HostnameServiceClient type
@@ -32,6 +34,22 @@ func (_m *HostnameServiceClient) CanReserveHostnames(hostnames []string, ownerAd
 	return r0
 }
 
+// HostnameRules provides a mock function with given fields:
+func (_m *HostnameServiceClient) HostnameRules() []v1beta2.HostnameRule {
+	ret := _m.Called()
+
+	var r0 []v1beta2.HostnameRule
+	if rf, ok := ret.Get(0).(func() []v1beta2.HostnameRule); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]v1beta2.HostnameRule)
+		}
+	}
+
+	return r0
+}
+
 // PrepareHostnamesForTransfer provides a mock function with given fields: ctx, hostnames, leaseID
 func (_m *HostnameServiceClient) PrepareHostnamesForTransfer(ctx context.Context, hostnames []string, leaseID typesv1beta2.LeaseID) error {
 	ret := _m.Called(ctx, hostnames, leaseID)
@@ -83,6 +101,50 @@ func (_m *HostnameServiceClient) ReserveHostnames(ctx context.Context, hostnames
 	return r0, r1
 }
 
+// SetHostnameRules provides a mock function with given fields: ctx, rules
+func (_m *HostnameServiceClient) SetHostnameRules(ctx context.Context, rules []v1beta2.HostnameRule) ([]v1beta2.HostnameRuleViolation, error) {
+	ret := _m.Called(ctx, rules)
+
+	var r0 []v1beta2.HostnameRuleViolation
+	if rf, ok := ret.Get(0).(func(context.Context, []v1beta2.HostnameRule) []v1beta2.HostnameRuleViolation); ok {
+		r0 = rf(ctx, rules)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]v1beta2.HostnameRuleViolation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []v1beta2.HostnameRule) error); ok {
+		r1 = rf(ctx, rules)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerificationToken provides a mock function with given fields: ownerAddr
+func (_m *HostnameServiceClient) VerificationToken(ownerAddr types.Address) (string, error) {
+	ret := _m.Called(ownerAddr)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(types.Address) string); ok {
+		r0 = rf(ownerAddr)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(types.Address) error); ok {
+		r1 = rf(ownerAddr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewHostnameServiceClient creates a new instance of HostnameServiceClient. It also registers the testing.TB interface on the mock and a cleanup function to assert the mocks expectations.
 func NewHostnameServiceClient(t testing.TB) *HostnameServiceClient {
 	mock := &HostnameServiceClient{}
@@ -107,6 +107,34 @@ func (_m *MetalLBClient) GetIPAddressUsage(ctx context.Context) (uint, uint, err
 	return r0, r1, r2
 }
 
+// GetIPv6AddressUsage provides a mock function with given fields: ctx
+func (_m *MetalLBClient) GetIPv6AddressUsage(ctx context.Context) (uint, uint, error) {
+	ret := _m.Called(ctx)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context) uint); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 uint
+	if rf, ok := ret.Get(1).(func(context.Context) uint); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Get(1).(uint)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context) error); ok {
+		r2 = rf(ctx)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetIPPassthroughs provides a mock function with given fields: ctx
 func (_m *MetalLBClient) GetIPPassthroughs(ctx context.Context) ([]v1beta2.IPPassthrough, error) {
 	ret := _m.Called(ctx)
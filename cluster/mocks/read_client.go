@@ -43,6 +43,29 @@ func (_m *ReadClient) AllHostnames(_a0 context.Context) ([]v1beta2.ActiveHostnam
 	return r0, r1
 }
 
+// DeclaredIPStates provides a mock function with given fields: ctx, leaseID
+func (_m *ReadClient) DeclaredIPStates(ctx context.Context, leaseID typesv1beta2.LeaseID) ([]v1beta2.DeclaredIPState, error) {
+	ret := _m.Called(ctx, leaseID)
+
+	var r0 []v1beta2.DeclaredIPState
+	if rf, ok := ret.Get(0).(func(context.Context, typesv1beta2.LeaseID) []v1beta2.DeclaredIPState); ok {
+		r0 = rf(ctx, leaseID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]v1beta2.DeclaredIPState)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, typesv1beta2.LeaseID) error); ok {
+		r1 = rf(ctx, leaseID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ForwardedPortStatus provides a mock function with given fields: _a0, _a1
 func (_m *ReadClient) ForwardedPortStatus(_a0 context.Context, _a1 typesv1beta2.LeaseID) (map[string][]v1beta2.ForwardedPortStatus, error) {
 	ret := _m.Called(_a0, _a1)
@@ -140,6 +163,34 @@ func (_m *ReadClient) GetManifestGroup(_a0 context.Context, _a1 typesv1beta2.Lea
 	return r0, r1, r2
 }
 
+// GetManifestStatus provides a mock function with given fields: _a0, _a1
+func (_m *ReadClient) GetManifestStatus(_a0 context.Context, _a1 typesv1beta2.LeaseID) (bool, v2beta1.ManifestStatus, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, typesv1beta2.LeaseID) bool); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 v2beta1.ManifestStatus
+	if rf, ok := ret.Get(1).(func(context.Context, typesv1beta2.LeaseID) v2beta1.ManifestStatus); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Get(1).(v2beta1.ManifestStatus)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, typesv1beta2.LeaseID) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // LeaseEvents provides a mock function with given fields: _a0, _a1, _a2, _a3
 func (_m *ReadClient) LeaseEvents(_a0 context.Context, _a1 typesv1beta2.LeaseID, _a2 string, _a3 bool) (v1beta2.EventsWatcher, error) {
 	ret := _m.Called(_a0, _a1, _a2, _a3)
@@ -209,6 +260,52 @@ func (_m *ReadClient) LeaseStatus(_a0 context.Context, _a1 typesv1beta2.LeaseID)
 	return r0, r1
 }
 
+// LeaseVolumeSnapshots provides a mock function with given fields: _a0, _a1
+func (_m *ReadClient) LeaseVolumeSnapshots(_a0 context.Context, _a1 typesv1beta2.LeaseID) ([]v1beta2.VolumeSnapshot, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []v1beta2.VolumeSnapshot
+	if rf, ok := ret.Get(0).(func(context.Context, typesv1beta2.LeaseID) []v1beta2.VolumeSnapshot); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]v1beta2.VolumeSnapshot)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, typesv1beta2.LeaseID) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NodePorts provides a mock function with given fields: _a0
+func (_m *ReadClient) NodePorts(_a0 context.Context) ([]v1beta2.NodePortAllocation, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []v1beta2.NodePortAllocation
+	if rf, ok := ret.Get(0).(func(context.Context) []v1beta2.NodePortAllocation); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]v1beta2.NodePortAllocation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ObserveHostnameState provides a mock function with given fields: ctx
 func (_m *ReadClient) ObserveHostnameState(ctx context.Context) (<-chan v1beta2.HostnameResourceEvent, error) {
 	ret := _m.Called(ctx)
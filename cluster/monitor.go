@@ -2,13 +2,18 @@ package cluster
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
 	"github.com/akash-network/provider/cluster/util"
+	crd "github.com/akash-network/provider/pkg/apis/akash.network/v2beta1"
 
 	"github.com/boz/go-lifecycle"
 	"github.com/tendermint/tendermint/libs/log"
@@ -44,11 +49,23 @@ type deploymentMonitor struct {
 
 	lease  mtypes.LeaseID
 	mgroup *manifest.Group
+	config MonitorConfig
 
 	attempts int
 	log      log.Logger
 	lc       lifecycle.Lifecycle
 
+	// reason the last check found the deployment unhealthy for
+	reason string
+
+	// rollbackAt is the deadline for an updated group to become healthy, zero when rollbacks are disabled
+	rollbackAt time.Time
+	healthych  chan<- *manifest.Group
+	rollbackch chan<- rollbackRequest
+
+	// rollbackTimeout also bounds the wait for the manager to act upon a rollback request
+	rollbackTimeout time.Duration
+
 	clusterSettings map[interface{}]interface{}
 }
 
@@ -59,9 +76,17 @@ func newDeploymentMonitor(dm *deploymentManager) *deploymentMonitor {
 		client:          dm.client,
 		lease:           dm.lease,
 		mgroup:          dm.mgroup,
+		config:          dm.config.monitorConfigForLease(dm.lease),
 		log:             dm.log.With("cmp", "deployment-monitor"),
 		lc:              lifecycle.New(),
 		clusterSettings: dm.config.ClusterSettings,
+		healthych:       dm.healthych,
+		rollbackch:      dm.rollbackch,
+	}
+
+	if dm.config.DeploymentRollbackTimeout > 0 && dm.lastGoodGroup != nil && dm.lastGoodGroup != dm.mgroup {
+		m.rollbackAt = time.Now().Add(dm.config.DeploymentRollbackTimeout)
+		m.rollbackTimeout = dm.config.DeploymentRollbackTimeout
 	}
 
 	go m.lc.WatchChannel(dm.lc.ShuttingDown())
@@ -83,8 +108,9 @@ func (m *deploymentMonitor) run() {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	var (
-		runch   <-chan runner.Result
-		closech <-chan runner.Result
+		runch     <-chan runner.Result
+		closech   <-chan runner.Result
+		restartch <-chan runner.Result
 	)
 
 	tickch := m.scheduleRetry()
@@ -109,13 +135,19 @@ loop:
 				m.log.Error("monitor check", "err", err)
 			}
 
-			ok := result.Value().(bool)
+			checkResult := result.Value().(monitorCheckResult)
+			ok := checkResult.ok
 
 			m.log.Info("check result", "ok", ok, "attempt", m.attempts)
 
 			if ok {
 				// healthy
 				m.attempts = 0
+				m.reason = ""
+				m.rollbackAt = time.Time{}
+				if !m.reportHealthy() {
+					break loop
+				}
 				tickch = m.scheduleHealthcheck()
 				m.publishStatus(event.ClusterDeploymentDeployed)
 				deploymentHealthCheckCounter.WithLabelValues("up").Inc()
@@ -125,20 +157,57 @@ loop:
 				deploymentHealthCheckCounter.WithLabelValues("down").Inc()
 			}
 
+			if checkResult.reason != "" {
+				m.reason = checkResult.reason
+				m.log.Info("deployment unhealthy", "reason", m.reason)
+			}
+
 			m.publishStatus(event.ClusterDeploymentPending)
 
-			if m.attempts <= monitorMaxRetries {
+			if !m.rollbackAt.IsZero() && (time.Now().After(m.rollbackAt) || m.attempts > int(m.config.MaxRetries)) {
+				m.log.Error("deployment update failed.  rolling back.", "reason", m.reason)
+				deploymentHealthCheckCounter.WithLabelValues("rollback").Inc()
+				if !m.requestRollback() {
+					break loop
+				}
+
+				// the manager stops this monitor once the previous group is redeployed. A request it
+				// dropped leaves the update deployed, which is monitored as any other group
+				m.rollbackAt = time.Time{}
+				tickch = m.schedule(m.rollbackTimeout, 0)
+				break
+			}
+
+			if m.attempts <= int(m.config.MaxRetries) {
 				// unhealthy.  retry
 				tickch = m.scheduleRetry()
 				break
 			}
 
-			m.log.Error("deployment failed.  closing lease.")
-			deploymentHealthCheckCounter.WithLabelValues("failed").Inc()
-			closech = m.runCloseLease(ctx)
+			switch m.config.FailurePolicy {
+			case MonitorFailurePolicyAlert:
+				m.log.Error("deployment failed.  keeping lease.", "reason", m.reason)
+				deploymentHealthCheckCounter.WithLabelValues("alert").Inc()
+				m.attempts = 0
+				tickch = m.scheduleRetry()
+			case MonitorFailurePolicyRestart:
+				m.log.Error("deployment failed.  restarting pods.", "reason", m.reason)
+				deploymentHealthCheckCounter.WithLabelValues("restart").Inc()
+				m.attempts = 0
+				restartch = m.runRestartPods(ctx)
+			default:
+				m.log.Error("deployment failed.  closing lease.", "reason", m.reason)
+				deploymentHealthCheckCounter.WithLabelValues("failed").Inc()
+				m.publishStatus(event.ClusterDeploymentFailed)
+				closech = m.runCloseLease(ctx)
+			}
 
 		case <-closech:
 			closech = nil
+
+		case <-restartch:
+			restartch = nil
+			tickch = m.scheduleRetry()
 		}
 	}
 	cancel()
@@ -153,6 +222,11 @@ loop:
 		<-closech
 	}
 
+	if restartch != nil {
+		m.log.Debug("read restartch")
+		<-restartch
+	}
+
 	// TODO
 	// Check that we got here
 	m.log.Debug("shutdown complete")
@@ -166,17 +240,23 @@ func (m *deploymentMonitor) runCheck(ctx context.Context) <-chan runner.Result {
 	})
 }
 
-func (m *deploymentMonitor) doCheck(ctx context.Context) (bool, error) {
+type monitorCheckResult struct {
+	ok     bool
+	reason string
+}
+
+func (m *deploymentMonitor) doCheck(ctx context.Context) (monitorCheckResult, error) {
 	clientCtx := util.ApplyToContext(ctx, m.clusterSettings)
 
 	status, err := m.client.LeaseStatus(clientCtx, m.lease)
 
 	if err != nil {
 		m.log.Error("lease status", "err", err)
-		return false, err
+		return monitorCheckResult{}, err
 	}
 
 	badsvc := 0
+	reasons := make([]string, 0)
 
 	for _, spec := range m.mgroup.Services {
 		service, foundService := status[spec.Name]
@@ -188,20 +268,80 @@ func (m *deploymentMonitor) doCheck(ctx context.Context) (bool, error) {
 					"available", service.Available,
 					"target", spec.Count,
 				)
+				reasons = append(reasons, serviceFailureReason(spec.Name, service.Failures))
 			}
 		}
 
 		if !foundService {
 			badsvc++
 			m.log.Debug("service status not found", "service", spec.Name)
+			reasons = append(reasons, fmt.Sprintf("service %q: not found", spec.Name))
 		}
 	}
 
-	return badsvc == 0, nil
+	return monitorCheckResult{
+		ok:     badsvc == 0,
+		reason: strings.Join(reasons, "; "),
+	}, nil
+}
+
+// serviceFailureReason summarizes distinct pod failures of the service, such as
+// service "web": ImagePullBackOff (2 pods): Back-off pulling image "nginx:bad"
+func serviceFailureReason(name string, failures []ctypes.ServiceFailure) string {
+	if len(failures) == 0 {
+		return fmt.Sprintf("service %q: available replicas below target", name)
+	}
+
+	type summary struct {
+		count   int
+		message string
+	}
+
+	byReason := make(map[string]*summary)
+	for _, failure := range failures {
+		entry, exists := byReason[failure.Reason]
+		if !exists {
+			entry = &summary{message: failure.Message}
+			byReason[failure.Reason] = entry
+		}
+		entry.count++
+	}
+
+	reasons := make([]string, 0, len(byReason))
+	for reason := range byReason {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	parts := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		entry := byReason[reason]
+		pods := "pods"
+		if entry.count == 1 {
+			pods = "pod"
+		}
+		part := fmt.Sprintf("%s (%d %s)", reason, entry.count, pods)
+		if entry.message != "" {
+			part += ": " + entry.message
+		}
+		parts = append(parts, part)
+	}
+
+	return fmt.Sprintf("service %q: %s", name, strings.Join(parts, ", "))
 }
 
 func (m *deploymentMonitor) runCloseLease(ctx context.Context) <-chan runner.Result {
+	// the lease is gone once closed, the Manifest CRD tells its tenant why until it is torn down
+	status := crd.ManifestStatus{
+		State:   crd.ManifestStateClosed,
+		Message: m.reason,
+	}
+
 	return runner.Do(func() runner.Result {
+		if err := m.client.UpdateManifestStatus(ctx, m.lease, status); err != nil {
+			m.log.Error("recording close reason", "err", err)
+		}
+
 		// TODO: retry, timeout
 		err := m.session.Client().Tx().Broadcast(ctx, &mtypes.MsgCloseBid{
 			BidID: m.lease.BidID(),
@@ -215,25 +355,80 @@ func (m *deploymentMonitor) runCloseLease(ctx context.Context) <-chan runner.Res
 	})
 }
 
+// reportHealthy lets the manager know the monitored group is a known good one to roll back to.
+// It returns false when the monitor was asked to shut down instead.
+func (m *deploymentMonitor) reportHealthy() bool {
+	if m.healthych == nil {
+		return true
+	}
+
+	select {
+	case m.healthych <- m.mgroup:
+		return true
+	case err := <-m.lc.ShutdownRequest():
+		// the manager stops reading once it shuts down
+		m.lc.ShutdownInitiated(err)
+		return false
+	}
+}
+
+// requestRollback asks the manager to redeploy the last known good group.
+// It returns false when the monitor was asked to shut down instead.
+func (m *deploymentMonitor) requestRollback() bool {
+	if m.rollbackch == nil {
+		return true
+	}
+
+	select {
+	case m.rollbackch <- rollbackRequest{group: m.mgroup, reason: m.reason}:
+		return true
+	case <-time.After(m.rollbackTimeout):
+		m.log.Error("rollback request not taken by the manager, monitoring the update")
+		return true
+	case err := <-m.lc.ShutdownRequest():
+		m.lc.ShutdownInitiated(err)
+		return false
+	}
+}
+
+func (m *deploymentMonitor) runRestartPods(ctx context.Context) <-chan runner.Result {
+	return runner.Do(func() runner.Result {
+		err := m.client.RestartLeasePods(ctx, m.lease)
+		if err != nil {
+			m.log.Error("restarting pods", "err", err)
+		}
+		return runner.NewResult(nil, err)
+	})
+}
+
 func (m *deploymentMonitor) publishStatus(status event.ClusterDeploymentStatus) {
+	reason := ""
+	if status != event.ClusterDeploymentDeployed {
+		reason = m.reason
+	}
+
 	if err := m.bus.Publish(event.ClusterDeployment{
 		LeaseID: m.lease,
 		Group:   m.mgroup,
 		Status:  status,
+		Reason:  reason,
 	}); err != nil {
 		m.log.Error("publishing manifest group deployed event", "err", err, "status", status)
 	}
 }
 
 func (m *deploymentMonitor) scheduleRetry() <-chan time.Time {
-	return m.schedule(monitorRetryPeriodMin, monitorRetryPeriodJitter)
+	return m.schedule(m.config.RetryPeriod, m.config.RetryPeriodJitter)
 }
 
 func (m *deploymentMonitor) scheduleHealthcheck() <-chan time.Time {
-	return m.schedule(monitorHealthcheckPeriodMin, monitorHealthcheckPeriodJitter)
+	return m.schedule(m.config.HealthcheckPeriod, m.config.HealthcheckPeriodJitter)
 }
 
 func (m *deploymentMonitor) schedule(min, jitter time.Duration) <-chan time.Time {
-	period := min + time.Duration(rand.Int63n(int64(jitter))) // nolint: gosec
+	period := min
+	if jitter > 0 {
+		period += time.Duration(rand.Int63n(int64(jitter))) // nolint: gosec
+	}
 	return time.After(period)
 }
@@ -0,0 +1,156 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+)
+
+// MonitorFailurePolicy decides what the deployment monitor does once a deployment
+// failed all of its health checks
+type MonitorFailurePolicy string
+
+const (
+	// MonitorFailurePolicyClose closes the bid, releasing the lease
+	MonitorFailurePolicyClose MonitorFailurePolicy = "close"
+	// MonitorFailurePolicyAlert keeps the lease and reports the failure through logs and metrics
+	MonitorFailurePolicyAlert MonitorFailurePolicy = "alert"
+	// MonitorFailurePolicyRestart keeps the lease and restarts pods which are not ready
+	MonitorFailurePolicyRestart MonitorFailurePolicy = "restart"
+)
+
+var ErrMonitorConfig = errors.New("monitor config")
+
+// MonitorConfig configures how often and for how long the deployment monitor checks
+// a lease before applying its failure policy
+type MonitorConfig struct {
+	MaxRetries              uint                 `yaml:"max_retries"`
+	RetryPeriod             time.Duration        `yaml:"retry_period"`
+	RetryPeriodJitter       time.Duration        `yaml:"retry_period_jitter"`
+	HealthcheckPeriod       time.Duration        `yaml:"healthcheck_period"`
+	HealthcheckPeriodJitter time.Duration        `yaml:"healthcheck_period_jitter"`
+	FailurePolicy           MonitorFailurePolicy `yaml:"failure_policy"`
+}
+
+func NewDefaultMonitorConfig() MonitorConfig {
+	return MonitorConfig{
+		MaxRetries:              monitorMaxRetries,
+		RetryPeriod:             monitorRetryPeriodMin,
+		RetryPeriodJitter:       monitorRetryPeriodJitter,
+		HealthcheckPeriod:       monitorHealthcheckPeriodMin,
+		HealthcheckPeriodJitter: monitorHealthcheckPeriodJitter,
+		FailurePolicy:           MonitorFailurePolicyClose,
+	}
+}
+
+// Merge returns a copy of the config where every field set in override replaces its own
+func (cfg MonitorConfig) Merge(override MonitorOverride) MonitorConfig {
+	if override.MaxRetries != nil {
+		cfg.MaxRetries = *override.MaxRetries
+	}
+	if override.RetryPeriod != nil {
+		cfg.RetryPeriod = *override.RetryPeriod
+	}
+	if override.RetryPeriodJitter != nil {
+		cfg.RetryPeriodJitter = *override.RetryPeriodJitter
+	}
+	if override.HealthcheckPeriod != nil {
+		cfg.HealthcheckPeriod = *override.HealthcheckPeriod
+	}
+	if override.HealthcheckPeriodJitter != nil {
+		cfg.HealthcheckPeriodJitter = *override.HealthcheckPeriodJitter
+	}
+	if override.FailurePolicy != "" {
+		cfg.FailurePolicy = override.FailurePolicy
+	}
+
+	return cfg
+}
+
+func (cfg MonitorConfig) Validate() error {
+	switch cfg.FailurePolicy {
+	case "", MonitorFailurePolicyClose, MonitorFailurePolicyAlert, MonitorFailurePolicyRestart:
+	default:
+		return fmt.Errorf("%w: unknown failure policy %q", ErrMonitorConfig, cfg.FailurePolicy)
+	}
+
+	if cfg.RetryPeriod < 0 || cfg.RetryPeriodJitter < 0 || cfg.HealthcheckPeriod < 0 || cfg.HealthcheckPeriodJitter < 0 {
+		return fmt.Errorf("%w: periods must not be negative", ErrMonitorConfig)
+	}
+
+	return nil
+}
+
+// MonitorOverride holds the monitor settings replacing the provider wide ones, fields left
+// unset keep their value so zero can be set explicitly
+type MonitorOverride struct {
+	MaxRetries              *uint                `yaml:"max_retries"`
+	RetryPeriod             *time.Duration       `yaml:"retry_period"`
+	RetryPeriodJitter       *time.Duration       `yaml:"retry_period_jitter"`
+	HealthcheckPeriod       *time.Duration       `yaml:"healthcheck_period"`
+	HealthcheckPeriodJitter *time.Duration       `yaml:"healthcheck_period_jitter"`
+	FailurePolicy           MonitorFailurePolicy `yaml:"failure_policy"`
+}
+
+func (o MonitorOverride) Validate() error {
+	return MonitorConfig{}.Merge(o).Validate()
+}
+
+// MonitorOverrides maps lease ID prefixes such as "owner" or "owner/dseq"
+// to monitor settings replacing the provider wide ones
+type MonitorOverrides map[string]MonitorOverride
+
+// ReadMonitorOverrides loads and validates per-lease monitor overrides from the YAML file at the given path
+func ReadMonitorOverrides(fpath string) (MonitorOverrides, error) {
+	buf, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(MonitorOverrides)
+	if err := yaml.Unmarshal(buf, &overrides); err != nil {
+		return nil, err
+	}
+
+	for prefix, override := range overrides {
+		if err := override.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: lease %q", err, prefix)
+		}
+	}
+
+	return overrides, nil
+}
+
+// ForLease merges every override matching the lease on top of cfg, longer prefixes taking precedence
+func (o MonitorOverrides) ForLease(cfg MonitorConfig, lid mtypes.LeaseID) MonitorConfig {
+	path := lid.String()
+
+	prefixes := make([]string, 0)
+	for prefix := range o {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) < len(prefixes[j])
+	})
+
+	for _, prefix := range prefixes {
+		cfg = cfg.Merge(o[prefix])
+	}
+
+	return cfg
+}
+
+// monitorConfigForLease resolves the monitor settings of the lease
+func (cfg Config) monitorConfigForLease(lid mtypes.LeaseID) MonitorConfig {
+	return cfg.MonitorOverrides.ForLease(cfg.Monitor, lid)
+}
@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/akash-network/node/testutil"
+)
+
+func TestMonitorConfigMerge(t *testing.T) {
+	maxRetries := uint(100)
+	cfg := NewDefaultMonitorConfig().Merge(MonitorOverride{
+		MaxRetries:    &maxRetries,
+		FailurePolicy: MonitorFailurePolicyAlert,
+	})
+
+	require.Equal(t, uint(100), cfg.MaxRetries)
+	require.Equal(t, MonitorFailurePolicyAlert, cfg.FailurePolicy)
+	require.Equal(t, monitorRetryPeriodMin, cfg.RetryPeriod)
+	require.Equal(t, monitorHealthcheckPeriodJitter, cfg.HealthcheckPeriodJitter)
+
+	// zero is a value like any other
+	zeroRetries := uint(0)
+	zeroJitter := time.Duration(0)
+	cfg = cfg.Merge(MonitorOverride{
+		MaxRetries:        &zeroRetries,
+		RetryPeriodJitter: &zeroJitter,
+	})
+
+	require.Zero(t, cfg.MaxRetries)
+	require.Zero(t, cfg.RetryPeriodJitter)
+	require.Equal(t, monitorRetryPeriodMin, cfg.RetryPeriod)
+}
+
+func TestMonitorConfigForLease(t *testing.T) {
+	lid := testutil.LeaseID(t)
+
+	cfg := Config{Monitor: NewDefaultMonitorConfig()}
+	require.Equal(t, NewDefaultMonitorConfig(), cfg.monitorConfigForLease(lid))
+
+	retryPeriod := time.Minute
+	one := uint(1)
+	cfg.Monitor.MaxRetries = 10
+	cfg.MonitorOverrides = MonitorOverrides{
+		lid.Owner: {
+			RetryPeriod:   &retryPeriod,
+			FailurePolicy: MonitorFailurePolicyAlert,
+		},
+		lid.DeploymentID().String(): {
+			FailurePolicy: MonitorFailurePolicyRestart,
+		},
+		// a longer owner address sharing the prefix must not match
+		lid.Owner + "x": {
+			MaxRetries: &one,
+		},
+	}
+
+	result := cfg.monitorConfigForLease(lid)
+	require.Equal(t, uint(10), result.MaxRetries)
+	require.Equal(t, time.Minute, result.RetryPeriod)
+	require.Equal(t, MonitorFailurePolicyRestart, result.FailurePolicy)
+
+	other := testutil.LeaseID(t)
+	require.Equal(t, cfg.Monitor, cfg.monitorConfigForLease(other))
+}
+
+func TestReadMonitorOverrides(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "overrides.yaml")
+
+	err := os.WriteFile(fpath, []byte(`
+akash1365yvmc4s7awdyj3n2sav7xfx76adc6dnmlx63:
+  max_retries: 120
+  retry_period: 30s
+  retry_period_jitter: 0s
+  failure_policy: alert
+`), 0o600)
+	require.NoError(t, err)
+
+	overrides, err := ReadMonitorOverrides(fpath)
+	require.NoError(t, err)
+
+	override := overrides["akash1365yvmc4s7awdyj3n2sav7xfx76adc6dnmlx63"]
+	require.Equal(t, uint(120), *override.MaxRetries)
+	require.Equal(t, 30*time.Second, *override.RetryPeriod)
+	require.Equal(t, time.Duration(0), *override.RetryPeriodJitter)
+	require.Nil(t, override.HealthcheckPeriod)
+	require.Equal(t, MonitorFailurePolicyAlert, override.FailurePolicy)
+
+	err = os.WriteFile(fpath, []byte(`
+akash1365yvmc4s7awdyj3n2sav7xfx76adc6dnmlx63:
+  failure_policy: reboot
+`), 0o600)
+	require.NoError(t, err)
+
+	_, err = ReadMonitorOverrides(fpath)
+	require.ErrorIs(t, err, ErrMonitorConfig)
+}
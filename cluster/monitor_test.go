@@ -2,10 +2,16 @@ package cluster
 
 import (
 	"testing"
+	"time"
 
 	manifest "github.com/akash-network/node/manifest/v2beta1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	broadcastmocks "github.com/akash-network/node/client/broadcaster/mocks"
+	clientmocks "github.com/akash-network/node/client/mocks"
 	"github.com/akash-network/node/pubsub"
 	"github.com/akash-network/node/testutil"
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
 	"github.com/boz/go-lifecycle"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -13,9 +19,21 @@ import (
 	"github.com/akash-network/provider/cluster/mocks"
 	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
 	"github.com/akash-network/provider/event"
+	crd "github.com/akash-network/provider/pkg/apis/akash.network/v2beta1"
 	"github.com/akash-network/provider/session"
 )
 
+// testMonitorConfig returns the default monitor settings with retries a millisecond apart
+func testMonitorConfig(maxRetries uint, policy MonitorFailurePolicy) MonitorConfig {
+	cfg := NewDefaultMonitorConfig()
+	cfg.MaxRetries = maxRetries
+	cfg.RetryPeriod = time.Millisecond
+	cfg.RetryPeriodJitter = time.Millisecond
+	cfg.FailurePolicy = policy
+
+	return cfg
+}
+
 func TestMonitorInstantiate(t *testing.T) {
 	myLog := testutil.Logger(t)
 	bus := pubsub.NewBus()
@@ -37,6 +55,7 @@ func TestMonitorInstantiate(t *testing.T) {
 		mgroup:  group,
 		log:     myLog,
 		lc:      lc,
+		config:  Config{Monitor: NewDefaultMonitorConfig()},
 	}
 	monitor := newDeploymentMonitor(myDeploymentManager)
 	require.NotNil(t, monitor)
@@ -74,6 +93,7 @@ func TestMonitorSendsClusterDeploymentPending(t *testing.T) {
 		mgroup:  group,
 		log:     myLog,
 		lc:      lc,
+		config:  Config{Monitor: NewDefaultMonitorConfig()},
 	}
 	monitor := newDeploymentMonitor(myDeploymentManager)
 	require.NotNil(t, monitor)
@@ -128,6 +148,7 @@ func TestMonitorSendsClusterDeploymentDeployed(t *testing.T) {
 		mgroup:  group,
 		log:     myLog,
 		lc:      lc,
+		config:  Config{Monitor: NewDefaultMonitorConfig()},
 	}
 	monitor := newDeploymentMonitor(myDeploymentManager)
 	require.NotNil(t, monitor)
@@ -139,3 +160,324 @@ func TestMonitorSendsClusterDeploymentDeployed(t *testing.T) {
 
 	monitor.lc.Shutdown(nil)
 }
+
+func TestServiceFailureReason(t *testing.T) {
+	require.Equal(t, `service "web": available replicas below target`, serviceFailureReason("web", nil))
+
+	failures := []ctypes.ServiceFailure{
+		{Pod: "web-1", Reason: "ImagePullBackOff", Message: `Back-off pulling image "nginx:bad"`},
+		{Pod: "web-2", Reason: "ImagePullBackOff", Message: `Back-off pulling image "nginx:bad"`},
+		{Pod: "web-3", Reason: "Unschedulable"},
+	}
+
+	require.Equal(t,
+		`service "web": ImagePullBackOff (2 pods): Back-off pulling image "nginx:bad", Unschedulable (1 pod)`,
+		serviceFailureReason("web", failures))
+}
+
+func TestMonitorRestartsPodsOnFailure(t *testing.T) {
+	const serviceName = "test"
+	myLog := testutil.Logger(t)
+	bus := pubsub.NewBus()
+	lid := testutil.LeaseID(t)
+
+	group := &manifest.Group{}
+	group.Services = make([]manifest.Service, 1)
+	group.Services[0].Name = serviceName
+	group.Services[0].Count = 1
+	client := &mocks.Client{}
+
+	statusResult := make(map[string]*ctypes.ServiceStatus)
+	statusResult[serviceName] = &ctypes.ServiceStatus{
+		Name:      serviceName,
+		Available: 0,
+		Total:     1,
+	}
+	client.On("LeaseStatus", mock.Anything, lid).Return(statusResult, nil)
+
+	restarted := make(chan struct{}, 1)
+	client.On("RestartLeasePods", mock.Anything, lid).Run(func(mock.Arguments) {
+		select {
+		case restarted <- struct{}{}:
+		default:
+		}
+	}).Return(nil)
+	mySession := session.New(myLog, nil, nil, -1)
+
+	lc := lifecycle.New()
+	myDeploymentManager := &deploymentManager{
+		bus:     bus,
+		session: mySession,
+		client:  client,
+		lease:   lid,
+		mgroup:  group,
+		log:     myLog,
+		lc:      lc,
+		config: Config{
+			Monitor: testMonitorConfig(1, MonitorFailurePolicyRestart),
+		},
+	}
+	monitor := newDeploymentMonitor(myDeploymentManager)
+	require.NotNil(t, monitor)
+
+	select {
+	case <-restarted:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for pods restart")
+	}
+
+	monitor.lc.Shutdown(nil)
+	client.AssertNotCalled(t, "TeardownLease", mock.Anything, lid)
+}
+
+func TestMonitorRecordsCloseReason(t *testing.T) {
+	const serviceName = "test"
+	myLog := testutil.Logger(t)
+	bus := pubsub.NewBus()
+	lid := testutil.LeaseID(t)
+
+	group := &manifest.Group{}
+	group.Services = make([]manifest.Service, 1)
+	group.Services[0].Name = serviceName
+	group.Services[0].Count = 1
+	client := &mocks.Client{}
+
+	statusResult := make(map[string]*ctypes.ServiceStatus)
+	statusResult[serviceName] = &ctypes.ServiceStatus{
+		Name:      serviceName,
+		Available: 0,
+		Total:     1,
+		Failures: []ctypes.ServiceFailure{{
+			Pod:     "test-0",
+			Reason:  "ImagePullBackOff",
+			Message: `Back-off pulling image "nginx:bad"`,
+		}},
+	}
+	client.On("LeaseStatus", mock.Anything, lid).Return(statusResult, nil)
+
+	recorded := make(chan crd.ManifestStatus, 1)
+	client.On("UpdateManifestStatus", mock.Anything, lid, mock.Anything).Run(func(args mock.Arguments) {
+		recorded <- args.Get(2).(crd.ManifestStatus)
+	}).Return(nil)
+
+	broadcasts := make(chan sdk.Msg, 1)
+	txClient := &broadcastmocks.Client{}
+	txClient.On("Broadcast", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		broadcasts <- args.Get(1).(sdk.Msg)
+	}).Return(nil)
+	akashClient := &clientmocks.Client{}
+	akashClient.On("Tx").Return(txClient)
+	mySession := session.New(myLog, akashClient, nil, -1)
+
+	lc := lifecycle.New()
+	myDeploymentManager := &deploymentManager{
+		bus:     bus,
+		session: mySession,
+		client:  client,
+		lease:   lid,
+		mgroup:  group,
+		log:     myLog,
+		lc:      lc,
+		config: Config{
+			Monitor: testMonitorConfig(0, MonitorFailurePolicyClose),
+		},
+	}
+	monitor := newDeploymentMonitor(myDeploymentManager)
+	require.NotNil(t, monitor)
+
+	status := testutil.ChannelWaitForValue(t, recorded).(crd.ManifestStatus)
+	require.Equal(t, crd.ManifestStateClosed, status.State)
+	require.Equal(t, `service "test": ImagePullBackOff (1 pod): Back-off pulling image "nginx:bad"`, status.Message)
+
+	msg := testutil.ChannelWaitForValue(t, broadcasts)
+	closeBid, ok := msg.(*mtypes.MsgCloseBid)
+	require.True(t, ok)
+	require.Equal(t, lid.BidID(), closeBid.BidID)
+
+	monitor.lc.Shutdown(nil)
+}
+
+func TestMonitorRequestsRollbackOfFailedUpdate(t *testing.T) {
+	const serviceName = "test"
+	myLog := testutil.Logger(t)
+	bus := pubsub.NewBus()
+	lid := testutil.LeaseID(t)
+
+	previous := &manifest.Group{}
+	group := &manifest.Group{}
+	group.Services = make([]manifest.Service, 1)
+	group.Services[0].Name = serviceName
+	group.Services[0].Count = 1
+	client := &mocks.Client{}
+
+	statusResult := make(map[string]*ctypes.ServiceStatus)
+	statusResult[serviceName] = &ctypes.ServiceStatus{
+		Name:      serviceName,
+		Available: 0,
+		Total:     1,
+		Failures: []ctypes.ServiceFailure{{
+			Pod:    "test-0",
+			Reason: "ImagePullBackOff",
+		}},
+	}
+	client.On("LeaseStatus", mock.Anything, lid).Return(statusResult, nil)
+	mySession := session.New(myLog, nil, nil, -1)
+
+	lc := lifecycle.New()
+	myDeploymentManager := &deploymentManager{
+		bus:           bus,
+		session:       mySession,
+		client:        client,
+		lease:         lid,
+		mgroup:        group,
+		lastGoodGroup: previous,
+		rollbackch:    make(chan rollbackRequest),
+		log:           myLog,
+		lc:            lc,
+		config: Config{
+			Monitor:                   testMonitorConfig(monitorMaxRetries, MonitorFailurePolicyClose),
+			DeploymentRollbackTimeout: time.Millisecond,
+		},
+	}
+	monitor := newDeploymentMonitor(myDeploymentManager)
+	require.NotNil(t, monitor)
+
+	select {
+	case req := <-myDeploymentManager.rollbackch:
+		require.Same(t, group, req.group)
+		require.Contains(t, req.reason, "ImagePullBackOff")
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for rollback request")
+	}
+
+	monitor.lc.Shutdown(nil)
+	client.AssertNotCalled(t, "TeardownLease", mock.Anything, lid)
+}
+
+func TestMonitorResumesAfterDroppedRollback(t *testing.T) {
+	const serviceName = "test"
+	myLog := testutil.Logger(t)
+	bus := pubsub.NewBus()
+	lid := testutil.LeaseID(t)
+
+	group := &manifest.Group{}
+	group.Services = make([]manifest.Service, 1)
+	group.Services[0].Name = serviceName
+	group.Services[0].Count = 1
+
+	checked := make(chan struct{}, 1)
+	client := &mocks.Client{}
+	client.On("LeaseStatus", mock.Anything, lid).Run(func(_ mock.Arguments) {
+		select {
+		case checked <- struct{}{}:
+		default:
+		}
+	}).Return(map[string]*ctypes.ServiceStatus{
+		serviceName: {Name: serviceName, Available: 0, Total: 1},
+	}, nil)
+
+	lc := lifecycle.New()
+	myDeploymentManager := &deploymentManager{
+		bus:           bus,
+		session:       session.New(myLog, nil, nil, -1),
+		client:        client,
+		lease:         lid,
+		mgroup:        group,
+		lastGoodGroup: &manifest.Group{},
+		rollbackch:    make(chan rollbackRequest),
+		log:           myLog,
+		lc:            lc,
+		config: Config{
+			Monitor:                   testMonitorConfig(monitorMaxRetries, MonitorFailurePolicyAlert),
+			DeploymentRollbackTimeout: time.Millisecond,
+		},
+	}
+	monitor := newDeploymentMonitor(myDeploymentManager)
+
+	// the manager drops the request, as it does for a group replaced meanwhile
+	select {
+	case <-myDeploymentManager.rollbackch:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for rollback request")
+	}
+
+	select {
+	case <-checked:
+	default:
+	}
+
+	select {
+	case <-checked:
+	case <-time.After(10 * time.Second):
+		t.Fatal("monitor did not resume checks after its rollback request was dropped")
+	}
+
+	monitor.lc.Shutdown(nil)
+}
+
+func TestMonitorShutdownWithPendingReports(t *testing.T) {
+	const serviceName = "test"
+
+	for _, healthy := range []bool{true, false} {
+		myLog := testutil.Logger(t)
+		bus := pubsub.NewBus()
+		lid := testutil.LeaseID(t)
+
+		group := &manifest.Group{}
+		group.Services = make([]manifest.Service, 1)
+		group.Services[0].Name = serviceName
+		group.Services[0].Count = 1
+
+		available := int32(0)
+		if healthy {
+			available = 1
+		}
+
+		checked := make(chan struct{}, 1)
+		client := &mocks.Client{}
+		client.On("LeaseStatus", mock.Anything, lid).Run(func(_ mock.Arguments) {
+			select {
+			case checked <- struct{}{}:
+			default:
+			}
+		}).Return(map[string]*ctypes.ServiceStatus{
+			serviceName: {Name: serviceName, Available: available, Total: 1},
+		}, nil)
+
+		lc := lifecycle.New()
+		myDeploymentManager := &deploymentManager{
+			bus:           bus,
+			session:       session.New(myLog, nil, nil, -1),
+			client:        client,
+			lease:         lid,
+			mgroup:        group,
+			lastGoodGroup: &manifest.Group{},
+			// nothing reads the reports, like a manager waiting for its monitor to stop
+			healthych:  make(chan *manifest.Group),
+			rollbackch: make(chan rollbackRequest),
+			log:        myLog,
+			lc:         lc,
+			config: Config{
+				Monitor:                   testMonitorConfig(monitorMaxRetries, MonitorFailurePolicyClose),
+				DeploymentRollbackTimeout: time.Millisecond,
+			},
+		}
+		monitor := newDeploymentMonitor(myDeploymentManager)
+
+		select {
+		case <-checked:
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for the monitor check")
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		// the manager shutting down stops the monitor blocked on sending its report
+		lc.ShutdownInitiated(nil)
+
+		select {
+		case <-monitor.done():
+		case <-time.After(10 * time.Second):
+			t.Fatalf("monitor blocked on a pending report, healthy: %v", healthy)
+		}
+	}
+}
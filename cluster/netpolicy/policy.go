@@ -0,0 +1,182 @@
+package netpolicy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ingressLabelName  = "app.kubernetes.io/name"
+	ingressLabelValue = "ingress-nginx"
+)
+
+var (
+	ErrNetworkPolicy = errors.New("network policy")
+	ErrInvalidRule   = fmt.Errorf("%w: invalid rule", ErrNetworkPolicy)
+)
+
+// Policy customizes the network policies installed in lease namespaces.
+// Traffic within the lease namespace and to the cluster DNS is always allowed.
+// Network policies are allow lists, denying a CIDR is done by listing it in
+// the except list of a wider one.
+type Policy struct {
+	IngressController IngressController `yaml:"ingress_controller"`
+	// Egress replaces the default rule, which allows public IPv4 addresses only, when non-empty
+	Egress []EgressRule `yaml:"egress"`
+}
+
+// IngressController selects the pods of the ingress controller allowed to reach lease pods
+type IngressController struct {
+	NamespaceLabels map[string]string `yaml:"namespace_labels"`
+	PodLabels       map[string]string `yaml:"pod_labels"`
+}
+
+// EgressRule allows traffic to either a CIDR, or to pods selected by labels, on the given ports.
+// Pod labels select pods of the namespaces matching the namespace labels, which must be set along them.
+// No ports allows every port.
+type EgressRule struct {
+	CIDR            string            `yaml:"cidr"`
+	Except          []string          `yaml:"except"`
+	NamespaceLabels map[string]string `yaml:"namespace_labels"`
+	PodLabels       map[string]string `yaml:"pod_labels"`
+	Ports           []Port            `yaml:"ports"`
+}
+
+// Port is a single port, or a range of them when EndPort is set
+type Port struct {
+	Protocol string `yaml:"protocol"`
+	Port     int32  `yaml:"port"`
+	EndPort  int32  `yaml:"end_port"`
+}
+
+// DefaultIngressController selects the pods of ingress-nginx
+func DefaultIngressController() IngressController {
+	return IngressController{
+		NamespaceLabels: map[string]string{ingressLabelName: ingressLabelValue},
+		PodLabels:       map[string]string{ingressLabelName: ingressLabelValue},
+	}
+}
+
+// DefaultEgress allows public IPv4 addresses only, the cloud instance metadata endpoint is not reachable either
+func DefaultEgress() []EgressRule {
+	return []EgressRule{
+		{
+			CIDR: "0.0.0.0/0",
+			Except: []string{
+				"10.0.0.0/8",
+				"192.168.0.0/16",
+				"172.16.0.0/12",
+				"169.254.169.254/32",
+			},
+		},
+	}
+}
+
+// ReadFile loads and validates a policy from the YAML file at the given path
+func ReadFile(fpath string) (*Policy, error) {
+	buf, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &Policy{}
+	if err := yaml.Unmarshal(buf, policy); err != nil {
+		return nil, err
+	}
+
+	if err := policy.ValidateBasic(); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// ValidateBasic checks all rules of the policy are well-formed
+func (p *Policy) ValidateBasic() error {
+	if p == nil {
+		return nil
+	}
+
+	for idx, rule := range p.Egress {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("egress rule %d: %w", idx, err)
+		}
+	}
+
+	return nil
+}
+
+// IngressControllerSelector returns the configured ingress controller, or the default one
+func (p *Policy) IngressControllerSelector() IngressController {
+	if p == nil || (len(p.IngressController.NamespaceLabels) == 0 && len(p.IngressController.PodLabels) == 0) {
+		return DefaultIngressController()
+	}
+
+	return p.IngressController
+}
+
+// EgressRules returns the configured egress rules, or the default ones
+func (p *Policy) EgressRules() []EgressRule {
+	if p == nil || len(p.Egress) == 0 {
+		return DefaultEgress()
+	}
+
+	return p.Egress
+}
+
+func (r EgressRule) validate() error {
+	selectors := len(r.NamespaceLabels) != 0 || len(r.PodLabels) != 0
+
+	switch {
+	case r.CIDR == "" && !selectors:
+		return fmt.Errorf("%w: either cidr or labels must be set", ErrInvalidRule)
+	case r.CIDR != "" && selectors:
+		return fmt.Errorf("%w: cidr and labels are exclusive", ErrInvalidRule)
+	case r.CIDR == "" && len(r.Except) != 0:
+		return fmt.Errorf("%w: except requires cidr", ErrInvalidRule)
+	case len(r.PodLabels) != 0 && len(r.NamespaceLabels) == 0:
+		// a pod selector alone only matches pods of the lease namespace
+		return fmt.Errorf("%w: pod_labels requires namespace_labels", ErrInvalidRule)
+	}
+
+	if r.CIDR != "" {
+		_, block, err := net.ParseCIDR(r.CIDR)
+		if err != nil {
+			return fmt.Errorf("%w: cidr %q", ErrInvalidRule, r.CIDR)
+		}
+
+		for _, except := range r.Except {
+			ip, _, err := net.ParseCIDR(except)
+			if err != nil {
+				return fmt.Errorf("%w: except %q", ErrInvalidRule, except)
+			}
+
+			if !block.Contains(ip) {
+				return fmt.Errorf("%w: except %q is outside of %q", ErrInvalidRule, except, r.CIDR)
+			}
+		}
+	}
+
+	for _, port := range r.Ports {
+		switch strings.ToUpper(port.Protocol) {
+		case "", "TCP", "UDP", "SCTP":
+		default:
+			return fmt.Errorf("%w: protocol %q", ErrInvalidRule, port.Protocol)
+		}
+
+		if port.Port < 1 || port.Port > 65535 {
+			return fmt.Errorf("%w: port %d", ErrInvalidRule, port.Port)
+		}
+
+		if port.EndPort != 0 && (port.EndPort < port.Port || port.EndPort > 65535) {
+			return fmt.Errorf("%w: port range %d-%d", ErrInvalidRule, port.Port, port.EndPort)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package netpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFile(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(fpath, []byte(`
+ingress_controller:
+  namespace_labels:
+    kubernetes.io/metadata.name: traefik
+  pod_labels:
+    app.kubernetes.io/name: traefik
+egress:
+  - cidr: 0.0.0.0/0
+    except:
+      - 10.0.0.0/8
+      - 169.254.169.254/32
+    ports:
+      - port: 443
+      - protocol: udp
+        port: 30000
+        end_port: 32767
+  - namespace_labels:
+      kubernetes.io/metadata.name: shared
+`), 0600))
+
+	policy, err := ReadFile(fpath)
+	require.NoError(t, err)
+	require.Equal(t, "traefik", policy.IngressControllerSelector().PodLabels["app.kubernetes.io/name"])
+	require.Len(t, policy.EgressRules(), 2)
+	require.Equal(t, int32(32767), policy.EgressRules()[0].Ports[1].EndPort)
+}
+
+func TestDefaults(t *testing.T) {
+	var policy *Policy
+	require.NoError(t, policy.ValidateBasic())
+	require.Equal(t, DefaultIngressController(), policy.IngressControllerSelector())
+	require.Equal(t, DefaultEgress(), policy.EgressRules())
+
+	policy = &Policy{}
+	require.Equal(t, DefaultEgress(), policy.EgressRules())
+}
+
+func TestValidateBasic(t *testing.T) {
+	invalid := []EgressRule{
+		{},
+		{CIDR: "0.0.0.0/0", PodLabels: map[string]string{"app": "x"}},
+		{PodLabels: map[string]string{"app": "x"}, Except: []string{"10.0.0.0/8"}},
+		{PodLabels: map[string]string{"app": "x"}},
+		{CIDR: "bogus"},
+		{CIDR: "10.0.0.0/8", Except: []string{"192.168.0.0/16"}},
+		{CIDR: "0.0.0.0/0", Ports: []Port{{Protocol: "icmp", Port: 1}}},
+		{CIDR: "0.0.0.0/0", Ports: []Port{{Port: 0}}},
+		{CIDR: "0.0.0.0/0", Ports: []Port{{Port: 100, EndPort: 50}}},
+	}
+
+	for _, rule := range invalid {
+		policy := &Policy{Egress: []EgressRule{rule}}
+		require.ErrorIs(t, policy.ValidateBasic(), ErrInvalidRule, "%+v", rule)
+	}
+
+	require.NoError(t, (&Policy{Egress: DefaultEgress()}).ValidateBasic())
+	require.NoError(t, (&Policy{Egress: []EgressRule{{
+		NamespaceLabels: map[string]string{"kubernetes.io/metadata.name": "shared"},
+		PodLabels:       map[string]string{"app": "x"},
+	}}}).ValidateBasic())
+}
@@ -0,0 +1,99 @@
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tendermint/tendermint/libs/log"
+
+	manifest "github.com/akash-network/node/manifest/v2beta1"
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+)
+
+var nodePortCollisionsGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "provider_inventory_node_port_collisions",
+	Help: "node ports held by more than one lease",
+})
+
+type nodePortKey struct {
+	nodePort uint16
+	proto    manifest.ServiceProtocol
+}
+
+func (k nodePortKey) String() string {
+	return fmt.Sprintf("%d/%v", k.nodePort, k.proto)
+}
+
+// nodePortTracker maps the node ports provisioned in the cluster to the leases holding them
+type nodePortTracker struct {
+	log   log.Logger
+	ports map[nodePortKey][]ctypes.NodePortAllocation
+}
+
+func newNodePortTracker(log log.Logger) *nodePortTracker {
+	return &nodePortTracker{
+		log:   log,
+		ports: make(map[nodePortKey][]ctypes.NodePortAllocation),
+	}
+}
+
+// update replaces the tracked ports with the ones read from the cluster and returns the ports
+// claimed by more than one lease. Colliding allocations are kept, each lease holding the port
+// uses one of the endpoints of the provider.
+func (t *nodePortTracker) update(allocations []ctypes.NodePortAllocation) []nodePortKey {
+	ports := make(map[nodePortKey][]ctypes.NodePortAllocation, len(allocations))
+	collisions := make([]nodePortKey, 0)
+
+	for _, allocation := range allocations {
+		key := nodePortKey{nodePort: allocation.NodePort, proto: allocation.Proto}
+		if existing, exists := ports[key]; exists && !existing[0].LeaseID.Equals(allocation.LeaseID) {
+			t.log.Error("node port held by more than one lease", "node-port", key,
+				"lease", existing[0].LeaseID, "other-lease", allocation.LeaseID)
+			if len(existing) == 1 {
+				collisions = append(collisions, key)
+			}
+		}
+		ports[key] = append(ports[key], allocation)
+	}
+
+	t.ports = ports
+	nodePortCollisionsGauge.Set(float64(len(collisions)))
+
+	return collisions
+}
+
+// release forgets the ports of an order once its lease is torn down, without waiting for the
+// services to be gone from the cluster
+func (t *nodePortTracker) release(order mtypes.OrderID) {
+	for key, allocations := range t.ports {
+		kept := allocations[:0]
+		for _, allocation := range allocations {
+			if !allocation.LeaseID.OrderID().Equals(order) {
+				kept = append(kept, allocation)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(t.ports, key)
+			continue
+		}
+		t.ports[key] = kept
+	}
+}
+
+// inUse returns the number of tracked allocations along with the orders holding them
+func (t *nodePortTracker) inUse() (uint, map[string]struct{}) {
+	count := uint(0)
+	orders := make(map[string]struct{})
+	for _, allocations := range t.ports {
+		for _, allocation := range allocations {
+			count++
+			orders[allocation.LeaseID.OrderID().String()] = struct{}{}
+		}
+	}
+
+	return count, orders
+}
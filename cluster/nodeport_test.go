@@ -0,0 +1,40 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	manifest "github.com/akash-network/node/manifest/v2beta1"
+	"github.com/akash-network/node/testutil"
+
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+)
+
+func TestNodePortTracker(t *testing.T) {
+	leaseA := testutil.LeaseID(t)
+	leaseB := testutil.LeaseID(t)
+
+	tracker := newNodePortTracker(testutil.Logger(t))
+
+	collisions := tracker.update([]ctypes.NodePortAllocation{
+		{LeaseID: leaseA, Service: "web", Port: 80, NodePort: 30080, Proto: manifest.TCP},
+		{LeaseID: leaseA, Service: "dns", Port: 53, NodePort: 30053, Proto: manifest.UDP},
+		{LeaseID: leaseB, Service: "web", Port: 80, NodePort: 30080, Proto: manifest.TCP},
+		{LeaseID: leaseB, Service: "web", Port: 80, NodePort: 30080, Proto: manifest.UDP},
+	})
+	require.Equal(t, []nodePortKey{{nodePort: 30080, proto: manifest.TCP}}, collisions)
+
+	// both leases holding the colliding port use an endpoint
+	inUse, orders := tracker.inUse()
+	require.Equal(t, uint(4), inUse)
+	require.Contains(t, orders, leaseA.OrderID().String())
+	require.Contains(t, orders, leaseB.OrderID().String())
+
+	tracker.release(leaseA.OrderID())
+
+	inUse, orders = tracker.inUse()
+	require.Equal(t, uint(2), inUse)
+	require.NotContains(t, orders, leaseA.OrderID().String())
+	require.Contains(t, orders, leaseB.OrderID().String())
+}
@@ -0,0 +1,385 @@
+package operatorclients
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+	"github.com/prometheus/common/expfmt"
+	"github.com/tendermint/tendermint/libs/log"
+	"k8s.io/client-go/rest"
+
+	"github.com/akash-network/provider/cluster/kube/builder"
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+	clusterutil "github.com/akash-network/provider/cluster/util"
+)
+
+const (
+	ingressMetricsPath = "/metrics"
+
+	// ingressMetricsScrapeInterval is how long a scrape of the controller is reused for, the rate of
+	// a hostname is computed between the last two scrapes its metrics were read from
+	ingressMetricsScrapeInterval = 10 * time.Second
+
+	/**
+	  Looking for the following ingress-nginx metrics
+	    nginx_ingress_controller_requests{ingress="app.example.com",namespace="<lease namespace>",status="200",...} 10
+	    nginx_ingress_controller_request_duration_seconds_bucket{ingress="app.example.com",namespace="<lease namespace>",le="0.1",...} 8
+	*/
+	metricNameIngressRequests        = "nginx_ingress_controller_requests"
+	metricNameIngressRequestDuration = "nginx_ingress_controller_request_duration_seconds"
+)
+
+var errIngressMetrics = errors.New("ingress metrics error")
+
+// IngressMetricsClient reads the requests served for the hostnames of leases from the metrics of
+// the ingress controller. Only the metrics of kubernetes/ingress-nginx are understood.
+type IngressMetricsClient interface {
+	LeaseHostnameMetrics(ctx context.Context, leaseID mtypes.LeaseID) ([]ctypes.HostnameMetrics, error)
+	Stop()
+	String() string
+}
+
+func NewIngressMetricsClient(logger log.Logger, kubeConfig *rest.Config, endpoint *net.SRV) (IngressMetricsClient, error) {
+	sda, err := clusterutil.NewServiceDiscoveryAgent(logger, kubeConfig, "metrics", "ingress-nginx-controller-metrics", "ingress-nginx", endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ingressMetricsClient{
+		sda:     sda,
+		log:     logger.With("client", "ingress-metrics"),
+		l:       &sync.Mutex{},
+		windows: make(map[ingressMetricsKey]*hostnameWindow),
+	}, nil
+}
+
+type ingressMetricsClient struct {
+	sda    clusterutil.ServiceDiscoveryAgent
+	client clusterutil.ServiceClient
+	log    log.Logger
+	l      sync.Locker
+
+	current *ingressMetricsSnapshot
+	// windows are kept per hostname so the rate of a hostname does not depend on the queries for
+	// other leases
+	windows map[ingressMetricsKey]*hostnameWindow
+}
+
+func (imc *ingressMetricsClient) String() string {
+	return fmt.Sprintf("<%T %p>", imc, imc)
+}
+
+func (imc *ingressMetricsClient) Stop() {
+	imc.sda.Stop()
+}
+
+func (imc *ingressMetricsClient) LeaseHostnameMetrics(ctx context.Context, leaseID mtypes.LeaseID) ([]ctypes.HostnameMetrics, error) {
+	imc.l.Lock()
+	defer imc.l.Unlock()
+
+	if imc.current == nil || time.Since(imc.current.at) >= ingressMetricsScrapeInterval {
+		snapshot, err := imc.scrape(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		imc.current = snapshot
+
+		// forget the hostnames the controller no longer serves
+		for key := range imc.windows {
+			if _, exists := snapshot.ingresses[key]; !exists {
+				delete(imc.windows, key)
+			}
+		}
+	}
+
+	return hostnameMetrics(imc.windows, imc.current, builder.LidNS(leaseID)), nil
+}
+
+func (imc *ingressMetricsClient) scrape(ctx context.Context) (*ingressMetricsSnapshot, error) {
+	if imc.client == nil {
+		var err error
+		imc.client, err = imc.sda.GetClient(ctx, false, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	request, err := imc.client.CreateRequest(ctx, http.MethodGet, ingressMetricsPath, nil)
+	if err != nil {
+		imc.client = nil
+		return nil, err
+	}
+
+	response, err := imc.client.DoRequest(request)
+	if err != nil {
+		// the controller may have moved, discover it again on the next scrape
+		imc.client = nil
+		imc.sda.DiscoverNow()
+		return nil, err
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		buf := &bytes.Buffer{}
+		_, _ = io.Copy(buf, response.Body)
+		imc.log.Error("checking ingress controller metrics returned", "status", response.StatusCode, "body", buf.String())
+		imc.client = nil
+		return nil, fmt.Errorf("%w: response status %d", errIngressMetrics, response.StatusCode)
+	}
+
+	return parseIngressMetrics(response.Body, time.Now())
+}
+
+type ingressMetricsKey struct {
+	namespace string
+	ingress   string
+}
+
+// ingressRequests are the counters of one ingress, summed over the controller pods
+type ingressRequests struct {
+	statusCodes map[string]float64
+	// buckets maps the upper bound of the duration buckets to their cumulative count
+	buckets map[float64]float64
+}
+
+func (r *ingressRequests) total() float64 {
+	total := float64(0)
+	for _, count := range r.statusCodes {
+		total += count
+	}
+
+	return total
+}
+
+// since returns the requests served after prev was read, or nil when a counter went backwards
+// because the controller restarted or another pod answered the scrape
+func (r *ingressRequests) since(prev *ingressRequests) *ingressRequests {
+	result := &ingressRequests{
+		statusCodes: make(map[string]float64, len(r.statusCodes)),
+		buckets:     make(map[float64]float64, len(r.buckets)),
+	}
+
+	for status, count := range r.statusCodes {
+		delta := count - prev.statusCodes[status]
+		if delta < 0 {
+			return nil
+		}
+		result.statusCodes[status] = delta
+	}
+
+	for bound, count := range r.buckets {
+		delta := count - prev.buckets[bound]
+		if delta < 0 {
+			return nil
+		}
+		result.buckets[bound] = delta
+	}
+
+	return result
+}
+
+// quantile estimates the q quantile of the request durations the way histogram_quantile does,
+// interpolating linearly within the bucket holding it
+func (r *ingressRequests) quantile(q float64) float64 {
+	bounds := make([]float64, 0, len(r.buckets))
+	for bound := range r.buckets {
+		bounds = append(bounds, bound)
+	}
+	sort.Float64s(bounds)
+
+	if len(bounds) == 0 {
+		return 0
+	}
+
+	total := r.buckets[bounds[len(bounds)-1]]
+	if total == 0 {
+		return 0
+	}
+
+	rank := q * total
+	lowerBound := float64(0)
+	lowerCount := float64(0)
+	for _, bound := range bounds {
+		count := r.buckets[bound]
+		if count >= rank {
+			if math.IsInf(bound, 1) {
+				return lowerBound
+			}
+			if count == lowerCount {
+				return bound
+			}
+			return lowerBound + (bound-lowerBound)*(rank-lowerCount)/(count-lowerCount)
+		}
+		lowerBound = bound
+		lowerCount = count
+	}
+
+	return lowerBound
+}
+
+type ingressMetricsSnapshot struct {
+	at        time.Time
+	ingresses map[ingressMetricsKey]*ingressRequests
+}
+
+type ingressSample struct {
+	at       time.Time
+	requests *ingressRequests
+}
+
+// hostnameWindow holds the last two scrapes the metrics of a hostname were read from
+type hostnameWindow struct {
+	previous *ingressSample
+	current  *ingressSample
+}
+
+// advance moves the window to the scrape at, reading the same scrape again keeps the window
+func (w *hostnameWindow) advance(at time.Time, requests *ingressRequests) {
+	if w.current != nil && w.current.at.Equal(at) {
+		return
+	}
+
+	w.previous = w.current
+	w.current = &ingressSample{at: at, requests: requests}
+}
+
+func parseIngressMetrics(r io.Reader, at time.Time) (*ingressMetricsSnapshot, error) {
+	var parser expfmt.TextParser
+	mf, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ingressMetricsSnapshot{
+		at:        at,
+		ingresses: make(map[ingressMetricsKey]*ingressRequests),
+	}
+
+	entry := func(key ingressMetricsKey) *ingressRequests {
+		requests, exists := result.ingresses[key]
+		if !exists {
+			requests = &ingressRequests{
+				statusCodes: make(map[string]float64),
+				buckets:     make(map[float64]float64),
+			}
+			result.ingresses[key] = requests
+		}
+		return requests
+	}
+
+	for name, family := range mf {
+		if name != metricNameIngressRequests && name != metricNameIngressRequestDuration {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			key := ingressMetricsKey{}
+			status := ""
+			for _, label := range metric.GetLabel() {
+				switch label.GetName() {
+				case "namespace":
+					key.namespace = label.GetValue()
+				case "ingress":
+					key.ingress = label.GetValue()
+				case "status":
+					status = label.GetValue()
+				}
+			}
+
+			if key.namespace == "" || key.ingress == "" {
+				continue
+			}
+
+			switch name {
+			case metricNameIngressRequests:
+				if counter := metric.GetCounter(); counter != nil {
+					entry(key).statusCodes[status] += counter.GetValue()
+				}
+			case metricNameIngressRequestDuration:
+				histogram := metric.GetHistogram()
+				if histogram == nil {
+					continue
+				}
+				requests := entry(key)
+				for _, bucket := range histogram.GetBucket() {
+					// the +Inf bucket is the sample count, which is always present
+					if math.IsInf(bucket.GetUpperBound(), 1) {
+						continue
+					}
+					requests.buckets[bucket.GetUpperBound()] += float64(bucket.GetCumulativeCount())
+				}
+				requests.buckets[math.Inf(1)] += float64(histogram.GetSampleCount())
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// hostnameMetrics returns the requests served for the ingresses in namespace and advances their
+// windows to the current scrape. The requests are counted over the window of each hostname when
+// its metrics were read from an earlier scrape.
+func hostnameMetrics(windows map[ingressMetricsKey]*hostnameWindow, current *ingressMetricsSnapshot, namespace string) []ctypes.HostnameMetrics {
+	result := make([]ctypes.HostnameMetrics, 0)
+
+	for key, requests := range current.ingresses {
+		if key.namespace != namespace {
+			continue
+		}
+
+		w, exists := windows[key]
+		if !exists {
+			w = &hostnameWindow{}
+			windows[key] = w
+		}
+		w.advance(current.at, requests)
+
+		window := float64(0)
+		if w.previous != nil {
+			if delta := requests.since(w.previous.requests); delta != nil {
+				requests = delta
+				window = current.at.Sub(w.previous.at).Seconds()
+			}
+		}
+
+		total := requests.total()
+		entry := ctypes.HostnameMetrics{
+			Hostname:      key.ingress,
+			Requests:      uint64(total),
+			WindowSeconds: window,
+			StatusCodes:   make(map[string]uint64, len(requests.statusCodes)),
+			Latency: ctypes.LatencyPercentiles{
+				P50: requests.quantile(0.5),
+				P90: requests.quantile(0.9),
+				P99: requests.quantile(0.99),
+			},
+		}
+		if window > 0 {
+			entry.RequestRate = total / window
+		}
+		for status, count := range requests.statusCodes {
+			entry.StatusCodes[status] = uint64(count)
+		}
+
+		result = append(result, entry)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Hostname < result[j].Hostname
+	})
+
+	return result
+}
@@ -0,0 +1,145 @@
+package operatorclients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/akash-network/node/testutil"
+
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+	clusterutil "github.com/akash-network/provider/cluster/util"
+)
+
+func testIngressMetrics(t *testing.T, at time.Time, ok uint64, failed uint64, fast uint64) *ingressMetricsSnapshot {
+	t.Helper()
+
+	text := fmt.Sprintf(`# TYPE nginx_ingress_controller_requests counter
+nginx_ingress_controller_requests{ingress="app.example.com",namespace="lease-ns",status="200"} %[1]d
+nginx_ingress_controller_requests{ingress="app.example.com",namespace="lease-ns",status="503"} %[2]d
+nginx_ingress_controller_requests{ingress="other.example.com",namespace="other-ns",status="200"} 7
+# TYPE nginx_ingress_controller_request_duration_seconds histogram
+nginx_ingress_controller_request_duration_seconds_bucket{ingress="app.example.com",namespace="lease-ns",le="0.1"} %[3]d
+nginx_ingress_controller_request_duration_seconds_bucket{ingress="app.example.com",namespace="lease-ns",le="1"} %[4]d
+nginx_ingress_controller_request_duration_seconds_bucket{ingress="app.example.com",namespace="lease-ns",le="+Inf"} %[4]d
+nginx_ingress_controller_request_duration_seconds_sum{ingress="app.example.com",namespace="lease-ns"} 1
+nginx_ingress_controller_request_duration_seconds_count{ingress="app.example.com",namespace="lease-ns"} %[4]d
+`, ok, failed, fast, ok+failed)
+
+	snapshot, err := parseIngressMetrics(strings.NewReader(text), at)
+	require.NoError(t, err)
+
+	return snapshot
+}
+
+func TestIngressHostnameMetrics(t *testing.T) {
+	start := time.Now()
+
+	windows := make(map[ingressMetricsKey]*hostnameWindow)
+
+	first := testIngressMetrics(t, start, 80, 20, 50)
+	metrics := hostnameMetrics(windows, first, "lease-ns")
+	require.Len(t, metrics, 1)
+	require.Equal(t, "app.example.com", metrics[0].Hostname)
+	require.Equal(t, uint64(100), metrics[0].Requests)
+	require.Equal(t, map[string]uint64{"200": 80, "503": 20}, metrics[0].StatusCodes)
+	require.Zero(t, metrics[0].RequestRate)
+	require.InDelta(t, 0.1, metrics[0].Latency.P50, 1e-9)
+	require.InDelta(t, 0.82, metrics[0].Latency.P90, 1e-9)
+
+	// the second scrape only counts the requests served since the first
+	second := testIngressMetrics(t, start.Add(10*time.Second), 130, 20, 100)
+	metrics = hostnameMetrics(windows, second, "lease-ns")
+	require.Len(t, metrics, 1)
+	require.Equal(t, uint64(50), metrics[0].Requests)
+	require.Equal(t, float64(10), metrics[0].WindowSeconds)
+	require.Equal(t, float64(5), metrics[0].RequestRate)
+	require.Equal(t, map[string]uint64{"200": 50, "503": 0}, metrics[0].StatusCodes)
+	require.Equal(t, ctypes.LatencyPercentiles{P50: 0.05, P90: 0.09, P99: 0.099}, roundLatency(metrics[0].Latency))
+
+	// reading the same scrape again keeps the window
+	metrics = hostnameMetrics(windows, second, "lease-ns")
+	require.Equal(t, uint64(50), metrics[0].Requests)
+	require.Equal(t, float64(10), metrics[0].WindowSeconds)
+
+	// counters going backwards after a restart are reported as they are
+	restarted := testIngressMetrics(t, start.Add(20*time.Second), 4, 0, 4)
+	metrics = hostnameMetrics(windows, restarted, "lease-ns")
+	require.Equal(t, uint64(4), metrics[0].Requests)
+	require.Zero(t, metrics[0].WindowSeconds)
+
+	require.Empty(t, hostnameMetrics(windows, first, "missing-ns"))
+}
+
+func TestIngressHostnameMetricsWindowPerHostname(t *testing.T) {
+	start := time.Now()
+	windows := make(map[ingressMetricsKey]*hostnameWindow)
+
+	require.Len(t, hostnameMetrics(windows, testIngressMetrics(t, start, 80, 20, 50), "lease-ns"), 1)
+
+	// scrapes made for another lease do not move the window of the hostname
+	hostnameMetrics(windows, testIngressMetrics(t, start.Add(10*time.Second), 100, 20, 60), "other-ns")
+	hostnameMetrics(windows, testIngressMetrics(t, start.Add(20*time.Second), 120, 20, 80), "other-ns")
+
+	metrics := hostnameMetrics(windows, testIngressMetrics(t, start.Add(30*time.Second), 140, 20, 100), "lease-ns")
+	require.Len(t, metrics, 1)
+	require.Equal(t, uint64(60), metrics[0].Requests)
+	require.Equal(t, float64(30), metrics[0].WindowSeconds)
+	require.Equal(t, float64(2), metrics[0].RequestRate)
+}
+
+type failingServiceClient struct{}
+
+func (failingServiceClient) CreateRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, method, "http://ingress-nginx.invalid"+path, body)
+}
+
+func (failingServiceClient) DoRequest(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+type countingDiscoveryAgent struct {
+	clients int
+}
+
+func (a *countingDiscoveryAgent) Stop()        {}
+func (a *countingDiscoveryAgent) DiscoverNow() {}
+
+func (a *countingDiscoveryAgent) GetClient(context.Context, bool, bool) (clusterutil.ServiceClient, error) {
+	a.clients++
+	return failingServiceClient{}, nil
+}
+
+func TestIngressMetricsClientRediscoversOnError(t *testing.T) {
+	sda := &countingDiscoveryAgent{}
+	imc := &ingressMetricsClient{
+		sda:     sda,
+		log:     testutil.Logger(t),
+		l:       &sync.Mutex{},
+		windows: make(map[ingressMetricsKey]*hostnameWindow),
+	}
+
+	_, err := imc.LeaseHostnameMetrics(context.Background(), testutil.LeaseID(t))
+	require.Error(t, err)
+	require.Nil(t, imc.client)
+
+	_, err = imc.LeaseHostnameMetrics(context.Background(), testutil.LeaseID(t))
+	require.Error(t, err)
+	require.Equal(t, 2, sda.clients)
+}
+
+func roundLatency(latency ctypes.LatencyPercentiles) ctypes.LatencyPercentiles {
+	round := func(v float64) float64 {
+		return float64(int64(v*1e6+0.5)) / 1e6
+	}
+
+	return ctypes.LatencyPercentiles{P50: round(latency.P50), P90: round(latency.P90), P99: round(latency.P99)}
+}
@@ -0,0 +1,63 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+)
+
+// OwnerQuota caps the leased IPs and NodePort endpoints held by the leases and pending
+// reservations of one owner. Zero is unlimited
+type OwnerQuota struct {
+	LeasedIPs uint `yaml:"leased_ips" json:"leased-ips"`
+	Endpoints uint `yaml:"endpoints" json:"endpoints"`
+}
+
+// OwnerQuotas is the quota of every owner along with the owners it is overridden for
+type OwnerQuotas struct {
+	Default OwnerQuota
+	Owners  map[string]OwnerQuota
+}
+
+// ReadOwnerQuotas loads the quotas overridden per owner address from the YAML file at the given path
+func ReadOwnerQuotas(fpath string) (map[string]OwnerQuota, error) {
+	buf, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(map[string]OwnerQuota)
+	if err := yaml.Unmarshal(buf, &owners); err != nil {
+		return nil, err
+	}
+
+	return owners, nil
+}
+
+// ForOwner returns the quota of the owner, an override replaces the default as a whole
+func (q OwnerQuotas) ForOwner(owner string) OwnerQuota {
+	if quota, exists := q.Owners[owner]; exists {
+		return quota
+	}
+
+	return q.Default
+}
+
+// Check returns ctypes.ErrOwnerQuotaExceeded when the owner holding inUse leased IPs and endpoints
+// requests that many more
+func (q OwnerQuota) Check(owner string, inUse OwnerQuota, requested OwnerQuota) error {
+	if q.LeasedIPs != 0 && requested.LeasedIPs != 0 && inUse.LeasedIPs+requested.LeasedIPs > q.LeasedIPs {
+		return fmt.Errorf("%w: owner %s holds %d leased IPs, requested %d more, limit is %d",
+			ctypes.ErrOwnerQuotaExceeded, owner, inUse.LeasedIPs, requested.LeasedIPs, q.LeasedIPs)
+	}
+
+	if q.Endpoints != 0 && requested.Endpoints != 0 && inUse.Endpoints+requested.Endpoints > q.Endpoints {
+		return fmt.Errorf("%w: owner %s holds %d endpoints, requested %d more, limit is %d",
+			ctypes.ErrOwnerQuotaExceeded, owner, inUse.Endpoints, requested.Endpoints, q.Endpoints)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+)
+
+func TestOwnerQuotas(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "quotas.yaml")
+	require.NoError(t, os.WriteFile(fpath, []byte(`
+akash1trusted:
+  leased_ips: 10
+`), 0o600))
+
+	owners, err := ReadOwnerQuotas(fpath)
+	require.NoError(t, err)
+
+	quotas := OwnerQuotas{
+		Default: OwnerQuota{LeasedIPs: 1, Endpoints: 5},
+		Owners:  owners,
+	}
+
+	quota := quotas.ForOwner("akash1other")
+	require.NoError(t, quota.Check("akash1other", OwnerQuota{}, OwnerQuota{LeasedIPs: 1, Endpoints: 5}))
+	require.ErrorIs(t, quota.Check("akash1other", OwnerQuota{LeasedIPs: 1}, OwnerQuota{LeasedIPs: 1}), ctypes.ErrOwnerQuotaExceeded)
+	require.ErrorIs(t, quota.Check("akash1other", OwnerQuota{Endpoints: 3}, OwnerQuota{Endpoints: 3}), ctypes.ErrOwnerQuotaExceeded)
+
+	// requests without leased IPs are not refused once the owner is at its limit
+	require.NoError(t, quota.Check("akash1other", OwnerQuota{LeasedIPs: 1}, OwnerQuota{Endpoints: 1}))
+
+	// overrides replace the default, unset limits are unlimited
+	quota = quotas.ForOwner("akash1trusted")
+	require.NoError(t, quota.Check("akash1trusted", OwnerQuota{LeasedIPs: 5, Endpoints: 100}, OwnerQuota{LeasedIPs: 5, Endpoints: 100}))
+	require.ErrorIs(t, quota.Check("akash1trusted", OwnerQuota{LeasedIPs: 10}, OwnerQuota{LeasedIPs: 1}), ctypes.ErrOwnerQuotaExceeded)
+}
@@ -0,0 +1,285 @@
+package cluster
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	sdkquery "github.com/cosmos/cosmos-sdk/types/query"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tendermint/tendermint/libs/log"
+
+	metricsutils "github.com/akash-network/node/util/metrics"
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+
+	"github.com/akash-network/provider/cluster/util"
+)
+
+const reconcileLeasesPageSize = 1000
+
+var (
+	reconcilerRunsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "provider_lease_reconciler_runs",
+		Help: "Number of comparisons of leases on chain with the cluster",
+	}, []string{"result"})
+
+	reconcilerOrphanedGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "provider_lease_reconciler_orphaned",
+		Help: "Leases deployed in the cluster which are not active on chain",
+	})
+
+	reconcilerMissingGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "provider_lease_reconciler_missing",
+		Help: "Leases active on chain without a deployment in the cluster",
+	})
+
+	reconcilerTeardownCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "provider_lease_reconciler_teardowns",
+		Help: "Number of orphaned leases torn down",
+	})
+)
+
+// OrphanedLease is a lease present in the cluster which is not active on chain
+type OrphanedLease struct {
+	LeaseID     mtypes.LeaseID `json:"lease_id"`
+	HasManifest bool           `json:"has_manifest"`
+}
+
+// LeaseReconciliation is the difference between the leases active on chain for a provider
+// and the leases deployed in its cluster
+type LeaseReconciliation struct {
+	Orphaned []OrphanedLease `json:"orphaned"`
+	// Missing leases are active on chain but have no manifest in the cluster
+	Missing []mtypes.LeaseID `json:"missing"`
+	// Deployed is the number of leases with a manifest or namespace in the cluster
+	Deployed int `json:"deployed"`
+	// Active is the number of leases active on chain
+	Active int `json:"active"`
+}
+
+// ReconcileLeases compares the leases active on chain for the provider with the Manifest CRDs
+// and lease namespaces in the cluster. Nothing is modified.
+func ReconcileLeases(ctx context.Context, client Client, qclient mtypes.QueryClient, provider string) (LeaseReconciliation, error) {
+	// the cluster is read first, leases created meanwhile are reported missing rather than torn down
+	deployments, err := client.Deployments(ctx)
+	if err != nil {
+		return LeaseReconciliation{}, err
+	}
+
+	namespaces, err := client.LeaseNamespaces(ctx)
+	if err != nil {
+		return LeaseReconciliation{}, err
+	}
+
+	active, err := activeProviderLeases(ctx, qclient, provider)
+	if err != nil {
+		return LeaseReconciliation{}, err
+	}
+
+	deployed := make(map[mtypes.LeaseID]bool)
+	for _, lid := range namespaces {
+		deployed[lid] = false
+	}
+	for _, deployment := range deployments {
+		deployed[deployment.LeaseID()] = true
+	}
+
+	result := LeaseReconciliation{
+		Orphaned: make([]OrphanedLease, 0),
+		Missing:  make([]mtypes.LeaseID, 0),
+		Deployed: len(deployed),
+		Active:   len(active),
+	}
+
+	for lid, hasManifest := range deployed {
+		if _, exists := active[lid]; !exists {
+			result.Orphaned = append(result.Orphaned, OrphanedLease{LeaseID: lid, HasManifest: hasManifest})
+		}
+	}
+
+	for lid := range active {
+		if hasManifest := deployed[lid]; !hasManifest {
+			result.Missing = append(result.Missing, lid)
+		}
+	}
+
+	sort.Slice(result.Orphaned, func(i, j int) bool {
+		return result.Orphaned[i].LeaseID.String() < result.Orphaned[j].LeaseID.String()
+	})
+	sort.Slice(result.Missing, func(i, j int) bool {
+		return result.Missing[i].String() < result.Missing[j].String()
+	})
+
+	return result, nil
+}
+
+func activeProviderLeases(ctx context.Context, qclient mtypes.QueryClient, provider string) (map[mtypes.LeaseID]struct{}, error) {
+	result := make(map[mtypes.LeaseID]struct{})
+
+	var key []byte
+	for {
+		resp, err := qclient.Leases(ctx, &mtypes.QueryLeasesRequest{
+			Filters: mtypes.LeaseFilters{
+				Provider: provider,
+				State:    mtypes.LeaseActive.String(),
+			},
+			Pagination: &sdkquery.PageRequest{
+				Key:   key,
+				Limit: reconcileLeasesPageSize,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range resp.Leases {
+			result[entry.Lease.LeaseID] = struct{}{}
+		}
+
+		if resp.Pagination == nil || len(resp.Pagination.NextKey) == 0 {
+			return result, nil
+		}
+		key = resp.Pagination.NextKey
+	}
+}
+
+// orphanTeardown tears down an orphaned lease through its deployment manager, reporting false
+// when the lease has none
+type orphanTeardown func(context.Context, mtypes.LeaseID) (bool, error)
+
+// leaseReconciler periodically reconciles the leases on chain with the cluster. Leases orphaned
+// for longer than the grace period are torn down when enabled, leases missing a deployment are reported.
+type leaseReconciler struct {
+	log             log.Logger
+	client          Client
+	qclient         mtypes.QueryClient
+	teardownManaged orphanTeardown
+	provider        string
+	config          Config
+
+	orphanedSince map[mtypes.LeaseID]time.Time
+	missingSince  map[mtypes.LeaseID]time.Time
+}
+
+func newLeaseReconciler(log log.Logger, client Client, qclient mtypes.QueryClient, teardownManaged orphanTeardown, provider string, config Config) *leaseReconciler {
+	return &leaseReconciler{
+		log:             log.With("cmp", "lease-reconciler"),
+		client:          client,
+		qclient:         qclient,
+		teardownManaged: teardownManaged,
+		provider:        provider,
+		config:          config,
+		orphanedSince:   make(map[mtypes.LeaseID]time.Time),
+		missingSince:    make(map[mtypes.LeaseID]time.Time),
+	}
+}
+
+func (r *leaseReconciler) run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.ReconcilePeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := r.reconcile(ctx, time.Now())
+		metricsutils.IncCounterVecWithLabelValues(reconcilerRunsCounter, "reconcile", err)
+		if err != nil && ctx.Err() == nil {
+			r.log.Error("reconciling leases", "err", err)
+		}
+	}
+}
+
+func (r *leaseReconciler) reconcile(ctx context.Context, now time.Time) error {
+	result, err := ReconcileLeases(ctx, r.client, r.qclient, r.provider)
+	if err != nil {
+		return err
+	}
+
+	reconcilerOrphanedGauge.Set(float64(len(result.Orphaned)))
+	reconcilerMissingGauge.Set(float64(len(result.Missing)))
+
+	teardown := r.config.ReconcileTeardown && r.teardownSafe(result)
+
+	orphanedSince := make(map[mtypes.LeaseID]time.Time, len(result.Orphaned))
+	for _, orphan := range result.Orphaned {
+		since, seen := r.orphanedSince[orphan.LeaseID]
+		if !seen {
+			since = now
+			r.log.Info("found orphaned lease", "lease", orphan.LeaseID, "manifest", orphan.HasManifest)
+		}
+		orphanedSince[orphan.LeaseID] = since
+
+		if !teardown || now.Sub(since) < r.config.ReconcileGracePeriod {
+			continue
+		}
+
+		if err := r.teardown(ctx, orphan); err != nil {
+			r.log.Error("tearing down orphaned lease", "lease", orphan.LeaseID, "err", err)
+			continue
+		}
+
+		// a teardown in progress is not retried before another grace period passed
+		orphanedSince[orphan.LeaseID] = now
+		reconcilerTeardownCounter.Inc()
+	}
+	r.orphanedSince = orphanedSince
+
+	missingSince := make(map[mtypes.LeaseID]time.Time, len(result.Missing))
+	for _, lid := range result.Missing {
+		since, seen := r.missingSince[lid]
+		if !seen {
+			since = now
+		}
+		missingSince[lid] = since
+
+		// leases wait for their manifest for a while after being won
+		if now.Sub(since) >= r.config.ReconcileGracePeriod {
+			r.log.Error("lease active on chain has no deployment", "lease", lid, "since", since)
+		}
+	}
+	r.missingSince = missingSince
+
+	return nil
+}
+
+// teardownSafe guards against tearing down healthy deployments when the chain node queried returns
+// too few active leases, such as a node still syncing or pruned
+func (r *leaseReconciler) teardownSafe(result LeaseReconciliation) bool {
+	if result.Active == 0 && result.Deployed != 0 {
+		r.log.Error("chain reports no active lease while the cluster has some, skipping teardowns", "deployed", result.Deployed)
+		return false
+	}
+
+	// a single orphan is not considered a mass teardown, whatever the number of deployed leases
+	maxPercent := int(r.config.ReconcileMaxOrphanedPercent)
+	if maxPercent != 0 && len(result.Orphaned) > 1 && len(result.Orphaned)*100 > maxPercent*result.Deployed {
+		r.log.Error("too many orphaned leases, skipping teardowns",
+			"orphaned", len(result.Orphaned), "deployed", result.Deployed, "max-percent", maxPercent)
+		return false
+	}
+
+	return true
+}
+
+func (r *leaseReconciler) teardown(ctx context.Context, orphan OrphanedLease) error {
+	r.log.Info("tearing down orphaned lease", "lease", orphan.LeaseID)
+
+	// leases with a manifest usually have a deployment manager, which tears down the lease
+	// as if the closing event was received
+	if orphan.HasManifest {
+		managed, err := r.teardownManaged(ctx, orphan.LeaseID)
+		if err != nil {
+			return err
+		}
+		if managed {
+			return nil
+		}
+	}
+
+	return r.client.TeardownLease(util.ApplyToContext(ctx, r.config.ClusterSettings), orphan.LeaseID)
+}
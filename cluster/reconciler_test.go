@@ -0,0 +1,211 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	sdkquery "github.com/cosmos/cosmos-sdk/types/query"
+
+	clientmocks "github.com/akash-network/node/client/mocks"
+	"github.com/akash-network/node/testutil"
+	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
+
+	"github.com/akash-network/provider/cluster/mocks"
+	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
+)
+
+type reconcilerScaffold struct {
+	client  *mocks.Client
+	qclient *clientmocks.QueryClient
+
+	deployed     mtypes.LeaseID
+	orphaned     mtypes.LeaseID
+	orphanedNS   mtypes.LeaseID
+	missing      mtypes.LeaseID
+	providerAddr string
+}
+
+func newReconcilerScaffold(t *testing.T) *reconcilerScaffold {
+	s := &reconcilerScaffold{
+		client:   &mocks.Client{},
+		qclient:  &clientmocks.QueryClient{},
+		deployed: testutil.LeaseID(t),
+	}
+	s.providerAddr = s.deployed.Provider
+
+	s.orphaned = s.deployed
+	s.orphaned.DSeq++
+	s.orphanedNS = s.deployed
+	s.orphanedNS.DSeq += 2
+	s.missing = s.deployed
+	s.missing.DSeq += 3
+
+	var deployments []ctypes.Deployment
+	for _, lid := range []mtypes.LeaseID{s.deployed, s.orphaned} {
+		deployment := &mocks.Deployment{}
+		deployment.On("LeaseID").Return(lid)
+		deployments = append(deployments, deployment)
+	}
+
+	s.client.On("Deployments", mock.Anything).Return(deployments, nil)
+	s.client.On("LeaseNamespaces", mock.Anything).Return([]mtypes.LeaseID{s.deployed, s.orphaned, s.orphanedNS}, nil)
+
+	// active leases are returned over two pages
+	s.qclient.On("Leases", mock.Anything, mock.MatchedBy(func(req *mtypes.QueryLeasesRequest) bool {
+		return len(req.Pagination.Key) == 0
+	})).Return(&mtypes.QueryLeasesResponse{
+		Leases:     []mtypes.QueryLeaseResponse{{Lease: mtypes.Lease{LeaseID: s.deployed}}},
+		Pagination: &sdkquery.PageResponse{NextKey: []byte("next")},
+	}, nil)
+	s.qclient.On("Leases", mock.Anything, mock.MatchedBy(func(req *mtypes.QueryLeasesRequest) bool {
+		return string(req.Pagination.Key) == "next"
+	})).Return(&mtypes.QueryLeasesResponse{
+		Leases: []mtypes.QueryLeaseResponse{{Lease: mtypes.Lease{LeaseID: s.missing}}},
+	}, nil)
+
+	return s
+}
+
+// newReconcilerConfig tears down orphans whatever their share of the leases, the scaffold orphans two of three
+func newReconcilerConfig() Config {
+	config := NewDefaultConfig()
+	config.ReconcilePeriod = time.Minute
+	config.ReconcileGracePeriod = time.Hour
+	config.ReconcileTeardown = true
+
+	return config
+}
+
+func TestReconcileLeases(t *testing.T) {
+	s := newReconcilerScaffold(t)
+
+	result, err := ReconcileLeases(context.Background(), s.client, s.qclient, s.providerAddr)
+	require.NoError(t, err)
+
+	require.ElementsMatch(t, []OrphanedLease{
+		{LeaseID: s.orphaned, HasManifest: true},
+		{LeaseID: s.orphanedNS, HasManifest: false},
+	}, result.Orphaned)
+	require.Equal(t, []mtypes.LeaseID{s.missing}, result.Missing)
+	require.Equal(t, 3, result.Deployed)
+	require.Equal(t, 2, result.Active)
+
+	s.client.AssertNotCalled(t, "TeardownLease", mock.Anything, mock.Anything)
+}
+
+func TestLeaseReconcilerTearsDownAfterGracePeriod(t *testing.T) {
+	s := newReconcilerScaffold(t)
+	s.client.On("TeardownLease", mock.Anything, s.orphanedNS).Return(nil)
+
+	managed := make([]mtypes.LeaseID, 0)
+	teardownManaged := func(_ context.Context, lid mtypes.LeaseID) (bool, error) {
+		managed = append(managed, lid)
+		return true, nil
+	}
+
+	config := newReconcilerConfig()
+
+	reconciler := newLeaseReconciler(testutil.Logger(t), s.client, s.qclient, teardownManaged, s.providerAddr, config)
+
+	now := time.Now()
+	require.NoError(t, reconciler.reconcile(context.Background(), now))
+	require.NoError(t, reconciler.reconcile(context.Background(), now.Add(time.Minute)))
+	s.client.AssertNotCalled(t, "TeardownLease", mock.Anything, mock.Anything)
+	require.Empty(t, managed)
+
+	require.NoError(t, reconciler.reconcile(context.Background(), now.Add(time.Hour)))
+	s.client.AssertCalled(t, "TeardownLease", mock.Anything, s.orphanedNS)
+	// the deployment manager of the orphaned manifest tears it down
+	require.Equal(t, []mtypes.LeaseID{s.orphaned}, managed)
+
+	// not retried before another grace period
+	require.NoError(t, reconciler.reconcile(context.Background(), now.Add(time.Hour+time.Minute)))
+	s.client.AssertNumberOfCalls(t, "TeardownLease", 1)
+}
+
+func TestLeaseReconcilerTearsDownManifestWithoutManager(t *testing.T) {
+	s := newReconcilerScaffold(t)
+	s.client.On("TeardownLease", mock.Anything, mock.Anything).Return(nil)
+
+	teardownManaged := func(context.Context, mtypes.LeaseID) (bool, error) {
+		return false, nil
+	}
+
+	config := newReconcilerConfig()
+
+	reconciler := newLeaseReconciler(testutil.Logger(t), s.client, s.qclient, teardownManaged, s.providerAddr, config)
+
+	now := time.Now()
+	require.NoError(t, reconciler.reconcile(context.Background(), now))
+	require.NoError(t, reconciler.reconcile(context.Background(), now.Add(time.Hour)))
+
+	// the namespace, workloads and Manifest CRD are removed by the reconciler itself
+	s.client.AssertCalled(t, "TeardownLease", mock.Anything, s.orphaned)
+	s.client.AssertCalled(t, "TeardownLease", mock.Anything, s.orphanedNS)
+	s.client.AssertNumberOfCalls(t, "TeardownLease", 2)
+}
+
+func TestLeaseReconcilerTeardownDisabled(t *testing.T) {
+	s := newReconcilerScaffold(t)
+
+	teardownManaged := func(context.Context, mtypes.LeaseID) (bool, error) {
+		return false, nil
+	}
+
+	config := newReconcilerConfig()
+	config.ReconcileTeardown = false
+
+	reconciler := newLeaseReconciler(testutil.Logger(t), s.client, s.qclient, teardownManaged, s.providerAddr, config)
+
+	now := time.Now()
+	require.NoError(t, reconciler.reconcile(context.Background(), now))
+	require.NoError(t, reconciler.reconcile(context.Background(), now.Add(2*time.Hour)))
+	s.client.AssertNotCalled(t, "TeardownLease", mock.Anything, mock.Anything)
+}
+
+func TestLeaseReconcilerSkipsMassTeardown(t *testing.T) {
+	s := newReconcilerScaffold(t)
+
+	teardownManaged := func(context.Context, mtypes.LeaseID) (bool, error) {
+		return false, nil
+	}
+
+	// two of the three deployed leases are orphaned
+	config := newReconcilerConfig()
+	config.ReconcileMaxOrphanedPercent = 50
+
+	reconciler := newLeaseReconciler(testutil.Logger(t), s.client, s.qclient, teardownManaged, s.providerAddr, config)
+
+	now := time.Now()
+	require.NoError(t, reconciler.reconcile(context.Background(), now))
+	require.NoError(t, reconciler.reconcile(context.Background(), now.Add(2*time.Hour)))
+	s.client.AssertNotCalled(t, "TeardownLease", mock.Anything, mock.Anything)
+
+	s.client.On("TeardownLease", mock.Anything, mock.Anything).Return(nil)
+	reconciler.config.ReconcileMaxOrphanedPercent = 70
+	require.NoError(t, reconciler.reconcile(context.Background(), now.Add(3*time.Hour)))
+	s.client.AssertNumberOfCalls(t, "TeardownLease", 2)
+}
+
+func TestLeaseReconcilerSkipsTeardownWithoutActiveLeases(t *testing.T) {
+	s := newReconcilerScaffold(t)
+
+	// a chain node still syncing reports no lease at all
+	qclient := &clientmocks.QueryClient{}
+	qclient.On("Leases", mock.Anything, mock.Anything).Return(&mtypes.QueryLeasesResponse{}, nil)
+
+	teardownManaged := func(context.Context, mtypes.LeaseID) (bool, error) {
+		return false, nil
+	}
+
+	reconciler := newLeaseReconciler(testutil.Logger(t), s.client, qclient, teardownManaged, s.providerAddr, newReconcilerConfig())
+
+	now := time.Now()
+	require.NoError(t, reconciler.reconcile(context.Background(), now))
+	require.NoError(t, reconciler.reconcile(context.Background(), now.Add(2*time.Hour)))
+	s.client.AssertNotCalled(t, "TeardownLease", mock.Anything, mock.Anything)
+}
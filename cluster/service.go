@@ -114,6 +114,7 @@ func NewService(ctx context.Context, session session.Session, bus pubsub.Bus, cl
 		managers:                       make(map[mtypes.LeaseID]*deploymentManager),
 		managerch:                      make(chan *deploymentManager),
 		checkDeploymentExistsRequestCh: make(chan checkDeploymentExistsRequest),
+		teardownOrphanRequestCh:        make(chan teardownOrphanRequest),
 
 		log:    log,
 		lc:     lc,
@@ -124,6 +125,11 @@ func NewService(ctx context.Context, session session.Session, bus pubsub.Bus, cl
 	go s.lc.WatchContext(ctx)
 	go s.run(ctx, deployments)
 
+	if cfg.ReconcilePeriod > 0 {
+		reconciler := newLeaseReconciler(log, client, session.Client().Query(), s.teardownOrphan, session.Provider().Owner, cfg)
+		go reconciler.run(ctx)
+	}
+
 	return s, nil
 }
 
@@ -137,6 +143,7 @@ type service struct {
 	hostnames *hostnameService
 
 	checkDeploymentExistsRequestCh chan checkDeploymentExistsRequest
+	teardownOrphanRequestCh        chan teardownOrphanRequest
 	statusch                       chan chan<- *ctypes.Status
 	managers                       map[mtypes.LeaseID]*deploymentManager
 
@@ -158,6 +165,38 @@ type checkDeploymentExistsRequest struct {
 	responseCh chan<- mtypes.LeaseID
 }
 
+type teardownOrphanRequest struct {
+	leaseID mtypes.LeaseID
+
+	responseCh chan<- bool
+}
+
+// teardownOrphan tears down a lease closed on chain as if its closing event was received.
+// It reports whether a deployment manager is tearing the lease down, leases without one are left
+// to the caller.
+func (s *service) teardownOrphan(ctx context.Context, leaseID mtypes.LeaseID) (bool, error) {
+	response := make(chan bool, 1)
+	req := teardownOrphanRequest{
+		leaseID:    leaseID,
+		responseCh: response,
+	}
+
+	select {
+	case s.teardownOrphanRequestCh <- req:
+	case <-s.lc.ShuttingDown():
+		return false, ErrNotRunning
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+
+	select {
+	case managed := <-response:
+		return managed, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
 var errNoManifestGroup = errors.New("no manifest group could be found")
 
 func (s *service) FindActiveLease(ctx context.Context, owner sdktypes.Address, dseq uint64, gseq uint32) (bool, mtypes.LeaseID, crd.ManifestGroup, error) {
@@ -302,6 +341,12 @@ loop:
 
 				key := ev.LeaseID
 				if manager := s.managers[key]; manager != nil {
+					// volumes the update grows are expanded in place, the storage must be available
+					if err := s.inventory.resize(ev.LeaseID.OrderID(), mgroup); err != nil {
+						s.log.Error("resizing reservation of updated deployment", "err", err, "lease", ev.LeaseID, "group-name", mgroup.Name)
+						break
+					}
+
 					if err := manager.update(mgroup); err != nil {
 						s.log.Error("updating deployment", "err", err, "lease", ev.LeaseID, "group-name", mgroup.Name)
 					}
@@ -329,6 +374,11 @@ loop:
 			delete(s.managers, dm.lease)
 		case req := <-s.checkDeploymentExistsRequestCh:
 			s.doCheckDeploymentExists(req)
+		case req := <-s.teardownOrphanRequestCh:
+			_, managed := s.managers[req.leaseID]
+			_ = s.bus.Publish(event.LeaseRemoveFundsMonitor{LeaseID: req.leaseID})
+			s.teardownLease(req.leaseID)
+			req.responseCh <- managed
 		}
 		s.updateDeploymentManagerGauge()
 	}
@@ -16,6 +16,19 @@ type ConnectHostnameToDeploymentDirective struct {
 	MaxBodySize uint32
 	NextTries   uint32
 	NextCases   []string
+	TLS         HostnameTLS
+}
+
+// HostnameTLS selects how the ingress of a hostname terminates TLS. TLS is disabled when both are empty
+type HostnameTLS struct {
+	// ClusterIssuer is the cert-manager ClusterIssuer which requests a certificate for the hostname
+	ClusterIssuer string
+	// WildcardSecret is the namespace/name of a provider certificate secret covering the hostname
+	WildcardSecret string
+}
+
+func (t HostnameTLS) Enabled() bool {
+	return t.ClusterIssuer != "" || t.WildcardSecret != ""
 }
 
 type ClusterIPPassthroughDirective struct {
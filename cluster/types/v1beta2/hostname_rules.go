@@ -0,0 +1,33 @@
+package v1beta2
+
+const (
+	// HostnameRuleExact matches the pattern only
+	HostnameRuleExact = "exact"
+	// HostnameRuleDomain matches the pattern and all its subdomains
+	HostnameRuleDomain = "domain"
+	// HostnameRuleGlob matches the pattern with * standing for any characters, dots included
+	HostnameRuleGlob = "glob"
+	// HostnameRuleRegex matches the whole hostname against the pattern
+	HostnameRuleRegex = "regex"
+
+	HostnameRuleBlock = "block"
+	HostnameRuleAllow = "allow"
+)
+
+// HostnameRule blocks hostnames from being reserved, or makes an exception to the block rules.
+// Hostnames are blocked when a block rule matches and no allow rule does.
+type HostnameRule struct {
+	Type    string `json:"type" yaml:"type"`
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Action  string `json:"action" yaml:"action"`
+	// Owner restricts an allow rule to the hostnames of a single tenant
+	Owner string `json:"owner,omitempty" yaml:"owner,omitempty"`
+}
+
+// HostnameRuleViolation is a hostname of a running lease blocked by rules set after it was reserved
+type HostnameRuleViolation struct {
+	Hostname string `json:"hostname"`
+	Owner    string `json:"owner"`
+	DSeq     uint64 `json:"dseq"`
+	GSeq     uint32 `json:"gseq"`
+}
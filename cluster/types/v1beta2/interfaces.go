@@ -13,4 +13,7 @@ type HostnameServiceClient interface {
 	ReleaseHostnames(leaseID mtypes.LeaseID) error
 	CanReserveHostnames(hostnames []string, ownerAddr sdktypes.Address) error
 	PrepareHostnamesForTransfer(ctx context.Context, hostnames []string, leaseID mtypes.LeaseID) error
+	VerificationToken(ownerAddr sdktypes.Address) (string, error)
+	HostnameRules() []HostnameRule
+	SetHostnameRules(ctx context.Context, rules []HostnameRule) ([]HostnameRuleViolation, error)
 }
@@ -27,4 +27,6 @@ type IPPassthrough interface {
 type IPLeaseState interface {
 	IPPassthrough
 	GetIP() string
+	// GetIPv6 is the IPv6 address of dual-stack endpoints, empty otherwise
+	GetIPv6() string
 }
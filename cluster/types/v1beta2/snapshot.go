@@ -0,0 +1,30 @@
+package v1beta2
+
+import (
+	"time"
+)
+
+const (
+	VolumeSnapshotTriggerScheduled = "scheduled"
+	VolumeSnapshotTriggerUpdate    = "update"
+	VolumeSnapshotTriggerTeardown  = "teardown"
+
+	VolumeSnapshotRestoring     = "restoring"
+	VolumeSnapshotRestored      = "restored"
+	VolumeSnapshotRestoreFailed = "failed"
+)
+
+// VolumeSnapshot is a point in time copy of a persistent volume of a lease
+type VolumeSnapshot struct {
+	Name        string    `json:"name"`
+	Service     string    `json:"service"`
+	Volume      string    `json:"volume"`
+	Replica     int       `json:"replica"`
+	Trigger     string    `json:"trigger"`
+	ReadyToUse  bool      `json:"ready_to_use"`
+	RestoreSize string    `json:"restore_size,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	// RestoreState is the progress of the last restore of the snapshot, empty when it was never restored
+	RestoreState   string `json:"restore_state,omitempty"`
+	RestoreMessage string `json:"restore_message,omitempty"`
+}
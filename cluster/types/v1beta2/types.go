@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"io"
+	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/pkg/errors"
@@ -20,6 +21,8 @@ import (
 var (
 	// ErrInsufficientCapacity is the new error when capacity is insufficient
 	ErrInsufficientCapacity = errors.New("insufficient capacity")
+	// ErrOwnerQuotaExceeded is returned when a reservation would take an owner over its quota
+	ErrOwnerQuotaExceeded = errors.New("owner quota exceeded")
 )
 
 // Status stores current leases and inventory statuses
@@ -87,6 +90,7 @@ func (inv *InventoryMetricTotal) AddResources(res types.Resources) {
 
 type InventoryNode struct {
 	Name        string              `json:"name"`
+	Arch        string              `json:"arch,omitempty"`
 	Allocatable InventoryNodeMetric `json:"allocatable"`
 	Available   InventoryNodeMetric `json:"available"`
 }
@@ -109,16 +113,83 @@ type ServiceStatus struct {
 	UpdatedReplicas    int32 `json:"updated_replicas"`
 	ReadyReplicas      int32 `json:"ready_replicas"`
 	AvailableReplicas  int32 `json:"available_replicas"`
+
+	Failures     []ServiceFailure      `json:"failures,omitempty"`
+	Certificates []HostnameCertificate `json:"certificates,omitempty"`
+	Hostnames    []HostnameState       `json:"hostnames,omitempty"`
+}
+
+// HostnameState describes whether the ingress of one of the URIs of the service is applied
+type HostnameState struct {
+	Hostname   string     `json:"hostname"`
+	State      string     `json:"state"`
+	Message    string     `json:"message,omitempty"`
+	LastUpdate *time.Time `json:"last_update,omitempty"`
+}
+
+// HostnameCertificate describes the TLS certificate served for one of the URIs of the service
+type HostnameCertificate struct {
+	Hostname string `json:"hostname"`
+	State    string `json:"state"`
+	Message  string `json:"message,omitempty"`
+}
+
+// ServiceFailure describes why a pod of the service is not running
+type ServiceFailure struct {
+	Pod     string `json:"pod"`
+	Reason  string `json:"reason"`
+	Message string `json:"message,omitempty"`
 }
 
 type ForwardedPortStatus struct {
 	Host         string                   `json:"host,omitempty"`
+	IPv6         string                   `json:"ipv6,omitempty"` // IPv6 address of dual-stack leased IP endpoints
 	Port         uint16                   `json:"port"`
 	ExternalPort uint16                   `json:"externalPort"`
 	Proto        manifest.ServiceProtocol `json:"proto"`
 	Name         string                   `json:"name"`
 }
 
+// NodePortAllocation is a node port of the cluster held by a service of a lease
+type NodePortAllocation struct {
+	LeaseID  mtypes.LeaseID           `json:"lease_id"`
+	Service  string                   `json:"service"`
+	Port     uint16                   `json:"port"`
+	NodePort uint16                   `json:"node_port"`
+	Proto    manifest.ServiceProtocol `json:"proto"`
+}
+
+// DeclaredIPState describes whether the LoadBalancer service of a leased IP of the lease is applied
+type DeclaredIPState struct {
+	ServiceName  string                   `json:"service_name"`
+	Port         uint32                   `json:"port"`
+	ExternalPort uint32                   `json:"external_port"`
+	Proto        manifest.ServiceProtocol `json:"proto"`
+	State        string                   `json:"state"`
+	Message      string                   `json:"message,omitempty"`
+	LastUpdate   *time.Time               `json:"last_update,omitempty"`
+}
+
+// HostnameMetrics are the requests the ingress controller served for one of the hostnames of a lease.
+// The rate and the latencies cover the window between the last two scrapes of the controller metrics,
+// or every request since the controller started when it was scraped only once, in which case the
+// window and the rate are zero.
+type HostnameMetrics struct {
+	Hostname      string             `json:"hostname"`
+	Requests      uint64             `json:"requests"`
+	WindowSeconds float64            `json:"window_seconds"`
+	RequestRate   float64            `json:"request_rate"`
+	StatusCodes   map[string]uint64  `json:"status_codes"`
+	Latency       LatencyPercentiles `json:"latency"`
+}
+
+// LatencyPercentiles are request durations in seconds
+type LatencyPercentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+}
+
 // LeaseStatus includes list of services with their status
 type LeaseStatus struct {
 	Services       map[string]*ServiceStatus        `json:"services"`
@@ -0,0 +1,42 @@
+package util
+
+import atypes "github.com/akash-network/node/types/v1beta2"
+
+// CPUAttributeArch is the cpu attribute key used by the SDL to pin a service to a CPU architecture
+const CPUAttributeArch = "arch"
+
+// GetCPUArch returns the CPU architecture requested by the given resource units, if any
+func GetCPUArch(r atypes.ResourceUnits) (string, bool) {
+	if r.CPU == nil {
+		return "", false
+	}
+
+	arch, set := r.CPU.Attributes.Find(CPUAttributeArch).AsString()
+	if !set || arch == "" {
+		return "", false
+	}
+
+	return arch, true
+}
+
+// GetCPUArchesOfResourceGroup returns the distinct CPU architectures requested by the given resource group
+func GetCPUArchesOfResourceGroup(resources atypes.ResourceGroup) []string {
+	seen := make(map[string]struct{})
+	result := make([]string, 0)
+
+	for _, resource := range resources.GetResources() {
+		arch, set := GetCPUArch(resource.Resources)
+		if !set {
+			continue
+		}
+
+		if _, exists := seen[arch]; exists {
+			continue
+		}
+
+		seen[arch] = struct{}{}
+		result = append(result, arch)
+	}
+
+	return result
+}
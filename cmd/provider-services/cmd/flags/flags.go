@@ -10,4 +10,8 @@ const (
 	FlagRetryDelay         = "retry-delay"
 
 	FlagKubeConfig = "kubeconfig"
+
+	FlagOwnerLeasedIPLimit = "owner-leased-ip-limit"
+	FlagOwnerEndpointLimit = "owner-endpoint-limit"
+	FlagOwnerQuotas        = "owner-quotas"
 )
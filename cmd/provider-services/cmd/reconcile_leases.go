@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+
+	sdkclient "github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	akashclient "github.com/akash-network/node/client"
+	cmdcommon "github.com/akash-network/node/cmd/common"
+
+	"github.com/akash-network/provider/cluster"
+	"github.com/akash-network/provider/cluster/kube"
+	providerflags "github.com/akash-network/provider/cmd/provider-services/cmd/flags"
+	cmdutil "github.com/akash-network/provider/cmd/provider-services/cmd/util"
+)
+
+func reconcileLeasesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "reconcile-leases [provider address]",
+		Short:        "compare leases active on chain with the cluster, without changing anything",
+		Long:         "lists leases deployed in the cluster which are no longer active on chain and would be torn down by the provider, and leases active on chain which have no deployment",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addr, err := sdk.AccAddressFromBech32(args[0])
+			if err != nil {
+				return err
+			}
+
+			return doReconcileLeases(cmd, addr)
+		},
+	}
+
+	cmd.Flags().String(providerflags.FlagK8sManifestNS, "lease", "Cluster manifest namespace")
+	if err := viper.BindPFlag(providerflags.FlagK8sManifestNS, cmd.Flags().Lookup(providerflags.FlagK8sManifestNS)); err != nil {
+		return nil
+	}
+
+	if err := providerflags.AddKubeConfigPathFlag(cmd); err != nil {
+		return nil
+	}
+
+	return cmd
+}
+
+func doReconcileLeases(cmd *cobra.Command, addr sdk.Address) error {
+	cctx, err := sdkclient.GetClientQueryContext(cmd)
+	if err != nil {
+		return err
+	}
+
+	ns := viper.GetString(providerflags.FlagK8sManifestNS)
+	if ns == "" {
+		return fmt.Errorf("%w: --%s required", errInvalidConfig, providerflags.FlagK8sManifestNS)
+	}
+
+	logger := cmdutil.OpenLogger().With("cmp", "reconcile-leases")
+
+	cclient, err := kube.NewClient(cmd.Context(), logger, ns, viper.GetString(providerflags.FlagKubeConfig))
+	if err != nil {
+		return err
+	}
+
+	result, err := cluster.ReconcileLeases(cmd.Context(), cclient, akashclient.NewQueryClientFromCtx(cctx), addr.String())
+	if err != nil {
+		return markRPCServerError(err)
+	}
+
+	return cmdcommon.PrintJSON(cctx, result)
+}
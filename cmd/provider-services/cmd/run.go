@@ -16,6 +16,8 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"golang.org/x/sync/errgroup"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/tendermint/tendermint/libs/log"
 
@@ -40,9 +42,12 @@ import (
 	"github.com/akash-network/provider/bidengine"
 	"github.com/akash-network/provider/client/broadcaster"
 	"github.com/akash-network/provider/cluster"
+	"github.com/akash-network/provider/cluster/bandwidth"
+	"github.com/akash-network/provider/cluster/imagepolicy"
 	"github.com/akash-network/provider/cluster/kube"
 	"github.com/akash-network/provider/cluster/kube/builder"
 	"github.com/akash-network/provider/cluster/kube/clientcommon"
+	"github.com/akash-network/provider/cluster/netpolicy"
 	"github.com/akash-network/provider/cluster/operatorclients"
 	providerflags "github.com/akash-network/provider/cmd/provider-services/cmd/flags"
 	cmdutil "github.com/akash-network/provider/cmd/provider-services/cmd/util"
@@ -80,6 +85,7 @@ const (
 	FlagOvercommitPercentCPU             = "overcommit-pct-cpu"
 	FlagOvercommitPercentStorage         = "overcommit-pct-storage"
 	FlagDeploymentBlockedHostnames       = "deployment-blocked-hostnames"
+	FlagDeploymentHostnameRules          = "deployment-hostname-rules"
 	FlagAuthPem                          = "auth-pem"
 	FlagDeploymentRuntimeClass           = "deployment-runtime-class"
 	FlagBidTimeout                       = "bid-timeout"
@@ -93,12 +99,48 @@ const (
 	FlagRPCQueryTimeout                  = "rpc-query-timeout"
 	FlagBidPriceIPScale                  = "bid-price-ip-scale"
 	FlagEnableIPOperator                 = "ip-operator"
+	FlagEnableIngressMetrics             = "ingress-metrics"
+	FlagIngressBackend                   = "ingress-backend"
 	FlagTxBroadcastTimeout               = "tx-broadcast-timeout"
+	FlagDeploymentImagePolicy            = "deployment-image-policy"
+	FlagDeploymentNetworkPolicy          = "deployment-network-policy"
+	FlagDeploymentBandwidthPolicy        = "deployment-bandwidth-policy"
+	FlagNetworkUsagePollPeriod           = "network-usage-poll-period"
+	FlagDeploymentReadinessProbesEnabled = "deployment-readiness-probes-enabled"
+	FlagDeploymentReadinessProbeImage    = "deployment-readiness-probe-image"
+	FlagDeploymentResourceQuotasEnabled  = "deployment-resource-quotas-enabled"
+	FlagDeploymentLeasedIPDualStack      = "deployment-leased-ip-dual-stack"
+	FlagDeploymentPodSecurityLevel       = "deployment-pod-security-level"
+	FlagDeploymentSecurityProfile        = "deployment-security-profile"
+	FlagMonitorMaxRetries                = "monitor-max-retries"
+	FlagMonitorRetryPeriod               = "monitor-retry-period"
+	FlagMonitorRetryPeriodJitter         = "monitor-retry-period-jitter"
+	FlagMonitorHealthcheckPeriod         = "monitor-healthcheck-period"
+	FlagMonitorHealthcheckPeriodJitter   = "monitor-healthcheck-period-jitter"
+	FlagMonitorFailurePolicy             = "monitor-failure-policy"
+	FlagMonitorOverrides                 = "monitor-overrides"
+	FlagDeploymentRollbackTimeout        = "deployment-rollback-timeout"
+	FlagDeploymentVolumeRetentionPeriod  = "deployment-volume-retention-period"
+	FlagDeploymentVolumeSnapshotClass    = "deployment-volume-snapshot-class"
+	FlagDeploymentVolumeSnapshotInterval = "deployment-volume-snapshot-interval"
+	FlagDeploymentVolumeSnapshotsKept    = "deployment-volume-snapshots-kept"
+	FlagLeaseReconcilePeriod             = "lease-reconcile-period"
+	FlagLeaseReconcileGracePeriod        = "lease-reconcile-grace-period"
+	FlagLeaseReconcileTeardown           = "lease-reconcile-teardown"
+	FlagLeaseReconcileMaxOrphanedPercent = "lease-reconcile-max-orphaned-percent"
+	FlagHostnameVerification             = "deployment-hostname-verification"
+	FlagHostnameVerificationSecret       = "deployment-hostname-verification-secret" // nolint: gosec
+	FlagHostnameVerificationResolver     = "deployment-hostname-verification-resolver"
+	FlagHostnameVerificationCacheTTL     = "deployment-hostname-verification-cache-ttl"
 )
 
+// retainedVolumeCollectPeriod is how often expired retained volumes are looked for
+const retainedVolumeCollectPeriod = 10 * time.Minute
+
 const (
 	serviceIPOperator       = "ip-operator"
 	serviceHostnameOperator = "hostname-operator"
+	serviceIngressMetrics   = "ingress-metrics"
 )
 
 var (
@@ -251,6 +293,36 @@ func RunCmd() *cobra.Command {
 		return nil
 	}
 
+	cmd.Flags().Bool(FlagDeploymentReadinessProbesEnabled, false, "Generate a TCP readiness probe for every exposed TCP port of each service")
+	if err := viper.BindPFlag(FlagDeploymentReadinessProbesEnabled, cmd.Flags().Lookup(FlagDeploymentReadinessProbesEnabled)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().String(FlagDeploymentReadinessProbeImage, builder.DefaultReadinessProbeImage, "Image of the containers probing the exposed ports of a service beyond its first one")
+	if err := viper.BindPFlag(FlagDeploymentReadinessProbeImage, cmd.Flags().Lookup(FlagDeploymentReadinessProbeImage)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Bool(FlagDeploymentResourceQuotasEnabled, false, "Cap lease namespaces with a resource quota and limit range derived from the manifest")
+	if err := viper.BindPFlag(FlagDeploymentResourceQuotasEnabled, cmd.Flags().Lookup(FlagDeploymentResourceQuotasEnabled)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Bool(FlagDeploymentLeasedIPDualStack, false, "The IP operator has an IPv6 pool and serves leased IPs dual-stack, resource quotas leave room for their IPv6 services")
+	if err := viper.BindPFlag(FlagDeploymentLeasedIPDualStack, cmd.Flags().Lookup(FlagDeploymentLeasedIPDualStack)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().String(FlagDeploymentPodSecurityLevel, "", "Pod Security Admission level enforced on lease namespaces: baseline, restricted, or empty for none")
+	if err := viper.BindPFlag(FlagDeploymentPodSecurityLevel, cmd.Flags().Lookup(FlagDeploymentPodSecurityLevel)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().String(FlagDeploymentSecurityProfile, string(builder.SecurityProfileDefault), "Security context of lease containers: default, hardened or hardened-read-only")
+	if err := viper.BindPFlag(FlagDeploymentSecurityProfile, cmd.Flags().Lookup(FlagDeploymentSecurityProfile)); err != nil {
+		return nil
+	}
+
 	cmd.Flags().String(FlagDockerImagePullSecretsName, "", "Name of the local image pull secret configured with kubectl")
 	if err := viper.BindPFlag(FlagDockerImagePullSecretsName, cmd.Flags().Lookup(FlagDockerImagePullSecretsName)); err != nil {
 		return nil
@@ -276,6 +348,11 @@ func RunCmd() *cobra.Command {
 		return nil
 	}
 
+	cmd.Flags().String(FlagDeploymentHostnameRules, "", "YAML file storing the hostname block and allow rules managed through the gateway")
+	if err := viper.BindPFlag(FlagDeploymentHostnameRules, cmd.Flags().Lookup(FlagDeploymentHostnameRules)); err != nil {
+		return nil
+	}
+
 	cmd.Flags().String(FlagAuthPem, "", "")
 
 	if err := providerflags.AddKubeConfigPathFlag(cmd); err != nil {
@@ -337,11 +414,159 @@ func RunCmd() *cobra.Command {
 		return nil
 	}
 
+	cmd.Flags().Bool(FlagEnableIngressMetrics, false, "serve per hostname request metrics of leases, scraped from the metrics of ingress-nginx")
+	if err := viper.BindPFlag(FlagEnableIngressMetrics, cmd.Flags().Lookup(FlagEnableIngressMetrics)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().String(FlagIngressBackend, kube.IngressBackendNginx, fmt.Sprintf("ingress controller the hostname operator routes hostnames with, --%s requires %s",
+		FlagEnableIngressMetrics, kube.IngressBackendNginx))
+	if err := viper.BindPFlag(FlagIngressBackend, cmd.Flags().Lookup(FlagIngressBackend)); err != nil {
+		return nil
+	}
+
 	cmd.Flags().Duration(FlagTxBroadcastTimeout, 30*time.Second, "tx broadcast timeout. defaults to 30s")
 	if err := viper.BindPFlag(FlagTxBroadcastTimeout, cmd.Flags().Lookup(FlagTxBroadcastTimeout)); err != nil {
 		return nil
 	}
 
+	cmd.Flags().String(FlagDeploymentImagePolicy, "", "path to the YAML file with the registry, repository and tag policy applied to deployment images")
+	if err := viper.BindPFlag(FlagDeploymentImagePolicy, cmd.Flags().Lookup(FlagDeploymentImagePolicy)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().String(FlagDeploymentNetworkPolicy, "", "path to the YAML file with the ingress controller and egress rules of lease network policies")
+	if err := viper.BindPFlag(FlagDeploymentNetworkPolicy, cmd.Flags().Lookup(FlagDeploymentNetworkPolicy)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().String(FlagDeploymentBandwidthPolicy, "", "path to the YAML file with the ingress and egress bandwidth limits of lease pods, requires the bandwidth CNI plugin")
+	if err := viper.BindPFlag(FlagDeploymentBandwidthPolicy, cmd.Flags().Lookup(FlagDeploymentBandwidthPolicy)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Duration(FlagNetworkUsagePollPeriod, time.Minute, "The period to collect the network usage of leases from kubelets, 0 to disable")
+	if err := viper.BindPFlag(FlagNetworkUsagePollPeriod, cmd.Flags().Lookup(FlagNetworkUsagePollPeriod)); err != nil {
+		return nil
+	}
+
+	monitorCfg := cluster.NewDefaultMonitorConfig()
+
+	cmd.Flags().Uint(FlagMonitorMaxRetries, monitorCfg.MaxRetries, "number of failed health checks before the monitor failure policy is applied to a deployment")
+	if err := viper.BindPFlag(FlagMonitorMaxRetries, cmd.Flags().Lookup(FlagMonitorMaxRetries)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Duration(FlagMonitorRetryPeriod, monitorCfg.RetryPeriod, "period between health checks of an unhealthy deployment")
+	if err := viper.BindPFlag(FlagMonitorRetryPeriod, cmd.Flags().Lookup(FlagMonitorRetryPeriod)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Duration(FlagMonitorRetryPeriodJitter, monitorCfg.RetryPeriodJitter, "maximum random delay added to the monitor retry period")
+	if err := viper.BindPFlag(FlagMonitorRetryPeriodJitter, cmd.Flags().Lookup(FlagMonitorRetryPeriodJitter)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Duration(FlagMonitorHealthcheckPeriod, monitorCfg.HealthcheckPeriod, "period between health checks of a healthy deployment")
+	if err := viper.BindPFlag(FlagMonitorHealthcheckPeriod, cmd.Flags().Lookup(FlagMonitorHealthcheckPeriod)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Duration(FlagMonitorHealthcheckPeriodJitter, monitorCfg.HealthcheckPeriodJitter, "maximum random delay added to the monitor healthcheck period")
+	if err := viper.BindPFlag(FlagMonitorHealthcheckPeriodJitter, cmd.Flags().Lookup(FlagMonitorHealthcheckPeriodJitter)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().String(FlagMonitorFailurePolicy, string(monitorCfg.FailurePolicy), "action taken once a deployment failed all health checks: close, alert or restart")
+	if err := viper.BindPFlag(FlagMonitorFailurePolicy, cmd.Flags().Lookup(FlagMonitorFailurePolicy)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().String(FlagMonitorOverrides, "", "path to the YAML file with monitor settings overridden per lease owner or lease ID prefix")
+	if err := viper.BindPFlag(FlagMonitorOverrides, cmd.Flags().Lookup(FlagMonitorOverrides)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Uint(providerflags.FlagOwnerLeasedIPLimit, 0, "maximum number of leased IPs the leases of one owner may hold. 0 is unlimited")
+	if err := viper.BindPFlag(providerflags.FlagOwnerLeasedIPLimit, cmd.Flags().Lookup(providerflags.FlagOwnerLeasedIPLimit)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Uint(providerflags.FlagOwnerEndpointLimit, 0, "maximum number of NodePort endpoints the leases of one owner may hold. 0 is unlimited")
+	if err := viper.BindPFlag(providerflags.FlagOwnerEndpointLimit, cmd.Flags().Lookup(providerflags.FlagOwnerEndpointLimit)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().String(providerflags.FlagOwnerQuotas, "", "path to the YAML file with the leased IP and endpoint limits overridden per owner address")
+	if err := viper.BindPFlag(providerflags.FlagOwnerQuotas, cmd.Flags().Lookup(providerflags.FlagOwnerQuotas)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Duration(FlagDeploymentRollbackTimeout, 0, "time an updated manifest may stay unhealthy before the previous one is deployed again. 0 disables rollbacks")
+	if err := viper.BindPFlag(FlagDeploymentRollbackTimeout, cmd.Flags().Lookup(FlagDeploymentRollbackTimeout)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Duration(FlagDeploymentVolumeRetentionPeriod, 0, "keep the persistent volumes and teardown volume snapshots of closed leases for this period so their owner may reuse them, 0 deletes them with the lease")
+	if err := viper.BindPFlag(FlagDeploymentVolumeRetentionPeriod, cmd.Flags().Lookup(FlagDeploymentVolumeRetentionPeriod)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().String(FlagDeploymentVolumeSnapshotClass, "", "VolumeSnapshotClass used to snapshot persistent volumes before manifest updates and lease teardown, empty disables snapshots. Teardown snapshots are kept for --deployment-volume-retention-period, or forever when the deletionPolicy of the class is Retain")
+	if err := viper.BindPFlag(FlagDeploymentVolumeSnapshotClass, cmd.Flags().Lookup(FlagDeploymentVolumeSnapshotClass)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Duration(FlagDeploymentVolumeSnapshotInterval, 0, "additionally snapshot persistent volumes of every lease at this interval, 0 to disable")
+	if err := viper.BindPFlag(FlagDeploymentVolumeSnapshotInterval, cmd.Flags().Lookup(FlagDeploymentVolumeSnapshotInterval)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Uint(FlagDeploymentVolumeSnapshotsKept, 5, "number of snapshots kept per persistent volume, 0 keeps all of them")
+	if err := viper.BindPFlag(FlagDeploymentVolumeSnapshotsKept, cmd.Flags().Lookup(FlagDeploymentVolumeSnapshotsKept)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Duration(FlagLeaseReconcilePeriod, 10*time.Minute, "how often leases active on chain are compared with the cluster to report orphaned deployments, 0 to disable")
+	if err := viper.BindPFlag(FlagLeaseReconcilePeriod, cmd.Flags().Lookup(FlagLeaseReconcilePeriod)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Duration(FlagLeaseReconcileGracePeriod, time.Hour, "how long a deployment may be orphaned before it is torn down")
+	if err := viper.BindPFlag(FlagLeaseReconcileGracePeriod, cmd.Flags().Lookup(FlagLeaseReconcileGracePeriod)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Bool(FlagLeaseReconcileTeardown, false, "tear down deployments orphaned for longer than the grace period instead of only reporting them")
+	if err := viper.BindPFlag(FlagLeaseReconcileTeardown, cmd.Flags().Lookup(FlagLeaseReconcileTeardown)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Uint(FlagLeaseReconcileMaxOrphanedPercent, 10, "skip teardowns when more than this percentage of the deployed leases is orphaned at once, 0 to disable the check")
+	if err := viper.BindPFlag(FlagLeaseReconcileMaxOrphanedPercent, cmd.Flags().Lookup(FlagLeaseReconcileMaxOrphanedPercent)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Bool(FlagHostnameVerification, false, "require DNS records proving the ownership of custom hostnames before they are reserved")
+	if err := viper.BindPFlag(FlagHostnameVerification, cmd.Flags().Lookup(FlagHostnameVerification)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().String(FlagHostnameVerificationSecret, "", "key the hostname verification tokens of tenants are derived from")
+	if err := viper.BindPFlag(FlagHostnameVerificationSecret, cmd.Flags().Lookup(FlagHostnameVerificationSecret)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().String(FlagHostnameVerificationResolver, "", "host:port of the DNS server hostname verification queries, the system resolver when empty")
+	if err := viper.BindPFlag(FlagHostnameVerificationResolver, cmd.Flags().Lookup(FlagHostnameVerificationResolver)); err != nil {
+		return nil
+	}
+
+	cmd.Flags().Duration(FlagHostnameVerificationCacheTTL, time.Hour, "how long a verified hostname is trusted before its DNS records are checked again")
+	if err := viper.BindPFlag(FlagHostnameVerificationCacheTTL, cmd.Flags().Lookup(FlagHostnameVerificationCacheTTL)); err != nil {
+		return nil
+	}
+
 	if err := providerflags.AddServiceEndpointFlag(cmd, serviceHostnameOperator); err != nil {
 		return nil
 	}
@@ -350,6 +575,10 @@ func RunCmd() *cobra.Command {
 		return nil
 	}
 
+	if err := providerflags.AddServiceEndpointFlag(cmd, serviceIngressMetrics); err != nil {
+		return nil
+	}
+
 	return cmd
 }
 
@@ -450,6 +679,12 @@ func doRunCmd(ctx context.Context, cmd *cobra.Command, _ []string) error {
 	deploymentIngressStaticHosts := viper.GetBool(FlagDeploymentIngressStaticHosts)
 	deploymentIngressDomain := viper.GetString(FlagDeploymentIngressDomain)
 	deploymentNetworkPoliciesEnabled := viper.GetBool(FlagDeploymentNetworkPoliciesEnabled)
+	deploymentReadinessProbesEnabled := viper.GetBool(FlagDeploymentReadinessProbesEnabled)
+	deploymentReadinessProbeImage := viper.GetString(FlagDeploymentReadinessProbeImage)
+	deploymentResourceQuotasEnabled := viper.GetBool(FlagDeploymentResourceQuotasEnabled)
+	deploymentLeasedIPDualStack := viper.GetBool(FlagDeploymentLeasedIPDualStack)
+	deploymentPodSecurityLevel := viper.GetString(FlagDeploymentPodSecurityLevel)
+	deploymentSecurityProfile := viper.GetString(FlagDeploymentSecurityProfile)
 	dockerImagePullSecretsName := viper.GetString(FlagDockerImagePullSecretsName)
 	strategy := viper.GetString(FlagBidPricingStrategy)
 	deploymentIngressExposeLBHosts := viper.GetBool(FlagDeploymentIngressExposeLBHosts)
@@ -467,13 +702,86 @@ func doRunCmd(ctx context.Context, cmd *cobra.Command, _ []string) error {
 	cachedResultMaxAge := viper.GetDuration(FlagCachedResultMaxAge)
 	rpcQueryTimeout := viper.GetDuration(FlagRPCQueryTimeout)
 	enableIPOperator := viper.GetBool(FlagEnableIPOperator)
+	enableIngressMetrics := viper.GetBool(FlagEnableIngressMetrics)
+	ingressBackend := viper.GetString(FlagIngressBackend)
 	txTimeout := viper.GetDuration(FlagTxBroadcastTimeout)
+	imagePolicyPath := viper.GetString(FlagDeploymentImagePolicy)
+	networkPolicyPath := viper.GetString(FlagDeploymentNetworkPolicy)
+	bandwidthPolicyPath := viper.GetString(FlagDeploymentBandwidthPolicy)
+	networkUsagePollPeriod := viper.GetDuration(FlagNetworkUsagePollPeriod)
+	volumeRetentionPeriod := viper.GetDuration(FlagDeploymentVolumeRetentionPeriod)
+	volumeSnapshotClass := viper.GetString(FlagDeploymentVolumeSnapshotClass)
+	volumeSnapshotInterval := viper.GetDuration(FlagDeploymentVolumeSnapshotInterval)
+	volumeSnapshotsKept := viper.GetUint(FlagDeploymentVolumeSnapshotsKept)
+	monitorOverridesPath := viper.GetString(FlagMonitorOverrides)
+
+	// only the request metrics of ingress-nginx are parsed, other controllers expose theirs differently
+	if enableIngressMetrics && ingressBackend != kube.IngressBackendNginx {
+		return fmt.Errorf("%w: --%s requires the %s ingress backend, got %q", errInvalidConfig, FlagEnableIngressMetrics, kube.IngressBackendNginx, ingressBackend)
+	}
 
 	pricing, err := createBidPricingStrategy(strategy)
 	if err != nil {
 		return err
 	}
 
+	monitorCfg := cluster.MonitorConfig{
+		MaxRetries:              viper.GetUint(FlagMonitorMaxRetries),
+		RetryPeriod:             viper.GetDuration(FlagMonitorRetryPeriod),
+		RetryPeriodJitter:       viper.GetDuration(FlagMonitorRetryPeriodJitter),
+		HealthcheckPeriod:       viper.GetDuration(FlagMonitorHealthcheckPeriod),
+		HealthcheckPeriodJitter: viper.GetDuration(FlagMonitorHealthcheckPeriodJitter),
+		FailurePolicy:           cluster.MonitorFailurePolicy(viper.GetString(FlagMonitorFailurePolicy)),
+	}
+	if err := monitorCfg.Validate(); err != nil {
+		return err
+	}
+
+	var monitorOverrides cluster.MonitorOverrides
+	if len(monitorOverridesPath) != 0 {
+		monitorOverrides, err = cluster.ReadMonitorOverrides(monitorOverridesPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	ownerQuotas := cluster.OwnerQuotas{
+		Default: cluster.OwnerQuota{
+			LeasedIPs: viper.GetUint(providerflags.FlagOwnerLeasedIPLimit),
+			Endpoints: viper.GetUint(providerflags.FlagOwnerEndpointLimit),
+		},
+	}
+	if ownerQuotasPath := viper.GetString(providerflags.FlagOwnerQuotas); len(ownerQuotasPath) != 0 {
+		ownerQuotas.Owners, err = cluster.ReadOwnerQuotas(ownerQuotasPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	var imagePolicy *imagepolicy.Policy
+	if len(imagePolicyPath) != 0 {
+		imagePolicy, err = imagepolicy.ReadFile(imagePolicyPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	var networkPolicy *netpolicy.Policy
+	if len(networkPolicyPath) != 0 {
+		networkPolicy, err = netpolicy.ReadFile(networkPolicyPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	var bandwidthPolicy *bandwidth.Policy
+	if len(bandwidthPolicyPath) != 0 {
+		bandwidthPolicy, err = bandwidth.ReadFile(bandwidthPolicyPath)
+		if err != nil {
+			return err
+		}
+	}
+
 	logger := cmdutil.OpenLogger().With("cmp", "provider")
 	kubeConfig, err := clientcommon.OpenKubeConfig(kubeConfigPath, logger)
 	if err != nil {
@@ -569,12 +877,24 @@ func doRunCmd(ctx context.Context, cmd *cobra.Command, _ []string) error {
 	kubeSettings.DeploymentIngressExposeLBHosts = deploymentIngressExposeLBHosts
 	kubeSettings.DeploymentIngressStaticHosts = deploymentIngressStaticHosts
 	kubeSettings.NetworkPoliciesEnabled = deploymentNetworkPoliciesEnabled
+	kubeSettings.NetworkPolicy = networkPolicy
+	kubeSettings.ReadinessProbesEnabled = deploymentReadinessProbesEnabled
+	kubeSettings.ReadinessProbeImage = deploymentReadinessProbeImage
+	kubeSettings.ResourceQuotasEnabled = deploymentResourceQuotasEnabled
+	kubeSettings.LeasedIPDualStack = deploymentLeasedIPDualStack
+	kubeSettings.PodSecurityLevel = builder.PodSecurityLevel(deploymentPodSecurityLevel)
+	kubeSettings.SecurityProfile = builder.SecurityProfile(deploymentSecurityProfile)
 	kubeSettings.ClusterPublicHostname = clusterPublicHostname
 	kubeSettings.CPUCommitLevel = overcommitPercentCPU
 	kubeSettings.MemoryCommitLevel = overcommitPercentMemory
 	kubeSettings.StorageCommitLevel = overcommitPercentStorage
 	kubeSettings.DeploymentRuntimeClass = deploymentRuntimeClass
 	kubeSettings.DockerImagePullSecretsName = strings.TrimSpace(dockerImagePullSecretsName)
+	kubeSettings.ImagePolicy = imagePolicy
+	kubeSettings.BandwidthPolicy = bandwidthPolicy
+	kubeSettings.VolumeRetentionPeriod = volumeRetentionPeriod
+	kubeSettings.VolumeSnapshotClass = volumeSnapshotClass
+	kubeSettings.VolumeSnapshotsKept = volumeSnapshotsKept
 
 	if err := builder.ValidateSettings(kubeSettings); err != nil {
 		return err
@@ -616,10 +936,33 @@ func doRunCmd(ctx context.Context, cmd *cobra.Command, _ []string) error {
 	config.MemoryCommitLevel = overcommitPercentMemory
 	config.StorageCommitLevel = overcommitPercentStorage
 	config.BlockedHostnames = blockedHostnames
+	config.HostnameRulesPath = viper.GetString(FlagDeploymentHostnameRules)
 	config.DeploymentIngressStaticHosts = deploymentIngressStaticHosts
 	config.DeploymentIngressDomain = deploymentIngressDomain
 	config.BidTimeout = bidTimeout
 	config.ManifestTimeout = manifestTimeout
+	config.ImagePolicy = imagePolicy
+	config.DeploymentMonitor = monitorCfg
+	config.DeploymentMonitorOverrides = monitorOverrides
+	config.OwnerQuotas = ownerQuotas
+	config.DeploymentRollbackTimeout = viper.GetDuration(FlagDeploymentRollbackTimeout)
+	config.LeaseReconcilePeriod = viper.GetDuration(FlagLeaseReconcilePeriod)
+	config.LeaseReconcileGracePeriod = viper.GetDuration(FlagLeaseReconcileGracePeriod)
+	config.LeaseReconcileTeardown = viper.GetBool(FlagLeaseReconcileTeardown)
+	config.LeaseReconcileMaxOrphaned = viper.GetUint(FlagLeaseReconcileMaxOrphanedPercent)
+	config.DeploymentHostnameVerification = cluster.HostnameVerificationConfig{
+		Enabled:  viper.GetBool(FlagHostnameVerification),
+		Secret:   viper.GetString(FlagHostnameVerificationSecret),
+		CacheTTL: viper.GetDuration(FlagHostnameVerificationCacheTTL),
+	}
+
+	if config.DeploymentHostnameVerification.Enabled && config.DeploymentHostnameVerification.Secret == "" {
+		return fmt.Errorf("%w: --%s required with --%s", errInvalidConfig, FlagHostnameVerificationSecret, FlagHostnameVerification)
+	}
+
+	if resolver := viper.GetString(FlagHostnameVerificationResolver); resolver != "" {
+		config.DeploymentHostnameVerification.Resolver = cluster.NewHostnameResolver(resolver)
+	}
 
 	if len(providerConfig) != 0 {
 		pConf, err := config2.ReadConfigPath(providerConfig)
@@ -661,6 +1004,19 @@ func doRunCmd(ctx context.Context, cmd *cobra.Command, _ []string) error {
 		}
 	}
 
+	// This value can be nil, the ingress controller metrics are not mandatory
+	var ingressMetricsClient operatorclients.IngressMetricsClient
+	if enableIngressMetrics {
+		endpoint, err := providerflags.GetServiceEndpointFlagValue(logger, serviceIngressMetrics)
+		if err != nil {
+			return err
+		}
+		ingressMetricsClient, err = operatorclients.NewIngressMetricsClient(logger, kubeConfig, endpoint)
+		if err != nil {
+			return err
+		}
+	}
+
 	endpoint, err := providerflags.GetServiceEndpointFlagValue(logger, serviceHostnameOperator)
 	if err != nil {
 		return err
@@ -690,6 +1046,7 @@ func doRunCmd(ctx context.Context, cmd *cobra.Command, _ []string) error {
 		service,
 		cquery,
 		ipOperatorClient,
+		ingressMetricsClient,
 		gwaddr,
 		cctx.FromAddress,
 		[]tls.Certificate{tlsCert},
@@ -718,6 +1075,38 @@ func doRunCmd(ctx context.Context, cmd *cobra.Command, _ []string) error {
 		return gateway.Close()
 	})
 
+	if viper.GetBool(FlagClusterK8s) {
+		kc, err := kubernetes.NewForConfig(kubeConfig)
+		if err != nil {
+			return err
+		}
+
+		if networkUsagePollPeriod > 0 {
+			collector := kube.NewNetworkUsageCollector(logger, kc, networkUsagePollPeriod)
+			group.Go(func() error {
+				return collector.Run(ctx)
+			})
+		}
+
+		dc, err := dynamic.NewForConfig(kubeConfig)
+		if err != nil {
+			return err
+		}
+
+		// volumes retained before the period was turned off still expire
+		collector := kube.NewRetainedVolumeCollector(logger, kc, dc, retainedVolumeCollectPeriod)
+		group.Go(func() error {
+			return collector.Run(ctx)
+		})
+
+		if volumeSnapshotClass != "" && volumeSnapshotInterval > 0 {
+			scheduler := kube.NewVolumeSnapshotScheduler(logger, kc, dc, volumeSnapshotClass, volumeSnapshotInterval, volumeSnapshotsKept)
+			group.Go(func() error {
+				return scheduler.Run(ctx)
+			})
+		}
+	}
+
 	if metricsRouter != nil {
 		group.Go(func() error {
 			// fixme ovrclk/engineering#609
@@ -740,6 +1129,9 @@ func doRunCmd(ctx context.Context, cmd *cobra.Command, _ []string) error {
 	if ipOperatorClient != nil {
 		ipOperatorClient.Stop()
 	}
+	if ingressMetricsClient != nil {
+		ingressMetricsClient.Stop()
+	}
 	hostnameOperatorClient.Stop()
 	if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, http.ErrServerClosed) {
 		return err
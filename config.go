@@ -10,6 +10,8 @@ import (
 	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
 
 	"github.com/akash-network/provider/bidengine"
+	"github.com/akash-network/provider/cluster"
+	"github.com/akash-network/provider/cluster/imagepolicy"
 )
 
 type Config struct {
@@ -34,6 +36,17 @@ type Config struct {
 	ClusterSettings                 map[interface{}]interface{}
 	RPCQueryTimeout                 time.Duration
 	CachedResultMaxAge              time.Duration
+	ImagePolicy                     *imagepolicy.Policy
+	DeploymentMonitor               cluster.MonitorConfig
+	DeploymentMonitorOverrides      cluster.MonitorOverrides
+	DeploymentRollbackTimeout       time.Duration
+	LeaseReconcilePeriod            time.Duration
+	LeaseReconcileGracePeriod       time.Duration
+	LeaseReconcileTeardown          bool
+	LeaseReconcileMaxOrphaned       uint
+	DeploymentHostnameVerification  cluster.HostnameVerificationConfig
+	HostnameRulesPath               string
+	OwnerQuotas                     cluster.OwnerQuotas
 }
 
 func NewDefaultConfig() Config {
@@ -44,6 +57,7 @@ func NewDefaultConfig() Config {
 			LeaseFundsCheckInterval: 1 * time.Minute,
 			WithdrawalPeriod:        24 * time.Hour,
 		},
-		MaxGroupVolumes: constants.DefaultMaxGroupVolumes,
+		MaxGroupVolumes:   constants.DefaultMaxGroupVolumes,
+		DeploymentMonitor: cluster.NewDefaultMonitorConfig(),
 	}
 }
@@ -45,6 +45,10 @@ const (
 	ClusterDeploymentPending ClusterDeploymentStatus = "pending"
 	// ClusterDeploymentDeployed is used when cluster deployment status is deployed
 	ClusterDeploymentDeployed ClusterDeploymentStatus = "deployed"
+	// ClusterDeploymentFailed is used when cluster deployment never became healthy and the lease is being closed
+	ClusterDeploymentFailed ClusterDeploymentStatus = "failed"
+	// ClusterDeploymentRolledBack is used when an update never became healthy and the previous group is deployed again
+	ClusterDeploymentRolledBack ClusterDeploymentStatus = "rolled-back"
 )
 
 // ClusterDeployment stores leaseID, group details and deployment status
@@ -52,6 +56,8 @@ type ClusterDeployment struct {
 	LeaseID mtypes.LeaseID
 	Group   *maniv2beta1.Group
 	Status  ClusterDeploymentStatus
+	// Reason describes why the deployment is not healthy, if known
+	Reason string
 }
 
 type LeaseAddFundsMonitor struct {
@@ -151,6 +151,7 @@ func mockManifestGroups(m integrationMocks, leaseID mtypes.LeaseID) {
 		AvailableReplicas:  0,
 	}
 	m.pcclient.On("LeaseStatus", mock.Anything, leaseID).Return(status, nil)
+	m.pcclient.On("GetManifestStatus", mock.Anything, leaseID).Return(false, v2beta1.ManifestStatus{}, nil)
 	m.pcclient.On("GetManifestGroup", mock.Anything, leaseID).Return(true, v2beta1.ManifestGroup{
 		Name: testGroupName,
 		Services: []v2beta1.ManifestService{{
@@ -335,7 +336,7 @@ func createMocks() integrationMocks {
 
 func withServer(t testing.TB, addr sdk.Address, pclient provider.Client, qclient *qmock.QueryClient, certs []tls.Certificate, ipoc operatorclients.IPOperatorClient, fn func(string)) {
 	t.Helper()
-	router := newRouter(testutil.Logger(t), addr, pclient, ipoc, map[interface{}]interface{}{})
+	router := newRouter(testutil.Logger(t), addr, pclient, ipoc, nil, map[interface{}]interface{}{})
 
 	if len(certs) == 0 {
 		crt := testutil.Certificate(
@@ -87,6 +87,21 @@ func requireOwner() mux.MiddlewareFunc {
 	}
 }
 
+// requireProviderOwner restricts administrative endpoints to the account of the provider itself,
+// it must follow requireOwner
+func requireProviderOwner() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !requestOwner(r).Equals(requestProvider(r)) {
+				http.Error(w, "", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func requireDeploymentID() mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
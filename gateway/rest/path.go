@@ -7,11 +7,14 @@ import (
 )
 
 const (
-	deploymentPathPrefix = "/deployment/{dseq}"
-	leasePathPrefix      = "/lease/{dseq}/{gseq}/{oseq}"
-	hostnamePrefix       = "/hostname"
-	endpointPrefix       = "/endpoint"
-	migratePathPrefix    = "/migrate"
+	deploymentPathPrefix   = "/deployment/{dseq}"
+	leasePathPrefix        = "/lease/{dseq}/{gseq}/{oseq}"
+	hostnamePrefix         = "/hostname"
+	endpointPrefix         = "/endpoint"
+	migratePathPrefix      = "/migrate"
+	verificationPathPrefix = "/verification"
+	rulesPathPrefix        = "/rules"
+	nodePortsPathPrefix    = "/node-ports"
 )
 
 func versionPath() string {
@@ -26,6 +29,18 @@ func validatePath() string {
 	return "validate"
 }
 
+func hostnameVerificationPath() string {
+	return "hostname/verification"
+}
+
+func hostnameRulesPath() string {
+	return "hostname/rules"
+}
+
+func nodePortsPath() string {
+	return "node-ports"
+}
+
 func leasePath(id mtypes.LeaseID) string {
 	return fmt.Sprintf("lease/%d/%d/%d", id.DSeq, id.GSeq, id.OSeq)
 }
@@ -50,6 +65,18 @@ func serviceStatusPath(id mtypes.LeaseID, service string) string {
 	return fmt.Sprintf("%s/service/%s/status", leasePath(id), service)
 }
 
+func leaseHostnameMetricsPath(id mtypes.LeaseID) string {
+	return fmt.Sprintf("%s/hostname-metrics", leasePath(id))
+}
+
 func serviceLogsPath(id mtypes.LeaseID) string {
 	return fmt.Sprintf("%s/logs", leasePath(id))
 }
+
+func leaseVolumeSnapshotsPath(id mtypes.LeaseID) string {
+	return fmt.Sprintf("%s/snapshots", leasePath(id))
+}
+
+func leaseVolumeSnapshotRestorePath(id mtypes.LeaseID, name string) string {
+	return fmt.Sprintf("%s/snapshots/%s/restore", leasePath(id), name)
+}
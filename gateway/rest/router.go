@@ -77,7 +77,7 @@ type wsStreamConfig struct {
 	client    cluster.ReadClient
 }
 
-func newRouter(log log.Logger, addr sdk.Address, pclient provider.Client, ipopclient operatorclients.IPOperatorClient, ctxConfig map[interface{}]interface{}) *mux.Router {
+func newRouter(log log.Logger, addr sdk.Address, pclient provider.Client, ipopclient operatorclients.IPOperatorClient, ingressMetrics operatorclients.IngressMetricsClient, ctxConfig map[interface{}]interface{}) *mux.Router {
 	router := mux.NewRouter()
 
 	// store provider address in context as lease endpoints below need it
@@ -118,6 +118,30 @@ func newRouter(log log.Logger, addr sdk.Address, pclient provider.Client, ipopcl
 	hostnameRouter.HandleFunc(migratePathPrefix, migrateHandler(log, pclient.Hostname(), pclient.ClusterService())).
 		Methods(http.MethodPost)
 
+	// GET /hostname/verification
+	// returns the token the DNS records of custom hostnames of the owner must hold
+	hostnameRouter.HandleFunc(verificationPathPrefix, hostnameVerificationHandler(log, pclient.Hostname())).
+		Methods(http.MethodGet)
+
+	// GET|PUT /hostname/rules
+	// hostname block rules are managed by the provider only
+	rulesRouter := hostnameRouter.PathPrefix(rulesPathPrefix).Subrouter()
+	rulesRouter.Use(requireProviderOwner())
+	rulesRouter.HandleFunc("", hostnameRulesHandler(log, pclient.Hostname())).
+		Methods(http.MethodGet)
+	rulesRouter.HandleFunc("", setHostnameRulesHandler(log, pclient.Hostname())).
+		Methods(http.MethodPut)
+
+	// GET /node-ports
+	// the node ports provisioned for leases, listed for the provider to configure its firewalls
+	nodePortsRouter := router.PathPrefix(nodePortsPathPrefix).Subrouter()
+	nodePortsRouter.Use(
+		requireOwner(),
+		requireProviderOwner(),
+	)
+	nodePortsRouter.HandleFunc("", nodePortsHandler(log, pclient.Cluster())).
+		Methods(http.MethodGet)
+
 	endpointRouter := router.PathPrefix(endpointPrefix).Subrouter()
 	endpointRouter.Use(requireOwner())
 	endpointRouter.HandleFunc(migratePathPrefix, migrateEndpointHandler(log, pclient.ClusterService(), pclient.Cluster())).
@@ -174,6 +198,21 @@ func newRouter(log log.Logger, addr sdk.Address, pclient provider.Client, ipopcl
 		leaseServiceStatusHandler(log, pclient.Cluster())).
 		Methods("GET")
 
+	// GET /lease/<lease-id>/hostname-metrics
+	lrouter.HandleFunc("/hostname-metrics",
+		leaseHostnameMetricsHandler(log, ingressMetrics)).
+		Methods(http.MethodGet)
+
+	// GET /lease/<lease-id>/snapshots
+	lrouter.HandleFunc("/snapshots",
+		leaseVolumeSnapshotsHandler(log, pclient.Cluster())).
+		Methods(http.MethodGet)
+
+	// POST /lease/<lease-id>/snapshots/<snapshot-name>/restore
+	lrouter.HandleFunc("/snapshots/{snapshotName}/restore",
+		leaseVolumeSnapshotRestoreHandler(log, pclient.Cluster())).
+		Methods(http.MethodPost)
+
 	// POST /lease/<lease-id>/shell
 	lrouter.HandleFunc("/shell",
 		leaseShellHandler(log, pclient.Manifest(), pclient.Cluster()))
@@ -617,6 +656,19 @@ func leaseStatusHandler(log log.Logger, cclient cluster.ReadClient, ipopclient o
 			return
 		}
 
+		hasStatus, manifestStatus, err := cclient.GetManifestStatus(req.Context(), leaseID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if hasStatus && manifestStatus.State != "" {
+			result.Manifest = &LeaseManifestStatus{
+				State:   manifestStatus.State,
+				Message: manifestStatus.Message,
+			}
+		}
+
 		hasLeasedIPs := false
 		if ipopclient != nil {
 		ipManifestGroupSearchLoop:
@@ -651,17 +703,26 @@ func leaseStatusHandler(log log.Logger, cclient cluster.ReadClient, ipopclient o
 					ExternalPort: ipLease.ExternalPort,
 					Protocol:     ipLease.Protocol,
 					IP:           ipLease.IP,
+					IPv6:         ipLease.IPv6,
 				})
 
 				result.IPs[ipLease.ServiceName] = entries
 			}
+
+			declared, err := cclient.DeclaredIPStates(req.Context(), leaseID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			addLeasedIPStates(result.IPs, declared)
 		}
 
 		hasForwardedPorts := false
 	portManifestGroupSearchLoop:
 		for _, service := range manifestGroup.Services {
 			for _, expose := range service.Expose {
-				if expose.Global && expose.ExternalPort != 80 {
+				// leased IPs are reported on every port, with both addresses of dual-stack endpoints
+				if expose.Global && (expose.ExternalPort != 80 || len(expose.IP) != 0) {
 					hasForwardedPorts = true
 					break portManifestGroupSearchLoop
 				}
@@ -697,6 +758,33 @@ func leaseStatusHandler(log log.Logger, cclient cluster.ReadClient, ipopclient o
 	}
 }
 
+// addLeasedIPStates adds the state the IP operator recorded to the leased IPs, IPs which are not assigned
+// yet are listed with their state only
+func addLeasedIPStates(ips map[string][]LeasedIPStatus, declared []cltypes.DeclaredIPState) {
+declaredLoop:
+	for _, state := range declared {
+		entries := ips[state.ServiceName]
+		for i := range entries {
+			entry := &entries[i]
+			if entry.ExternalPort == state.ExternalPort && entry.Protocol == state.Proto.ToString() {
+				entry.State = state.State
+				entry.Message = state.Message
+				entry.LastUpdate = state.LastUpdate
+				continue declaredLoop
+			}
+		}
+
+		ips[state.ServiceName] = append(entries, LeasedIPStatus{
+			Port:         state.Port,
+			ExternalPort: state.ExternalPort,
+			Protocol:     state.Proto.ToString(),
+			State:        state.State,
+			Message:      state.Message,
+			LastUpdate:   state.LastUpdate,
+		})
+	}
+}
+
 func leaseServiceStatusHandler(log log.Logger, cclient cluster.ReadClient) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		status, err := cclient.ServiceStatus(req.Context(), requestLeaseID(req), requestService(req))
@@ -720,6 +808,150 @@ func leaseServiceStatusHandler(log log.Logger, cclient cluster.ReadClient) http.
 	}
 }
 
+func hostnameVerificationHandler(log log.Logger, hostnameService cltypes.HostnameServiceClient) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		token, err := hostnameService.VerificationToken(requestOwner(req))
+		if err != nil {
+			if errors.Is(err, cluster.ErrHostnameVerificationDisabled) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(log, w, HostnameVerification{
+			RecordPrefix: cluster.HostnameVerificationRecordPrefix,
+			Token:        token,
+		})
+	}
+}
+
+func hostnameRulesHandler(log log.Logger, hostnameService cltypes.HostnameServiceClient) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		rules := hostnameService.HostnameRules()
+		if rules == nil {
+			rules = []cltypes.HostnameRule{}
+		}
+
+		writeJSON(log, w, rules)
+	}
+}
+
+func setHostnameRulesHandler(log log.Logger, hostnameService cltypes.HostnameServiceClient) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var rules []cltypes.HostnameRule
+
+		defer func() {
+			_ = req.Body.Close()
+		}()
+
+		if err := json.NewDecoder(req.Body).Decode(&rules); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		violations, err := hostnameService.SetHostnameRules(req.Context(), rules)
+		if err != nil {
+			if errors.Is(err, cluster.ErrInvalidHostnameRule) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// leases keep the hostnames they were granted, the provider decides what to do with them
+		for _, violation := range violations {
+			log.Info("hostname in use is now blocked", "hostname", violation.Hostname, "owner", violation.Owner,
+				"dseq", violation.DSeq, "gseq", violation.GSeq)
+		}
+
+		if violations == nil {
+			violations = []cltypes.HostnameRuleViolation{}
+		}
+
+		writeJSON(log, w, HostnameRulesUpdate{Violations: violations})
+	}
+}
+
+func nodePortsHandler(log log.Logger, cclient cluster.ReadClient) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		allocations, err := cclient.NodePorts(req.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if allocations == nil {
+			allocations = []cltypes.NodePortAllocation{}
+		}
+
+		writeJSON(log, w, allocations)
+	}
+}
+
+func leaseHostnameMetricsHandler(log log.Logger, ingressMetrics operatorclients.IngressMetricsClient) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		// This value can be nil, reading the metrics of the ingress controller is not mandatory
+		if ingressMetrics == nil {
+			http.Error(w, "ingress metrics are not enabled on this provider", http.StatusNotImplemented)
+			return
+		}
+
+		leaseID := requestLeaseID(req)
+		metrics, err := ingressMetrics.LeaseHostnameMetrics(req.Context(), leaseID)
+		if err != nil {
+			log.Error("reading hostname metrics", "lease", leaseID, "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(log, w, metrics)
+	}
+}
+
+func leaseVolumeSnapshotsHandler(log log.Logger, cclient cluster.ReadClient) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		snapshots, err := cclient.LeaseVolumeSnapshots(req.Context(), requestLeaseID(req))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if snapshots == nil {
+			snapshots = []cltypes.VolumeSnapshot{}
+		}
+
+		writeJSON(log, w, snapshots)
+	}
+}
+
+func leaseVolumeSnapshotRestoreHandler(log log.Logger, cclient cluster.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		leaseID := requestLeaseID(req)
+		name := mux.Vars(req)["snapshotName"]
+
+		err := cclient.RestoreVolumeSnapshot(req.Context(), leaseID, name)
+		if err != nil {
+			if errors.Is(err, kubeclienterrors.ErrVolumeSnapshotNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, kubeclienterrors.ErrVolumeSnapshotNotReady) || errors.Is(err, kubeclienterrors.ErrVolumeRestoreInProgress) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			log.Error("restoring volume snapshot", "lease", leaseID, "snapshot", name, "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// the restore runs in the background, its progress is in the restore state of the snapshot
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
 func leaseLogsHandler(log log.Logger, cclient cluster.ReadClient) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		upgrader := websocket.Upgrader{
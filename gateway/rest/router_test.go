@@ -20,6 +20,7 @@ import (
 	"github.com/cosmos/cosmos-sdk/version"
 
 	qmock "github.com/akash-network/node/client/mocks"
+	manifest "github.com/akash-network/node/manifest/v2beta1"
 	"github.com/akash-network/node/sdl"
 	"github.com/akash-network/node/testutil"
 	manifestValidation "github.com/akash-network/node/validation"
@@ -631,6 +632,10 @@ func mockManifestGroupsForRouterTest(rt *routerTest, leaseID mtypes.LeaseID) {
 			Params: nil,
 		}},
 	}, nil)
+	rt.pcclient.On("GetManifestStatus", mock.Anything, leaseID).Return(true, v2beta1.ManifestStatus{
+		State:   v2beta1.ManifestStateRolledBack,
+		Message: `service "web": available replicas below target`,
+	}, nil)
 }
 
 func TestRouteLeaseStatusOk(t *testing.T) {
@@ -661,10 +666,12 @@ func TestRouteLeaseStatusOk(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, resp.StatusCode, http.StatusOK)
 
-		data := make(map[string]interface{})
+		data := LeaseStatus{}
 		dec := json.NewDecoder(resp.Body)
 		err = dec.Decode(&data)
 		require.NoError(t, err)
+		require.NotNil(t, data.Manifest)
+		require.Equal(t, v2beta1.ManifestStateRolledBack, data.Manifest.State)
 	})
 }
 
@@ -916,3 +923,228 @@ func TestRouteServiceStatusError(t *testing.T) {
 		require.Regexp(t, "^generic test error(?s:.)*$", string(data))
 	})
 }
+
+func TestRouteVolumeSnapshotsOK(t *testing.T) {
+	runRouterTest(t, true, func(test *routerTest) {
+		dseq := uint64(testutil.RandRangeInt(1, 1000))
+		oseq := uint32(testutil.RandRangeInt(2000, 3000))
+		gseq := uint32(testutil.RandRangeInt(4000, 5000))
+
+		snapshots := []clustertypes.VolumeSnapshot{
+			{
+				Name:       "db-data-db-0-scheduled-1700000000",
+				Service:    "db",
+				Volume:     "data",
+				Trigger:    clustertypes.VolumeSnapshotTriggerScheduled,
+				ReadyToUse: true,
+				CreatedAt:  time.Unix(1700000000, 0).UTC(),
+			},
+		}
+
+		test.pcclient.On("LeaseVolumeSnapshots", mock.Anything, types.LeaseID{
+			Owner:    test.caddr.String(),
+			DSeq:     dseq,
+			GSeq:     gseq,
+			OSeq:     oseq,
+			Provider: test.paddr.String(),
+		}).Return(snapshots, nil)
+
+		lid := types.LeaseID{
+			DSeq:     dseq,
+			GSeq:     gseq,
+			OSeq:     oseq,
+			Provider: test.paddr.String(),
+		}
+
+		uri, err := makeURI(test.host, leaseVolumeSnapshotsPath(lid))
+		require.NoError(t, err)
+
+		req, err := http.NewRequest("GET", uri, nil)
+		require.NoError(t, err)
+
+		req.Header.Set("Content-Type", contentTypeJSON)
+
+		resp, err := test.gclient.hclient.Do(req)
+		require.NoError(t, err)
+
+		require.Equal(t, resp.StatusCode, http.StatusOK)
+		var data []clustertypes.VolumeSnapshot
+		dec := json.NewDecoder(resp.Body)
+		err = dec.Decode(&data)
+		require.NoError(t, err)
+		require.Equal(t, snapshots, data)
+	})
+}
+
+func TestRouteVolumeSnapshotRestoreOK(t *testing.T) {
+	runRouterTest(t, true, func(test *routerTest) {
+		dseq := uint64(testutil.RandRangeInt(1, 1000))
+		oseq := uint32(testutil.RandRangeInt(2000, 3000))
+		gseq := uint32(testutil.RandRangeInt(4000, 5000))
+
+		const snapshotName = "db-data-db-0-update-1700000000"
+
+		test.pcclient.On("RestoreVolumeSnapshot", mock.Anything, types.LeaseID{
+			Owner:    test.caddr.String(),
+			DSeq:     dseq,
+			GSeq:     gseq,
+			OSeq:     oseq,
+			Provider: test.paddr.String(),
+		}, snapshotName).Return(nil)
+
+		lid := types.LeaseID{
+			DSeq:     dseq,
+			GSeq:     gseq,
+			OSeq:     oseq,
+			Provider: test.paddr.String(),
+		}
+
+		uri, err := makeURI(test.host, leaseVolumeSnapshotRestorePath(lid, snapshotName))
+		require.NoError(t, err)
+
+		req, err := http.NewRequest("POST", uri, nil)
+		require.NoError(t, err)
+
+		resp, err := test.gclient.hclient.Do(req)
+		require.NoError(t, err)
+
+		require.Equal(t, resp.StatusCode, http.StatusAccepted)
+		test.pcclient.AssertExpectations(t)
+	})
+}
+
+func TestRouteVolumeSnapshotRestoreNotFound(t *testing.T) {
+	runRouterTest(t, true, func(test *routerTest) {
+		dseq := uint64(testutil.RandRangeInt(1, 1000))
+		oseq := uint32(testutil.RandRangeInt(2000, 3000))
+		gseq := uint32(testutil.RandRangeInt(4000, 5000))
+
+		test.pcclient.On("RestoreVolumeSnapshot", mock.Anything, types.LeaseID{
+			Owner:    test.caddr.String(),
+			DSeq:     dseq,
+			GSeq:     gseq,
+			OSeq:     oseq,
+			Provider: test.paddr.String(),
+		}, "missing").Return(kubeclienterrors.ErrVolumeSnapshotNotFound)
+
+		lid := types.LeaseID{
+			DSeq:     dseq,
+			GSeq:     gseq,
+			OSeq:     oseq,
+			Provider: test.paddr.String(),
+		}
+
+		uri, err := makeURI(test.host, leaseVolumeSnapshotRestorePath(lid, "missing"))
+		require.NoError(t, err)
+
+		req, err := http.NewRequest("POST", uri, nil)
+		require.NoError(t, err)
+
+		resp, err := test.gclient.hclient.Do(req)
+		require.NoError(t, err)
+
+		require.Equal(t, resp.StatusCode, http.StatusNotFound)
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Regexp(t, "^kube: volume snapshot not found(?s:.)*$", string(data))
+	})
+}
+
+func TestRouteHostnameVerificationOK(t *testing.T) {
+	runRouterTest(t, true, func(test *routerTest) {
+		test.hostnameClient.On("VerificationToken", test.caddr).Return("token", nil)
+
+		uri, err := makeURI(test.host, hostnameVerificationPath())
+		require.NoError(t, err)
+
+		req, err := http.NewRequest("GET", uri, nil)
+		require.NoError(t, err)
+
+		resp, err := test.gclient.hclient.Do(req)
+		require.NoError(t, err)
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var data HostnameVerification
+		dec := json.NewDecoder(resp.Body)
+		err = dec.Decode(&data)
+		require.NoError(t, err)
+		require.Equal(t, HostnameVerification{RecordPrefix: "_akash-verification", Token: "token"}, data)
+	})
+}
+
+func TestRouteHostnameRulesForbidden(t *testing.T) {
+	runRouterTest(t, true, func(test *routerTest) {
+		uri, err := makeURI(test.host, hostnameRulesPath())
+		require.NoError(t, err)
+
+		buf, err := json.Marshal([]clustertypes.HostnameRule{{
+			Type:    clustertypes.HostnameRuleGlob,
+			Pattern: "*.example.com",
+			Action:  clustertypes.HostnameRuleBlock,
+		}})
+		require.NoError(t, err)
+
+		req, err := http.NewRequest("PUT", uri, bytes.NewBuffer(buf))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentTypeJSON)
+
+		// only the provider manages the rules
+		resp, err := test.gclient.hclient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		test.hostnameClient.AssertNotCalled(t, "SetHostnameRules", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRouteNodePortsForbidden(t *testing.T) {
+	runRouterTest(t, true, func(test *routerTest) {
+		uri, err := makeURI(test.host, nodePortsPath())
+		require.NoError(t, err)
+
+		req, err := http.NewRequest("GET", uri, nil)
+		require.NoError(t, err)
+
+		// only the provider lists the node ports of every lease
+		resp, err := test.gclient.hclient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		test.pcclient.AssertNotCalled(t, "NodePorts", mock.Anything)
+	})
+}
+
+func TestRouteHostnameMetricsNotEnabled(t *testing.T) {
+	runRouterTest(t, true, func(test *routerTest) {
+		lid := types.LeaseID{
+			DSeq:     uint64(testutil.RandRangeInt(1, 1000)),
+			GSeq:     uint32(testutil.RandRangeInt(4000, 5000)),
+			OSeq:     uint32(testutil.RandRangeInt(2000, 3000)),
+			Provider: test.paddr.String(),
+		}
+
+		uri, err := makeURI(test.host, leaseHostnameMetricsPath(lid))
+		require.NoError(t, err)
+
+		req, err := http.NewRequest("GET", uri, nil)
+		require.NoError(t, err)
+
+		resp, err := test.gclient.hclient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+	})
+}
+
+func TestAddLeasedIPStates(t *testing.T) {
+	ips := map[string][]LeasedIPStatus{
+		"web": {{Port: 80, ExternalPort: 80, Protocol: "TCP", IP: "10.0.0.1"}},
+	}
+
+	addLeasedIPStates(ips, []clustertypes.DeclaredIPState{
+		{ServiceName: "web", Port: 80, ExternalPort: 80, Proto: manifest.TCP, State: "active"},
+		{ServiceName: "dns", Port: 53, ExternalPort: 53, Proto: manifest.UDP, State: "failed", Message: "pool exhausted"},
+	})
+
+	require.Equal(t, map[string][]LeasedIPStatus{
+		"web": {{Port: 80, ExternalPort: 80, Protocol: "TCP", IP: "10.0.0.1", State: "active"}},
+		"dns": {{Port: 53, ExternalPort: 53, Protocol: "UDP", State: "failed", Message: "pool exhausted"}},
+	}, ips)
+}
@@ -24,6 +24,7 @@ func NewServer(
 	pclient provider.Client,
 	cquery ctypes.QueryClient,
 	ipopclient operatorclients.IPOperatorClient,
+	ingressMetrics operatorclients.IngressMetricsClient,
 	address string,
 	pid sdk.Address,
 	certs []tls.Certificate,
@@ -33,7 +34,7 @@ func NewServer(
 	// nolint: gosec
 	srv := &http.Server{
 		Addr:    address,
-		Handler: newRouter(log, pid, pclient, ipopclient, clusterConfig),
+		Handler: newRouter(log, pid, pclient, ipopclient, ingressMetrics, clusterConfig),
 		BaseContext: func(_ net.Listener) context.Context {
 			return ctx
 		},
@@ -1,6 +1,8 @@
 package rest
 
 import (
+	"time"
+
 	cltypes "github.com/akash-network/provider/cluster/types/v1beta2"
 )
 
@@ -9,10 +11,33 @@ type LeasedIPStatus struct {
 	ExternalPort uint32
 	Protocol     string
 	IP           string
+	IPv6         string
+	State        string
+	Message      string
+	LastUpdate   *time.Time
 }
 
 type LeaseStatus struct {
 	Services       map[string]*cltypes.ServiceStatus        `json:"services"`
 	ForwardedPorts map[string][]cltypes.ForwardedPortStatus `json:"forwarded_ports"` // Container services that are externally accessible
 	IPs            map[string][]LeasedIPStatus              `json:"ips"`
+	// Manifest reports lease wide state such as a rollback of a failed update
+	Manifest *LeaseManifestStatus `json:"manifest,omitempty"`
+}
+
+type LeaseManifestStatus struct {
+	State   string `json:"state"`
+	Message string `json:"message,omitempty"`
+}
+
+// HostnameVerification is published as a TXT record named <RecordPrefix>.<hostname> to prove
+// the ownership of a custom hostname
+type HostnameVerification struct {
+	RecordPrefix string `json:"record_prefix"`
+	Token        string `json:"token"`
+}
+
+// HostnameRulesUpdate lists the hostnames of running leases blocked by new hostname rules
+type HostnameRulesUpdate struct {
+	Violations []cltypes.HostnameRuleViolation `json:"violations"`
 }
@@ -1,10 +1,15 @@
 package manifest
 
-import "time"
+import (
+	"time"
+
+	"github.com/akash-network/provider/cluster/imagepolicy"
+)
 
 type ServiceConfig struct {
 	HTTPServicesRequireAtLeastOneHost bool
 	ManifestTimeout                   time.Duration
 	RPCQueryTimeout                   time.Duration
 	CachedResultMaxAge                time.Duration
+	ImagePolicy                       *imagepolicy.Policy
 }
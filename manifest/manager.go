@@ -440,6 +440,10 @@ func (m *manager) validateRequest(req manifestRequest) error {
 		return err
 	}
 
+	if err = m.config.ImagePolicy.CheckManifest(req.value.Manifest); err != nil {
+		return err
+	}
+
 	groupNames := make([]string, 0)
 
 	for _, lease := range m.localLeases {
@@ -22,6 +22,7 @@ import (
 	"github.com/akash-network/node/sdl"
 
 	"github.com/akash-network/provider/cluster"
+	"github.com/akash-network/provider/cluster/imagepolicy"
 	"github.com/akash-network/provider/event"
 	"github.com/akash-network/provider/session"
 
@@ -345,6 +346,63 @@ func TestManagerRequiresHostname(t *testing.T) {
 	}
 }
 
+func TestManagerEnforcesImagePolicy(t *testing.T) {
+	sdl2, err := sdl.ReadFile("../testdata/deployment/deployment-v2.yaml")
+	require.NoError(t, err)
+
+	sdlManifest, err := sdl2.Manifest()
+	require.NoError(t, err)
+
+	lid := testutil.LeaseID(t)
+	lid.GSeq = 0
+	did := lid.DeploymentID()
+	dgroups, err := sdl2.DeploymentGroups()
+	require.NoError(t, err)
+
+	// Tell the service that a lease has been won
+	dgroup := &dtypes.Group{
+		GroupID:   lid.GroupID(),
+		State:     0,
+		GroupSpec: *dgroups[0],
+	}
+
+	ev := event.LeaseWon{
+		LeaseID: lid,
+		Group:   dgroup,
+		Price:   sdk.NewDecCoin("uakt", sdk.NewInt(111)),
+	}
+
+	leases := []mtypes.Lease{{
+		LeaseID:   lid,
+		State:     mtypes.LeaseActive,
+		Price:     ev.Price,
+		CreatedAt: 0,
+	}}
+
+	cfg := ServiceConfig{
+		ImagePolicy: &imagepolicy.Policy{
+			DeniedRegistries: []string{"quay.io"},
+		},
+	}
+	s := serviceForManifestTest(t, cfg, sdl2, did, leases, lid.GetProvider(), false)
+
+	err = s.bus.Publish(ev)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second) // Wait for publish to do its thing
+
+	err = s.svc.Submit(context.Background(), did, sdlManifest)
+	require.ErrorIs(t, err, imagepolicy.ErrImageRejected)
+
+	s.cancel()
+	select {
+	case <-s.svc.lc.Done():
+
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for service shutdown")
+	}
+}
+
 func TestManagerAllowsUpdate(t *testing.T) {
 	sdl2, err := sdl.ReadFile("../testdata/deployment/deployment-v2.yaml")
 	require.NoError(t, err)
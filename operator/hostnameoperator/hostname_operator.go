@@ -29,8 +29,19 @@ import (
 	crd "github.com/akash-network/provider/pkg/apis/akash.network/v2beta1"
 )
 
+const (
+	flagTLSClusterIssuer        = "tls-cluster-issuer"
+	flagTLSWildcardSecret       = "tls-wildcard-secret"
+	flagTLSStatusInterval       = "tls-status-interval"
+	flagDeploymentIngressDomain = "deployment-ingress-domain"
+	flagIngressBackend          = "ingress-backend"
+	flagIngressClass            = "ingress-class"
+	flagIngressGateway          = "ingress-gateway"
+)
+
 var (
 	errExpectedResourceNotFound = fmt.Errorf("%w: resource not found", operatorcommon.ErrObservationStopped)
+	errInvalidConfig            = errors.New("hostname operator: invalid config")
 )
 
 type hostnameOperator struct {
@@ -43,6 +54,7 @@ type hostnameOperator struct {
 	log log.Logger
 
 	cfg    operatorcommon.OperatorConfig
+	tls    tlsConfig
 	server operatorcommon.OperatorHTTP
 
 	flagHostnamesData  operatorcommon.PrepareFlagFn
@@ -117,6 +129,13 @@ func (op *hostnameOperator) monitorUntilError(parentCtx context.Context) error {
 	prepareTicker := time.NewTicker(op.cfg.WebRefreshInterval)
 	defer prepareTicker.Stop()
 
+	var tlsStatusCh <-chan time.Time
+	if op.tls.enabled() && op.tls.statusInterval > 0 {
+		tlsStatusTicker := time.NewTicker(op.tls.statusInterval)
+		defer tlsStatusTicker.Stop()
+		tlsStatusCh = tlsStatusTicker.C
+	}
+
 	var exitError error
 loop:
 	for {
@@ -138,6 +157,8 @@ loop:
 			}
 		case <-pruneTicker.C:
 			op.prune()
+		case <-tlsStatusCh:
+			op.refreshPendingTLSStatus(ctx)
 		case <-prepareTicker.C:
 			if err := op.server.PrepareAll(); err != nil {
 				op.log.Error("preparing web data failed", "err", err)
@@ -168,12 +189,14 @@ func (op *hostnameOperator) prepareHostnamesData(pd operatorcommon.PreparedResul
 			ExternalPort uint32
 			ServiceName  string
 			LastUpdate   string
+			TLSState     string `json:",omitempty"`
 		}{
 			LeaseID:      entry.presentLease,
 			Namespace:    clusterutil.LeaseIDToNamespace(entry.presentLease),
 			ExternalPort: entry.presentExternalPort,
 			ServiceName:  entry.presentServiceName,
 			LastUpdate:   entry.lastChangeAt.String(),
+			TLSState:     entry.tlsState,
 		}
 		data[hostname] = preparedEntry
 	}
@@ -188,6 +211,39 @@ func (op *hostnameOperator) prepareHostnamesData(pd operatorcommon.PreparedResul
 	return nil
 }
 
+// refreshTLSStatus records the state of the certificate of the hostname, failing to do so
+// does not stop the operator as the check is retried periodically
+func (op *hostnameOperator) refreshTLSStatus(ctx context.Context, hostname string, entry *managedHostname) {
+	status, err := op.client.UpdateHostnameTLSStatus(ctx, entry.presentLease, hostname)
+	if err != nil {
+		op.log.Error("failed updating hostname tls status", "hostname", hostname, "err", err)
+		return
+	}
+
+	if status.State != entry.tlsState {
+		op.log.Info("hostname tls state", "hostname", hostname, "state", status.State, "message", status.Message)
+	}
+	entry.tlsState = status.State
+}
+
+func (op *hostnameOperator) refreshPendingTLSStatus(ctx context.Context) {
+	changed := false
+	for hostname, entry := range op.hostnames {
+		if !entry.tls || entry.tlsState == crd.ProviderHostTLSStateIssued {
+			continue
+		}
+
+		state := entry.tlsState
+		op.refreshTLSStatus(ctx, hostname, &entry)
+		op.hostnames[hostname] = entry
+		changed = changed || state != entry.tlsState
+	}
+
+	if changed {
+		op.flagHostnamesData()
+	}
+}
+
 func (op *hostnameOperator) prune() {
 	if op.leasesIgnored.Prune() {
 		op.flagIgnoreListData()
@@ -231,6 +287,19 @@ func (op *hostnameOperator) recordEventError(ev ctypes.HostnameResourceEvent, fa
 	op.flagIgnoreListData()
 }
 
+// recordEventState writes the outcome of applying the hostname to its status, failing to do so
+// does not stop the operator as the state is written again on the next event of the hostname
+func (op *hostnameOperator) recordEventState(ctx context.Context, ev ctypes.HostnameResourceEvent, failure error) {
+	state, message := crd.ProviderHostStateActive, ""
+	if failure != nil {
+		state, message = crd.ProviderHostStateFailed, failure.Error()
+	}
+
+	if err := op.client.UpdateHostnameState(ctx, ev.GetHostname(), state, message); err != nil {
+		op.log.Error("failed updating hostname state", "hostname", ev.GetHostname(), "err", err)
+	}
+}
+
 func (op *hostnameOperator) isEventIgnored(ev ctypes.HostnameResourceEvent) bool {
 	return op.leasesIgnored.IsFlagged(ev.GetLeaseID())
 }
@@ -248,6 +317,7 @@ func (op *hostnameOperator) applyEvent(ctx context.Context, ev ctypes.HostnameRe
 		}
 		err := op.applyAddOrUpdateEvent(ctx, ev)
 		op.recordEventError(ev, err)
+		op.recordEventState(ctx, ev, err)
 		return err
 	default:
 		return fmt.Errorf("%w: unknown event type %v", operatorcommon.ErrObservationStopped, ev.GetEventType())
@@ -373,6 +443,9 @@ func (op *hostnameOperator) applyAddOrUpdateEvent(ctx context.Context, ev ctypes
 	}
 
 	directive := buildDirective(ev, selectedExpose)
+	if op.tls.enabled() {
+		directive.TLS = op.tls.forHostname(ev.GetHostname())
+	}
 
 	if isSameLease {
 		shouldConnect := false
@@ -384,6 +457,9 @@ func (op *hostnameOperator) applyAddOrUpdateEvent(ctx context.Context, ev ctypes
 		} else if entry.presentExternalPort != ev.GetExternalPort() || entry.presentServiceName != ev.GetServiceName() {
 			shouldConnect = true
 			op.log.Debug("hostname target has changed, applying")
+		} else if entry.tls != directive.TLS.Enabled() {
+			shouldConnect = true
+			op.log.Debug("hostname tls has changed, applying")
 		}
 
 		if shouldConnect {
@@ -408,6 +484,12 @@ func (op *hostnameOperator) applyAddOrUpdateEvent(ctx context.Context, ev ctypes
 		entry.presentLease = leaseID
 		entry.lastEvent = ev
 		entry.lastChangeAt = time.Now()
+		entry.tls = directive.TLS.Enabled()
+		if entry.tls {
+			op.refreshTLSStatus(ctx, ev.GetHostname(), &entry)
+		} else {
+			entry.tlsState = ""
+		}
 		op.hostnames[ev.GetHostname()] = entry
 		op.flagHostnamesData()
 	}
@@ -415,7 +497,7 @@ func (op *hostnameOperator) applyAddOrUpdateEvent(ctx context.Context, ev ctypes
 	return err
 }
 
-func newHostnameOperator(logger log.Logger, client cluster.Client, config operatorcommon.OperatorConfig, ilc operatorcommon.IgnoreListConfig) (*hostnameOperator, error) {
+func newHostnameOperator(logger log.Logger, client cluster.Client, config operatorcommon.OperatorConfig, ilc operatorcommon.IgnoreListConfig, tls tlsConfig) (*hostnameOperator, error) {
 	opHTTP, err := operatorcommon.NewOperatorHTTP()
 	if err != nil {
 		return nil, err
@@ -425,6 +507,7 @@ func newHostnameOperator(logger log.Logger, client cluster.Client, config operat
 		client:        client,
 		log:           logger,
 		cfg:           config,
+		tls:           tls,
 		server:        opHTTP,
 		leasesIgnored: operatorcommon.NewIgnoreList(ilc),
 	}
@@ -445,12 +528,30 @@ func doHostnameOperator(cmd *cobra.Command) error {
 	logger := operatorcommon.OpenLogger().With("op", "hostname")
 	logger.Info("HTTP listening", "address", listenAddr)
 
-	client, err := clusterClient.NewClient(cmd.Context(), logger, ns, configPath)
+	tls := tlsConfig{
+		clusterIssuer:  viper.GetString(flagTLSClusterIssuer),
+		wildcardSecret: viper.GetString(flagTLSWildcardSecret),
+		ingressDomain:  viper.GetString(flagDeploymentIngressDomain),
+		statusInterval: viper.GetDuration(flagTLSStatusInterval),
+	}
+
+	ingress := clusterClient.IngressConfig{
+		Backend:   viper.GetString(flagIngressBackend),
+		ClassName: viper.GetString(flagIngressClass),
+		Gateway:   viper.GetString(flagIngressGateway),
+	}
+
+	// TLS of HTTPRoutes terminates on the listeners of the gateway
+	if ingress.Backend == clusterClient.IngressBackendGatewayAPI && tls.enabled() {
+		return fmt.Errorf("%w: TLS flags are not supported with the %s ingress backend", errInvalidConfig, clusterClient.IngressBackendGatewayAPI)
+	}
+
+	client, err := clusterClient.NewClient(cmd.Context(), logger, ns, configPath, clusterClient.WithIngressConfig(ingress))
 	if err != nil {
 		return err
 	}
 
-	op, err := newHostnameOperator(logger, client, config, operatorcommon.IgnoreListConfigFromViper())
+	op, err := newHostnameOperator(logger, client, config, operatorcommon.IgnoreListConfigFromViper(), tls)
 	if err != nil {
 		return err
 	}
@@ -487,7 +588,7 @@ func doHostnameOperator(cmd *cobra.Command) error {
 func Cmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:          "hostname-operator",
-		Short:        "kubernetes operator interfacing with k8s nginx ingress",
+		Short:        "kubernetes operator routing lease hostnames through the ingress controller",
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return doHostnameOperator(cmd)
@@ -500,5 +601,41 @@ func Cmd() *cobra.Command {
 		panic(err)
 	}
 
+	cmd.Flags().String(flagTLSClusterIssuer, "", "cert-manager ClusterIssuer requesting certificates for hostname ingresses. TLS is disabled when neither this nor the wildcard secret is set")
+	if err := viper.BindPFlag(flagTLSClusterIssuer, cmd.Flags().Lookup(flagTLSClusterIssuer)); err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().String(flagTLSWildcardSecret, "", "namespace/name of a TLS secret with the wildcard certificate of the deployment ingress domain")
+	if err := viper.BindPFlag(flagTLSWildcardSecret, cmd.Flags().Lookup(flagTLSWildcardSecret)); err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().String(flagDeploymentIngressDomain, "", "domain covered by the wildcard certificate")
+	if err := viper.BindPFlag(flagDeploymentIngressDomain, cmd.Flags().Lookup(flagDeploymentIngressDomain)); err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().String(flagIngressBackend, clusterClient.IngressBackendNginx, fmt.Sprintf("ingress controller routing hostnames: %s, %s, %s or %s",
+		clusterClient.IngressBackendNginx, clusterClient.IngressBackendHAProxy, clusterClient.IngressBackendTraefik, clusterClient.IngressBackendGatewayAPI))
+	if err := viper.BindPFlag(flagIngressBackend, cmd.Flags().Lookup(flagIngressBackend)); err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().String(flagIngressClass, clusterClient.DefaultIngressConfig().ClassName, "ingress class of the Ingress and IngressRoute resources")
+	if err := viper.BindPFlag(flagIngressClass, cmd.Flags().Lookup(flagIngressClass)); err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().String(flagIngressGateway, "", fmt.Sprintf("namespace/name of the Gateway HTTPRoutes attach to, with the %s ingress backend", clusterClient.IngressBackendGatewayAPI))
+	if err := viper.BindPFlag(flagIngressGateway, cmd.Flags().Lookup(flagIngressGateway)); err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().Duration(flagTLSStatusInterval, time.Minute, "how often certificates which are not issued yet are checked")
+	if err := viper.BindPFlag(flagTLSStatusInterval, cmd.Flags().Lookup(flagTLSStatusInterval)); err != nil {
+		panic(err)
+	}
+
 	return cmd
 }
@@ -119,6 +119,7 @@ func makeHostnameOperatorScaffold(t *testing.T) *hostnameOperatorScaffold {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*20)
 
 	client := &mocks.Client{}
+	client.On("UpdateHostnameState", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	scaffold := &hostnameOperatorScaffold{
 		ctx:    ctx,
 		cancel: cancel,
@@ -138,7 +139,7 @@ func makeHostnameOperatorScaffold(t *testing.T) *hostnameOperatorScaffold {
 			FailureLimit: 3,
 			EntryLimit:   19,
 			AgeLimit:     time.Hour,
-		})
+		}, tlsConfig{})
 	require.NoError(t, err)
 
 	scaffold.op = op
@@ -343,6 +344,7 @@ func TestHostnameOperatorApplyAddWithError(t *testing.T) {
 		require.Error(t, err)
 		require.ErrorIs(t, err, io.EOF)
 	}
+	s.client.AssertCalled(t, "UpdateHostnameState", mock.Anything, hostname, crd.ProviderHostStateFailed, io.EOF.Error())
 
 	_, exists := s.op.hostnames[hostname]
 	require.False(t, exists) // not added
@@ -438,6 +440,7 @@ func TestHostnameOperatorApplyAdd(t *testing.T) {
 	managedValue, exists := s.op.hostnames[hostname]
 	require.True(t, exists) // not added
 	require.Equal(t, managedValue.presentLease, leaseID)
+	s.client.AssertCalled(t, "UpdateHostnameState", mock.Anything, hostname, crd.ProviderHostStateActive, "")
 
 	require.NoError(t, s.op.server.PrepareAll())
 
@@ -616,3 +619,67 @@ func TestHostnameOperatorApplyUpdate(t *testing.T) {
 	require.True(t, exists) // not added
 	require.Equal(t, managedValue.presentLease, secondLeaseID)
 }
+
+func TestTLSConfigForHostname(t *testing.T) {
+	cfg := tlsConfig{
+		clusterIssuer:  "letsencrypt",
+		wildcardSecret: "ingress/wildcard",
+		ingressDomain:  "ingress.example.com",
+	}
+
+	require.Equal(t, cluster.HostnameTLS{WildcardSecret: "ingress/wildcard"}, cfg.forHostname("abcd.ingress.example.com"))
+	require.Equal(t, cluster.HostnameTLS{ClusterIssuer: "letsencrypt"}, cfg.forHostname("a.b.ingress.example.com"))
+	require.Equal(t, cluster.HostnameTLS{ClusterIssuer: "letsencrypt"}, cfg.forHostname("tenant.com"))
+
+	cfg.clusterIssuer = ""
+	require.False(t, cfg.forHostname("tenant.com").Enabled())
+}
+
+func TestHostnameOperatorApplyAddWithTLS(t *testing.T) {
+	s := makeHostnameOperatorScaffold(t)
+	require.NotNil(t, s)
+	defer s.cancel()
+	s.op.tls = tlsConfig{clusterIssuer: "letsencrypt"}
+
+	leaseID := testutil.LeaseID(t)
+	ev := testHostnameResourceEv{
+		leaseID:      leaseID,
+		hostname:     "tenant.com",
+		eventType:    cluster.ProviderResourceAdd,
+		serviceName:  "the-service",
+		externalPort: 80,
+	}
+
+	serviceExpose := crd.ManifestServiceExpose{
+		Port:         80,
+		ExternalPort: 80,
+		Global:       true,
+	}
+	s.client.On("GetManifestGroup", mock.Anything, leaseID).Return(true, crd.ManifestGroup{
+		Services: []crd.ManifestService{{
+			Name:   "the-service",
+			Count:  1,
+			Expose: []crd.ManifestServiceExpose{serviceExpose},
+		}},
+	}, nil)
+
+	directive := buildDirective(ev, serviceExpose)
+	directive.TLS = cluster.HostnameTLS{ClusterIssuer: "letsencrypt"}
+	s.client.On("ConnectHostnameToDeployment", mock.Anything, directive).Return(nil)
+	s.client.On("UpdateHostnameTLSStatus", mock.Anything, leaseID, "tenant.com").Return(crd.ProviderHostTLSStatus{
+		State: crd.ProviderHostTLSStatePending,
+	}, nil).Once()
+
+	require.NoError(t, s.op.applyEvent(s.ctx, ev))
+	require.Equal(t, crd.ProviderHostTLSStatePending, s.op.hostnames["tenant.com"].tlsState)
+
+	s.client.On("UpdateHostnameTLSStatus", mock.Anything, leaseID, "tenant.com").Return(crd.ProviderHostTLSStatus{
+		State: crd.ProviderHostTLSStateIssued,
+	}, nil).Once()
+	s.op.refreshPendingTLSStatus(s.ctx)
+	require.Equal(t, crd.ProviderHostTLSStateIssued, s.op.hostnames["tenant.com"].tlsState)
+
+	// issued certificates are not checked again
+	s.op.refreshPendingTLSStatus(s.ctx)
+	s.client.AssertNumberOfCalls(t, "UpdateHostnameTLSStatus", 2)
+}
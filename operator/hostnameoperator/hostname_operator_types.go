@@ -1,6 +1,7 @@
 package hostnameoperator
 
 import (
+	"strings"
 	"time"
 
 	mtypes "github.com/akash-network/node/x/market/types/v1beta2"
@@ -15,4 +16,35 @@ type managedHostname struct {
 	presentServiceName  string
 	presentExternalPort uint32
 	lastChangeAt        time.Time
+
+	tls      bool
+	tlsState string
+}
+
+// tlsConfig selects how the ingresses of hostnames terminate TLS
+type tlsConfig struct {
+	// cert-manager ClusterIssuer requesting certificates for hostnames
+	clusterIssuer string
+	// namespace/name of the provider wildcard certificate for hostnames under ingressDomain
+	wildcardSecret string
+	ingressDomain  string
+	// how often certificates which are not issued yet are checked
+	statusInterval time.Duration
+}
+
+func (cfg tlsConfig) enabled() bool {
+	return cfg.clusterIssuer != "" || cfg.wildcardSecret != ""
+}
+
+// forHostname prefers the wildcard certificate for hostnames it covers, other hostnames
+// get a certificate from the cluster issuer
+func (cfg tlsConfig) forHostname(hostname string) ctypes.HostnameTLS {
+	if cfg.wildcardSecret != "" && cfg.ingressDomain != "" && strings.HasSuffix(hostname, "."+cfg.ingressDomain) {
+		// a wildcard certificate covers a single label only
+		if !strings.Contains(strings.TrimSuffix(hostname, "."+cfg.ingressDomain), ".") {
+			return ctypes.HostnameTLS{WildcardSecret: cfg.wildcardSecret}
+		}
+	}
+
+	return ctypes.HostnameTLS{ClusterIssuer: cfg.clusterIssuer}
 }
@@ -26,6 +26,8 @@ import (
 
 	"github.com/akash-network/provider/cluster"
 	clusterClient "github.com/akash-network/provider/cluster/kube"
+	"github.com/akash-network/provider/cluster/kube/ipbackend"
+	"github.com/akash-network/provider/cluster/kube/ippool"
 	"github.com/akash-network/provider/cluster/kube/metallb"
 	"github.com/akash-network/provider/cluster/types/v1beta2"
 	ctypes "github.com/akash-network/provider/cluster/types/v1beta2"
@@ -33,6 +35,7 @@ import (
 	providerflags "github.com/akash-network/provider/cmd/provider-services/cmd/flags"
 	ipoptypes "github.com/akash-network/provider/operator/ipoperator/types"
 	"github.com/akash-network/provider/operator/operatorcommon"
+	crd "github.com/akash-network/provider/pkg/apis/akash.network/v2beta1"
 )
 
 const (
@@ -49,11 +52,14 @@ type ipOperator struct {
 	flagIgnoredLeases operatorcommon.PrepareFlagFn
 	flagUsage         operatorcommon.PrepareFlagFn
 	cfg               operatorcommon.OperatorConfig
+	quotas            cluster.OwnerQuotas
 
-	available uint
-	inUse     uint
+	available     uint
+	inUse         uint
+	availableIPv6 uint
+	inUseIPv6     uint
 
-	mllbc metallb.Client
+	ipbc ipbackend.Client
 
 	barrier *barrier
 
@@ -67,7 +73,7 @@ func (op *ipOperator) monitorUntilError(ctx context.Context) error {
 
 	op.state = make(map[string]managedIP)
 	op.log.Info("fetching existing IP passthroughs")
-	entries, err := op.mllbc.GetIPPassthroughs(ctx)
+	entries, err := op.ipbc.GetIPPassthroughs(ctx)
 	if err != nil {
 		return err
 	}
@@ -85,6 +91,7 @@ func (op *ipOperator) monitorUntilError(ctx context.Context) error {
 		}
 	}
 	op.flagState()
+	op.flagUsage()
 
 	// Get the present counts before starting
 	err = op.updateCounts(ctx)
@@ -99,7 +106,7 @@ func (op *ipOperator) monitorUntilError(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	poolChanges, err := op.mllbc.DetectPoolChanges(ctx)
+	poolChanges, err := op.ipbc.DetectPoolChanges(ctx)
 	if err != nil {
 		return err
 	}
@@ -196,7 +203,12 @@ func (op *ipOperator) updateCounts(parentCtx context.Context) error {
 	// This is tried in a loop, don't wait for a long period of time for a response
 	ctx, cancel := context.WithTimeout(parentCtx, time.Minute)
 	defer cancel()
-	inUse, available, err := op.mllbc.GetIPAddressUsage(ctx)
+	inUse, available, err := op.ipbc.GetIPAddressUsage(ctx)
+	if err != nil {
+		return err
+	}
+
+	inUseIPv6, availableIPv6, err := op.ipbc.GetIPv6AddressUsage(ctx)
 	if err != nil {
 		return err
 	}
@@ -205,9 +217,12 @@ func (op *ipOperator) updateCounts(parentCtx context.Context) error {
 	defer op.dataLock.Unlock()
 	op.inUse = inUse
 	op.available = available
+	op.inUseIPv6 = inUseIPv6
+	op.availableIPv6 = availableIPv6
 
 	op.flagUsage()
-	op.log.Info("ip address inventory", "in-use", op.inUse, "available", op.available)
+	op.log.Info("ip address inventory", "in-use", op.inUse, "available", op.available,
+		"in-use-ipv6", op.inUseIPv6, "available-ipv6", op.availableIPv6)
 	return nil
 }
 
@@ -228,6 +243,21 @@ func (op *ipOperator) recordEventError(ev v1beta2.IPResourceEvent, failure error
 	op.flagIgnoredLeases()
 }
 
+// recordEventState writes the outcome of applying the leased IP to its status, failing to do so
+// does not stop the operator as the state is written again on the next event of the leased IP
+func (op *ipOperator) recordEventState(ctx context.Context, ev v1beta2.IPResourceEvent, failure error) {
+	state, message := crd.ProviderLeasedIPStateActive, ""
+	if failure != nil {
+		state, message = crd.ProviderLeasedIPStateFailed, failure.Error()
+	}
+
+	err := op.client.UpdateIPState(ctx, ev.GetSharingKey(), ev.GetProtocol(), ev.GetExternalPort(), state, message)
+	if err != nil {
+		op.log.Error("failed updating leased ip state", "lease", ev.GetLeaseID(), "sharing-key", ev.GetSharingKey(),
+			"external-port", ev.GetExternalPort(), "err", err)
+	}
+}
+
 func (op *ipOperator) applyEvent(ctx context.Context, ev v1beta2.IPResourceEvent) error {
 	op.log.Debug("apply event", "event-type", ev.GetEventType(), "lease", ev.GetLeaseID())
 	switch ev.GetEventType() {
@@ -241,6 +271,7 @@ func (op *ipOperator) applyEvent(ctx context.Context, ev v1beta2.IPResourceEvent
 		}
 		err := op.applyAddOrUpdateEvent(ctx, ev)
 		op.recordEventError(ev, err)
+		op.recordEventState(ctx, ev, err)
 		return err
 	default:
 		return fmt.Errorf("%w: unknown event type %v", operatorcommon.ErrObservationStopped, ev.GetEventType())
@@ -256,12 +287,13 @@ func (op *ipOperator) applyDeleteEvent(parentCtx context.Context, ev v1beta2.IPR
 	// for services that allocate an IP but that do not belong to at least 1 CRD
 	ctx, cancel := context.WithTimeout(parentCtx, time.Minute*5)
 	defer cancel()
-	err := op.mllbc.PurgeIPPassthrough(ctx, directive)
+	err := op.ipbc.PurgeIPPassthrough(ctx, directive)
 
 	if err == nil {
 		uid := getStateKey(ev.GetLeaseID(), ev.GetSharingKey(), ev.GetExternalPort())
 		delete(op.state, uid)
 		op.flagState()
+		op.flagUsage()
 	}
 
 	return err
@@ -323,7 +355,7 @@ func (op *ipOperator) applyAddOrUpdateEvent(ctx context.Context, ev v1beta2.IPRe
 
 		if shouldConnect {
 			op.log.Debug("Updating ip passthrough", "lease", leaseID)
-			err = op.mllbc.CreateIPPassthrough(ctx, directive)
+			err = op.ipbc.CreateIPPassthrough(ctx, directive)
 		}
 	} else {
 
@@ -341,13 +373,13 @@ func (op *ipOperator) applyAddOrUpdateEvent(ctx context.Context, ev v1beta2.IPRe
 			Protocol:     entry.presentProtocol,
 		}
 		// Delete the entry & recreate it with the new lease associated  to it
-		err = op.mllbc.PurgeIPPassthrough(ctx, deleteDirective)
+		err = op.ipbc.PurgeIPPassthrough(ctx, deleteDirective)
 		if err != nil {
 			return err
 		}
 		// Remove the current value from the state
 		delete(op.state, uid)
-		err = op.mllbc.CreateIPPassthrough(ctx, directive)
+		err = op.ipbc.CreateIPPassthrough(ctx, directive)
 	}
 
 	if err != nil {
@@ -365,6 +397,7 @@ func (op *ipOperator) applyAddOrUpdateEvent(ctx context.Context, ev v1beta2.IPRe
 	entry.lastChangedAt = time.Now()
 	op.state[uid] = entry
 	op.flagState()
+	op.flagUsage()
 
 	op.log.Info("update complete", "lease", leaseID)
 
@@ -375,8 +408,28 @@ func (op *ipOperator) prepareUsage(pd operatorcommon.PreparedResult) error {
 	op.dataLock.Lock()
 	defer op.dataLock.Unlock()
 	value := ipoptypes.IPAddressUsage{
-		Available: op.available,
-		InUse:     op.inUse,
+		Available:     op.available,
+		InUse:         op.inUse,
+		AvailableIPv6: op.availableIPv6,
+		InUseIPv6:     op.inUseIPv6,
+		Owners:        make(map[string]ipoptypes.OwnerIPUsage),
+	}
+
+	// an address is shared by the services of a lease with the same sharing key
+	addresses := make(map[string]map[string]struct{})
+	for _, entry := range op.state {
+		owner := entry.presentLease.Owner
+		if addresses[owner] == nil {
+			addresses[owner] = make(map[string]struct{})
+		}
+		addresses[owner][entry.presentLease.String()+"/"+entry.presentSharingKey] = struct{}{}
+	}
+
+	for owner, ownerAddresses := range addresses {
+		value.Owners[owner] = ipoptypes.OwnerIPUsage{
+			InUse: uint(len(ownerAddresses)),
+			Limit: op.quotas.ForOwner(owner).LeasedIPs,
+		}
 	}
 
 	buf := &bytes.Buffer{}
@@ -451,7 +504,7 @@ func handleHTTPError(op *ipOperator, rw http.ResponseWriter, req *http.Request,
 	}
 }
 
-func newIPOperator(logger log.Logger, client cluster.Client, cfg operatorcommon.OperatorConfig, ilc operatorcommon.IgnoreListConfig, mllbc metallb.Client) (*ipOperator, error) {
+func newIPOperator(logger log.Logger, client cluster.Client, cfg operatorcommon.OperatorConfig, ilc operatorcommon.IgnoreListConfig, quotas cluster.OwnerQuotas, ipbc ipbackend.Client) (*ipOperator, error) {
 	opHTTP, err := operatorcommon.NewOperatorHTTP()
 	if err != nil {
 		return nil, err
@@ -462,10 +515,11 @@ func newIPOperator(logger log.Logger, client cluster.Client, cfg operatorcommon.
 		log:           logger,
 		server:        opHTTP,
 		leasesIgnored: operatorcommon.NewIgnoreList(ilc),
-		mllbc:         mllbc,
+		ipbc:          ipbc,
 		dataLock:      &sync.Mutex{},
 		barrier:       &barrier{},
 		cfg:           cfg,
+		quotas:        quotas,
 	}
 
 	retval.server.GetRouter().Use(func(next http.Handler) http.Handler {
@@ -539,7 +593,7 @@ func handleIPLeaseStatusGet(op *ipOperator, rw http.ResponseWriter, req *http.Re
 		Provider: op.cfg.ProviderAddress,
 	}
 
-	ipStatus, err := op.mllbc.GetIPAddressStatusForLease(req.Context(), leaseID)
+	ipStatus, err := op.ipbc.GetIPAddressStatusForLease(req.Context(), leaseID)
 	if err != nil {
 		op.log.Error("Could not get IP address status", "lease-id", leaseID, "error", err)
 		handleHTTPError(op, rw, req, err, http.StatusInternalServerError)
@@ -564,6 +618,7 @@ func handleIPLeaseStatusGet(op *ipOperator, rw http.ResponseWriter, req *http.Re
 			ExternalPort: v.GetExternalPort(),
 			ServiceName:  v.GetServiceName(),
 			IP:           v.GetIP(),
+			IPv6:         v.GetIPv6(),
 			Protocol:     v.GetProtocol().ToString(),
 		}
 	}
@@ -578,6 +633,7 @@ func doIPOperator(cmd *cobra.Command) error {
 	ns := viper.GetString(providerflags.FlagK8sManifestNS)
 	listenAddr := viper.GetString(providerflags.FlagListenAddress)
 	poolName := viper.GetString(flagMetalLbPoolName)
+	poolNameIPv6 := viper.GetString(flagMetalLbPoolNameIPv6)
 	logger := operatorcommon.OpenLogger().With("operator", "ip")
 
 	opcfg := operatorcommon.GetOperatorConfigFromViper()
@@ -591,20 +647,47 @@ func doIPOperator(cmd *cobra.Command) error {
 		return err
 	}
 
-	metalLbEndpoint, err := providerflags.GetServiceEndpointFlagValue(logger, serviceMetalLb)
-	if err != nil {
-		return err
-	}
+	var ipbc ipbackend.Client
+	switch backend := viper.GetString(flagIPBackend); backend {
+	case ipbackend.BackendMetalLB:
+		metalLbEndpoint, err := providerflags.GetServiceEndpointFlagValue(logger, serviceMetalLb)
+		if err != nil {
+			return err
+		}
 
-	mllbc, err := metallb.NewClient(configPath, logger, poolName, metalLbEndpoint)
-	if err != nil {
-		return err
+		ipbc, err = metallb.NewClient(configPath, logger, poolName, poolNameIPv6, metalLbEndpoint)
+		if err != nil {
+			return err
+		}
+	case ipbackend.BackendStaticPool:
+		ipbc, err = ippool.NewClient(configPath, logger, ippool.Config{
+			Pools:      viper.GetStringSlice(flagIPPool),
+			Assignment: viper.GetString(flagIPPoolAssignment),
+			Namespace:  ns,
+		})
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: unknown ip backend %q", errInvalidConfig, backend)
 	}
 
-	logger.Info("clients", "kube", client, "metallb", mllbc)
+	logger.Info("clients", "kube", client, "ip-backend", ipbc)
 	logger.Info("HTTP listening", "address", listenAddr)
 
-	op, err := newIPOperator(logger, client, opcfg, operatorcommon.IgnoreListConfigFromViper(), mllbc)
+	quotas := cluster.OwnerQuotas{
+		Default: cluster.OwnerQuota{
+			LeasedIPs: viper.GetUint(providerflags.FlagOwnerLeasedIPLimit),
+		},
+	}
+	if quotasPath := viper.GetString(providerflags.FlagOwnerQuotas); len(quotasPath) != 0 {
+		quotas.Owners, err = cluster.ReadOwnerQuotas(quotasPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	op, err := newIPOperator(logger, client, opcfg, operatorcommon.IgnoreListConfigFromViper(), quotas, ipbc)
 	if err != nil {
 		return err
 	}
@@ -662,6 +745,6 @@ func (op *ipOperator) run(parentCtx context.Context) error {
 		}
 	}
 
-	op.mllbc.Stop()
+	op.ipbc.Stop()
 	return parentCtx.Err()
 }
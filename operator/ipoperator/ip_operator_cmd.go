@@ -1,21 +1,32 @@
 package ipoperator
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/akash-network/provider/cluster/kube/ipbackend"
+	"github.com/akash-network/provider/cluster/kube/ippool"
 	providerflags "github.com/akash-network/provider/cmd/provider-services/cmd/flags"
 	"github.com/akash-network/provider/operator/operatorcommon"
 )
 
 const (
-	flagMetalLbPoolName = "metal-lb-pool"
+	flagMetalLbPoolName     = "metal-lb-pool"
+	flagMetalLbPoolNameIPv6 = "metal-lb-pool-ipv6"
+	flagIPBackend           = "ip-backend"
+	flagIPPool              = "ip-pool"
+	flagIPPoolAssignment    = "ip-pool-assignment"
 )
 
+var errInvalidConfig = errors.New("ip operator: invalid config")
+
 func Cmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:          "ip-operator",
-		Short:        "kubernetes operator interfacing with Metal LB",
+		Short:        "kubernetes operator assigning leased IPs through Metal LB or a static address pool",
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return doIPOperator(cmd)
@@ -39,5 +50,43 @@ func Cmd() *cobra.Command {
 		panic(err)
 	}
 
+	cmd.Flags().String(flagMetalLbPoolNameIPv6, "", "metal LB ip address pool of the IPv6 addresses, leased IPs are dual-stack when set")
+	err = viper.BindPFlag(flagMetalLbPoolNameIPv6, cmd.Flags().Lookup(flagMetalLbPoolNameIPv6))
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().String(flagIPBackend, ipbackend.BackendMetalLB, fmt.Sprintf("backend assigning the leased IPs: %s or %s",
+		ipbackend.BackendMetalLB, ipbackend.BackendStaticPool))
+	err = viper.BindPFlag(flagIPBackend, cmd.Flags().Lookup(flagIPBackend))
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().StringSlice(flagIPPool, nil, fmt.Sprintf("IPv4 and IPv6 CIDRs the %s backend allocates addresses from, leased IPs are dual-stack when both families are given", ipbackend.BackendStaticPool))
+	err = viper.BindPFlag(flagIPPool, cmd.Flags().Lookup(flagIPPool))
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().String(flagIPPoolAssignment, ippool.AssignmentLoadBalancerIP, fmt.Sprintf("how the %s backend assigns addresses to services: %s, %s or %s",
+		ipbackend.BackendStaticPool, ippool.AssignmentLoadBalancerIP, ippool.AssignmentKubeVIP, ippool.AssignmentCilium))
+	err = viper.BindPFlag(flagIPPoolAssignment, cmd.Flags().Lookup(flagIPPoolAssignment))
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().Uint(providerflags.FlagOwnerLeasedIPLimit, 0, "maximum number of leased IPs the leases of one owner may hold, reported on the usage endpoint. 0 is unlimited")
+	err = viper.BindPFlag(providerflags.FlagOwnerLeasedIPLimit, cmd.Flags().Lookup(providerflags.FlagOwnerLeasedIPLimit))
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().String(providerflags.FlagOwnerQuotas, "", "path to the YAML file with the leased IP limits overridden per owner address")
+	err = viper.BindPFlag(providerflags.FlagOwnerQuotas, cmd.Flags().Lookup(providerflags.FlagOwnerQuotas))
+	if err != nil {
+		panic(err)
+	}
+
 	return cmd
 }
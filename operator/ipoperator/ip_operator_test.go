@@ -13,9 +13,11 @@ import (
 	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
+	"github.com/akash-network/provider/cluster"
 	"github.com/akash-network/provider/cluster/mocks"
 	"github.com/akash-network/provider/cluster/types/v1beta2"
 	"github.com/akash-network/provider/operator/operatorcommon"
+	crd "github.com/akash-network/provider/pkg/apis/akash.network/v2beta1"
 )
 
 type ipOperatorScaffold struct {
@@ -35,6 +37,7 @@ func runIPOperator(t *testing.T, run bool, prerun, fn func(ctx context.Context,
 
 	l := testutil.Logger(t)
 	client := &mocks.Client{}
+	client.On("UpdateIPState", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mllbc := &mocks.MetalLBClient{}
 	mllbc.On("Stop")
 
@@ -55,7 +58,7 @@ func runIPOperator(t *testing.T, run bool, prerun, fn func(ctx context.Context,
 		RetryDelay:         time.Second,
 		ProviderAddress:    providerAddr.String(),
 	}
-	op, err := newIPOperator(l, client, opcfg, ilc, mllbc)
+	op, err := newIPOperator(l, client, opcfg, ilc, cluster.OwnerQuotas{}, mllbc)
 
 	require.NoError(t, err)
 	require.NotNil(t, op)
@@ -153,6 +156,7 @@ func TestIPOperatorAddEvent(t *testing.T) {
 			eventType:    v1beta2.ProviderResourceAdd,
 		})
 		require.NoError(t, err)
+		s.clusterMock.AssertCalled(t, "UpdateIPState", mock.Anything, "akey", manifest.TCP, uint32(10001), crd.ProviderLeasedIPStateActive, "")
 	})
 }
 
@@ -247,6 +251,7 @@ func TestIPOperatorGivesUpOnErrors(t *testing.T) {
 			err := s.op.applyEvent(ctx, fakeEvent)
 			require.ErrorIs(t, err, fakeError)
 		}
+		s.clusterMock.AssertCalled(t, "UpdateIPState", mock.Anything, "akey", manifest.TCP, uint32(10001), crd.ProviderLeasedIPStateFailed, fakeError.Error())
 
 		err := s.op.applyEvent(ctx, fakeEvent)
 		require.NoError(t, err) // Nothing happens because this is ignored
@@ -259,6 +264,7 @@ func TestIPOperatorRun(t *testing.T) {
 	runIPOperator(t, true, func(ctx context.Context, s ipOperatorScaffold) {
 		s.metalMock.On("GetIPPassthroughs", mock.Anything).Return(nil, nil)
 		s.metalMock.On("GetIPAddressUsage", mock.Anything).Return(uint(0), uint(3), nil)
+		s.metalMock.On("GetIPv6AddressUsage", mock.Anything).Return(uint(0), uint(0), nil)
 		events := make(chan v1beta2.IPResourceEvent)
 		go func() {
 			select {
@@ -3,4 +3,15 @@ package types
 type IPAddressUsage struct {
 	Available uint
 	InUse     uint
+	// AvailableIPv6 and InUseIPv6 are zero unless leased IPs are dual-stack
+	AvailableIPv6 uint
+	InUseIPv6     uint
+	// Owners is the usage of the owners holding leased IPs
+	Owners map[string]OwnerIPUsage `json:",omitempty"`
+}
+
+type OwnerIPUsage struct {
+	InUse uint
+	// Limit is zero when the owner is unlimited
+	Limit uint
 }
@@ -5,5 +5,6 @@ type LeaseIPStatus struct {
 	ExternalPort uint32
 	ServiceName  string
 	IP           string
+	IPv6         string
 	Protocol     string
 }
@@ -36,6 +36,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ProviderHost{},
 		&ProviderHostList{})
 
+	scheme.AddKnownTypes(SchemeGroupVersion,
+		&ProviderIPAllocation{},
+		&ProviderIPAllocationList{})
+
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&ProviderLeasedIP{},
 		&ProviderLeasedIPList{})
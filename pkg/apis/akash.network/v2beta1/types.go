@@ -30,6 +30,15 @@ type Manifest struct {
 	Status ManifestStatus `json:"status,omitempty"`
 }
 
+const (
+	// ManifestStateRolledBack is set when an update of the manifest failed and the previous group was deployed again
+	ManifestStateRolledBack = "rolled-back"
+	// ManifestStateFailed is set when deploying the manifest failed and could not be reverted
+	ManifestStateFailed = "failed"
+	// ManifestStateClosed is set when the lease is being closed because its deployment never became healthy
+	ManifestStateClosed = "closed"
+)
+
 // ManifestStatus stores state and message of manifest
 type ManifestStatus struct {
 	State   string `json:"state,omitempty"`
@@ -40,6 +49,8 @@ type ManifestStatus struct {
 type ManifestSpec struct {
 	LeaseID LeaseID       `json:"lease_id"`
 	Group   ManifestGroup `json:"group"`
+	// LastGoodGroup is the group last found healthy, which a failed update is rolled back to
+	LastGoodGroup *ManifestGroup `json:"last_good_group,omitempty"`
 }
 
 // Deployment returns the cluster.Deployment that the saved manifest represents.
@@ -49,7 +60,7 @@ func (m Manifest) Deployment() (ctypes.Deployment, error) {
 		return nil, err
 	}
 
-	group, err := m.Spec.Group.toAkash()
+	group, err := m.Spec.Group.ToAkash()
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +82,7 @@ func (d deployment) ManifestGroup() maniv2beta1.Group {
 
 // NewManifest creates new manifest with provided details. Returns error in case of failure.
 func NewManifest(ns string, lid mtypes.LeaseID, mgroup *maniv2beta1.Group) (*Manifest, error) {
-	group, err := manifestGroupFromAkash(mgroup)
+	group, err := ManifestGroupFromAkash(mgroup)
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +158,7 @@ type ManifestGroup struct {
 }
 
 // ToAkash returns akash group details formatted from manifest group
-func (m ManifestGroup) toAkash() (maniv2beta1.Group, error) {
+func (m ManifestGroup) ToAkash() (maniv2beta1.Group, error) {
 	am := maniv2beta1.Group{
 		Name:     m.Name,
 		Services: make([]maniv2beta1.Service, 0, len(m.Services)),
@@ -165,7 +176,7 @@ func (m ManifestGroup) toAkash() (maniv2beta1.Group, error) {
 }
 
 // ManifestGroupFromAkash returns manifest group instance from akash group
-func manifestGroupFromAkash(m *maniv2beta1.Group) (ManifestGroup, error) {
+func ManifestGroupFromAkash(m *maniv2beta1.Group) (ManifestGroup, error) {
 	ma := ManifestGroup{
 		Name:     m.Name,
 		Services: make([]ManifestService, 0, len(m.Services)),
@@ -464,6 +475,34 @@ type ProviderHost struct {
 type ProviderHostStatus struct {
 	State   string `json:"state,omitempty"`
 	Message string `json:"message,omitempty"`
+	// LastUpdate is when the hostname operator last changed State or Message
+	LastUpdate *metav1.Time          `json:"last_update,omitempty"`
+	TLS        ProviderHostTLSStatus `json:"tls,omitempty"`
+}
+
+const (
+	// ProviderHostStatePending is set while the ingress of the hostname is not applied yet
+	ProviderHostStatePending = "pending"
+	// ProviderHostStateActive is set once the ingress of the hostname routes to the lease
+	ProviderHostStateActive = "active"
+	// ProviderHostStateFailed is set when the ingress of the hostname could not be applied, Message holds the error
+	ProviderHostStateFailed = "failed"
+)
+
+const (
+	// ProviderHostTLSStatePending is set while the certificate of the hostname is being issued
+	ProviderHostTLSStatePending = "pending"
+	// ProviderHostTLSStateIssued is set once the ingress of the hostname serves a valid certificate
+	ProviderHostTLSStateIssued = "issued"
+	// ProviderHostTLSStateFailed is set when the certificate of the hostname could not be issued
+	ProviderHostTLSStateFailed = "failed"
+)
+
+// ProviderHostTLSStatus stores the state of the certificate of the hostname ingress
+type ProviderHostTLSStatus struct {
+	State      string `json:"state,omitempty"`
+	Message    string `json:"message,omitempty"`
+	SecretName string `json:"secret_name,omitempty"`
 }
 
 type ProviderHostSpec struct {
@@ -484,6 +523,31 @@ type ProviderHostList struct {
 	Items           []ProviderHost `json:"items"`
 }
 
+// ProviderIPAllocation reserves an address of the static leased IP pool for the services of a lease
+// sharing it. It is named after the address, so the API server never lets an address be allocated twice.
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ProviderIPAllocation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec ProviderIPAllocationSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ProviderIPAllocationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ProviderIPAllocation `json:"items"`
+}
+
+type ProviderIPAllocationSpec struct {
+	LeaseID    LeaseID `json:"lease_id"`
+	SharingKey string  `json:"sharing_key"`
+	IP         string  `json:"ip"`
+}
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 type ProviderLeasedIP struct {
@@ -504,8 +568,19 @@ type ProviderLeasedIPList struct {
 type ProviderLeasedIPStatus struct {
 	State   string `json:"state,omitempty"`
 	Message string `json:"message,omitempty"`
+	// LastUpdate is when the IP operator last changed State or Message
+	LastUpdate *metav1.Time `json:"last_update,omitempty"`
 }
 
+const (
+	// ProviderLeasedIPStatePending is set while the LoadBalancer service of the leased IP is not applied yet
+	ProviderLeasedIPStatePending = "pending"
+	// ProviderLeasedIPStateActive is set once the LoadBalancer service of the leased IP is applied
+	ProviderLeasedIPStateActive = "active"
+	// ProviderLeasedIPStateFailed is set when the LoadBalancer service could not be applied, Message holds the error
+	ProviderLeasedIPStateFailed = "failed"
+)
+
 type ProviderLeasedIPSpec struct {
 	LeaseID      LeaseID `json:"lease_id"`
 	ServiceName  string  `json:"service_name"`
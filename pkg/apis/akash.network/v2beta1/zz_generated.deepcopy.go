@@ -462,6 +462,11 @@ func (in *ManifestSpec) DeepCopyInto(out *ManifestSpec) {
 	*out = *in
 	out.LeaseID = in.LeaseID
 	in.Group.DeepCopyInto(&out.Group)
+	if in.LastGoodGroup != nil {
+		in, out := &in.LastGoodGroup, &out.LastGoodGroup
+		*out = new(ManifestGroup)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -513,7 +518,7 @@ func (in *ProviderHost) DeepCopyInto(out *ProviderHost) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -587,6 +592,11 @@ func (in *ProviderHostSpec) DeepCopy() *ProviderHostSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProviderHostStatus) DeepCopyInto(out *ProviderHostStatus) {
 	*out = *in
+	if in.LastUpdate != nil {
+		in, out := &in.LastUpdate, &out.LastUpdate
+		*out = (*in).DeepCopy()
+	}
+	out.TLS = in.TLS
 	return
 }
 
@@ -600,13 +610,106 @@ func (in *ProviderHostStatus) DeepCopy() *ProviderHostStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderHostTLSStatus) DeepCopyInto(out *ProviderHostTLSStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderHostTLSStatus.
+func (in *ProviderHostTLSStatus) DeepCopy() *ProviderHostTLSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderHostTLSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderIPAllocation) DeepCopyInto(out *ProviderIPAllocation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderIPAllocation.
+func (in *ProviderIPAllocation) DeepCopy() *ProviderIPAllocation {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderIPAllocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProviderIPAllocation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderIPAllocationList) DeepCopyInto(out *ProviderIPAllocationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ProviderIPAllocation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderIPAllocationList.
+func (in *ProviderIPAllocationList) DeepCopy() *ProviderIPAllocationList {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderIPAllocationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProviderIPAllocationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderIPAllocationSpec) DeepCopyInto(out *ProviderIPAllocationSpec) {
+	*out = *in
+	out.LeaseID = in.LeaseID
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderIPAllocationSpec.
+func (in *ProviderIPAllocationSpec) DeepCopy() *ProviderIPAllocationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderIPAllocationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProviderLeasedIP) DeepCopyInto(out *ProviderLeasedIP) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -681,6 +784,10 @@ func (in *ProviderLeasedIPSpec) DeepCopy() *ProviderLeasedIPSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProviderLeasedIPStatus) DeepCopyInto(out *ProviderLeasedIPStatus) {
 	*out = *in
+	if in.LastUpdate != nil {
+		in, out := &in.LastUpdate, &out.LastUpdate
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
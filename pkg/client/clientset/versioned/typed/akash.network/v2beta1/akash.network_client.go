@@ -32,6 +32,7 @@ type AkashV2beta1Interface interface {
 	InventoryRequestsGetter
 	ManifestsGetter
 	ProviderHostsGetter
+	ProviderIPAllocationsGetter
 	ProviderLeasedIPsGetter
 }
 
@@ -56,6 +57,10 @@ func (c *AkashV2beta1Client) ProviderHosts(namespace string) ProviderHostInterfa
 	return newProviderHosts(c, namespace)
 }
 
+func (c *AkashV2beta1Client) ProviderIPAllocations(namespace string) ProviderIPAllocationInterface {
+	return newProviderIPAllocations(c, namespace)
+}
+
 func (c *AkashV2beta1Client) ProviderLeasedIPs(namespace string) ProviderLeasedIPInterface {
 	return newProviderLeasedIPs(c, namespace)
 }
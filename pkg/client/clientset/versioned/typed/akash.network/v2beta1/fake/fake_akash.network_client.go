@@ -44,6 +44,10 @@ func (c *FakeAkashV2beta1) ProviderHosts(namespace string) v2beta1.ProviderHostI
 	return &FakeProviderHosts{c, namespace}
 }
 
+func (c *FakeAkashV2beta1) ProviderIPAllocations(namespace string) v2beta1.ProviderIPAllocationInterface {
+	return &FakeProviderIPAllocations{c, namespace}
+}
+
 func (c *FakeAkashV2beta1) ProviderLeasedIPs(namespace string) v2beta1.ProviderLeasedIPInterface {
 	return &FakeProviderLeasedIPs{c, namespace}
 }
@@ -26,4 +26,6 @@ type ManifestExpansion interface{}
 
 type ProviderHostExpansion interface{}
 
+type ProviderIPAllocationExpansion interface{}
+
 type ProviderLeasedIPExpansion interface{}
@@ -32,6 +32,8 @@ type Interface interface {
 	Manifests() ManifestInformer
 	// ProviderHosts returns a ProviderHostInformer.
 	ProviderHosts() ProviderHostInformer
+	// ProviderIPAllocations returns a ProviderIPAllocationInformer.
+	ProviderIPAllocations() ProviderIPAllocationInformer
 	// ProviderLeasedIPs returns a ProviderLeasedIPInformer.
 	ProviderLeasedIPs() ProviderLeasedIPInformer
 }
@@ -67,6 +69,11 @@ func (v *version) ProviderHosts() ProviderHostInformer {
 	return &providerHostInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// ProviderIPAllocations returns a ProviderIPAllocationInformer.
+func (v *version) ProviderIPAllocations() ProviderIPAllocationInformer {
+	return &providerIPAllocationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // ProviderLeasedIPs returns a ProviderLeasedIPInformer.
 func (v *version) ProviderLeasedIPs() ProviderLeasedIPInformer {
 	return &providerLeasedIPInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -42,6 +42,14 @@ type ProviderHostListerExpansion interface{}
 // ProviderHostNamespaceLister.
 type ProviderHostNamespaceListerExpansion interface{}
 
+// ProviderIPAllocationListerExpansion allows custom methods to be added to
+// ProviderIPAllocationLister.
+type ProviderIPAllocationListerExpansion interface{}
+
+// ProviderIPAllocationNamespaceListerExpansion allows custom methods to be added to
+// ProviderIPAllocationNamespaceLister.
+type ProviderIPAllocationNamespaceListerExpansion interface{}
+
 // ProviderLeasedIPListerExpansion allows custom methods to be added to
 // ProviderLeasedIPLister.
 type ProviderLeasedIPListerExpansion interface{}
@@ -78,6 +78,16 @@ func NewService(ctx context.Context,
 	clusterConfig.DeploymentIngressStaticHosts = cfg.DeploymentIngressStaticHosts
 	clusterConfig.DeploymentIngressDomain = cfg.DeploymentIngressDomain
 	clusterConfig.ClusterSettings = cfg.ClusterSettings
+	clusterConfig.Monitor = cfg.DeploymentMonitor
+	clusterConfig.MonitorOverrides = cfg.DeploymentMonitorOverrides
+	clusterConfig.DeploymentRollbackTimeout = cfg.DeploymentRollbackTimeout
+	clusterConfig.ReconcilePeriod = cfg.LeaseReconcilePeriod
+	clusterConfig.ReconcileGracePeriod = cfg.LeaseReconcileGracePeriod
+	clusterConfig.ReconcileTeardown = cfg.LeaseReconcileTeardown
+	clusterConfig.ReconcileMaxOrphanedPercent = cfg.LeaseReconcileMaxOrphaned
+	clusterConfig.HostnameVerification = cfg.DeploymentHostnameVerification
+	clusterConfig.HostnameRulesPath = cfg.HostnameRulesPath
+	clusterConfig.OwnerQuotas = cfg.OwnerQuotas
 
 	bc, err := newBalanceChecker(ctx, bankTypes.NewQueryClient(cctx), aclient.NewQueryClientFromCtx(cctx), accAddr, session, bus, cfg.BalanceCheckerCfg)
 	if err != nil {
@@ -114,6 +124,7 @@ func NewService(ctx context.Context,
 		ManifestTimeout:                   cfg.ManifestTimeout,
 		RPCQueryTimeout:                   cfg.RPCQueryTimeout,
 		CachedResultMaxAge:                cfg.CachedResultMaxAge,
+		ImagePolicy:                       cfg.ImagePolicy,
 	}
 
 	manifest, err := manifest.NewService(ctx, session, bus, cluster.HostnameService(), manifestConfig)